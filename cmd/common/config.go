@@ -0,0 +1,255 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/config"
+)
+
+const (
+	profileFlag   = "profile"
+	configCmdName = "config"
+)
+
+var (
+	profile string
+
+	// settings are the defaults loaded from the configuration files
+	settings = &config.Settings{}
+)
+
+// AddConfigFlags adds the flag selecting the profile of the configuration files.
+// It must be parsed before calling LoadConfig().
+func AddConfigFlags(rootCmd *cobra.Command) {
+	rootCmd.PersistentFlags().StringVar(
+		&profile,
+		profileFlag,
+		"",
+		"Profile of the configuration files to use; see 'config view'",
+	)
+}
+
+// LoadConfig loads the configuration files of app and applies the defaults they
+// hold to the flags of rootCmd. The loaded settings are returned; they are also
+// applied to the gadget commands when they run. It must be called after adding
+// the command returned by NewConfigCmd().
+func LoadConfig(app string, rootCmd *cobra.Command) (*config.Settings, error) {
+	cfg := config.Load(app, log.Warnf)
+
+	s, err := cfg.Resolve(profile)
+	if err != nil {
+		// The profile doesn't need to exist to be modified
+		cmd, _, findErr := rootCmd.Find(os.Args[1:])
+		if findErr != nil || !isConfigCmd(cmd) {
+			return nil, err
+		}
+		s, _ = cfg.Resolve("")
+	}
+	settings = s
+
+	if err := applyParams(rootCmd.PersistentFlags(), settings.Params); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// ApplyConfig applies the defaults of the loaded configuration to the flags of
+// cmd and its subcommands. It must be called once all the commands are added.
+func ApplyConfig(cmd *cobra.Command) error {
+	gadget := settings.Gadget(commandKey(cmd))
+	for _, flags := range []*pflag.FlagSet{cmd.PersistentFlags(), cmd.Flags()} {
+		if err := applyParams(flags, gadget.Params); err != nil {
+			return fmt.Errorf("applying config to %q: %w", cmd.CommandPath(), err)
+		}
+	}
+
+	for _, c := range cmd.Commands() {
+		if err := ApplyConfig(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isConfigCmd(cmd *cobra.Command) bool {
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		if c.Name() == configCmdName && !c.Parent().HasParent() {
+			return true
+		}
+	}
+	return false
+}
+
+// commandKey returns the key of cmd in the configuration, which is the path of
+// the command without the root one, separated by slashes, e.g. "trace/exec"
+func commandKey(cmd *cobra.Command) string {
+	var names []string
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		names = append([]string{c.Name()}, names...)
+	}
+	return strings.Join(names, "/")
+}
+
+// applyParams sets the given values as defaults of the flags. Flags that don't
+// exist or that were already given on the command line are skipped.
+func applyParams(flags *pflag.FlagSet, values map[string]string) error {
+	for key, value := range values {
+		flag := flags.Lookup(key)
+		if flag == nil || flag.Changed {
+			continue
+		}
+
+		var err error
+		switch v := flag.Value.(type) {
+		case *Param:
+			// Set the wrapped param to don't consider the value as given on the
+			// command line: it's replaced by the values given there
+			err = v.Param.Set(value)
+		case pflag.SliceValue:
+			err = v.Replace(strings.Split(value, ","))
+		default:
+			err = v.Set(value)
+		}
+		if err != nil {
+			return fmt.Errorf("setting default value of --%s: %w", key, err)
+		}
+		flag.DefValue = flag.Value.String()
+	}
+	return nil
+}
+
+// resetRepeatableFlags makes the values of the repeatable params to be replaced
+// instead of appended when the flags are parsed again
+func resetRepeatableFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if p, ok := flag.Value.(*Param); ok {
+			p.changed = false
+		}
+	})
+}
+
+// NewConfigCmd returns the command to manage the configuration files of app
+func NewConfigCmd(app string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   configCmdName,
+		Short: "Manage the configuration files holding the default values of the flags",
+		Long: fmt.Sprintf(`Manage the configuration files holding the default values of the flags.
+
+The configuration is read from the following files, each one overriding the
+previous ones:
+  system:  /etc/%[1]s/config.yaml
+  user:    $XDG_CONFIG_HOME/%[1]s/config.yaml (~/.config/%[1]s/config.yaml)
+  project: .%[1]s.yaml in the current directory or its parents
+
+The settings of a profile override the base ones when it's selected with
+--profile.`, app),
+	}
+
+	cmd.AddCommand(newConfigViewCmd(app), newConfigSetCmd(app))
+	return cmd
+}
+
+func newConfigViewCmd(app string) *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "Show the configuration merged from all files, or the one of a scope",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var cfg *config.Config
+			if scope == "" {
+				cfg = config.Load(app, log.Warnf)
+			} else {
+				path, err := config.Path(app, config.Scope(scope))
+				if err != nil {
+					return err
+				}
+				cfg, err = config.LoadFile(path)
+				if err != nil {
+					return err
+				}
+			}
+
+			out, err := yaml.Marshal(cfg)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), string(out))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&scope, "scope", "", "Show only the file of this scope (system, user, project)")
+	return cmd
+}
+
+func newConfigSetCmd(app string) *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "set KEY VALUE",
+		Short: "Set a value in the configuration file of a scope; an empty value removes it",
+		Long: `Set a value in the configuration file of a scope; an empty value removes it.
+If --profile is given, the value is set in that profile.
+
+Keys:
+  params.<flag>                          default value of a flag of all commands
+  output                                 default output mode of all gadgets
+  hiddenColumnTags                       tags of the columns hidden by default
+  gadgets.<category>/<gadget>.params.<flag>
+                                         default value of a flag of a gadget
+  gadgets.<category>/<gadget>.output     default output mode of a gadget
+  gadgets.<category>/<gadget>.columns    default columns of a gadget
+  gadgets.<category>/<gadget>.filters    default filters of a gadget
+
+Lists are given separated by commas.`,
+		Example: `  config set params.runtimes docker,containerd
+  config set --profile prod params.containerd-namespace k8s.io
+  config set gadgets.trace/exec.columns comm,pid,args
+  config set --scope project gadgets.trace/open.filters 'fname:~^/etc'`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := config.Path(app, config.Scope(scope))
+			if err != nil {
+				return err
+			}
+			cfg, err := config.LoadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := cfg.Set(profile, args[0], args[1]); err != nil {
+				return err
+			}
+			if err := cfg.Save(path); err != nil {
+				return fmt.Errorf("saving %q: %w", path, err)
+			}
+			log.Infof("Configuration saved to %q", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&scope, "scope", string(config.ScopeUser), "Scope of the file to modify (system, user, project)")
+	return cmd
+}
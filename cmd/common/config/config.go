@@ -0,0 +1,404 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config implements the configuration files holding the default values
+// of the flags of ig and kubectl-gadget. The files are layered: the system one is
+// overridden by the one of the user, which is overridden by the one of the
+// project. Each file can define named profiles that override its base settings
+// when selected.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Scope string
+
+const (
+	ScopeSystem  Scope = "system"
+	ScopeUser    Scope = "user"
+	ScopeProject Scope = "project"
+)
+
+// AllScopes contains the scopes ordered from the lowest to the highest precedence
+var AllScopes = []Scope{ScopeSystem, ScopeUser, ScopeProject}
+
+// GadgetSettings holds the defaults of a specific gadget
+type GadgetSettings struct {
+	// Params are the default values of the flags of the gadget, indexed by flag
+	// name. They override the global ones.
+	Params map[string]string `yaml:"params,omitempty"`
+
+	// Output is the default output mode, e.g. "json" or "columns=comm,pid"
+	Output string `yaml:"output,omitempty"`
+
+	// Columns are the default columns used by the columns output mode
+	Columns []string `yaml:"columns,omitempty"`
+
+	// Filters are the default filters, using the syntax of --filter
+	Filters []string `yaml:"filters,omitempty"`
+}
+
+// Settings holds the defaults of all commands
+type Settings struct {
+	// Params are the default values of the flags, indexed by flag name. They are
+	// applied to all the commands having a flag with that name, like the ones of
+	// the runtimes and the operators.
+	Params map[string]string `yaml:"params,omitempty"`
+
+	// Output is the default output mode of all gadgets
+	Output string `yaml:"output,omitempty"`
+
+	// HiddenColumnTags replaces the tags of the columns hidden by default
+	HiddenColumnTags []string `yaml:"hiddenColumnTags,omitempty"`
+
+	// Gadgets holds the settings of specific gadgets, indexed by their category
+	// and name separated by a slash, e.g. "trace/exec"
+	Gadgets map[string]*GadgetSettings `yaml:"gadgets,omitempty"`
+}
+
+// Config is the content of a configuration file
+type Config struct {
+	Settings `yaml:",inline"`
+
+	// Profiles holds named settings that override the base ones when selected
+	// with --profile
+	Profiles map[string]*Settings `yaml:"profiles,omitempty"`
+}
+
+// Path returns the path of the configuration file of app for the given scope.
+// The project file is searched in the current directory and its parents; if it
+// doesn't exist, the path in the current directory is returned.
+func Path(app string, scope Scope) (string, error) {
+	switch scope {
+	case ScopeSystem:
+		return filepath.Join("/etc", app, "config.yaml"), nil
+	case ScopeUser:
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, app, "config.yaml"), nil
+	case ScopeProject:
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		name := "." + app + ".yaml"
+		for dir := cwd; ; dir = filepath.Dir(dir) {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+		return filepath.Join(cwd, name), nil
+	default:
+		return "", fmt.Errorf("unknown scope %q: valid scopes are %s", scope, joinScopes())
+	}
+}
+
+func joinScopes() string {
+	scopes := make([]string, 0, len(AllScopes))
+	for _, scope := range AllScopes {
+		scopes = append(scopes, string(scope))
+	}
+	return strings.Join(scopes, ", ")
+}
+
+// LoadFile reads the configuration file at path. A missing file results in an
+// empty configuration. Files that aren't owned by root or the invoking user, or
+// that are writable by group or others, are rejected.
+func LoadFile(path string) (*Config, error) {
+	config := &Config{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return config, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOwner(path, fi); err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("parsing %q: %w", path, err)
+	}
+	return config, nil
+}
+
+// Save writes the configuration to path, creating its directory if needed
+func (c *Config) Save(path string) error {
+	content, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// Load reads and merges the configuration files of all scopes of app. Files
+// that can't be read are reported through warn and skipped.
+func Load(app string, warn func(format string, args ...any)) *Config {
+	config := &Config{}
+	for _, scope := range AllScopes {
+		path, err := Path(app, scope)
+		if err != nil {
+			warn("getting path of %s config: %s", scope, err)
+			continue
+		}
+		layer, err := LoadFile(path)
+		if err != nil {
+			warn("loading %s config: %s", scope, err)
+			continue
+		}
+		config.Merge(layer)
+	}
+	return config
+}
+
+// Merge overrides c with the values set in other
+func (c *Config) Merge(other *Config) {
+	c.Settings.merge(&other.Settings)
+
+	for name, profile := range other.Profiles {
+		if c.Profiles == nil {
+			c.Profiles = make(map[string]*Settings)
+		}
+		if c.Profiles[name] == nil {
+			c.Profiles[name] = &Settings{}
+		}
+		c.Profiles[name].merge(profile)
+	}
+}
+
+// Resolve returns the base settings overridden by the ones of the given profile,
+// if any
+func (c *Config) Resolve(profile string) (*Settings, error) {
+	settings := &Settings{}
+	settings.merge(&c.Settings)
+
+	if profile != "" {
+		p, ok := c.Profiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("profile %q not found: available profiles are: %s",
+				profile, strings.Join(c.ProfileNames(), ", "))
+		}
+		settings.merge(p)
+	}
+
+	return settings, nil
+}
+
+// ProfileNames returns the sorted names of the profiles
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Settings) merge(other *Settings) {
+	s.Params = mergeParams(s.Params, other.Params)
+	if other.Output != "" {
+		s.Output = other.Output
+	}
+	if other.HiddenColumnTags != nil {
+		s.HiddenColumnTags = other.HiddenColumnTags
+	}
+
+	for name, gadget := range other.Gadgets {
+		if s.Gadgets == nil {
+			s.Gadgets = make(map[string]*GadgetSettings)
+		}
+		if s.Gadgets[name] == nil {
+			s.Gadgets[name] = &GadgetSettings{}
+		}
+		s.Gadgets[name].merge(gadget)
+	}
+}
+
+func (g *GadgetSettings) merge(other *GadgetSettings) {
+	g.Params = mergeParams(g.Params, other.Params)
+	if other.Output != "" {
+		g.Output = other.Output
+	}
+	if other.Columns != nil {
+		g.Columns = other.Columns
+	}
+	if other.Filters != nil {
+		g.Filters = other.Filters
+	}
+}
+
+func mergeParams(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// Gadget returns the settings of the gadget with the given key, e.g.
+// "trace/exec", including the global params and output
+func (s *Settings) Gadget(key string) *GadgetSettings {
+	gadget := &GadgetSettings{
+		Params: mergeParams(nil, s.Params),
+		Output: s.Output,
+	}
+	if g, ok := s.Gadgets[key]; ok {
+		gadget.merge(g)
+	}
+	return gadget
+}
+
+// Set sets the value of key in the base settings or in the given profile. An
+// empty value removes the key. Valid keys are:
+//
+//	params.<flag>
+//	output
+//	hiddenColumnTags
+//	gadgets.<category>/<gadget>.params.<flag>
+//	gadgets.<category>/<gadget>.output
+//	gadgets.<category>/<gadget>.columns
+//	gadgets.<category>/<gadget>.filters
+//
+// Lists are given separated by commas.
+func (c *Config) Set(profile, key, value string) error {
+	settings := &c.Settings
+	if profile != "" {
+		if c.Profiles == nil {
+			c.Profiles = make(map[string]*Settings)
+		}
+		if c.Profiles[profile] == nil {
+			c.Profiles[profile] = &Settings{}
+		}
+		settings = c.Profiles[profile]
+	}
+
+	section, rest, _ := strings.Cut(key, ".")
+	switch section {
+	case "params":
+		if rest == "" {
+			return fmt.Errorf("missing flag name in %q", key)
+		}
+		settings.Params = setParam(settings.Params, rest, value)
+	case "output":
+		settings.Output = value
+	case "hiddenColumnTags":
+		settings.HiddenColumnTags = splitList(value)
+	case "gadgets":
+		name, field, _ := strings.Cut(rest, ".")
+		if name == "" || field == "" {
+			return fmt.Errorf("expected gadgets.<category>/<gadget>.<field>, got %q", key)
+		}
+		if settings.Gadgets == nil {
+			settings.Gadgets = make(map[string]*GadgetSettings)
+		}
+		gadget := settings.Gadgets[name]
+		if gadget == nil {
+			gadget = &GadgetSettings{}
+		}
+		if err := gadget.set(field, value); err != nil {
+			return fmt.Errorf("setting %q: %w", key, err)
+		}
+		settings.Gadgets[name] = gadget
+		if gadget.isEmpty() {
+			delete(settings.Gadgets, name)
+		}
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+
+	return nil
+}
+
+func (g *GadgetSettings) set(field, value string) error {
+	name, rest, _ := strings.Cut(field, ".")
+	switch name {
+	case "params":
+		if rest == "" {
+			return errors.New("missing flag name")
+		}
+		g.Params = setParam(g.Params, rest, value)
+	case "output":
+		g.Output = value
+	case "columns":
+		g.Columns = splitList(value)
+	case "filters":
+		g.Filters = splitList(value)
+	default:
+		return fmt.Errorf("unknown field %q", field)
+	}
+	return nil
+}
+
+func (g *GadgetSettings) isEmpty() bool {
+	return len(g.Params) == 0 && g.Output == "" && g.Columns == nil && g.Filters == nil
+}
+
+func setParam(params map[string]string, key, value string) map[string]string {
+	if value == "" {
+		delete(params, key)
+		if len(params) == 0 {
+			return nil
+		}
+		return params
+	}
+	if params == nil {
+		params = make(map[string]string)
+	}
+	params[key] = value
+	return params
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeAndResolve(t *testing.T) {
+	t.Parallel()
+
+	system := &Config{
+		Settings: Settings{
+			Params: map[string]string{"runtimes": "docker", "host": "false"},
+			Output: "json",
+			Gadgets: map[string]*GadgetSettings{
+				"trace/exec": {Columns: []string{"comm"}},
+			},
+		},
+	}
+	user := &Config{
+		Settings: Settings{
+			Params: map[string]string{"runtimes": "containerd"},
+			Gadgets: map[string]*GadgetSettings{
+				"trace/exec": {Filters: []string{"comm:bash"}},
+			},
+		},
+		Profiles: map[string]*Settings{
+			"prod": {
+				Params: map[string]string{"containerd-namespace": "k8s.io"},
+				Output: "columns",
+			},
+		},
+	}
+
+	cfg := &Config{}
+	cfg.Merge(system)
+	cfg.Merge(user)
+
+	base, err := cfg.Resolve("")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"runtimes": "containerd", "host": "false"}, base.Params)
+	require.Equal(t, "json", base.Output)
+	require.Equal(t, &GadgetSettings{
+		Columns: []string{"comm"},
+		Filters: []string{"comm:bash"},
+	}, base.Gadgets["trace/exec"])
+
+	prod, err := cfg.Resolve("prod")
+	require.NoError(t, err)
+	require.Equal(t, "columns", prod.Output)
+	require.Equal(t, "k8s.io", prod.Params["containerd-namespace"])
+	require.Equal(t, "containerd", prod.Params["runtimes"])
+
+	// Resolving must not modify the configuration
+	require.NotContains(t, cfg.Params, "containerd-namespace")
+
+	_, err = cfg.Resolve("dev")
+	require.ErrorContains(t, err, "available profiles are: prod")
+}
+
+func TestGadget(t *testing.T) {
+	t.Parallel()
+
+	settings := &Settings{
+		Params: map[string]string{"runtimes": "docker", "host": "false"},
+		Output: "json",
+		Gadgets: map[string]*GadgetSettings{
+			"trace/exec": {
+				Params: map[string]string{"host": "true"},
+				Output: "columns",
+			},
+		},
+	}
+
+	exec := settings.Gadget("trace/exec")
+	require.Equal(t, map[string]string{"runtimes": "docker", "host": "true"}, exec.Params)
+	require.Equal(t, "columns", exec.Output)
+
+	open := settings.Gadget("trace/open")
+	require.Equal(t, settings.Params, open.Params)
+	require.Equal(t, "json", open.Output)
+
+	// The global params must not be modified by the ones of the gadgets
+	require.Equal(t, "false", settings.Params["host"])
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{}
+
+	require.NoError(t, cfg.Set("", "params.runtimes", "docker"))
+	require.NoError(t, cfg.Set("", "output", "json"))
+	require.NoError(t, cfg.Set("", "hiddenColumnTags", "kubernetes,runtime"))
+	require.NoError(t, cfg.Set("", "gadgets.trace/exec.params.host", "true"))
+	require.NoError(t, cfg.Set("", "gadgets.trace/exec.columns", "comm,pid"))
+	require.NoError(t, cfg.Set("prod", "params.containerd-namespace", "k8s.io"))
+
+	require.Equal(t, &Config{
+		Settings: Settings{
+			Params:           map[string]string{"runtimes": "docker"},
+			Output:           "json",
+			HiddenColumnTags: []string{"kubernetes", "runtime"},
+			Gadgets: map[string]*GadgetSettings{
+				"trace/exec": {
+					Params:  map[string]string{"host": "true"},
+					Columns: []string{"comm", "pid"},
+				},
+			},
+		},
+		Profiles: map[string]*Settings{
+			"prod": {Params: map[string]string{"containerd-namespace": "k8s.io"}},
+		},
+	}, cfg)
+
+	// Empty values remove the keys
+	require.NoError(t, cfg.Set("", "params.runtimes", ""))
+	require.NoError(t, cfg.Set("", "gadgets.trace/exec.params.host", ""))
+	require.NoError(t, cfg.Set("", "gadgets.trace/exec.columns", ""))
+	require.Nil(t, cfg.Params)
+	require.NotContains(t, cfg.Gadgets, "trace/exec")
+
+	for _, key := range []string{"foo", "params", "gadgets.trace/exec", "gadgets.trace/exec.foo"} {
+		require.Error(t, cfg.Set("", key, "bar"), key)
+	}
+}
+
+func TestLoadFileAndSave(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "ig", "config.yaml")
+
+	cfg, err := LoadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, &Config{}, cfg)
+
+	require.NoError(t, cfg.Set("", "params.host", "true"))
+	require.NoError(t, cfg.Set("dev", "gadgets.trace/open.filters", "fname:~^/etc"))
+	require.NoError(t, cfg.Save(path))
+
+	loaded, err := LoadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, cfg, loaded)
+}
+
+func TestPath(t *testing.T) {
+	t.Parallel()
+
+	path, err := Path("ig", ScopeSystem)
+	require.NoError(t, err)
+	require.Equal(t, "/etc/ig/config.yaml", path)
+
+	_, err = Path("ig", Scope("foo"))
+	require.ErrorContains(t, err, "unknown scope")
+}
+
+func TestLoadFileUntrusted(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".ig.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("output: json\n"), 0o600))
+	require.NoError(t, os.Chmod(path, 0o666))
+
+	_, err := LoadFile(path)
+	require.ErrorContains(t, err, "writable by group or others")
+}
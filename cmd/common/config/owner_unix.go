@@ -0,0 +1,57 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// checkOwner makes sure that the file described by fi can only have been
+// written by root or the invoking user: ig usually runs as root, so a file
+// planted by another user could e.g. redirect the events to any path.
+func checkOwner(path string, fi fs.FileInfo) error {
+	if fi.Mode().Perm()&0o022 != 0 {
+		return fmt.Errorf("%q is writable by group or others", path)
+	}
+
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if !trustedUID(stat.Uid) {
+		return fmt.Errorf("%q is owned by uid %d, expected root or the invoking user", path, stat.Uid)
+	}
+	return nil
+}
+
+func trustedUID(uid uint32) bool {
+	if uid == 0 || int(uid) == os.Getuid() {
+		return true
+	}
+
+	// When running through sudo, also trust the user that invoked it
+	if os.Getuid() == 0 {
+		if sudoUID, err := strconv.ParseUint(os.Getenv("SUDO_UID"), 10, 32); err == nil {
+			return uint32(sudoUID) == uid
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+)
+
+func TestApplyParams(t *testing.T) {
+	t.Parallel()
+
+	pd := &params.ParamDesc{
+		Key:          "labels",
+		DefaultValue: "default=true",
+		TypeHint:     params.TypeStringMap,
+	}
+	p := &Param{Param: pd.ToParam()}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.VarP(p, "labels", "", "")
+	names := flags.StringSlice("names", nil, "")
+	host := flags.Bool("host", false, "")
+
+	err := applyParams(flags, map[string]string{
+		"labels":  "app=web",
+		"names":   "a,b",
+		"host":    "true",
+		"unknown": "foo",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"app": "web"}, p.AsStringMap())
+	require.Equal(t, []string{"a", "b"}, *names)
+	require.True(t, *host)
+	require.Equal(t, "true", flags.Lookup("host").DefValue)
+
+	// Values from the configuration are replaced by the ones given on the
+	// command line
+	require.NoError(t, flags.Parse([]string{"--labels", "app=db", "--names", "c"}))
+	require.Equal(t, map[string]string{"app": "db"}, p.AsStringMap())
+	require.Equal(t, []string{"c"}, *names)
+
+	// Flags given on the command line are kept
+	require.NoError(t, applyParams(flags, map[string]string{"names": "d"}))
+	require.Equal(t, []string{"c"}, *names)
+
+	require.Error(t, applyParams(flags, map[string]string{"host": "foo"}))
+}
+
+func TestCommandKey(t *testing.T) {
+	t.Parallel()
+
+	root := &cobra.Command{Use: "ig"}
+	trace := &cobra.Command{Use: "trace"}
+	exec := &cobra.Command{Use: "exec"}
+	config := &cobra.Command{Use: configCmdName}
+	set := &cobra.Command{Use: "set"}
+	root.AddCommand(trace, config)
+	trace.AddCommand(exec)
+	config.AddCommand(set)
+
+	require.Equal(t, "trace/exec", commandKey(exec))
+	require.Equal(t, "", commandKey(root))
+	require.False(t, isConfigCmd(exec))
+	require.True(t, isConfigCmd(set))
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	log "github.com/sirupsen/logrus"
@@ -27,6 +28,7 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/frontends"
 	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
 type frontend struct {
@@ -34,6 +36,9 @@ type frontend struct {
 	cancel     func()
 	log        logger.Logger
 	isTerminal bool
+
+	mu      sync.Mutex
+	dropped types.DroppedEvents
 }
 
 func NewFrontend() frontends.Frontend {
@@ -79,7 +84,26 @@ func (f *frontend) GetContext() context.Context {
 	return f.ctx
 }
 
+// ReportDropped warns when events were dropped because too many of them were
+// generated; events dropped on purpose (sampling, rate limit) are only shown
+// in the summary when closing the frontend
+func (f *frontend) ReportDropped(dropped types.DroppedEvents) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if dropped.Unintended() > f.dropped.Unintended() {
+		f.log.Warn(dropped.String())
+	}
+	f.dropped = dropped
+}
+
 func (f *frontend) Close() {
+	f.mu.Lock()
+	if f.dropped.Total() > 0 {
+		f.log.Info(f.dropped.String())
+	}
+	f.mu.Unlock()
+
 	f.cancel()
 	f.ctx = nil
 }
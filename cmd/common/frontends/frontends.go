@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
 type Frontend interface {
@@ -27,4 +28,8 @@ type Frontend interface {
 	Clear()
 	Close()
 	GetContext() context.Context
+
+	// ReportDropped updates the number of events of the gadget run that were
+	// dropped so far
+	ReportDropped(dropped types.DroppedEvents)
 }
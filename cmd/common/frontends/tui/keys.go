@@ -0,0 +1,126 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"unicode/utf8"
+)
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyPageUp
+	keyPageDown
+	keyDelete
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyTab
+	keyCtrlC
+	keyUnknown
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// escapeSequences maps the (xterm compatible) sequences following ESC to keys
+var escapeSequences = map[string]keyCode{
+	"[A":  keyUp,
+	"[B":  keyDown,
+	"[C":  keyRight,
+	"[D":  keyLeft,
+	"[H":  keyHome,
+	"[F":  keyEnd,
+	"OA":  keyUp,
+	"OB":  keyDown,
+	"OC":  keyRight,
+	"OD":  keyLeft,
+	"OH":  keyHome,
+	"OF":  keyEnd,
+	"[1~": keyHome,
+	"[3~": keyDelete,
+	"[4~": keyEnd,
+	"[5~": keyPageUp,
+	"[6~": keyPageDown,
+	"[7~": keyHome,
+	"[8~": keyEnd,
+}
+
+// parseKeys decodes the input read from a terminal in raw mode. A single ESC
+// is treated as the escape key, as it's not followed by the rest of a sequence
+// in the same read.
+func parseKeys(buf []byte) []key {
+	var keys []key
+	for len(buf) > 0 {
+		switch b := buf[0]; {
+		case b == 0x1b:
+			if len(buf) == 1 {
+				keys = append(keys, key{code: keyEscape})
+				return keys
+			}
+			n, code := parseEscapeSequence(buf[1:])
+			keys = append(keys, key{code: code})
+			buf = buf[1+n:]
+			continue
+		case b == '\r' || b == '\n':
+			keys = append(keys, key{code: keyEnter})
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, key{code: keyBackspace})
+		case b == '\t':
+			keys = append(keys, key{code: keyTab})
+		case b == 0x03:
+			keys = append(keys, key{code: keyCtrlC})
+		case b < 0x20:
+			keys = append(keys, key{code: keyUnknown})
+		default:
+			r, size := utf8.DecodeRune(buf)
+			keys = append(keys, key{code: keyRune, r: r})
+			buf = buf[size:]
+			continue
+		}
+		buf = buf[1:]
+	}
+	return keys
+}
+
+// parseEscapeSequence returns the length and the key of the sequence at the
+// start of buf, which followed an ESC
+func parseEscapeSequence(buf []byte) (int, keyCode) {
+	if buf[0] != '[' && buf[0] != 'O' {
+		// Alt+key or ESC followed by another key; drop the ESC
+		return 0, keyUnknown
+	}
+	// Sequences end with a letter or '~', parameters are digits and ';'
+	for i := 1; i < len(buf); i++ {
+		b := buf[i]
+		if (b >= '0' && b <= '9') || b == ';' {
+			continue
+		}
+		if code, ok := escapeSequences[string(buf[:i+1])]; ok {
+			return i + 1, code
+		}
+		return i + 1, keyUnknown
+	}
+	return len(buf), keyUnknown
+}
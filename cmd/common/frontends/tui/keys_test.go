@@ -0,0 +1,97 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []key
+	}{
+		"runes": {
+			input:    "aö",
+			expected: []key{{code: keyRune, r: 'a'}, {code: keyRune, r: 'ö'}},
+		},
+		"escape": {
+			input:    "\x1b",
+			expected: []key{{code: keyEscape}},
+		},
+		"arrows": {
+			input:    "\x1b[A\x1b[B\x1bOC\x1b[D",
+			expected: []key{{code: keyUp}, {code: keyDown}, {code: keyRight}, {code: keyLeft}},
+		},
+		"paging": {
+			input:    "\x1b[5~\x1b[6~q",
+			expected: []key{{code: keyPageUp}, {code: keyPageDown}, {code: keyRune, r: 'q'}},
+		},
+		"modified": {
+			input:    "\x1b[1;5Aj",
+			expected: []key{{code: keyUnknown}, {code: keyRune, r: 'j'}},
+		},
+		"control": {
+			input:    "\r\x7f\x03",
+			expected: []key{{code: keyEnter}, {code: keyBackspace}, {code: keyCtrlC}},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, parseKeys([]byte(test.input)))
+		})
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	var submitted string
+	p := newPrompt("Filters", "comm:ls", func(s string) error {
+		if s == "invalid" {
+			return errors.New("invalid filter")
+		}
+		submitted = s
+		return nil
+	})
+
+	for _, k := range parseKeys([]byte("\x7f\x7fcat\x1b[H\x1b[3~C")) {
+		done, err := p.handleKey(k)
+		require.NoError(t, err)
+		require.False(t, done)
+	}
+	require.Equal(t, "Comm:cat", string(p.text))
+
+	done, err := p.handleKey(key{code: keyEnter})
+	require.NoError(t, err)
+	require.True(t, done)
+	require.Equal(t, "Comm:cat", submitted)
+
+	p = newPrompt("Filters", "invalid", p.submit)
+	done, err = p.handleKey(key{code: keyEnter})
+	require.Error(t, err)
+	require.False(t, done)
+}
+
+func TestFit(t *testing.T) {
+	require.Equal(t, "abc  ", fit("abc", 5))
+	require.Equal(t, "ab", fit("abc", 2))
+	require.Equal(t, styleInverse+"ab"+styleReset+"c ", fit(styleInverse+"ab"+styleReset+"c", 4))
+	require.Equal(t, styleBold+"äb", fit(styleBold+"äbc", 2))
+	require.Equal(t, []string{"a", "b"}, splitList(" a, ,b,"))
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"strings"
+)
+
+// prompt is a single line editor, e.g. for the filters
+type prompt struct {
+	label  string
+	text   []rune
+	cursor int
+
+	// submit is called with the text once enter is pressed; the prompt stays
+	// open if it returns an error
+	submit func(string) error
+}
+
+func newPrompt(label, text string, submit func(string) error) *prompt {
+	p := &prompt{
+		label:  label,
+		text:   []rune(text),
+		submit: submit,
+	}
+	p.cursor = len(p.text)
+	return p
+}
+
+// handleKey edits the text; it returns true once the prompt is done, either
+// because the text was submitted successfully or editing was cancelled
+func (p *prompt) handleKey(k key) (done bool, err error) {
+	switch k.code {
+	case keyRune:
+		p.text = append(p.text[:p.cursor], append([]rune{k.r}, p.text[p.cursor:]...)...)
+		p.cursor++
+	case keyBackspace:
+		if p.cursor > 0 {
+			p.text = append(p.text[:p.cursor-1], p.text[p.cursor:]...)
+			p.cursor--
+		}
+	case keyDelete:
+		if p.cursor < len(p.text) {
+			p.text = append(p.text[:p.cursor], p.text[p.cursor+1:]...)
+		}
+	case keyLeft:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case keyRight:
+		if p.cursor < len(p.text) {
+			p.cursor++
+		}
+	case keyHome:
+		p.cursor = 0
+	case keyEnd:
+		p.cursor = len(p.text)
+	case keyEscape, keyCtrlC:
+		return true, nil
+	case keyEnter:
+		if err := p.submit(string(p.text)); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// String returns the prompt as shown to the user; the cursor is rendered as
+// block at its position
+func (p *prompt) String() string {
+	var out strings.Builder
+	out.WriteString(p.label)
+	out.WriteString(": ")
+	out.WriteString(string(p.text[:p.cursor]))
+	out.WriteString(styleInverse)
+	if p.cursor < len(p.text) {
+		out.WriteRune(p.text[p.cursor])
+	} else {
+		out.WriteRune(' ')
+	}
+	out.WriteString(styleReset)
+	if p.cursor < len(p.text) {
+		out.WriteString(string(p.text[p.cursor+1:]))
+	}
+	return out.String()
+}
+
+// splitList splits a comma separated list as given in prompts, ignoring empty
+// entries
+func splitList(s string) []string {
+	var out []string
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
@@ -0,0 +1,373 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tui provides an interactive terminal frontend. Events are kept in a
+// parser.EventTable, so sorting, filters and the shown columns can be changed
+// while the gadget is running, and single events can be inspected as JSON.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
+
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/frontends"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	// refreshInterval limits how often the screen is redrawn
+	refreshInterval = 100 * time.Millisecond
+
+	// maxMessages is the number of log messages kept
+	maxMessages = 100
+
+	// MaxEvents is the number of events of tables that should be kept for
+	// scrolling back
+	MaxEvents = 10000
+)
+
+// TableConfig holds the initial settings of a table shown by the frontend
+type TableConfig struct {
+	// Title is shown in the status bar, e.g. the name of the gadget
+	Title string
+	// Columns are the attributes of all columns that can be shown
+	Columns     []columns.Attributes
+	ShowColumns []string
+	SortBy      []string
+	Filters     []string
+	// Stream must be set for gadgets emitting single events; new events are
+	// appended and followed unless the user scrolls up. Otherwise, the table
+	// is replaced with every array of events.
+	Stream bool
+}
+
+var _ frontends.Frontend = (*Frontend)(nil)
+
+type Frontend struct {
+	ctx    context.Context
+	cancel func()
+
+	in       *os.File
+	out      *os.File
+	oldState *term.State
+	oldLog   io.Writer
+
+	mu      sync.Mutex
+	width   int
+	height  int
+	dirty   bool
+	done    chan struct{}
+	closed  bool
+	message string
+
+	messages []string
+	view     *view
+}
+
+// NewFrontend switches the terminal to raw mode and the alternate screen and
+// returns the frontend drawing on it. Close restores the terminal.
+func NewFrontend() (*Frontend, error) {
+	in, out := os.Stdin, os.Stdout
+	if !term.IsTerminal(int(in.Fd())) || !term.IsTerminal(int(out.Fd())) {
+		return nil, errors.New("the interactive frontend needs a terminal")
+	}
+	width, height, err := term.GetSize(int(out.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("getting terminal size: %w", err)
+	}
+	oldState, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("setting terminal to raw mode: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &Frontend{
+		ctx:      ctx,
+		cancel:   cancel,
+		in:       in,
+		out:      out,
+		oldState: oldState,
+		width:    width,
+		height:   height,
+		dirty:    true,
+		done:     make(chan struct{}),
+	}
+
+	// Log messages would mess up the screen, so they are shown in the status
+	// line instead
+	f.oldLog = log.StandardLogger().Out
+	log.SetOutput(&logWriter{f: f})
+
+	// Switch to the alternate screen and hide the cursor
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-stop:
+			log.Debugf("got signal, cancelling context")
+			cancel()
+		case <-f.done:
+		}
+		signal.Stop(stop)
+	}()
+
+	go f.readInput()
+	go f.refresh()
+
+	return f, nil
+}
+
+// SetTable shows table with the given settings instead of the output lines
+func (f *Frontend) SetTable(table parser.EventTable, config *TableConfig) error {
+	v, err := newView(table, config)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.view = v
+	f.dirty = true
+	table.SetChangeCallback(f.setDirty)
+	return nil
+}
+
+// Finish notes that the gadget stopped; the frontend is kept open, so the
+// events can still be looked at, until the user quits
+func (f *Frontend) Finish(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err != nil {
+		f.addMessage(fmt.Sprintf("[error] %v", err))
+	} else {
+		f.addMessage("Gadget finished, press q to quit")
+	}
+	f.dirty = true
+}
+
+// Wait blocks until the user quits
+func (f *Frontend) Wait() {
+	<-f.ctx.Done()
+}
+
+func (f *Frontend) setDirty() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.dirty = true
+}
+
+// addMessage adds a line to the messages; f.mu must be held
+func (f *Frontend) addMessage(msg string) {
+	f.messages = append(f.messages, msg)
+	if len(f.messages) > maxMessages {
+		f.messages = f.messages[len(f.messages)-maxMessages:]
+	}
+	f.message = msg
+	f.dirty = true
+}
+
+func (f *Frontend) Logf(severity logger.Level, format string, params ...any) {
+	if !log.IsLevelEnabled(severity) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.addMessage(fmt.Sprintf("[%s] %s", severity, fmt.Sprintf(format, params...)))
+}
+
+// Output adds payload to the messages; events are shown using the table set
+// with SetTable
+func (f *Frontend) Output(payload string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, line := range strings.Split(payload, "\n") {
+		f.addMessage(line)
+	}
+}
+
+// ReportDropped shows the number of dropped events in the status bar
+func (f *Frontend) ReportDropped(dropped types.DroppedEvents) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.view != nil && f.view.dropped != dropped.Total() {
+		f.view.dropped = dropped.Total()
+		f.dirty = true
+	}
+}
+
+func (f *Frontend) IsTerminal() bool {
+	return true
+}
+
+// Clear does nothing, as the screen is redrawn completely anyway
+func (f *Frontend) Clear() {
+}
+
+func (f *Frontend) GetContext() context.Context {
+	return f.ctx
+}
+
+func (f *Frontend) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+	f.closed = true
+	f.cancel()
+	close(f.done)
+
+	// Leave the alternate screen and show the cursor again
+	fmt.Fprint(f.out, "\x1b[?25h\x1b[?1049l")
+	term.Restore(int(f.in.Fd()), f.oldState)
+	log.SetOutput(f.oldLog)
+}
+
+// readInput handles the keys pressed by the user
+func (f *Frontend) readInput() {
+	buf := make([]byte, 256)
+	for {
+		n, err := f.in.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			if !f.handleKey(k) {
+				f.cancel()
+				return
+			}
+		}
+	}
+}
+
+// handleKey returns false, if the user quits
+func (f *Frontend) handleKey(k key) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return false
+	}
+	f.dirty = true
+	if f.view == nil {
+		return k.code != keyCtrlC && !(k.code == keyRune && k.r == 'q')
+	}
+	quit, msg := f.view.handleKey(k, f.height)
+	if msg != "" {
+		f.addMessage(msg)
+	}
+	return !quit
+}
+
+// refresh redraws the screen when something changed
+func (f *Frontend) refresh() {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-ticker.C:
+		}
+
+		width, height, err := term.GetSize(int(f.out.Fd()))
+
+		f.mu.Lock()
+		if f.closed {
+			f.mu.Unlock()
+			return
+		}
+		if err == nil && (width != f.width || height != f.height) {
+			f.width, f.height = width, height
+			f.dirty = true
+		}
+		if f.dirty {
+			f.dirty = false
+			f.draw()
+		}
+		f.mu.Unlock()
+	}
+}
+
+// draw renders the whole screen; f.mu must be held
+func (f *Frontend) draw() {
+	var lines []string
+	if f.view != nil {
+		lines = f.view.render(f.width, f.height, f.message)
+	} else {
+		lines = f.renderMessages()
+	}
+
+	var out strings.Builder
+	out.WriteString("\x1b[H")
+	for i := 0; i < f.height; i++ {
+		line := ""
+		if i < len(lines) {
+			line = lines[i]
+		}
+		out.WriteString(fit(line, f.width))
+		out.WriteString(styleReset)
+		if i < f.height-1 {
+			out.WriteString("\r\n")
+		}
+	}
+	f.out.WriteString(out.String())
+}
+
+// renderMessages renders the latest messages, if no table is set
+func (f *Frontend) renderMessages() []string {
+	messages := f.messages
+	if len(messages) > f.height {
+		messages = messages[len(messages)-f.height:]
+	}
+	return messages
+}
+
+// logWriter adds the messages written by logrus to the frontend
+type logWriter struct {
+	f *Frontend
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.f.mu.Lock()
+	defer w.f.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		w.f.addMessage(line)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,517 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+)
+
+const (
+	styleReset   = "\x1b[0m"
+	styleBold    = "\x1b[1m"
+	styleInverse = "\x1b[7m"
+)
+
+type mode int
+
+const (
+	modeTable mode = iota
+	modeDetail
+	modeColumns
+	modeHelp
+)
+
+// chromeLines is the number of lines that aren't rows in table mode: status
+// bar, header, message and key hints
+const chromeLines = 4
+
+var helpLines = []string{
+	"Keys",
+	"",
+	"  up/k, down/j        select event",
+	"  pgup, pgdown        scroll a page",
+	"  home/g, end/G       go to the first / last event; end follows new events",
+	"  enter               show the selected event as JSON",
+	"  space/p             pause / resume updating the events",
+	"  f, /                edit filters, e.g. comm:cat,pid:>100",
+	"  s                   edit sort columns, e.g. -sent,comm",
+	"  <, >                sort by the previous / next shown column",
+	"  r                   reverse the sort order of the first sort column",
+	"  c                   choose the shown columns",
+	"  ?                   show this help",
+	"  q, ctrl+c           quit",
+	"",
+	"Filters use the same syntax as --filter: column:value, column:!value,",
+	"column:>value, column:<=value, column:~regex",
+}
+
+// view holds the state of the table shown by the frontend
+type view struct {
+	table  parser.EventTable
+	config *TableConfig
+
+	mode        mode
+	prompt      *prompt
+	columns     []columns.Attributes
+	shown       []string
+	sortBy      []string
+	filters     []string
+	paused      bool
+	follow      bool
+	selected    int
+	offset      int
+	detail      []string
+	detailTitle string
+	scroll      int
+	colCursor   int
+	dropped     uint64
+}
+
+func newView(table parser.EventTable, config *TableConfig) (*view, error) {
+	v := &view{
+		table:   table,
+		config:  config,
+		columns: config.Columns,
+		shown:   config.ShowColumns,
+		follow:  config.Stream,
+	}
+	if err := v.setSorting(config.SortBy); err != nil {
+		return nil, err
+	}
+	if err := v.setFilters(config.Filters); err != nil {
+		return nil, err
+	}
+	if err := table.SetShowColumns(v.shown); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *view) setSorting(sortBy []string) error {
+	if err := v.table.SetSorting(sortBy); err != nil {
+		return err
+	}
+	v.sortBy = sortBy
+	return nil
+}
+
+func (v *view) setFilters(filters []string) error {
+	if err := v.table.SetFilters(filters); err != nil {
+		return err
+	}
+	v.filters = filters
+	return nil
+}
+
+// rowsHeight returns the number of rows shown in table mode
+func rowsHeight(height int) int {
+	return max(height-chromeLines, 1)
+}
+
+// detailHeight returns the number of lines of an event shown in detail mode
+func detailHeight(height int) int {
+	return max(height-3, 1)
+}
+
+// handleKey returns whether the user quits and optionally a message to show
+func (v *view) handleKey(k key, height int) (bool, string) {
+	if v.prompt != nil {
+		done, err := v.prompt.handleKey(k)
+		if err != nil {
+			return false, err.Error()
+		}
+		if done {
+			v.prompt = nil
+		}
+		return false, ""
+	}
+
+	switch v.mode {
+	case modeDetail:
+		return v.handleDetailKey(k, height), ""
+	case modeColumns:
+		return false, v.handleColumnsKey(k)
+	case modeHelp:
+		if k.code == keyCtrlC {
+			return true, ""
+		}
+		v.mode = modeTable
+		return false, ""
+	}
+	return v.handleTableKey(k, height)
+}
+
+func (v *view) handleTableKey(k key, height int) (bool, string) {
+	n := v.table.Len()
+	page := rowsHeight(height)
+
+	move := func(delta int) {
+		v.follow = false
+		v.selected = max(min(v.selected+delta, n-1), 0)
+		if v.config.Stream && v.selected == n-1 && delta > 0 {
+			v.follow = true
+		}
+	}
+
+	switch k.code {
+	case keyCtrlC:
+		return true, ""
+	case keyUp:
+		move(-1)
+	case keyDown:
+		move(1)
+	case keyPageUp:
+		move(-page)
+	case keyPageDown:
+		move(page)
+	case keyHome:
+		v.follow = false
+		v.selected = 0
+	case keyEnd:
+		v.selected = max(n-1, 0)
+		v.follow = v.config.Stream
+	case keyEnter:
+		return false, v.showDetail()
+	case keyRune:
+		switch k.r {
+		case 'q':
+			return true, ""
+		case 'k':
+			move(-1)
+		case 'j':
+			move(1)
+		case 'g':
+			v.follow = false
+			v.selected = 0
+		case 'G':
+			v.selected = max(n-1, 0)
+			v.follow = v.config.Stream
+		case ' ', 'p':
+			v.paused = !v.paused
+			v.table.SetPaused(v.paused)
+		case 'f', '/':
+			v.prompt = newPrompt("Filters", strings.Join(v.filters, ","), func(s string) error {
+				return v.setFilters(splitList(s))
+			})
+		case 's':
+			v.prompt = newPrompt("Sort by", strings.Join(v.sortBy, ","), func(s string) error {
+				return v.setSorting(splitList(s))
+			})
+		case '<':
+			return false, v.cycleSorting(-1)
+		case '>':
+			return false, v.cycleSorting(1)
+		case 'r':
+			return false, v.reverseSorting()
+		case 'c':
+			v.mode = modeColumns
+			v.colCursor = 0
+		case '?':
+			v.mode = modeHelp
+		}
+	}
+	return false, ""
+}
+
+// cycleSorting sorts by the shown column before or after the current first
+// sort column
+func (v *view) cycleSorting(delta int) string {
+	if len(v.shown) == 0 {
+		return ""
+	}
+	idx := -1
+	if len(v.sortBy) > 0 {
+		current := strings.TrimPrefix(v.sortBy[0], "-")
+		for i, col := range v.shown {
+			if strings.EqualFold(col, current) {
+				idx = i
+				break
+			}
+		}
+	}
+	idx = (idx + delta + len(v.shown)) % len(v.shown)
+	if err := v.setSorting([]string{v.shown[idx]}); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func (v *view) reverseSorting() string {
+	if len(v.sortBy) == 0 {
+		return "not sorted; use s, < or > to sort"
+	}
+	sortBy := append([]string{}, v.sortBy...)
+	if strings.HasPrefix(sortBy[0], "-") {
+		sortBy[0] = sortBy[0][1:]
+	} else {
+		sortBy[0] = "-" + sortBy[0]
+	}
+	if err := v.setSorting(sortBy); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func (v *view) showDetail() string {
+	ev, err := v.table.EventJSON(v.selected)
+	if err != nil {
+		return err.Error()
+	}
+	v.detail = strings.Split(ev, "\n")
+	v.detailTitle = fmt.Sprintf("Event %d", v.selected+1)
+	v.scroll = 0
+	v.mode = modeDetail
+	return ""
+}
+
+func (v *view) handleDetailKey(k key, height int) bool {
+	page := detailHeight(height)
+	maxScroll := max(len(v.detail)-page, 0)
+	switch k.code {
+	case keyCtrlC:
+		return true
+	case keyUp:
+		v.scroll--
+	case keyDown:
+		v.scroll++
+	case keyPageUp:
+		v.scroll -= page
+	case keyPageDown:
+		v.scroll += page
+	case keyHome:
+		v.scroll = 0
+	case keyEnd:
+		v.scroll = maxScroll
+	case keyEnter, keyEscape:
+		v.mode = modeTable
+	case keyRune:
+		switch k.r {
+		case 'k':
+			v.scroll--
+		case 'j':
+			v.scroll++
+		case 'q':
+			v.mode = modeTable
+		}
+	}
+	v.scroll = max(min(v.scroll, maxScroll), 0)
+	return false
+}
+
+func (v *view) isShown(name string) bool {
+	for _, col := range v.shown {
+		if strings.EqualFold(col, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *view) handleColumnsKey(k key) string {
+	switch k.code {
+	case keyCtrlC, keyEnter, keyEscape:
+		v.mode = modeTable
+	case keyUp:
+		v.colCursor = max(v.colCursor-1, 0)
+	case keyDown:
+		v.colCursor = min(v.colCursor+1, len(v.columns)-1)
+	case keyRune:
+		switch k.r {
+		case 'q', 'c':
+			v.mode = modeTable
+		case 'k':
+			v.colCursor = max(v.colCursor-1, 0)
+		case 'j':
+			v.colCursor = min(v.colCursor+1, len(v.columns)-1)
+		case ' ', 'x':
+			return v.toggleColumn(v.columns[v.colCursor].Name)
+		}
+	}
+	return ""
+}
+
+func (v *view) toggleColumn(name string) string {
+	shown := make([]string, 0, len(v.shown)+1)
+	for _, col := range v.shown {
+		if !strings.EqualFold(col, name) {
+			shown = append(shown, col)
+		}
+	}
+	if len(shown) == len(v.shown) {
+		shown = append(shown, name)
+	}
+	if len(shown) == 0 {
+		return "at least one column must be shown"
+	}
+	if err := v.table.SetShowColumns(shown); err != nil {
+		return err.Error()
+	}
+	v.shown = shown
+	return ""
+}
+
+// render returns the lines of the screen
+func (v *view) render(width, height int, message string) []string {
+	v.table.SetWidth(width)
+
+	var lines []string
+	switch v.mode {
+	case modeDetail:
+		lines = v.renderDetail(height)
+	case modeColumns:
+		lines = v.renderColumns(height)
+	case modeHelp:
+		lines = append([]string{styleInverse + " Help"}, helpLines...)
+	default:
+		lines = v.renderTable(height)
+	}
+
+	// Message and key hints / prompt are always on the last two lines
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+	lines = lines[:max(height-2, 0)]
+	lines = append(lines, message)
+	if v.prompt != nil {
+		lines = append(lines, v.prompt.String())
+	} else {
+		lines = append(lines, styleBold+v.keyHints())
+	}
+	return lines
+}
+
+func (v *view) renderTable(height int) []string {
+	n := v.table.Len()
+	rows := rowsHeight(height)
+
+	if v.follow {
+		v.selected = n - 1
+	}
+	v.selected = max(min(v.selected, n-1), 0)
+	if v.selected < v.offset {
+		v.offset = v.selected
+	}
+	if v.selected >= v.offset+rows {
+		v.offset = v.selected - rows + 1
+	}
+	v.offset = max(min(v.offset, n-rows), 0)
+
+	status := fmt.Sprintf(" %s | %d events", v.config.Title, n)
+	if v.dropped > 0 {
+		status += fmt.Sprintf(" (%d dropped)", v.dropped)
+	}
+	if len(v.sortBy) > 0 {
+		status += " | sort: " + strings.Join(v.sortBy, ",")
+	}
+	if len(v.filters) > 0 {
+		status += " | filter: " + strings.Join(v.filters, ",")
+	}
+	switch {
+	case v.paused:
+		status += fmt.Sprintf(" | PAUSED (%d new)", v.table.Pending())
+	case v.follow:
+		status += " | following"
+	}
+
+	lines := []string{
+		styleInverse + status,
+		styleBold + v.table.FormatHeader(),
+	}
+	for i, row := range v.table.FormatRows(v.offset, rows) {
+		if v.offset+i == v.selected {
+			row = styleInverse + row
+		}
+		lines = append(lines, row)
+	}
+	return lines
+}
+
+func (v *view) renderDetail(height int) []string {
+	lines := []string{styleInverse + " " + v.detailTitle}
+	end := min(v.scroll+detailHeight(height), len(v.detail))
+	return append(lines, v.detail[v.scroll:end]...)
+}
+
+func (v *view) renderColumns(height int) []string {
+	lines := []string{styleInverse + " Columns (space: toggle, enter: done)"}
+	rows := max(height-3, 1)
+	offset := max(v.colCursor-rows+1, 0)
+	for i := offset; i < len(v.columns) && i < offset+rows; i++ {
+		col := v.columns[i]
+		mark := "[ ]"
+		if v.isShown(col.Name) {
+			mark = "[x]"
+		}
+		line := fmt.Sprintf("%s %-30s %s", mark, col.Name, col.Description)
+		if i == v.colCursor {
+			line = styleInverse + line
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (v *view) keyHints() string {
+	switch v.mode {
+	case modeDetail:
+		return "up/down: scroll  enter/esc: back"
+	case modeColumns:
+		return "up/down: select  space: toggle  enter/esc: back"
+	case modeHelp:
+		return "any key: back"
+	}
+	return "q: quit  enter: details  space: pause  f: filter  s: sort  </>: sort column  r: reverse  c: columns  ?: help"
+}
+
+// fit cuts s to width visible characters or fills it up with spaces, which get
+// the style active at the end of s. Escape sequences aren't counted.
+func fit(s string, width int) string {
+	var out strings.Builder
+	visible := 0
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			// Copy escape sequences up to their final letter
+			j := i + 1
+			for j < len(s) && !(s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z') {
+				j++
+			}
+			if j < len(s) {
+				j++
+			}
+			out.WriteString(s[i:j])
+			i = j
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if visible >= width {
+			continue
+		}
+		if r == '\t' {
+			r = ' '
+		}
+		out.WriteRune(r)
+		visible++
+	}
+	if visible < width {
+		out.WriteString(strings.Repeat(" ", width-visible))
+	}
+	return out.String()
+}
@@ -24,9 +24,14 @@ import (
 
 const FilePrefix = "@"
 
-// Param is a wrapper around params.Param. It's used to implement the logic that reads parameters from files.
+// Param is a wrapper around params.Param. It's used to implement the logic that reads parameters from files
+// and to append the values of repeated flags holding lists.
 type Param struct {
 	*params.Param
+
+	// changed is set once the value was given on the command line; later values of repeatable params are
+	// appended instead of replacing the previous ones
+	changed bool
 }
 
 func (p *Param) Set(val string) error {
@@ -36,7 +41,17 @@ func (p *Param) Set(val string) error {
 		if err != nil {
 			return fmt.Errorf("reading file %q for parameter %q: %w", filepath, p.Key, err)
 		}
-		return p.Param.Set(string(data))
+		val = string(data)
+		if p.IsRepeatable() {
+			val = strings.TrimSpace(val)
+		}
+	}
+	if p.IsRepeatable() && p.changed && p.String() != "" && val != "" {
+		val = p.String() + "," + val
+	}
+	if err := p.Param.Set(val); err != nil {
+		return err
 	}
-	return p.Param.Set(val)
+	p.changed = true
+	return nil
 }
@@ -43,3 +43,24 @@ func TestHandleFileArguments(t *testing.T) {
 	require.Nil(t, err, "Error while handling file argument")
 	require.Equal(t, p.AsString(), fileContent)
 }
+
+func TestRepeatedFlags(t *testing.T) {
+	t.Parallel()
+
+	pd := &params.ParamDesc{
+		Key:          "labels",
+		DefaultValue: "default=true",
+		TypeHint:     params.TypeStringMap,
+	}
+	p := Param{
+		Param: pd.ToParam(),
+	}
+
+	// The first value replaces the default one, the next ones are appended
+	require.NoError(t, p.Set("app=web"))
+	require.NoError(t, p.Set("tier=db,zone=a"))
+	require.Equal(t, map[string]string{"app": "web", "tier": "db", "zone": "a"}, p.AsStringMap())
+
+	require.Error(t, p.Set("invalid"))
+	require.Equal(t, "app=web,tier=db,zone=a", p.String())
+}
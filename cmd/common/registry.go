@@ -19,6 +19,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -29,6 +30,7 @@ import (
 
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/frontends"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/frontends/console"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/frontends/tui"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
@@ -38,6 +40,7 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/recording"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
 )
 
@@ -46,6 +49,7 @@ const (
 	OutputModeJSON       = "json"
 	OutputModeJSONPretty = "jsonpretty"
 	OutputModeYAML       = "yaml"
+	OutputModeParquet    = "parquet"
 )
 
 const (
@@ -104,6 +108,7 @@ func AddCommandsFromRegistry(rootCmd *cobra.Command, runtime runtime.Runtime, hi
 			operatorsGlobalParamsCollection,
 			gadgetInfo.OperatorParamsCollection.ToParams(),
 			hiddenColumnTags,
+			nil,
 		))
 	}
 }
@@ -178,6 +183,10 @@ func buildOutputFormatsHelp(outputFormats gadgets.OutputFormats) []string {
 	return outputFormatsHelp
 }
 
+// buildCommandFromGadget builds the command running gadgetDesc. If replayed is
+// set, the command replays a recording with the given header instead: the
+// recorded params are used as defaults, operators aren't run and there is no
+// --record flag.
 func buildCommandFromGadget(
 	gadgetDesc gadgets.GadgetDesc,
 	runtime runtime.Runtime,
@@ -185,6 +194,7 @@ func buildCommandFromGadget(
 	operatorsGlobalParamsCollection params.Collection,
 	operatorsParamsCollection params.Collection,
 	hiddenColumnTags []string,
+	replayed *recording.Header,
 ) *cobra.Command {
 	runGadgetDesc, isRunGadget := gadgetDesc.(runTypes.RunGadgetDesc)
 	var runGadgetInfo *runTypes.GadgetInfo
@@ -192,6 +202,8 @@ func buildCommandFromGadget(
 	var outputMode string
 	var filters []string
 	var timeout int
+	var recordPath string
+	var useTUI bool
 
 	var skipParams []params.ValueHint
 	if skipParamsInterface, ok := gadgetDesc.(gadgets.GadgetDescSkipParams); ok {
@@ -210,8 +222,11 @@ func buildCommandFromGadget(
 	// Instantiate gadget params - this is important, because the params get filled out by cobra
 	gadgetParams := gadgetDesc.ParamDescs().ToParams()
 
-	// Get per gadget operators
-	validOperators := operators.GetOperatorsForGadget(gadgetDesc)
+	// Get per gadget operators; when replaying, the events were already enriched
+	var validOperators operators.Operators
+	if replayed == nil {
+		validOperators = operators.GetOperatorsForGadget(gadgetDesc)
+	}
 
 	// TODO: Combine remote operator params with locally available ones
 	//  Example use case: setting default namespace for kubernetes
@@ -291,6 +306,18 @@ func buildCommandFromGadget(
 				)
 			}
 
+			if replayed == nil {
+				cmd.PersistentFlags().StringVar(
+					&recordPath,
+					"record",
+					"",
+					"Record the events of the gadget to the given file to look at them again later using 'ig replay'",
+				)
+			}
+
+			// Defaults of this gadget from the configuration files
+			gadgetSettings := settings.Gadget(commandKey(cmd))
+
 			// Add params matching the gadget type
 			extraGadgetParams.Add(*gadgets.GadgetParams(gadgetDesc, gType, parser).ToParams()...)
 
@@ -319,12 +346,23 @@ func buildCommandFromGadget(
 			// Add parser output flags
 			if parser != nil {
 				outputFormats.Append(buildColumnsOutputFormat(gadgetParams, parser, hiddenColumnTags))
+				outputFormats.Append(gadgets.OutputFormats{
+					OutputModeParquet: {
+						Name: "Parquet",
+						Description: "The events are written to the Parquet file given with '-o parquet=path', e.g. to load them into DuckDB.\n  " +
+							"All columns are written; nested columns are flattened, e.g. 'k8s.namespace' becomes 'k8s_namespace'.",
+					},
+				})
 				defaultOutputFormat = "columns"
 
+				defaultFilters := gadgetSettings.Filters
+				if defaultFilters == nil {
+					defaultFilters = []string{}
+				}
 				cmd.PersistentFlags().StringSliceVarP(
 					&filters,
 					"filter", "F",
-					[]string{},
+					defaultFilters,
 					`Filter rules
 		  A filter can match any column using the following syntax
 		    columnName:value       - matches, if the content of columnName equals exactly value
@@ -337,6 +375,14 @@ func buildCommandFromGadget(
 					     see [https://github.com/google/re2/wiki/Syntax] for more information on the syntax
 		`,
 				)
+
+				cmd.PersistentFlags().BoolVar(
+					&useTUI,
+					"tui",
+					false,
+					"Show the events in an interactive table that allows changing the sorting, filters and columns "+
+						"while the gadget is running; press '?' for help",
+				)
 			}
 
 			// Add alternative output formats available in the gadgets
@@ -348,6 +394,18 @@ func buildCommandFromGadget(
 
 			outputFormatsHelp := buildOutputFormatsHelp(outputFormats)
 
+			if gadgetSettings.Output != "" {
+				mode, _, _ := strings.Cut(gadgetSettings.Output, "=")
+				if _, ok := outputFormats[mode]; ok {
+					defaultOutputFormat = gadgetSettings.Output
+				} else {
+					log.Warnf("Ignoring output mode %q of the configuration: not supported by the gadget", mode)
+				}
+			}
+			if len(gadgetSettings.Columns) > 0 && defaultOutputFormat == OutputModeColumns {
+				defaultOutputFormat = OutputModeColumns + "=" + strings.Join(gadgetSettings.Columns, ",")
+			}
+
 			cmd.PersistentFlags().StringVarP(
 				&outputMode,
 				"output",
@@ -358,6 +416,29 @@ func buildCommandFromGadget(
 
 			gadgetParams.Add(extraGadgetParams...)
 
+			if replayed != nil {
+				// Use the recorded params as defaults, but don't send the replayed
+				// events to the sinks of the recording again
+				recordedParams := make(map[string]string, len(replayed.Params))
+				for key, value := range replayed.Params {
+					if key != gadgets.ParamSink {
+						recordedParams[key] = value
+					}
+				}
+				if err := gadgetParams.CopyFromMap(recordedParams, ""); err != nil {
+					return fmt.Errorf("applying recorded params: %w", err)
+				}
+			}
+
+			// Apply the defaults to the flags added above
+			if err := applyParams(cmd.PersistentFlags(), gadgetSettings.Params); err != nil {
+				return fmt.Errorf("applying config: %w", err)
+			}
+
+			// The flags are parsed again, don't append the values of repeatable
+			// params to the ones of the first parsing
+			resetRepeatableFlags(cmd)
+
 			return cmd.ParseFlags(args)
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
@@ -390,7 +471,27 @@ func buildCommandFromGadget(
 			}
 			defer runtime.Close()
 
-			fe := console.NewFrontend()
+			outputModeInfo := strings.SplitN(outputMode, "=", 2)
+			outputModeName := outputModeInfo[0]
+			outputModeParams := ""
+			if len(outputModeInfo) > 1 {
+				outputModeParams = outputModeInfo[1]
+			}
+
+			var fe frontends.Frontend
+			var tuiFrontend *tui.Frontend
+			if useTUI {
+				if outputModeName != OutputModeColumns {
+					return fmt.Errorf("--tui can only be used with the %s output mode", OutputModeColumns)
+				}
+				tuiFrontend, err = tui.NewFrontend()
+				if err != nil {
+					return err
+				}
+				fe = tuiFrontend
+			} else {
+				fe = console.NewFrontend()
+			}
 			defer fe.Close()
 
 			ctx := fe.GetContext()
@@ -434,15 +535,39 @@ func buildCommandFromGadget(
 			)
 			defer gadgetCtx.Cancel()
 
-			outputModeInfo := strings.SplitN(outputMode, "=", 2)
-			outputModeName := outputModeInfo[0]
-			outputModeParams := ""
-			if len(outputModeInfo) > 1 {
-				outputModeParams = outputModeInfo[1]
+			var recorder *recording.Writer
+			if recordPath != "" {
+				hostname, _ := os.Hostname()
+				recorder, err = recording.Create(recordPath, &recording.Header{
+					Category:         gadgetDesc.Category(),
+					Gadget:           gadgetDesc.Name(),
+					Params:           gadgetParams.ParamMap(),
+					Args:             args,
+					GadgetInfo:       runGadgetInfo,
+					HiddenColumnTags: hiddenColumnTags,
+					Node:             hostname,
+					StartTime:        time.Now(),
+				})
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := recorder.Close(); err != nil {
+						log.Warnf("writing recording: %v", err)
+					}
+				}()
+				if parser != nil {
+					parser.SetRecordCallback(func(key string, ev any) {
+						if err := recorder.Record(key, ev); err != nil {
+							gadgetCtx.Logger().Warnf("recording event: %v", err)
+						}
+					})
+				}
 			}
 
 			if parser == nil {
 				var transformResult func(any) ([]byte, error)
+				combineResults := false
 
 				switch outputModeName {
 				default:
@@ -456,6 +581,7 @@ func buildCommandFromGadget(
 					}
 
 					transformResult = formats[outputModeName].Transform
+					combineResults = formats[outputModeName].RequiresCombinedResult
 				case OutputModeJSON:
 					transformResult = func(result any) ([]byte, error) {
 						r, _ := result.([]byte)
@@ -476,10 +602,22 @@ func buildCommandFromGadget(
 				// returned after handling those results.
 				results, err := runtime.RunGadget(gadgetCtx)
 
+				// Payloads of all nodes, for the output formats combining them
+				payloads := make(map[string][]byte, len(results))
+
 				for node, result := range results {
 					if result.Error != nil {
 						continue
 					}
+					if recorder != nil {
+						if err := recorder.RecordResult(node, result.Payload); err != nil {
+							gadgetCtx.Logger().Warnf("recording result for %q: %v", node, err)
+						}
+					}
+					if combineResults {
+						payloads[node] = result.Payload
+						continue
+					}
 					transformed, err := transformResult(result.Payload)
 					if err != nil {
 						gadgetCtx.Logger().Warnf("transform result for %q failed: %v", node, err)
@@ -488,6 +626,15 @@ func buildCommandFromGadget(
 					results[node].Payload = transformed
 				}
 
+				if combineResults {
+					transformed, terr := transformResult(payloads)
+					if terr != nil {
+						return fmt.Errorf("transforming results: %w", terr)
+					}
+					fe.Output(string(transformed))
+					return err
+				}
+
 				if len(results) == 1 {
 					// still need to iterate as we don't necessarily know the key
 					for _, result := range results {
@@ -510,8 +657,9 @@ func buildCommandFromGadget(
 				return err
 			}
 
-			// Add filters if requested
-			if len(filters) > 0 {
+			// Add filters if requested; the interactive frontend applies them itself,
+			// so they can be changed later on
+			if len(filters) > 0 && tuiFrontend == nil {
 				err = parser.SetFilters(filters)
 				if err != nil {
 					return fmt.Errorf("setting filters: %w", err)
@@ -620,6 +768,39 @@ func buildCommandFromGadget(
 
 			parser.SetLogCallback(fe.Logf)
 
+			stopDropReports := reportDroppedEvents(parser, fe)
+			defer stopDropReports()
+
+			if tuiFrontend != nil {
+				var sortBy []string
+				if gType.CanSort() {
+					sortBy = gadgetParams.Get(gadgets.ParamSortBy).AsStringSlice()
+				}
+				table := parser.GetEventTable(tui.MaxEvents)
+				err := tuiFrontend.SetTable(table, &tui.TableConfig{
+					Title:       strings.TrimSpace(gadgetDesc.Category() + " " + gadgetDesc.Name()),
+					Columns:     parser.GetColumnAttributes(),
+					ShowColumns: valid,
+					SortBy:      sortBy,
+					Filters:     filters,
+					Stream:      !gType.CanSort(),
+				})
+				if err != nil {
+					return err
+				}
+				parser.SetEventCallback(table.EventHandlerFunc())
+				parser.SetEventCallback(table.EventHandlerFuncArray())
+
+				// Keep the frontend open after the gadget stopped until the user quits
+				_, err = runtime.RunGadget(gadgetCtx)
+				tuiFrontend.Finish(err)
+				tuiFrontend.Wait()
+				if err != nil {
+					return fmt.Errorf("running gadget: %w", err)
+				}
+				return nil
+			}
+
 			// Wire up callbacks before handing over to runtime depending on the output mode
 			switch outputModeName {
 			default:
@@ -674,6 +855,29 @@ func buildCommandFromGadget(
 				}
 				fe.Output(formatter.FormatHeader())
 				parser.SetEventCallback(formatter.EventHandlerFuncArray())
+			case OutputModeParquet:
+				if outputModeParams == "" {
+					return fmt.Errorf("missing file name: use '-o %s=path'", OutputModeParquet)
+				}
+				file, err := os.Create(outputModeParams)
+				if err != nil {
+					return fmt.Errorf("creating parquet file: %w", err)
+				}
+				defer file.Close()
+
+				parquetWriter := parser.GetParquetWriter(file)
+				parser.SetEventCallback(parquetWriter.EventHandlerFunc())
+				parser.SetEventCallback(parquetWriter.EventHandlerFuncArray())
+
+				_, err = runtime.RunGadget(gadgetCtx)
+				if closeErr := parquetWriter.Close(); closeErr != nil {
+					return fmt.Errorf("writing parquet file: %w", closeErr)
+				}
+				if err != nil {
+					return fmt.Errorf("running gadget: %w", err)
+				}
+				gadgetCtx.Logger().Infof("Events written to %q", outputModeParams)
+				return nil
 			case OutputModeJSON:
 				jsonCallback := printEventAsJSONFn(fe)
 				if isRunGadget {
@@ -793,11 +997,25 @@ func AddFlags(cmd *cobra.Command, params *params.Params, skipParams []params.Val
 			desc += " [" + strings.Join(p.PossibleValues, ", ") + "]"
 		}
 
-		flag := cmd.PersistentFlags().VarPF(&Param{p}, p.Key, p.Alias, desc)
+		flag := cmd.PersistentFlags().VarPF(&Param{Param: p}, p.Key, p.Alias, desc)
 		if p.IsMandatory {
 			cmd.MarkPersistentFlagRequired(p.Key)
 		}
 
+		// Complete the possible values of the flag, along with their descriptions
+		if p.PossibleValues != nil {
+			completions := make([]string, 0, len(p.PossibleValues))
+			for _, v := range p.PossibleValues {
+				if d, ok := p.ValueDescriptions[v]; ok {
+					v += "\t" + d
+				}
+				completions = append(completions, v)
+			}
+			cmd.RegisterFlagCompletionFunc(p.Key, func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+				return completions, cobra.ShellCompDirectiveNoFileComp
+			})
+		}
+
 		// Allow passing a boolean flag as --foo instead of having to use --foo=true
 		if p.IsBoolFlag() {
 			flag.NoOptDefVal = "true"
@@ -805,6 +1023,32 @@ func AddFlags(cmd *cobra.Command, params *params.Params, skipParams []params.Val
 	}
 }
 
+// reportDroppedEvents periodically passes the number of dropped events of the
+// parser to the frontend. The returned function stops it after a last report.
+func reportDroppedEvents(parser parser.Parser, fe frontends.Frontend) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fe.ReportDropped(parser.DroppedEvents())
+			case <-done:
+				fe.ReportDropped(parser.DroppedEvents())
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
 func printEventAsJSONFn(fe frontends.Frontend) func(ev any) {
 	return func(ev any) {
 		d, err := json.Marshal(ev)
@@ -0,0 +1,95 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/recording"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime/replay"
+)
+
+func NewReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay FILE [FILE...] [flags]",
+		Short: "Replay gadget runs recorded using --record",
+		Long: `Replay gadget runs recorded using --record
+
+The events are fed through the same filters, sorting and output formats as
+when running the gadget, so all flags of the recorded gadget (like --filter,
+--output or --sort) can be used. Recordings of the same gadget taken on
+different nodes are merged when passing several files.`,
+		Example: `  ig replay exec.rec -o columns=comm,pid
+  ig replay node1.rec node2.rec --speed 0 --sort -sent`,
+		SilenceUsage: true,
+
+		// Flags depend on the recorded gadget and are handled by its command
+		DisableFlagParsing: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var files, gadgetArgs []string
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "-") {
+					gadgetArgs = args[i:]
+					break
+				}
+				files = append(files, arg)
+			}
+
+			if len(files) == 0 {
+				if len(gadgetArgs) > 0 && gadgetArgs[0] != "-h" && gadgetArgs[0] != "--help" {
+					return fmt.Errorf("recordings must be given before any flags")
+				}
+				return cmd.Help()
+			}
+
+			rec, err := recording.Open(files...)
+			if err != nil {
+				return err
+			}
+
+			header := rec.Header
+			gadgetDesc := gadgetregistry.Get(header.Category, header.Gadget)
+			if gadgetDesc == nil {
+				return fmt.Errorf("gadget %q of category %q is not available", header.Gadget, header.Category)
+			}
+
+			replayRuntime := replay.New(rec)
+			gadgetCmd := buildCommandFromGadget(
+				gadgetDesc,
+				replayRuntime,
+				replayRuntime.GlobalParamDescs().ToParams(),
+				params.Collection{},
+				params.Collection{},
+				header.HiddenColumnTags,
+				header,
+			)
+			// Add the gadget command as child to inherit the global flags
+			cmd.AddCommand(gadgetCmd)
+
+			gadgetArgs = append(gadgetArgs, header.Args...)
+			if err := gadgetCmd.PreRunE(gadgetCmd, gadgetArgs); err != nil {
+				return err
+			}
+			return gadgetCmd.RunE(gadgetCmd, gadgetArgs)
+		},
+	}
+	return cmd
+}
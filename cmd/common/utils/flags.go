@@ -103,6 +103,8 @@ type RuntimesSocketPathConfig struct {
 	Containerd string
 	Crio       string
 	Podman     string
+	LXD        string
+	Machined   string
 }
 
 func AddRuntimesSocketPathFlags(command *cobra.Command, config *RuntimesSocketPathConfig) {
@@ -133,6 +135,20 @@ func AddRuntimesSocketPathFlags(command *cobra.Command, config *RuntimesSocketPa
 		runtimeclient.PodmanDefaultSocketPath,
 		"Podman Unix socket path",
 	)
+
+	command.PersistentFlags().StringVarP(
+		&config.LXD,
+		"lxd-socketpath", "",
+		runtimeclient.LXDDefaultSocketPath,
+		"LXD REST API Unix socket path",
+	)
+
+	command.PersistentFlags().StringVarP(
+		&config.Machined,
+		"machined-socketpath", "",
+		runtimeclient.MachinedDefaultSocketPath,
+		"D-Bus system bus Unix socket path used to reach systemd-machined",
+	)
 }
 
 func AddRegistryAuthVariablesAndFlags(cmd *cobra.Command, authOptions *oci.AuthOptions) {
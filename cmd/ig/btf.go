@@ -0,0 +1,151 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/btfgen"
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+)
+
+func newBTFCommand() *cobra.Command {
+	btfCmd := &cobra.Command{
+		Use:   "btf",
+		Short: "Inspect the BTF information used to run gadgets",
+	}
+	btfCmd.AddCommand(newBTFCheckCommand())
+	return btfCmd
+}
+
+func gadgetName(desc gadgets.GadgetDesc) string {
+	if desc.Category() == gadgets.CategoryNone {
+		return desc.Name()
+	}
+	return desc.Category() + " " + desc.Name()
+}
+
+func newBTFCheckCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check [gadget...]",
+		Short: "Check which gadgets can run on the current kernel",
+		Long: `Check which gadgets can run on the current kernel
+
+The CO-RE relocations and the kernel functions used by the eBPF programs of the
+built-in gadgets are resolved against the BTF information found for the
+current kernel. Gadgets can be selected by their full name, e.g. "trace exec".`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			descs := gadgetregistry.GetAll()
+			sort.Slice(descs, func(i, j int) bool {
+				return gadgetName(descs[i]) < gadgetName(descs[j])
+			})
+
+			selected := make(map[string]bool)
+			for _, arg := range args {
+				selected[arg] = false
+			}
+
+			release, _ := btfgen.KernelRelease()
+			source := btfgen.GetSource()
+			out := cmd.OutOrStdout()
+
+			fmt.Fprintf(out, "Kernel: %s\n", release)
+			if source.Path != "" {
+				fmt.Fprintf(out, "BTF source: %s (%s)\n", source.Kind, source.Path)
+			} else {
+				fmt.Fprintf(out, "BTF source: %s\n", source.Kind)
+			}
+			for _, err := range source.Errors {
+				fmt.Fprintf(out, "  %s\n", err)
+			}
+			fmt.Fprintln(out)
+
+			w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "GADGET\tSTATUS\tDETAILS")
+
+			failed := 0
+			for _, desc := range descs {
+				name := gadgetName(desc)
+				if len(args) > 0 {
+					if _, ok := selected[name]; !ok {
+						continue
+					}
+					selected[name] = true
+				}
+
+				getter, ok := desc.(gadgets.EBPFSpecsGetter)
+				if !ok {
+					if len(args) > 0 {
+						fmt.Fprintf(w, "%s\tunknown\tgadget doesn't provide its eBPF programs\n", name)
+					}
+					continue
+				}
+
+				specs, err := getter.EBPFSpecs()
+				if err != nil {
+					failed++
+					fmt.Fprintf(w, "%s\tfailed\t%s\n", name, err)
+					continue
+				}
+
+				status := "ok"
+				var details []string
+				for _, problem := range btfgen.Check(specs...) {
+					if problem.Fatal {
+						status = "failed"
+					} else if status == "ok" {
+						status = "warning"
+					}
+					details = append(details, problem.String())
+				}
+				if status == "failed" {
+					failed++
+				}
+
+				if len(details) == 0 {
+					details = []string{""}
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", name, status, details[0])
+				for _, detail := range details[1:] {
+					fmt.Fprintf(w, "\t\t%s\n", detail)
+				}
+			}
+			w.Flush()
+
+			var unknown []string
+			for name, found := range selected {
+				if !found {
+					unknown = append(unknown, name)
+				}
+			}
+			if len(unknown) > 0 {
+				sort.Strings(unknown)
+				return fmt.Errorf("unknown gadgets: %s", strings.Join(unknown, ", "))
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d gadgets can't run on this kernel", failed)
+			}
+			return nil
+		},
+	}
+}
@@ -16,9 +16,11 @@ package containers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/moby/moby/pkg/stringid"
@@ -28,15 +30,22 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/ig/utils"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/systemd"
 	igmanager "github.com/inspektor-gadget/inspektor-gadget/pkg/ig-manager"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
 )
 
 const igSubKey = "ig-key"
 
+// exitTimeout is how long the DELETED events wait for the runtime to tell how
+// the container terminated
+const exitTimeout = 500 * time.Millisecond
+
 func NewListContainersCmd() *cobra.Command {
 	var commonFlags utils.CommonFlags
 	var optionWatch bool
+	var optionSystemdUnits bool
+	var optionUnit string
 
 	cmd := &cobra.Command{
 		Use:   "list-containers",
@@ -49,7 +58,17 @@ func NewListContainersCmd() *cobra.Command {
 				return err
 			}
 
-			igmanager, err := igmanager.NewManager(commonFlags.RuntimeConfigs)
+			if optionUnit != "" && !optionSystemdUnits {
+				return commonutils.WrapInErrInvalidArg("--unit",
+					errors.New("systemd units are only handled with --systemd-units"))
+			}
+
+			var opts []containercollection.ContainerCollectionOption
+			if optionSystemdUnits {
+				opts = append(opts, containercollection.WithSystemdUnits())
+			}
+
+			igmanager, err := igmanager.NewManager(commonFlags.RuntimeConfigs, opts...)
 			if err != nil {
 				return commonutils.WrapInErrManagerInit(err)
 			}
@@ -58,6 +77,7 @@ func NewListContainersCmd() *cobra.Command {
 			selector := containercollection.ContainerSelector{
 				Runtime: containercollection.RuntimeSelector{
 					ContainerName: commonFlags.Containername,
+					SystemdUnit:   systemd.NormalizeUnitName(optionUnit),
 				},
 			}
 
@@ -88,18 +108,39 @@ func NewListContainersCmd() *cobra.Command {
 				}
 				return event.Container.Runtime.ContainerImageName
 			})
+			// Show how the containers terminated in the DELETED events
+			for _, name := range []string{"exit.code", "exit.reason"} {
+				if col, ok := cols.GetColumn(name); ok {
+					col.Visible = true
+				}
+			}
 
 			parser, err := commonutils.NewGadgetParserWithK8sAndRuntimeInfo(&commonFlags.OutputConfig, cols)
 			if err != nil {
 				return commonutils.WrapInErrParserCreate(err)
 			}
+			var printMu sync.Mutex
+			printEvent := func(event *containercollection.PubSubEvent) {
+				printMu.Lock()
+				defer printMu.Unlock()
+				if err := printPubSubEvent(parser, commonFlags, event); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+				}
+			}
 			containers := igmanager.ContainerCollection.Subscribe(
 				igSubKey,
 				selector,
 				func(event containercollection.PubSubEvent) {
-					if err = printPubSubEvent(parser, commonFlags, &event); err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+					if event.Type != containercollection.EventTypeRemoveContainer {
+						printEvent(&event)
+						return
 					}
+					// Don't delay the removal of the container while the
+					// runtime reports how it terminated
+					go func() {
+						event.Exit = event.Container.WaitExit(exitTimeout)
+						printEvent(&event)
+					}()
 				},
 			)
 			defer igmanager.ContainerCollection.Unsubscribe(igSubKey)
@@ -108,6 +149,7 @@ func NewListContainersCmd() *cobra.Command {
 				fmt.Println(parser.BuildColumnsHeader())
 			}
 			timestamp := time.Now().Format(time.RFC3339)
+			printMu.Lock()
 			for _, container := range containers {
 				e := containercollection.PubSubEvent{
 					Timestamp: timestamp,
@@ -115,9 +157,11 @@ func NewListContainersCmd() *cobra.Command {
 					Container: container,
 				}
 				if err = printPubSubEvent(parser, commonFlags, &e); err != nil {
+					printMu.Unlock()
 					return err
 				}
 			}
+			printMu.Unlock()
 
 			utils.WaitForEnd(&commonFlags)
 			return nil
@@ -129,6 +173,16 @@ func NewListContainersCmd() *cobra.Command {
 		"watch", "w",
 		false,
 		"After listing the containers, watch for new containers")
+	cmd.Flags().BoolVar(
+		&optionSystemdUnits,
+		"systemd-units",
+		false,
+		"Handle the systemd services and slices and the plain cgroups of the host as containers. Requires cgroup v2")
+	cmd.Flags().StringVar(
+		&optionUnit,
+		"unit",
+		"",
+		"Show only the systemd unit with that name, or the units in it if it's a slice")
 
 	utils.AddCommonFlags(cmd, &commonFlags)
 
@@ -15,7 +15,12 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -23,13 +28,17 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 
 	gadgetservice "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/sinks"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/webui"
 )
 
-func newDaemonCommand(runtime runtime.Runtime) *cobra.Command {
+func newDaemonCommand(runtime runtime.Runtime, hiddenColumnTags []string) *cobra.Command {
 	daemonCmd := &cobra.Command{
 		Use:          "daemon",
 		Short:        "Run Inspektor Gadget as a daemon",
@@ -40,6 +49,12 @@ func newDaemonCommand(runtime runtime.Runtime) *cobra.Command {
 	var socket string
 	var group string
 	var eventBufferLength uint64
+	var sinkURLs []string
+	var sinkFileDir string
+	var sinkAllowedHosts []string
+	var webAddress string
+	var webTokenFile string
+	var webInsecure bool
 
 	daemonCmd.PersistentFlags().StringVarP(
 		&group,
@@ -63,11 +78,62 @@ func newDaemonCommand(runtime runtime.Runtime) *cobra.Command {
 		16384,
 		"The events buffer length. A low value could impact horizontal scaling.")
 
+	daemonCmd.PersistentFlags().StringSliceVarP(
+		&sinkURLs,
+		"sink",
+		"",
+		[]string{},
+		"Send the events of all gadgets to the given sinks, e.g. file:///var/log/ig/events.jsonl?max-size=100MB,"+
+			" syslog+tcp://host:514, otlp-grpc://host:4317, otlp-http://host:4318 or kafka://broker:9092/topic")
+
+	daemonCmd.PersistentFlags().StringVarP(
+		&sinkFileDir,
+		"sink-file-dir",
+		"",
+		"",
+		"Allow clients to send the events of a gadget run to file sinks inside this directory")
+
+	daemonCmd.PersistentFlags().StringSliceVarP(
+		&sinkAllowedHosts,
+		"sink-allowed-hosts",
+		"",
+		[]string{},
+		"Allow clients to send the events of a gadget run to network sinks on these hosts, given as host or host:port")
+
+	daemonCmd.PersistentFlags().StringVarP(
+		&webAddress,
+		"web-address",
+		"",
+		"",
+		"Serve a web interface to run gadgets at the given loopback address, e.g. 127.0.0.1:8080. Browsers"+
+			" need the token written to --web-token-file")
+
+	daemonCmd.PersistentFlags().StringVarP(
+		&webTokenFile,
+		"web-token-file",
+		"",
+		"/var/run/ig/web-token",
+		"File to write the token of the web interface to, readable by the group given with --group")
+
+	daemonCmd.PersistentFlags().BoolVarP(
+		&webInsecure,
+		"web-insecure",
+		"",
+		false,
+		"Allow serving the web interface on non-loopback addresses. The token is sent in plain text, so only"+
+			" use it on trusted networks")
+
 	daemonCmd.RunE = func(cmd *cobra.Command, args []string) error {
 		if os.Geteuid() != 0 {
 			return fmt.Errorf("%s must be run as root to be able to run eBPF programs", filepath.Base(os.Args[0]))
 		}
 
+		for _, sinkURL := range sinkURLs {
+			if err := sinks.Validate(sinkURL); err != nil {
+				return fmt.Errorf("invalid sink: %w", err)
+			}
+		}
+
 		socketType, socketPath, err := api.ParseSocketAddress(socket)
 		if err != nil {
 			return fmt.Errorf("invalid daemon-socket address: %w", err)
@@ -85,14 +151,115 @@ func newDaemonCommand(runtime runtime.Runtime) *cobra.Command {
 			return fmt.Errorf("group %q not found", group)
 		}
 
+		if webAddress != "" {
+			listener, err := net.Listen("tcp", webAddress)
+			if err != nil {
+				return fmt.Errorf("listening for web interface: %w", err)
+			}
+			if addr, ok := listener.Addr().(*net.TCPAddr); !ok || !addr.IP.IsLoopback() {
+				if !webInsecure {
+					listener.Close()
+					return fmt.Errorf("refusing to serve the web interface on non-loopback address %s without --web-insecure", listener.Addr())
+				}
+				log.Warnf("serving the web interface on non-loopback address %s, its token is sent in plain text", listener.Addr())
+			}
+
+			token, err := writeWebToken(webTokenFile, gid)
+			if err != nil {
+				listener.Close()
+				return fmt.Errorf("writing web interface token: %w", err)
+			}
+			defer os.Remove(webTokenFile)
+
+			stop, err := serveWebUI(listener, socketType, socketPath, hiddenColumnTags, token)
+			if err != nil {
+				return err
+			}
+			defer stop()
+			log.Infof("serving web interface at http://%s/?token=<token>, with the token from %q", listener.Addr(), webTokenFile)
+		}
+
 		log.Infof("starting Inspektor Gadget daemon at %q", socket)
 		service := gadgetservice.NewService(log.StandardLogger(), eventBufferLength)
 		return service.Run(gadgetservice.RunConfig{
-			SocketType: socketType,
-			SocketPath: socketPath,
-			SocketGID:  gid,
+			SocketType:       socketType,
+			SocketPath:       socketPath,
+			SocketGID:        gid,
+			Sinks:            sinkURLs,
+			SinkFileDir:      sinkFileDir,
+			SinkAllowedHosts: sinkAllowedHosts,
 		})
 	}
 
 	return daemonCmd
 }
+
+// writeWebToken generates a random token for the web interface and writes it to
+// path, only readable by root and gid like the unix socket of the daemon
+func writeWebToken(path string, gid int) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	// Don't reuse an existing file, it could be readable by others
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := f.Chown(0, gid); err != nil {
+		return "", err
+	}
+	if err := f.Chmod(0o640); err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(token + "\n"); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// serveWebUI serves the web interface on listener, using the gadget service
+// of this daemon. Browsers must authenticate with token. Columns with any of
+// hiddenColumnTags are hidden by default.
+func serveWebUI(listener net.Listener, socketType, socketPath string, hiddenColumnTags []string, token string) (func(), error) {
+	target := "unix://" + socketPath
+	if socketType == "tcp" {
+		target = "passthrough:///" + socketPath
+	}
+	conn, err := grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("connecting to gadget service: %w", err)
+	}
+
+	node, err := os.Hostname()
+	if err != nil {
+		node = "local"
+	}
+	clients := map[string]api.GadgetManagerClient{node: api.NewGadgetManagerClient(conn)}
+	connect := func(ctx context.Context, nodes []string) (map[string]api.GadgetManagerClient, func(), error) {
+		return clients, func() {}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server := webui.New(connect, log.StandardLogger(), hiddenColumnTags)
+	server.SetToken(token)
+	go func() {
+		if err := server.Serve(ctx, listener); err != nil {
+			log.Errorf("serving web interface: %v", err)
+		}
+	}()
+	return func() {
+		cancel()
+		conn.Close()
+	}, nil
+}
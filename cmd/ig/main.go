@@ -28,18 +28,23 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/image"
 	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/ig/containers"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/btfgen"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime/local"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/experimental"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
 
 	// This is a blank include that actually imports all gadgets
-	// TODO: traceloop is imported separately because it is not in all-gadgets
+	// TODO: advise seccomp and traceloop are imported separately because they
+	// are not in all-gadgets
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/all-gadgets"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/traceloop/tracer"
 
 	// Another blank import for the used operator
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/conntrackresolver"
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/localmanager"
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/prometheus"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/reversedns"
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/socketenricher"
 )
 
@@ -53,8 +58,10 @@ func main() {
 		Short: "Collection of gadgets for containers",
 	}
 	common.AddVerboseFlag(rootCmd)
+	common.AddConfigFlags(rootCmd)
 
 	host.AddFlags(rootCmd)
+	btfgen.AddFlags(rootCmd)
 
 	rootCmd.AddCommand(
 		containers.NewListContainersCmd(),
@@ -70,15 +77,32 @@ func main() {
 		os.Exit(1)
 	}
 
+	rootCmd.AddCommand(common.NewConfigCmd("ig"))
+	settings, err := common.LoadConfig("ig", rootCmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	runtime := local.New()
 	hiddenColumnTags := []string{"kubernetes"}
+	if settings.HiddenColumnTags != nil {
+		hiddenColumnTags = settings.HiddenColumnTags
+	}
 	common.AddCommandsFromRegistry(rootCmd, runtime, hiddenColumnTags)
 
-	rootCmd.AddCommand(newDaemonCommand(runtime))
+	rootCmd.AddCommand(newDaemonCommand(runtime, hiddenColumnTags))
+	rootCmd.AddCommand(newBTFCommand())
+	rootCmd.AddCommand(common.NewReplayCmd())
 	rootCmd.AddCommand(image.NewImageCmd())
 	rootCmd.AddCommand(common.NewLoginCmd())
 	rootCmd.AddCommand(common.NewLogoutCmd())
 
+	if err := common.ApplyConfig(rootCmd); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
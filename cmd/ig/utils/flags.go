@@ -79,6 +79,10 @@ func AddCommonFlags(command *cobra.Command, commonFlags *CommonFlags) {
 				socketPath = commonFlags.RuntimesSocketPathConfig.Crio
 			case types.RuntimeNamePodman:
 				socketPath = commonFlags.RuntimesSocketPathConfig.Podman
+			case types.RuntimeNameLXD:
+				socketPath = commonFlags.RuntimesSocketPathConfig.LXD
+			case types.RuntimeNameMachined:
+				socketPath = commonFlags.RuntimesSocketPathConfig.Machined
 			default:
 				return commonutils.WrapInErrInvalidArg("--runtime / -r",
 					fmt.Errorf("runtime %q is not supported", p))
@@ -138,7 +142,7 @@ func AddCommonFlags(command *cobra.Command, commonFlags *CommonFlags) {
 	command.PersistentFlags().StringVarP(
 		&commonFlags.Runtimes,
 		"runtimes", "r",
-		strings.Join(containerutils.AvailableRuntimes, ","),
+		strings.Join(containerutils.DefaultRuntimes, ","),
 		fmt.Sprintf("Container runtimes to be used separated by comma. Supported values are: %s",
 			strings.Join(containerutils.AvailableRuntimes, ", ")),
 	)
@@ -50,7 +50,7 @@ var rootCmd = &cobra.Command{
 	Short: "Collection of gadgets for Kubernetes developers",
 }
 
-var infoSkipCommands = []string{"deploy", "undeploy", "version"}
+var infoSkipCommands = []string{"deploy", "undeploy", "version", "config"}
 
 func init() {
 	utils.FlagInit(rootCmd)
@@ -62,6 +62,7 @@ func main() {
 	}
 
 	common.AddVerboseFlag(rootCmd)
+	common.AddConfigFlags(rootCmd)
 
 	// grpcruntime.New() will try to fetch the info from the cluster by
 	// default. Make sure we don't do this when certain commands are run
@@ -71,6 +72,7 @@ func main() {
 	isHelp := false
 	isVersion := false
 	isDeployUndeploy := false
+	isConfig := false
 	for _, arg := range os.Args[1:] {
 		for _, skipCmd := range infoSkipCommands {
 			if arg == skipCmd {
@@ -80,6 +82,7 @@ func main() {
 
 		isVersion = isVersion || arg == "version"
 		isDeployUndeploy = isDeployUndeploy || arg == "deploy" || arg == "undeploy"
+		isConfig = isConfig || arg == "config"
 		isHelp = isHelp || arg == "--help" || arg == "-h"
 	}
 
@@ -104,7 +107,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	if !isHelp && !isDeployUndeploy && !runtimeGlobalParams.Get(grpcruntime.ParamGadgetNamespace).IsSet() {
+	// Apply the defaults of the configuration files before using the runtime
+	// params, e.g. to get the gadget namespace
+	rootCmd.AddCommand(common.NewConfigCmd("kubectl-gadget"))
+	settings, err := common.LoadConfig("kubectl-gadget", rootCmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !isHelp && !isDeployUndeploy && !isConfig && !runtimeGlobalParams.Get(grpcruntime.ParamGadgetNamespace).IsSet() {
 		gadgetNamespaces, err := utils.GetRunningGadgetNamespaces()
 		if err != nil {
 			log.Fatalf("Searching for running Inspektor Gadget instances: %s", err)
@@ -133,12 +145,21 @@ func main() {
 	gadgetNamespace := runtimeGlobalParams.Get(grpcruntime.ParamGadgetNamespace).AsString()
 
 	hiddenColumnTags := []string{"runtime"}
+	if settings.HiddenColumnTags != nil {
+		hiddenColumnTags = settings.HiddenColumnTags
+	}
 	common.AddCommandsFromRegistry(rootCmd, grpcRuntime, hiddenColumnTags)
 
 	// Advise and traceloop category is still being handled by CRs for now
 	rootCmd.AddCommand(advise.NewAdviseCmd(gadgetNamespace))
 	rootCmd.AddCommand(NewTraceloopCmd(gadgetNamespace))
 	rootCmd.AddCommand(common.NewSyncCommand(grpcRuntime))
+	rootCmd.AddCommand(newWebCmd(grpcRuntime, hiddenColumnTags))
+
+	if err := common.ApplyConfig(rootCmd); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,82 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+	grpcruntime "github.com/inspektor-gadget/inspektor-gadget/pkg/runtime/grpc"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/webui"
+)
+
+func newWebCmd(runtime *grpcruntime.Runtime, hiddenColumnTags []string) *cobra.Command {
+	var address string
+
+	cmd := &cobra.Command{
+		Use:   "web",
+		Short: "Serve a web interface to run gadgets on the cluster",
+		Long: `Serve a web interface to run gadgets on the cluster. Requests are proxied to the
+gadget pods of the selected nodes using the credentials of the current user.
+Anyone able to reach the address can run gadgets with these credentials.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			connect := func(ctx context.Context, nodes []string) (map[string]api.GadgetManagerClient, func(), error) {
+				runtimeParams := runtime.ParamDescs().ToParams()
+				if err := runtimeParams.Set(grpcruntime.ParamNode, strings.Join(nodes, ",")); err != nil {
+					return nil, nil, err
+				}
+				conns, err := runtime.DialNodes(ctx, runtimeParams)
+				if err != nil {
+					return nil, nil, err
+				}
+				clients := make(map[string]api.GadgetManagerClient, len(conns))
+				for node, conn := range conns {
+					clients[node] = api.NewGadgetManagerClient(conn)
+				}
+				return clients, func() {
+					for _, conn := range conns {
+						conn.Close()
+					}
+				}, nil
+			}
+
+			listener, err := net.Listen("tcp", address)
+			if err != nil {
+				return fmt.Errorf("listening for web interface: %w", err)
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			fmt.Fprintf(os.Stderr, "Serving web interface at http://%s\n", listener.Addr())
+			return webui.New(connect, log.StandardLogger(), hiddenColumnTags).Serve(ctx, listener)
+		},
+	}
+
+	cmd.Flags().StringVar(&address, "address", "127.0.0.1:8080", "Address to serve the web interface at")
+
+	return cmd
+}
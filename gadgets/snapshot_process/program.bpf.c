@@ -49,7 +49,7 @@ int ig_snap_proc(struct bpf_iter__task *ctx)
 
 	__u64 mntns_id = task->nsproxy->mnt_ns->ns.inum;
 
-	if (gadget_should_discard_mntns_id(mntns_id))
+	if (gadget_should_discard_task(task))
 		return 0;
 
 	parent = task->real_parent;
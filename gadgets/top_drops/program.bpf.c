@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2024 The Inspektor Gadget authors
+
+#include <vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_endian.h>
+
+#include <gadget/mntns_filter.h>
+#include <gadget/types.h>
+#include <gadget/macros.h>
+#include <gadget/kernel_stack_map.h>
+
+#define GADGET_TYPE_TRACING
+#include <gadget/sockets-map.h>
+
+/* The maximum number of items in maps */
+#define MAX_ENTRIES 10240
+
+#define TASK_COMM_LEN 16
+
+/* Define here, because there are conflicts with include files */
+#define AF_INET 2
+#define AF_INET6 10
+#define ETH_P_IP 0x0800
+#define ETH_P_IPV6 0x86DD
+
+// This enum only provides names for the most common values of the L4 protocol column
+enum drop_l4_proto {
+	icmp = 1,
+	tcp = 6,
+	udp = 17,
+	icmpv6 = 58,
+};
+
+struct drop_key {
+	gadget_mntns_id mntns_id;
+	gadget_netns_id netns;
+	__u32 reason;
+	struct gadget_l3endpoint_t remote;
+	__u32 proto;
+	gadget_kernel_stack kernel_stack;
+};
+
+struct drops {
+	gadget_mntns_id mntns_id;
+	gadget_netns_id netns;
+	enum skb_drop_reason reason;
+	struct gadget_l3endpoint_t remote;
+	enum drop_l4_proto proto;
+	gadget_kernel_stack kernel_stack;
+	__u32 pid;
+	__u8 comm[TASK_COMM_LEN];
+	__u64 packets;
+	__u64 bytes;
+};
+
+const volatile bool kernel_stacks = false;
+
+GADGET_PARAM(kernel_stacks);
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct drop_key);
+	__type(value, struct drops);
+} stats SEC(".maps");
+
+GADGET_TOPPER(topdrops, stats);
+
+// fill_remote_from_sock uses the peer of the socket as remote endpoint
+static __always_inline void fill_remote_from_sock(struct drop_key *key,
+						  struct sock *sk)
+{
+	unsigned int family = BPF_CORE_READ(sk, __sk_common.skc_family);
+
+	switch (family) {
+	case AF_INET:
+		key->remote.version = 4;
+		BPF_CORE_READ_INTO(&key->remote.addr.v4, sk,
+				   __sk_common.skc_daddr);
+		break;
+	case AF_INET6:
+		key->remote.version = 6;
+		BPF_CORE_READ_INTO(&key->remote.addr.v6, sk,
+				   __sk_common.skc_v6_daddr.in6_u.u6_addr32);
+		break;
+	}
+	key->proto = BPF_CORE_READ_BITFIELD_PROBED(sk, sk_protocol);
+}
+
+// fill_remote_from_skb uses the source of the packet as remote endpoint. Packets without socket
+// are usually dropped on reception.
+static __always_inline void fill_remote_from_skb(struct drop_key *key,
+						 struct sk_buff *skb,
+						 __u16 protocol)
+{
+	void *head = BPF_CORE_READ(skb, head);
+	__u16 network_header = BPF_CORE_READ(skb, network_header);
+
+	switch (protocol) {
+	case ETH_P_IP: {
+		struct iphdr iph;
+
+		if (bpf_probe_read_kernel(&iph, sizeof(iph),
+					  head + network_header))
+			return;
+		key->remote.version = 4;
+		key->remote.addr.v4 = iph.saddr;
+		key->proto = iph.protocol;
+		break;
+	}
+	case ETH_P_IPV6: {
+		struct ipv6hdr ip6h;
+
+		if (bpf_probe_read_kernel(&ip6h, sizeof(ip6h),
+					  head + network_header))
+			return;
+		key->remote.version = 6;
+		__builtin_memcpy(&key->remote.addr.v6, &ip6h.saddr,
+				 sizeof(key->remote.addr.v6));
+		key->proto = ip6h.nexthdr;
+		break;
+	}
+	}
+}
+
+SEC("tracepoint/skb/kfree_skb")
+int ig_top_drops(struct trace_event_raw_kfree_skb *ctx)
+{
+	struct sk_buff *skb = (struct sk_buff *)ctx->skbaddr;
+	struct sock *sk = BPF_CORE_READ(skb, sk);
+	struct sockets_value *skb_val = NULL;
+	struct drop_key key = {};
+	struct drops *dropsp;
+	struct drops zero = {};
+
+	// Drop reasons are only available since Linux 5.17
+	if (bpf_core_field_exists(ctx->reason))
+		key.reason = ctx->reason;
+
+	// Packets consumed normally go through this tracepoint as well on recent kernels
+	if (bpf_core_enum_value_exists(enum skb_drop_reason, SKB_CONSUMED) &&
+	    key.reason == bpf_core_enum_value(enum skb_drop_reason, SKB_CONSUMED))
+		return 0;
+
+	key.netns = BPF_CORE_READ(skb, dev, nd_net.net, ns.inum);
+
+	if (sk) {
+		if (key.netns == 0)
+			key.netns = BPF_CORE_READ(sk, __sk_common.skc_net.net,
+						  ns.inum);
+		fill_remote_from_sock(&key, sk);
+		skb_val = gadget_socket_lookup(sk, key.netns);
+		if (skb_val != NULL)
+			key.mntns_id = skb_val->mntns;
+	} else {
+		fill_remote_from_skb(&key, skb, ctx->protocol);
+	}
+
+	// Drops without socket can't be related to a mount namespace, so they are not reported when
+	// filtering by containers.
+	if (gadget_should_discard_mntns_id(key.mntns_id))
+		return 0;
+
+	key.kernel_stack = kernel_stacks ? gadget_get_kernel_stack(ctx) : -1;
+
+	dropsp = bpf_map_lookup_elem(&stats, &key);
+	if (!dropsp) {
+		zero.mntns_id = key.mntns_id;
+		zero.netns = key.netns;
+		zero.reason = key.reason;
+		zero.remote = key.remote;
+		zero.proto = key.proto;
+		zero.kernel_stack = key.kernel_stack;
+		bpf_map_update_elem(&stats, &key, &zero, BPF_NOEXIST);
+		dropsp = bpf_map_lookup_elem(&stats, &key);
+		if (!dropsp)
+			return 0;
+	}
+
+	if (skb_val != NULL) {
+		dropsp->pid = skb_val->pid_tgid >> 32;
+		__builtin_memcpy(dropsp->comm, skb_val->task,
+				 sizeof(dropsp->comm));
+	}
+	__sync_fetch_and_add(&dropsp->packets, 1);
+	__sync_fetch_and_add(&dropsp->bytes, BPF_CORE_READ(skb, len));
+
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
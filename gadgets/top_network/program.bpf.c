@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2024 The Inspektor Gadget authors
+
+#include <vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#define GADGET_NO_BUF_RESERVE
+#include <gadget/buffer.h>
+#include <gadget/types.h>
+#include <gadget/macros.h>
+
+/* The maximum number of items in maps */
+#define MAX_ENTRIES 10240
+
+#define TC_ACT_UNSPEC -1
+
+struct stats {
+	gadget_netns_id netns;
+	__u64 rx_bytes;
+	__u64 tx_bytes;
+	__u64 rx_packets;
+	__u64 tx_packets;
+	__u64 drops;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct stats);
+} netstats SEC(".maps");
+
+GADGET_TOPPER(topnetwork, netstats);
+
+static __always_inline struct stats *lookup_or_init(__u32 netns)
+{
+	struct stats *statsp;
+	struct stats zero = {};
+
+	statsp = bpf_map_lookup_elem(&netstats, &netns);
+	if (statsp)
+		return statsp;
+
+	zero.netns = netns;
+	bpf_map_update_elem(&netstats, &netns, &zero, BPF_NOEXIST);
+
+	// The map is full: count the packets that can't be accounted for
+	statsp = bpf_map_lookup_elem(&netstats, &netns);
+	if (!statsp)
+		gadget_count_lost();
+
+	return statsp;
+}
+
+// The tc programs are attached on the host side of the container's network interfaces, however
+// ingress and egress are relative to the container: Inspektor Gadget already inverts the direction
+// when attaching them.
+
+SEC("classifier/ingress/rx")
+int ig_topnet_rx(struct __sk_buff *skb)
+{
+	// cb[0] initialized by dispatcher.bpf.c
+	struct stats *statsp = lookup_or_init(skb->cb[0]);
+
+	if (!statsp)
+		return TC_ACT_UNSPEC;
+
+	__sync_fetch_and_add(&statsp->rx_bytes, skb->len);
+	__sync_fetch_and_add(&statsp->rx_packets, 1);
+
+	return TC_ACT_UNSPEC;
+}
+
+SEC("classifier/egress/tx")
+int ig_topnet_tx(struct __sk_buff *skb)
+{
+	// cb[0] initialized by dispatcher.bpf.c
+	struct stats *statsp = lookup_or_init(skb->cb[0]);
+
+	if (!statsp)
+		return TC_ACT_UNSPEC;
+
+	__sync_fetch_and_add(&statsp->tx_bytes, skb->len);
+	__sync_fetch_and_add(&statsp->tx_packets, 1);
+
+	return TC_ACT_UNSPEC;
+}
+
+SEC("tracepoint/skb/kfree_skb")
+int ig_topnet_drop(struct trace_event_raw_kfree_skb *ctx)
+{
+	struct sk_buff *skb = (struct sk_buff *)ctx->skbaddr;
+	struct stats *statsp;
+	__u32 netns;
+
+	netns = BPF_CORE_READ(skb, dev, nd_net.net, ns.inum);
+	if (netns == 0)
+		netns = BPF_CORE_READ(skb, sk, __sk_common.skc_net.net, ns.inum);
+
+	// Only account drops in network namespaces where the tc programs are attached, i.e. the
+	// ones of the selected containers.
+	statsp = bpf_map_lookup_elem(&netstats, &netns);
+	if (!statsp)
+		return 0;
+
+	__sync_fetch_and_add(&statsp->drops, 1);
+
+	return 0;
+}
+
+char _license[] SEC("license") = "GPL";
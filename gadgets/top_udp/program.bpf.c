@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2024 The Inspektor Gadget authors
+//
+// Based on tcptop from pkg/gadgets/top/tcp
+#include <vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include <bpf/bpf_endian.h>
+#include <gadget/mntns_filter.h>
+#include <gadget/types.h>
+#include <gadget/macros.h>
+
+/* The maximum number of items in maps */
+#define MAX_ENTRIES 10240
+
+#define TASK_COMM_LEN 16
+
+/* Define here, because there are conflicts with include files */
+#define AF_INET 2
+#define AF_INET6 10
+
+struct flow_key {
+	struct gadget_l4endpoint_t src;
+	struct gadget_l4endpoint_t dst;
+	gadget_mntns_id mntns_id;
+	__u32 pid;
+};
+
+struct traffic {
+	struct gadget_l4endpoint_t src;
+	struct gadget_l4endpoint_t dst;
+	gadget_mntns_id mntns_id;
+	__u32 pid;
+	__u8 comm[TASK_COMM_LEN];
+	__u64 sent;
+	__u64 received;
+	__u64 sent_packets;
+	__u64 received_packets;
+};
+
+const volatile pid_t filter_pid = 0;
+const volatile __u8 filter_family = 0;
+
+GADGET_PARAM(filter_pid);
+GADGET_PARAM(filter_family);
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct flow_key);
+	__type(value, struct traffic);
+} stats SEC(".maps");
+
+GADGET_TOPPER(topudp, stats);
+
+static __always_inline void fill_l3_from_sock(struct flow_key *key,
+					      struct sock *sk, __u16 family)
+{
+	if (family == AF_INET) {
+		BPF_CORE_READ_INTO(&key->src.l3.addr.v4, sk,
+				   __sk_common.skc_rcv_saddr);
+		BPF_CORE_READ_INTO(&key->dst.l3.addr.v4, sk,
+				   __sk_common.skc_daddr);
+		key->src.l3.version = key->dst.l3.version = 4;
+	} else {
+		BPF_CORE_READ_INTO(
+			&key->src.l3.addr.v6, sk,
+			__sk_common.skc_v6_rcv_saddr.in6_u.u6_addr32);
+		BPF_CORE_READ_INTO(&key->dst.l3.addr.v6, sk,
+				   __sk_common.skc_v6_daddr.in6_u.u6_addr32);
+		key->src.l3.version = key->dst.l3.version = 6;
+	}
+	key->src.port = BPF_CORE_READ(sk, __sk_common.skc_num);
+	key->dst.port = bpf_ntohs(BPF_CORE_READ(sk, __sk_common.skc_dport));
+}
+
+/*
+ * Unconnected UDP sockets don't have the destination stored in the socket, it's
+ * provided in msg->msg_name for each call to sendmsg().
+ */
+static __always_inline void fill_dst_from_msg(struct flow_key *key,
+					      struct msghdr *msg, __u16 family)
+{
+	void *name = BPF_CORE_READ(msg, msg_name);
+
+	if (!name)
+		return;
+
+	if (family == AF_INET) {
+		struct sockaddr_in *sin = name;
+
+		BPF_CORE_READ_INTO(&key->dst.l3.addr.v4, sin, sin_addr.s_addr);
+		key->dst.port = bpf_ntohs(BPF_CORE_READ(sin, sin_port));
+	} else {
+		struct sockaddr_in6 *sin6 = name;
+
+		BPF_CORE_READ_INTO(&key->dst.l3.addr.v6, sin6,
+				   sin6_addr.in6_u.u6_addr32);
+		key->dst.port = bpf_ntohs(BPF_CORE_READ(sin6, sin6_port));
+	}
+}
+
+/*
+ * Unconnected UDP sockets don't have the peer stored in the socket, get it from
+ * the headers of the received packet.
+ */
+static __always_inline void fill_dst_from_skb(struct flow_key *key,
+					      struct sk_buff *skb, __u16 family)
+{
+	unsigned char *head = BPF_CORE_READ(skb, head);
+	__u16 network_header = BPF_CORE_READ(skb, network_header);
+	__u16 transport_header = BPF_CORE_READ(skb, transport_header);
+	struct udphdr *udph = (struct udphdr *)(head + transport_header);
+
+	if (family == AF_INET) {
+		struct iphdr *iph = (struct iphdr *)(head + network_header);
+
+		BPF_CORE_READ_INTO(&key->dst.l3.addr.v4, iph, saddr);
+	} else {
+		struct ipv6hdr *ip6h = (struct ipv6hdr *)(head + network_header);
+
+		BPF_CORE_READ_INTO(&key->dst.l3.addr.v6, ip6h,
+				   saddr.in6_u.u6_addr32);
+	}
+	key->dst.port = bpf_ntohs(BPF_CORE_READ(udph, source));
+}
+
+static __always_inline int probe_udp(bool receiving, struct sock *sk,
+				     struct msghdr *msg, struct sk_buff *skb,
+				     size_t size)
+{
+	struct flow_key key = {};
+	struct traffic *trafficp;
+	__u64 mntns_id;
+	__u16 family;
+	__u32 pid;
+
+	pid = bpf_get_current_pid_tgid() >> 32;
+	if (filter_pid != 0 && filter_pid != pid)
+		return 0;
+
+	family = BPF_CORE_READ(sk, __sk_common.skc_family);
+	if (family != AF_INET && family != AF_INET6)
+		return 0;
+
+	if ((filter_family == 4 && family != AF_INET) ||
+	    (filter_family == 6 && family != AF_INET6))
+		return 0;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	key.pid = pid;
+	key.mntns_id = mntns_id;
+	key.src.proto = key.dst.proto = IPPROTO_UDP;
+	fill_l3_from_sock(&key, sk, family);
+
+	if (key.dst.port == 0) {
+		if (msg)
+			fill_dst_from_msg(&key, msg, family);
+		else if (skb)
+			fill_dst_from_skb(&key, skb, family);
+	}
+
+	trafficp = bpf_map_lookup_elem(&stats, &key);
+	if (!trafficp) {
+		struct traffic zero = {};
+
+		zero.src = key.src;
+		zero.dst = key.dst;
+		zero.mntns_id = key.mntns_id;
+		zero.pid = key.pid;
+		bpf_get_current_comm(&zero.comm, sizeof(zero.comm));
+
+		bpf_map_update_elem(&stats, &key, &zero, BPF_NOEXIST);
+		trafficp = bpf_map_lookup_elem(&stats, &key);
+		if (!trafficp)
+			return 0;
+	}
+
+	if (receiving) {
+		__sync_fetch_and_add(&trafficp->received, size);
+		__sync_fetch_and_add(&trafficp->received_packets, 1);
+	} else {
+		__sync_fetch_and_add(&trafficp->sent, size);
+		__sync_fetch_and_add(&trafficp->sent_packets, 1);
+	}
+
+	return 0;
+}
+
+SEC("kprobe/udp_sendmsg")
+int BPF_KPROBE(ig_topudp_sdmsg, struct sock *sk, struct msghdr *msg,
+	       size_t len)
+{
+	return probe_udp(false, sk, msg, NULL, len);
+}
+
+SEC("kprobe/udpv6_sendmsg")
+int BPF_KPROBE(ig_topudp6_sdmsg, struct sock *sk, struct msghdr *msg,
+	       size_t len)
+{
+	return probe_udp(false, sk, msg, NULL, len);
+}
+
+/*
+ * skb_consume_udp() is called by both udp_recvmsg() and udpv6_recvmsg() once the
+ * datagram has been copied to user space, len is the number of bytes copied.
+ */
+SEC("kprobe/skb_consume_udp")
+int BPF_KPROBE(ig_topudp_consume, struct sock *sk, struct sk_buff *skb, int len)
+{
+	if (len <= 0)
+		return 0;
+
+	return probe_udp(true, sk, NULL, skb, len);
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -36,11 +36,11 @@ int BPF_KPROBE(ig_oom_kill, struct oom_control *oc, const char *message)
 	u64 mntns_id;
 	u64 uid_gid = bpf_get_current_uid_gid();
 
-	mntns_id = (u64)BPF_CORE_READ(oc, chosen, nsproxy, mnt_ns, ns.inum);
-
-	if (gadget_should_discard_mntns_id(mntns_id))
+	if (gadget_should_discard_task(BPF_CORE_READ(oc, chosen)))
 		return 0;
 
+	mntns_id = (u64)BPF_CORE_READ(oc, chosen, nsproxy, mnt_ns, ns.inum);
+
 	event = gadget_reserve_buf(&events, sizeof(*event));
 	if (!event)
 		return 0;
@@ -18,6 +18,25 @@
 	} name SEC(".maps");				\
 	const void *gadget_map_tracer_##name __attribute__((unused));
 
+/* Counts the events that couldn't be written to the ring buffer because it was
+ * full. Keep the name aligned with pkg/gadgets/run/types/metadata.go */
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, __u32);
+	__type(value, __u64);
+} gadget_lost_samples SEC(".maps");
+
+static __always_inline void gadget_count_lost(void)
+{
+	static const int zero = 0;
+	__u64 *count;
+
+	count = bpf_map_lookup_elem(&gadget_lost_samples, &zero);
+	if (count)
+		(*count)++;
+}
+
 #ifndef GADGET_NO_BUF_RESERVE
 struct {
 	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
@@ -29,9 +48,14 @@ struct {
 static __always_inline void *gadget_reserve_buf(void *map, __u64 size)
 {
 	static const int zero = 0;
+	void *buf;
 
-	if (bpf_core_enum_value_exists(enum bpf_func_id, BPF_FUNC_ringbuf_reserve))
-		return bpf_ringbuf_reserve(map, size, 0);
+	if (bpf_core_enum_value_exists(enum bpf_func_id, BPF_FUNC_ringbuf_reserve)) {
+		buf = bpf_ringbuf_reserve(map, size, 0);
+		if (!buf)
+			gadget_count_lost();
+		return buf;
+	}
 
 	return bpf_map_lookup_elem(&gadget_heap, &zero);
 }
@@ -56,7 +80,8 @@ static __always_inline long gadget_submit_buf(void *ctx, void *map, void *buf, _
 static __always_inline long gadget_output_buf(void *ctx, void *map, void *buf, __u64 size)
 {
 	if (bpf_core_enum_value_exists(enum bpf_func_id, BPF_FUNC_ringbuf_output)) {
-		bpf_ringbuf_output(map, buf, size, 0);
+		if (bpf_ringbuf_output(map, buf, size, 0))
+			gadget_count_lost();
 		return 0;
 	}
 
@@ -0,0 +1,52 @@
+/* SPDX-License-Identifier: (GPL-2.0 WITH Linux-syscall-note) OR Apache-2.0 */
+
+#ifndef __HISTOGRAM_BPF_H
+#define __HISTOGRAM_BPF_H
+
+#include <gadget/bits.bpf.h>
+
+// Layouts of the slots of a histogram. They must match the layouts defined in
+// pkg/histogram.
+#define HIST_LAYOUT_EXP2 0
+#define HIST_LAYOUT_LINEAR 1
+#define HIST_LAYOUT_LOG_LINEAR 2
+
+// Number of bits used to split each power of two in the log-linear layout;
+// it must match histogram.LogLinearSubBucketBits.
+#define HIST_LOG_LINEAR_SUB_BITS 1
+#define HIST_LOG_LINEAR_SUB_BUCKETS (1 << HIST_LOG_LINEAR_SUB_BITS)
+
+// gadget_hist_slot returns the slot of value according to the given layout.
+// step is only used by HIST_LAYOUT_LINEAR. The result is capped to
+// max_slots - 1.
+static __always_inline u64 gadget_hist_slot(u64 value, u32 layout, u64 step,
+					    u64 max_slots)
+{
+	u64 slot, exp;
+
+	switch (layout) {
+	case HIST_LAYOUT_LINEAR:
+		slot = step ? value / step : 0;
+		break;
+	case HIST_LAYOUT_LOG_LINEAR:
+		if (value < HIST_LOG_LINEAR_SUB_BUCKETS) {
+			slot = value;
+			break;
+		}
+		exp = log2l(value);
+		slot = HIST_LOG_LINEAR_SUB_BUCKETS *
+			       (exp - HIST_LOG_LINEAR_SUB_BITS + 1) +
+		       ((value >> (exp - HIST_LOG_LINEAR_SUB_BITS)) &
+			(HIST_LOG_LINEAR_SUB_BUCKETS - 1));
+		break;
+	default:
+		slot = log2l(value);
+		break;
+	}
+
+	if (slot >= max_slots)
+		slot = max_slots - 1;
+	return slot;
+}
+
+#endif /* __HISTOGRAM_BPF_H */
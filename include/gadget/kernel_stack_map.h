@@ -0,0 +1,36 @@
+/* SPDX-License-Identifier: Apache-2.0 */
+
+#ifndef __KERNEL_STACK_MAP_H
+#define __KERNEL_STACK_MAP_H
+
+#include <bpf/bpf_helpers.h>
+
+#include <gadget/types.h>
+
+// Keep this aligned with pkg/gadgets/run/types/metadata.go
+
+#ifndef KERNEL_STACK_MAP_MAX_ENTRIES
+#define KERNEL_STACK_MAP_MAX_ENTRIES 10000
+#endif
+
+#define PERF_MAX_STACK_DEPTH 127
+
+// ig_kstack is the map used to store the kernel stacks. The ids saved in gadget_kernel_stack
+// fields are looked up in this map from user space. It's reduced to a single entry at load time
+// when no program calls gadget_get_kernel_stack() or when the gadget defines a kernel_stacks
+// constant set to false.
+struct {
+	__uint(type, BPF_MAP_TYPE_STACK_TRACE);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, PERF_MAX_STACK_DEPTH * sizeof(__u64));
+	__uint(max_entries, KERNEL_STACK_MAP_MAX_ENTRIES);
+} ig_kstack SEC(".maps");
+
+// gadget_get_kernel_stack returns the id of the current kernel stack, or a negative value on
+// error. Stacks with the same hash replace each other to avoid filling the map.
+static __always_inline gadget_kernel_stack gadget_get_kernel_stack(void *ctx)
+{
+	return bpf_get_stackid(ctx, &ig_kstack, BPF_F_REUSE_STACKID);
+}
+
+#endif /* __KERNEL_STACK_MAP_H */
@@ -24,4 +24,12 @@
 	const void *gadget_snapshotter_##name##___##type __attribute__((unused)); \
 	const struct type *unusedevent_##name##___##type __attribute__((unused));
 
+// GADGET_TOPPER is used to define a topper. The content of the map is periodically collected and
+// sent to user space. Currently only one topper per eBPF object is allowed.
+// name is the topper's name
+// map_name is the name of the hash map used to accumulate the statistics. Its value must be the
+// structure that describes the event
+#define GADGET_TOPPER(name, map_name) \
+	const void *gadget_topper_##name##___##map_name __attribute__((unused));
+
 #endif /* __MACROS_H */
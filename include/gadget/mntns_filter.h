@@ -18,12 +18,78 @@ struct {
 	__uint(max_entries, 1024);
 } gadget_mntns_filter_map SEC(".maps");
 
+const volatile bool gadget_filter_by_cgroup = false;
+
+// gadget_cgroup_filter_map contains the cgroup v2 ids of the selected containers that don't have
+// their own mount namespace, like most systemd units. Their sub-cgroups are selected as well.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__type(key, __u64);
+	__type(value, __u32);
+	__uint(max_entries, 1024);
+} gadget_cgroup_filter_map SEC(".maps");
+
+// GADGET_CGROUP_MAX_LEVELS is the number of levels of the cgroup hierarchy looked up in
+// gadget_cgroup_filter_map
+#define GADGET_CGROUP_MAX_LEVELS 16
+
+// gadget_task_in_cgroup_filter returns true if the cgroup of the given task, or the current one if
+// task is NULL, or one of its ancestors is in gadget_cgroup_filter_map.
+static __always_inline bool gadget_task_in_cgroup_filter(struct task_struct *task)
+{
+	struct cgroup *cgrp = NULL;
+	__u64 cgroup_id;
+
+	if (task)
+		cgrp = BPF_CORE_READ(task, cgroups, dfl_cgrp);
+
+#pragma unroll
+	for (int level = 0; level < GADGET_CGROUP_MAX_LEVELS; level++) {
+		if (task) {
+			// Walk up from the cgroup of the task
+			if (!cgrp)
+				return false;
+			cgroup_id = BPF_CORE_READ(cgrp, kn, id);
+			cgrp = BPF_CORE_READ(cgrp, self.parent, cgroup);
+		} else {
+			// Walk down from the root to the cgroup of the current task
+			cgroup_id = bpf_get_current_ancestor_cgroup_id(level);
+			if (!cgroup_id)
+				return false;
+		}
+		if (bpf_map_lookup_elem(&gadget_cgroup_filter_map, &cgroup_id))
+			return true;
+	}
+
+	return false;
+}
+
+static __always_inline bool gadget_should_discard_ids(gadget_mntns_id mntns_id,
+							struct task_struct *task)
+{
+	if (!gadget_filter_by_mntns)
+		return false;
+	if (bpf_map_lookup_elem(&gadget_mntns_filter_map, &mntns_id))
+		return false;
+	if (!gadget_filter_by_cgroup)
+		return true;
+
+	return !gadget_task_in_cgroup_filter(task);
+}
+
 // gadget_should_discard_mntns_id returns true if events generated from the given mntns_id should
-// not be taken into consideration.
+// not be taken into consideration. Events from other mount namespaces are kept when the current
+// task belongs to one of the cgroups of gadget_cgroup_filter_map or to one of their sub-cgroups.
 static __always_inline bool gadget_should_discard_mntns_id(gadget_mntns_id mntns_id)
 {
-	return gadget_filter_by_mntns &&
-	       !bpf_map_lookup_elem(&gadget_mntns_filter_map, &mntns_id);
+	return gadget_should_discard_ids(mntns_id, NULL);
+}
+
+// gadget_should_discard_task is like gadget_should_discard_mntns_id for events about a task that
+// isn't the current one.
+static __always_inline bool gadget_should_discard_task(struct task_struct *task)
+{
+	return gadget_should_discard_ids(BPF_CORE_READ(task, nsproxy, mnt_ns, ns.inum), task);
 }
 
 #endif
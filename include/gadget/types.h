@@ -28,10 +28,18 @@ struct gadget_l4endpoint_t {
 // Inode id of a mount namespace. It's used to enrich the event in user space
 typedef __u64 gadget_mntns_id;
 
+// Inode id of a network namespace. It's used to enrich the event in user space
+typedef __u32 gadget_netns_id;
+
 // gadget_timestamp is a type that represents the nanoseconds since the system boot. Gadgets can use
 // this type to provide a timestamp. The value contained must be the one returned by
 // bpf_ktime_get_boot_ns() and it's automatically converted by Inspektor Gadget to a human friendly
 // time.
 typedef __u64 gadget_timestamp;
 
+// gadget_kernel_stack is the id of a kernel stack, as returned by gadget_get_kernel_stack() from
+// gadget/kernel_stack_map.h. It's automatically converted by Inspektor Gadget to the list of
+// symbols of the stack. Negative values mean the stack isn't available.
+typedef __s32 gadget_kernel_stack;
+
 #endif /* __TYPES_H */
@@ -50,6 +50,7 @@ func newProfileTCPRTTCmd(flags string, useTimeout bool, node string, unit histog
 				}
 
 				r.Histograms[0].Intervals = nil
+				r.Histograms[0].Percentiles = nil
 
 				if r.Histograms[0].Average != 0 {
 					r.Histograms[0].Average = 1
@@ -33,6 +33,7 @@ func TestProfileBio(t *testing.T) {
 
 			normalize := func(e *bioprofileTypes.Report) {
 				e.Intervals = nil
+				e.Percentiles = nil
 			}
 
 			ExpectEntriesToMatch(t, output, normalize, expectedEntry)
@@ -39,6 +39,7 @@ func TestProfileBlockIO(t *testing.T) {
 
 				normalize := func(e *bioprofileTypes.Report) {
 					e.Intervals = nil
+					e.Percentiles = nil
 				}
 
 				ExpectEntriesToMatch(t, output, normalize, expectedEntry)
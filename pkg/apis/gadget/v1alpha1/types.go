@@ -68,6 +68,60 @@ const (
 	TraceOutputModeExternalResource TraceOutputMode = "ExternalResource"
 )
 
+// TraceOutputFormat defines how events of image-based gadgets are formatted
+// +kubebuilder:validation:Enum=JSON;Columns
+type TraceOutputFormat string
+
+const (
+	// TraceOutputFormatJSON formats each event as a JSON object
+	TraceOutputFormatJSON TraceOutputFormat = "JSON"
+	// TraceOutputFormatColumns formats events as a table using the gadget's
+	// default columns
+	TraceOutputFormatColumns TraceOutputFormat = "Columns"
+)
+
+// TraceTrigger defines an event that starts a run of a scheduled trace
+// +kubebuilder:validation:Enum=PodStart;OOMKill
+type TraceTrigger string
+
+const (
+	// TraceTriggerPodStart starts a run when a container matching the
+	// filter starts
+	TraceTriggerPodStart TraceTrigger = "PodStart"
+	// TraceTriggerOOMKill starts a run when a process in a container
+	// matching the filter is killed by the OOM killer
+	TraceTriggerOOMKill TraceTrigger = "OOMKill"
+)
+
+// TraceRunReasonSchedule is the reason of runs started by TraceSchedule.Cron
+const TraceRunReasonSchedule = "Schedule"
+
+const (
+	// TraceRunResultSucceeded is the result of runs that finished without error
+	TraceRunResultSucceeded = "Succeeded"
+	// TraceRunResultFailed is the result of runs that returned an error
+	TraceRunResultFailed = "Failed"
+)
+
+// TraceSchedule defines when a trace is run automatically by the controller
+type TraceSchedule struct {
+	// Cron is a cron expression with five fields ("minute hour
+	// day-of-month month day-of-week"), or a descriptor like "@hourly",
+	// giving the start of each run
+	Cron string `json:"cron,omitempty"`
+
+	// Duration is the maximum duration of each run. Defaults to 5m.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// Triggers start a run when the given events happen in containers
+	// matching the filter
+	Triggers []TraceTrigger `json:"triggers,omitempty"`
+
+	// HistoryLimit is the number of runs kept in Status.History. Defaults
+	// to 10.
+	HistoryLimit int32 `json:"historyLimit,omitempty"`
+}
+
 // ContainerFilter filters events based on different criteria
 type ContainerFilter struct {
 	// Namespace selects events from this pod namespace
@@ -81,6 +135,28 @@ type ContainerFilter struct {
 
 	// ContainerName selects events from containers with this name
 	ContainerName string `json:"containerName,omitempty"`
+
+	// Selector selects events from pods matching this label selector. It
+	// supports the same set-based syntax as kubectl, e.g.
+	// "app in (nginx,redis),tier!=frontend". It is combined with Labels.
+	Selector string `json:"selector,omitempty"`
+
+	// PodnameRegex selects events from pods whose name matches this
+	// regular expression
+	PodnameRegex string `json:"podnameRegex,omitempty"`
+
+	// Owner selects events from pods owned by this resource, given as
+	// "[kind/]name", e.g. "deployment/nginx"
+	Owner string `json:"owner,omitempty"`
+
+	// ContainerImage selects events from containers running this image.
+	// It accepts a name (glob patterns allowed), "name@sha256:..." or
+	// "sha256:..."
+	ContainerImage string `json:"containerImage,omitempty"`
+
+	// ExcludeNamespace is a comma-separated list of namespaces whose
+	// events are discarded
+	ExcludeNamespace string `json:"excludeNamespace,omitempty"`
 }
 
 // TraceSpec defines the desired state of Trace
@@ -91,9 +167,15 @@ type TraceSpec struct {
 	// Node is the name of the node on which this trace should run
 	Node string `json:"node,omitempty"`
 
-	// Gadget is the name of the gadget such as "seccomp"
+	// Gadget is the name of the gadget such as "seccomp". It defaults to
+	// "run" when Image is set.
 	Gadget string `json:"gadget,omitempty"`
 
+	// Image is the OCI reference of an image-based gadget, such as
+	// "ghcr.io/inspektor-gadget/gadget/trace_open:latest". It is run by the
+	// "run" gadget using the same pipeline as "kubectl gadget run".
+	Image string `json:"image,omitempty"`
+
 	// RunMode is "Auto" to automatically start the trace as soon as the
 	// resource is created, or "Manual" to be controlled by the
 	// "gadget.kinvolk.io/operation" annotation
@@ -119,8 +201,21 @@ type TraceSpec struct {
 	// TODO: Ideally it should be a map[string]interface{} but it's not
 	// supported: https://github.com/kubernetes-sigs/controller-tools/issues/636
 
-	// Parameters contains gadget specific configurations.
+	// Parameters contains gadget specific configurations. For image-based
+	// gadgets, keys follow "kubectl gadget run": gadget parameters are used
+	// as-is, operator parameters are prefixed with "operator.<name>." and
+	// runtime parameters with "runtime.".
 	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// OutputFormat is "JSON" (default) or "Columns". It is only used by
+	// image-based gadgets.
+	OutputFormat TraceOutputFormat `json:"outputFormat,omitempty"`
+
+	// Schedule runs the trace automatically, in addition to the
+	// "gadget.kinvolk.io/operation" annotation. Each run is started with the
+	// "start" operation (or "collect" for gadgets without it) and finished
+	// with "generate", if available, and "stop".
+	Schedule *TraceSchedule `json:"schedule,omitempty"`
 }
 
 // TraceState defines state for the trace
@@ -156,6 +251,41 @@ type TraceStatus struct {
 	// OperationError that represents a fatal error, the OperationWarning could
 	// be ignored according to the context.
 	OperationWarning string `json:"operationWarning,omitempty"`
+
+	// History contains the results of the last runs started by
+	// Spec.Schedule, the most recent last
+	History []TraceRun `json:"history,omitempty"`
+}
+
+// TraceRun is the result of a run started by TraceSpec.Schedule
+type TraceRun struct {
+	// Reason is "Schedule", "PodStart" or "OOMKill"
+	Reason string `json:"reason"`
+
+	// Details describes the event that triggered the run, such as the
+	// container that started
+	Details string `json:"details,omitempty"`
+
+	// StartTime is when the run was started
+	StartTime metav1.Time `json:"startTime"`
+
+	// EndTime is when the run finished
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// Result is "Succeeded" or "Failed"
+	Result string `json:"result,omitempty"`
+
+	// Output is Status.Output at the end of the run. It's truncated to
+	// the first 4096 bytes to keep the size of the Trace bounded, the
+	// whole output of the last run stays in Status.Output.
+	Output string `json:"output,omitempty"`
+
+	// OutputSize is the size in bytes of Status.Output at the end of the
+	// run, before truncation
+	OutputSize int `json:"outputSize,omitempty"`
+
+	// Error is the OperationError returned by the gadget during the run
+	Error string `json:"error,omitempty"`
 }
 
 // +genclient
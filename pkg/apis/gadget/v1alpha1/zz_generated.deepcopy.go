@@ -20,6 +20,7 @@
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -51,7 +52,7 @@ func (in *Trace) DeepCopyInto(out *Trace) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Trace.
@@ -104,6 +105,51 @@ func (in *TraceList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TraceRun) DeepCopyInto(out *TraceRun) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TraceRun.
+func (in *TraceRun) DeepCopy() *TraceRun {
+	if in == nil {
+		return nil
+	}
+	out := new(TraceRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TraceSchedule) DeepCopyInto(out *TraceSchedule) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Triggers != nil {
+		in, out := &in.Triggers, &out.Triggers
+		*out = make([]TraceTrigger, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TraceSchedule.
+func (in *TraceSchedule) DeepCopy() *TraceSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(TraceSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TraceSpec) DeepCopyInto(out *TraceSpec) {
 	*out = *in
@@ -119,6 +165,11 @@ func (in *TraceSpec) DeepCopyInto(out *TraceSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(TraceSchedule)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TraceSpec.
@@ -134,6 +185,13 @@ func (in *TraceSpec) DeepCopy() *TraceSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TraceStatus) DeepCopyInto(out *TraceStatus) {
 	*out = *in
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]TraceRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TraceStatus.
@@ -0,0 +1,297 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpfstats
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/cilium/ebpf"
+)
+
+// maxObjectsDepth limits how deep RegisterObjects looks for programs and maps
+// in nested structs
+const maxObjectsDepth = 4
+
+// GadgetInfo identifies the gadget instance that loaded eBPF programs and maps
+type GadgetInfo struct {
+	// ID is the ID of the gadget context
+	ID string
+
+	// Name is the name of the gadget, e.g. "trace exec"
+	Name string
+
+	// Image is the image of image-based gadgets
+	Image string
+}
+
+var (
+	ownersMutex   sync.Mutex
+	programOwners = make(map[ebpf.ProgramID]*GadgetInfo)
+	mapOwners     = make(map[ebpf.MapID]*GadgetInfo)
+
+	// perfBufferSizes holds the memory mapped for each CPU by the readers of
+	// perf event arrays, as it's chosen by the reader and not by the map
+	perfBufferSizes = make(map[ebpf.MapID]uint64)
+)
+
+// RegisterObjects attributes all programs and maps in objs to the given gadget
+// instance. objs can contain *ebpf.Program, *ebpf.Map and *ebpf.Collection as
+// well as structs, slices and maps (or pointers to them) holding those, like the
+// structs generated by bpf2go. The returned function removes the attribution and has to be called
+// before the objects are closed, as their IDs can be reused by the kernel.
+//
+// The attribution is only kept in memory: ProgramOwner and MapOwner don't know
+// about objects loaded by other processes, e.g. another ig invocation. The
+// kernel doesn't keep anything that could identify the gadget instance, as
+// program and map names come from the eBPF object and are limited to 16
+// characters.
+func RegisterObjects(info GadgetInfo, objs ...any) func() {
+	var progIDs []ebpf.ProgramID
+	var mapIDs []ebpf.MapID
+	for _, obj := range objs {
+		collectObjects(reflect.ValueOf(obj), &progIDs, &mapIDs, 0)
+	}
+
+	ownersMutex.Lock()
+	defer ownersMutex.Unlock()
+
+	owner := &info
+	for _, id := range progIDs {
+		programOwners[id] = owner
+	}
+	for _, id := range mapIDs {
+		mapOwners[id] = owner
+	}
+
+	return func() {
+		ownersMutex.Lock()
+		defer ownersMutex.Unlock()
+
+		for _, id := range progIDs {
+			if programOwners[id] == owner {
+				delete(programOwners, id)
+			}
+		}
+		for _, id := range mapIDs {
+			if mapOwners[id] == owner {
+				delete(mapOwners, id)
+				delete(perfBufferSizes, id)
+			}
+		}
+	}
+}
+
+// SetPerfBufferSize records the memory mapped for each CPU by the reader of
+// the perf event array m, including the metadata page. It's forgotten when the
+// attribution of m is removed or, for maps that were never attributed, once
+// the map doesn't exist anymore.
+func SetPerfBufferSize(m *ebpf.Map, size uint64) {
+	info, err := m.Info()
+	if err != nil {
+		return
+	}
+	id, ok := info.ID()
+	if !ok {
+		return
+	}
+
+	ownersMutex.Lock()
+	defer ownersMutex.Unlock()
+
+	for oldID := range perfBufferSizes {
+		if _, ok := mapOwners[oldID]; ok {
+			continue
+		}
+		old, err := ebpf.NewMapFromID(oldID)
+		if errors.Is(err, os.ErrNotExist) {
+			delete(perfBufferSizes, oldID)
+		} else if err == nil {
+			old.Close()
+		}
+	}
+	perfBufferSizes[id] = size
+}
+
+// PerfBufferSize returns the memory mapped for each CPU by the reader of the
+// perf event array with the given ID, if it was set using SetPerfBufferSize
+func PerfBufferSize(id ebpf.MapID) (uint64, bool) {
+	ownersMutex.Lock()
+	defer ownersMutex.Unlock()
+
+	size, ok := perfBufferSizes[id]
+	return size, ok
+}
+
+// Objects attributes the programs and maps loaded by a component at different
+// times, e.g. each time it attaches to a container, to the gadget instance
+// using it. Objects added before the gadget instance is known are attributed
+// once SetOwner is called. The zero value is ready to use.
+type Objects struct {
+	mu    sync.Mutex
+	owner *GadgetInfo
+	// objs holds the function removing the attribution of each object, nil
+	// if the owner isn't known yet
+	objs map[any]func()
+}
+
+// SetOwner attributes the objects added so far and the ones added later to the
+// given gadget instance
+func (o *Objects) SetOwner(info GadgetInfo) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.owner = &info
+	for obj, unregister := range o.objs {
+		o.objs[obj] = RegisterObjects(info, obj)
+		if unregister != nil {
+			unregister()
+		}
+	}
+}
+
+// Add attributes obj to the owner. obj has to be comparable, e.g. a pointer to
+// the structs generated by bpf2go.
+func (o *Objects) Add(obj any) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.objs == nil {
+		o.objs = make(map[any]func())
+	}
+	var unregister func()
+	if o.owner != nil {
+		unregister = RegisterObjects(*o.owner, obj)
+	}
+	o.objs[obj] = unregister
+}
+
+// Remove removes the attribution of obj. Call it before closing obj.
+func (o *Objects) Remove(obj any) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if unregister := o.objs[obj]; unregister != nil {
+		unregister()
+	}
+	delete(o.objs, obj)
+}
+
+// Clear removes the attribution of all the objects
+func (o *Objects) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, unregister := range o.objs {
+		if unregister != nil {
+			unregister()
+		}
+	}
+	o.objs = nil
+}
+
+// ProgramOwner returns the gadget instance that loaded the program with the
+// given ID, if it was registered using RegisterObjects
+func ProgramOwner(id ebpf.ProgramID) (GadgetInfo, bool) {
+	ownersMutex.Lock()
+	defer ownersMutex.Unlock()
+
+	owner, ok := programOwners[id]
+	if !ok {
+		return GadgetInfo{}, false
+	}
+	return *owner, true
+}
+
+// MapOwner returns the gadget instance that loaded the map with the given ID,
+// if it was registered using RegisterObjects
+func MapOwner(id ebpf.MapID) (GadgetInfo, bool) {
+	ownersMutex.Lock()
+	defer ownersMutex.Unlock()
+
+	owner, ok := mapOwners[id]
+	if !ok {
+		return GadgetInfo{}, false
+	}
+	return *owner, true
+}
+
+var (
+	programType    = reflect.TypeOf((*ebpf.Program)(nil))
+	mapType        = reflect.TypeOf((*ebpf.Map)(nil))
+	collectionType = reflect.TypeOf((*ebpf.Collection)(nil))
+)
+
+func collectObjects(v reflect.Value, progIDs *[]ebpf.ProgramID, mapIDs *[]ebpf.MapID, depth int) {
+	if !v.IsValid() || depth > maxObjectsDepth {
+		return
+	}
+
+	switch v.Type() {
+	case programType:
+		if prog := (*ebpf.Program)(v.UnsafePointer()); prog != nil {
+			if info, err := prog.Info(); err == nil {
+				if id, ok := info.ID(); ok {
+					*progIDs = append(*progIDs, id)
+				}
+			}
+		}
+		return
+	case mapType:
+		if m := (*ebpf.Map)(v.UnsafePointer()); m != nil {
+			if info, err := m.Info(); err == nil {
+				if id, ok := info.ID(); ok {
+					*mapIDs = append(*mapIDs, id)
+				}
+			}
+		}
+		return
+	case collectionType:
+		if coll := (*ebpf.Collection)(v.UnsafePointer()); coll != nil {
+			for _, prog := range coll.Programs {
+				collectObjects(reflect.ValueOf(prog), progIDs, mapIDs, depth+1)
+			}
+			for _, m := range coll.Maps {
+				collectObjects(reflect.ValueOf(m), progIDs, mapIDs, depth+1)
+			}
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		collectObjects(v.Elem(), progIDs, mapIDs, depth+1)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			// Unexported fields (e.g. the structs embedded by bpf2go) can be
+			// read, as only pointers are taken from them
+			collectObjects(v.Field(i), progIDs, mapIDs, depth+1)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectObjects(v.Index(i), progIDs, mapIDs, depth+1)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			collectObjects(iter.Value(), progIDs, mapIDs, depth+1)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpfstats
+
+import (
+	"testing"
+
+	"github.com/cilium/ebpf"
+	"github.com/stretchr/testify/require"
+
+	utilstest "github.com/inspektor-gadget/inspektor-gadget/internal/test"
+)
+
+type testObjects struct {
+	testMaps
+	Unused *ebpf.Map
+}
+
+type testMaps struct {
+	Events *ebpf.Map
+}
+
+func TestRegisterObjects(t *testing.T) {
+	utilstest.RequireRoot(t)
+
+	m, err := ebpf.NewMap(&ebpf.MapSpec{
+		Type:       ebpf.Array,
+		KeySize:    4,
+		ValueSize:  4,
+		MaxEntries: 1,
+	})
+	require.NoError(t, err)
+	defer m.Close()
+
+	info, err := m.Info()
+	require.NoError(t, err)
+	id, ok := info.ID()
+	require.True(t, ok)
+
+	gadget := GadgetInfo{ID: "id", Name: "trace test"}
+	unregister := RegisterObjects(gadget, &testObjects{testMaps: testMaps{Events: m}}, nil)
+
+	owner, ok := MapOwner(id)
+	require.True(t, ok)
+	require.Equal(t, gadget, owner)
+
+	unregister()
+
+	_, ok = MapOwner(id)
+	require.False(t, ok)
+}
+
+func TestObjects(t *testing.T) {
+	utilstest.RequireRoot(t)
+
+	m, err := ebpf.NewMap(&ebpf.MapSpec{
+		Type:       ebpf.Array,
+		KeySize:    4,
+		ValueSize:  4,
+		MaxEntries: 1,
+	})
+	require.NoError(t, err)
+	defer m.Close()
+
+	info, err := m.Info()
+	require.NoError(t, err)
+	id, ok := info.ID()
+	require.True(t, ok)
+
+	var objects Objects
+
+	// Objects added before the owner is known are attributed later
+	objects.Add(m)
+	_, ok = MapOwner(id)
+	require.False(t, ok)
+
+	gadget := GadgetInfo{ID: "id", Name: "trace test"}
+	objects.SetOwner(gadget)
+	owner, ok := MapOwner(id)
+	require.True(t, ok)
+	require.Equal(t, gadget, owner)
+
+	SetPerfBufferSize(m, 8192)
+	size, ok := PerfBufferSize(id)
+	require.True(t, ok)
+	require.Equal(t, uint64(8192), size)
+
+	// Changing the owner keeps the size of the perf buffers
+	other := GadgetInfo{ID: "other", Name: "trace test"}
+	objects.SetOwner(other)
+	owner, ok = MapOwner(id)
+	require.True(t, ok)
+	require.Equal(t, other, owner)
+	_, ok = PerfBufferSize(id)
+	require.True(t, ok)
+
+	objects.Remove(m)
+	_, ok = MapOwner(id)
+	require.False(t, ok)
+	_, ok = PerfBufferSize(id)
+	require.False(t, ok)
+}
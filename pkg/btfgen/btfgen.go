@@ -15,12 +15,17 @@
 // Package btfgen provides a way to load BTF information generated with btfgen. Files to be
 // incluided into the binary have to be generated with BTFGen (make btfgen on the root) before
 // compiling the binary.
+//
+// For kernels that don't expose BTF information and aren't covered by the embedded files, the BTF
+// is looked for in a file, directory or archive given by the user and then in the vmlinux images
+// and debuginfo packages installed on the host.
 package btfgen
 
 import (
 	"bufio"
 	"bytes"
 	_ "embed"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -28,27 +33,121 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/btf"
-	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
 	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
 )
 
+// BTFPathEnv is the environment variable that can be used instead of the
+// --btf-path flag
+const BTFPathEnv = "INSPEKTOR_GADGET_BTF_PATH"
+
+// SourceKind describes where the BTF information of the kernel was found
+type SourceKind string
+
+const (
+	// SourceKernel is the BTF exposed by the kernel in /sys/kernel/btf/vmlinux
+	SourceKernel SourceKind = "kernel"
+	// SourceExternal is a BTF file, a directory or an archive given by the user
+	SourceExternal SourceKind = "external"
+	// SourceEmbedded is one of the BTF files embedded in the binary
+	SourceEmbedded SourceKind = "embedded"
+	// SourceVmlinux is the BTF extracted from a vmlinux image or debuginfo
+	// package installed on the host
+	SourceVmlinux SourceKind = "vmlinux"
+	// SourceNone means no BTF information was found for this kernel
+	SourceNone SourceKind = "none"
+)
+
+// Source describes the BTF information used for the current kernel
+type Source struct {
+	Kind SourceKind
+	Path string
+
+	// Errors contains the reason why each source that was tried before
+	// failed
+	Errors []string
+
+	// full is true if the BTF contains all the kernel types and should be
+	// minimized before being used to load a gadget
+	full bool
+}
+
 var (
-	spec *btf.Spec
-	once sync.Once
+	spec    *btf.Spec
+	source  = Source{Kind: SourceNone}
+	btfPath string
+	once    sync.Once
 )
 
+// AddFlags adds the flag to specify where to find the BTF information for
+// kernels that don't expose it
+func AddFlags(command *cobra.Command) {
+	command.PersistentFlags().StringVar(
+		&btfPath,
+		"btf-path",
+		"",
+		"BTF file, directory or archive in BTFHub layout to use if the kernel doesn't expose BTF information. "+
+			"Can also be set with the "+BTFPathEnv+" environment variable",
+	)
+}
+
+func getBTFPath() string {
+	if btfPath != "" {
+		return btfPath
+	}
+	return os.Getenv(BTFPathEnv)
+}
+
 func initialize() error {
 	// If the kernel exposes BTF; nothing to do
 	_, err := btf.LoadKernelSpec()
 	if err == nil {
+		source = Source{Kind: SourceKernel, Path: "/sys/kernel/btf/vmlinux"}
 		return nil
 	}
+	source.Errors = append(source.Errors, fmt.Sprintf("%s: %s", SourceKernel, err))
 
 	info, err := getOSInfo()
 	if err != nil {
-		return err
+		// Some sources can still be used without the OS information
+		source.Errors = append(source.Errors, fmt.Sprintf("getting OS info: %s", err))
+	}
+
+	loaders := []struct {
+		kind SourceKind
+		full bool
+		load func(*osInfo) (*btf.Spec, string, error)
+	}{
+		{SourceExternal, true, loadExternal},
+		{SourceEmbedded, false, loadEmbedded},
+		{SourceVmlinux, true, loadVmlinux},
+	}
+
+	for _, loader := range loaders {
+		s, path, err := loader.load(info)
+		if err != nil {
+			source.Errors = append(source.Errors, fmt.Sprintf("%s: %s", loader.kind, err))
+			continue
+		}
+
+		spec = s
+		source.Kind = loader.kind
+		source.Path = path
+		source.full = loader.full
+		return nil
+	}
+
+	return fmt.Errorf("no BTF information found for this kernel:\n%s", strings.Join(source.Errors, "\n"))
+}
+
+func loadEmbedded(info *osInfo) (*btf.Spec, string, error) {
+	if info == nil {
+		return nil, "", errors.New("unknown OS")
 	}
 
 	// architecture naming is a mess:
@@ -60,33 +159,53 @@ func initialize() error {
 		goarch = "x86"
 	}
 
-	btfFile := fmt.Sprintf("btfs/%s/%s/%s/%s/%s.btf",
-		goarch, info.ID, info.VersionID, info.Arch, info.Kernel)
+	btfFile := fmt.Sprintf("btfs/%s/%s", goarch, info.btfHubPath())
 
 	file, err := btfs.ReadFile(btfFile)
 	if err != nil {
-		return fmt.Errorf("reading %s BTF file %w", btfFile, err)
+		return nil, "", fmt.Errorf("reading %s BTF file %w", btfFile, err)
 	}
 
 	s, err := btf.LoadSpecFromReader(bytes.NewReader(file))
 	if err != nil {
-		return fmt.Errorf("loading BTF spec: %w", err)
+		return nil, "", fmt.Errorf("loading BTF spec: %w", err)
 	}
 
-	spec = s
-	return nil
+	return s, btfFile, nil
 }
 
-// GetBTFSpec returns the BTF spec with kernel information for the current kernel version. If the
-// kernel exposes BTF information or if the BTF for this kernel is not found, it returns nil.
-func GetBTFSpec() *btf.Spec {
+func load() {
 	once.Do(func() {
 		err := initialize()
 		if err != nil {
 			log.Warnf("Failed to initialize BTF: %v", err)
 		}
 	})
-	return spec
+}
+
+// GetBTFSpec returns the BTF spec with kernel information for the current kernel version. If the
+// kernel exposes BTF information or if the BTF for this kernel is not found, it returns nil.
+// When the BTF was found in a source containing all kernel types, like a vmlinux image, and specs
+// are given, the returned spec only contains the types used by them, as the embedded ones do.
+func GetBTFSpec(specs ...*ebpf.CollectionSpec) *btf.Spec {
+	load()
+
+	if spec == nil || !source.full || len(specs) == 0 {
+		return spec
+	}
+
+	minimized, err := Minimize(spec, specs...)
+	if err != nil {
+		log.Debugf("Failed to minimize BTF from %s, using all types: %v", source.Path, err)
+		return spec
+	}
+	return minimized
+}
+
+// GetSource returns where the BTF information for the current kernel was found
+func GetSource() Source {
+	load()
+	return source
 }
 
 type osInfo struct {
@@ -96,6 +215,21 @@ type osInfo struct {
 	Kernel    string
 }
 
+// btfHubPath returns the path of the BTF file of the kernel in the BTFHub
+// layout
+func (i *osInfo) btfHubPath() string {
+	return fmt.Sprintf("%s/%s/%s/%s.btf", i.ID, i.VersionID, i.Arch, i.Kernel)
+}
+
+// KernelRelease returns the release of the running kernel
+func KernelRelease() (string, error) {
+	uts := &unix.Utsname{}
+	if err := unix.Uname(uts); err != nil {
+		return "", fmt.Errorf("calling uname: %w", err)
+	}
+	return unix.ByteSliceToString(uts.Release[:]), nil
+}
+
 func getOSInfo() (*osInfo, error) {
 	osInfo := &osInfo{}
 
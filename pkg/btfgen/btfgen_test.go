@@ -0,0 +1,181 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btfgen
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/btf"
+	"github.com/stretchr/testify/require"
+)
+
+var testOSInfo = &osInfo{
+	ID:        "ubuntu",
+	VersionID: "20.04",
+	Arch:      "x86_64",
+	Kernel:    "5.4.0-1234-generic",
+}
+
+func marshalTypes(t *testing.T, types ...btf.Type) []byte {
+	t.Helper()
+
+	builder, err := btf.NewBuilder(types)
+	require.NoError(t, err)
+	raw, err := builder.Marshal(nil, nil)
+	require.NoError(t, err)
+	return raw
+}
+
+func kernelTypes() []btf.Type {
+	integer := &btf.Int{Name: "int", Size: 4, Encoding: btf.Signed}
+	return []btf.Type{
+		&btf.Struct{
+			Name: "task_struct",
+			Size: 4,
+			Members: []btf.Member{
+				{Name: "pid", Type: integer},
+			},
+		},
+		&btf.Struct{
+			Name: "sock",
+			Size: 4,
+			Members: []btf.Member{
+				{Name: "skc_family", Type: integer},
+			},
+		},
+	}
+}
+
+func requireTaskStruct(t *testing.T, s *btf.Spec) {
+	t.Helper()
+
+	var task *btf.Struct
+	require.NoError(t, s.TypeByName("task_struct", &task))
+	require.Equal(t, "pid", task.Members[0].Name)
+}
+
+func TestLoadFromDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, testOSInfo.btfHubPath())
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, marshalTypes(t, kernelTypes()...), 0o644))
+
+	s, found, err := loadFromDir(dir, testOSInfo)
+	require.NoError(t, err)
+	require.Equal(t, path, found)
+	requireTaskStruct(t, s)
+
+	_, _, err = loadFromDir(t.TempDir(), testOSInfo)
+	require.Error(t, err)
+}
+
+func TestLoadFromArchive(t *testing.T) {
+	t.Parallel()
+
+	content := marshalTypes(t, kernelTypes()...)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, name := range []string{"README.md", "btfs/" + testOSInfo.btfHubPath()} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	path := filepath.Join(t.TempDir(), "btfs.tar.gz")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	s, err := loadFromArchive(path, testOSInfo)
+	require.NoError(t, err)
+	requireTaskStruct(t, s)
+
+	_, err = loadFromArchive(path, &osInfo{Kernel: "6.0.0"})
+	require.Error(t, err)
+}
+
+func TestLoadVmlinuxDWARF(t *testing.T) {
+	// The test binary is an ELF file without .BTF section. The fake pahole
+	// writes the BTF it would have generated from its DWARF.
+	vmlinux, err := os.Executable()
+	require.NoError(t, err)
+
+	cp, err := exec.LookPath("cp")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	btfPath := filepath.Join(dir, "vmlinux.btf")
+	require.NoError(t, os.WriteFile(btfPath, marshalTypes(t, kernelTypes()...), 0o644))
+
+	t.Setenv("PATH", dir)
+	_, err = loadVmlinuxFile(vmlinux)
+	require.ErrorContains(t, err, "pahole")
+
+	script := "#!/bin/sh\n" + cp + " " + btfPath + " \"${1#--btf_encode_detached=}\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pahole"), []byte(script), 0o755))
+
+	s, err := loadVmlinuxFile(vmlinux)
+	require.NoError(t, err)
+	requireTaskStruct(t, s)
+}
+
+func TestMinimize(t *testing.T) {
+	t.Parallel()
+
+	kernel, err := btf.LoadSpecFromReader(bytes.NewReader(marshalTypes(t, kernelTypes()...)))
+	require.NoError(t, err)
+
+	// The gadget only uses a flavor of task_struct
+	local := &btf.Struct{
+		Name: "task_struct___old",
+		Size: 4,
+		Members: []btf.Member{
+			{Name: "pid", Type: &btf.Int{Name: "int", Size: 4, Encoding: btf.Signed}},
+		},
+	}
+	localSpec, err := btf.LoadSpecFromReader(bytes.NewReader(marshalTypes(t, local)))
+	require.NoError(t, err)
+
+	minimized, err := Minimize(kernel, &ebpf.CollectionSpec{Types: localSpec})
+	require.NoError(t, err)
+
+	requireTaskStruct(t, minimized)
+	_, err = minimized.AnyTypeByName("sock")
+	require.ErrorIs(t, err, btf.ErrNotFound)
+}
+
+func TestEssentialName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "task_struct", essentialName("task_struct"))
+	require.Equal(t, "task_struct", essentialName("task_struct___old"))
+	require.Equal(t, "", essentialName(""))
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btfgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/btf"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/kallsyms"
+)
+
+// Problem is an issue found while checking whether a program can run on the
+// current kernel
+type Problem struct {
+	Program string
+
+	// Fatal is true if the program can't be loaded or attached. Otherwise,
+	// the issue only matters if the affected code runs.
+	Fatal bool
+
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Program, p.Message)
+}
+
+// Check verifies that the CO-RE relocations and the kernel functions used by
+// the programs in specs can be resolved on the current kernel, using the same
+// BTF information gadgets are loaded with
+func Check(specs ...*ebpf.CollectionSpec) []Problem {
+	load()
+
+	var target *btf.Spec
+	var targetErr error
+	if source.Kind == SourceKernel {
+		target, targetErr = btf.LoadKernelSpec()
+	} else if spec != nil {
+		target = GetBTFSpec(specs...)
+	} else {
+		targetErr = fmt.Errorf("no BTF information found for kernel")
+	}
+
+	// kallsyms is only needed for kprobes, don't fail if it can't be read
+	syms, _ := kallsyms.NewKAllSyms()
+
+	var problems []Problem
+	for _, collSpec := range specs {
+		names := make([]string, 0, len(collSpec.Programs))
+		for name := range collSpec.Programs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			problems = append(problems, checkProgram(collSpec.Programs[name], target, targetErr, syms)...)
+		}
+	}
+
+	return problems
+}
+
+func checkProgram(prog *ebpf.ProgramSpec, target *btf.Spec, targetErr error, syms *kallsyms.KAllSyms) []Problem {
+	var problems []Problem
+	problem := func(fatal bool, format string, a ...any) {
+		problems = append(problems, Problem{
+			Program: prog.Name,
+			Fatal:   fatal,
+			Message: fmt.Sprintf(format, a...),
+		})
+	}
+
+	var relos []*btf.CORERelocation
+	iter := prog.Instructions.Iterate()
+	for iter.Next() {
+		if relo := btf.CORERelocationMetadata(iter.Ins); relo != nil {
+			relos = append(relos, relo)
+		}
+	}
+
+	if len(relos) > 0 {
+		if targetErr != nil {
+			problem(true, "%d CO-RE relocations need BTF: %s", len(relos), targetErr)
+		} else if fixups, err := btf.CORERelocate(relos, target, prog.ByteOrder); err != nil {
+			problem(true, "CO-RE relocations: %s", err)
+		} else {
+			var poisoned []string
+			for i := range fixups {
+				// Poisoned relocations are replaced by an invalid call that
+				// is rejected by the verifier if it can be reached
+				if strings.HasSuffix(fixups[i].String(), "=poison") {
+					poisoned = append(poisoned, relos[i].String())
+				}
+			}
+			if len(poisoned) > 0 {
+				problem(false, "%d CO-RE relocations can't be resolved, the program is rejected if they are reachable, e.g. %s",
+					len(poisoned), poisoned[0])
+			}
+		}
+	}
+
+	switch {
+	case prog.Type == ebpf.Kprobe && (strings.HasPrefix(prog.SectionName, "kprobe/") ||
+		strings.HasPrefix(prog.SectionName, "kretprobe/")):
+		if syms != nil && prog.AttachTo != "" && !syms.SymbolExists(prog.AttachTo) {
+			problem(true, "kernel function %q not found", prog.AttachTo)
+		}
+	case prog.Type == ebpf.Tracing && (prog.AttachType == ebpf.AttachTraceFEntry ||
+		prog.AttachType == ebpf.AttachTraceFExit):
+		// The kernel needs to expose BTF to attach fentry and fexit programs
+		if source.Kind != SourceKernel {
+			problem(true, "attaching to %q needs BTF exposed by the kernel", prog.AttachTo)
+		} else if target == nil {
+			problem(true, "attaching to %q: %s", prog.AttachTo, targetErr)
+		} else if _, err := target.AnyTypeByName(prog.AttachTo); err != nil {
+			problem(true, "kernel function %q not found in BTF", prog.AttachTo)
+		}
+	}
+
+	return problems
+}
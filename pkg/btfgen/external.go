@@ -0,0 +1,223 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btfgen
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cilium/ebpf/btf"
+	"github.com/ulikunitz/xz"
+)
+
+// downloadTimeout is the maximum time to download the BTF of the kernel
+const downloadTimeout = 60 * time.Second
+
+// loadExternal loads the BTF of the running kernel from the path given by
+// the user. It can be:
+// - a BTF file or an ELF file with a .BTF section, used as is
+// - a directory in BTFHub layout: <id>/<version>/<arch>/<kernel>.btf[.tar.xz]
+// - a tar archive (optionally compressed with gzip or xz) containing a BTFHub
+// tree or the BTF file of the kernel
+// - an HTTP(S) URL to download any of the files above from. If it ends with a
+// slash, it's the base URL of a BTFHub archive mirror.
+func loadExternal(info *osInfo) (*btf.Spec, string, error) {
+	path := getBTFPath()
+	if path == "" {
+		return nil, "", errors.New("no path given")
+	}
+
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return loadFromURL(path, info)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if stat.IsDir() {
+		return loadFromDir(path, info)
+	}
+
+	if isArchive(path) {
+		if info == nil {
+			return nil, "", errors.New("unknown OS, can't look for the kernel in the archive")
+		}
+		s, err := loadFromArchive(path, info)
+		return s, path, err
+	}
+
+	s, err := btf.LoadSpec(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("loading %s: %w", path, err)
+	}
+	return s, path, nil
+}
+
+func loadFromDir(dir string, info *osInfo) (*btf.Spec, string, error) {
+	if info == nil {
+		return nil, "", errors.New("unknown OS, can't look for the kernel in the directory")
+	}
+
+	candidates := []string{
+		filepath.Join(dir, info.btfHubPath()),
+		filepath.Join(dir, info.btfHubPath()+".tar.xz"),
+		filepath.Join(dir, info.Kernel+".btf"),
+		filepath.Join(dir, info.Kernel+".btf.tar.xz"),
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err != nil {
+			continue
+		}
+
+		var s *btf.Spec
+		var err error
+		if isArchive(candidate) {
+			s, err = loadFromArchive(candidate, info)
+		} else {
+			s, err = btf.LoadSpec(candidate)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("loading %s: %w", candidate, err)
+		}
+		return s, candidate, nil
+	}
+
+	return nil, "", fmt.Errorf("%s not found in %s", info.btfHubPath(), dir)
+}
+
+func loadFromURL(url string, info *osInfo) (*btf.Spec, string, error) {
+	if strings.HasSuffix(url, "/") {
+		if info == nil {
+			return nil, "", errors.New("unknown OS, can't build the URL of the kernel")
+		}
+		url += info.btfHubPath() + ".tar.xz"
+	}
+
+	client := http.Client{Timeout: downloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, "", fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+
+	if !isArchive(url) {
+		content, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, "", fmt.Errorf("downloading %s: %w", url, err)
+		}
+		s, err := btf.LoadSpecFromReader(bytes.NewReader(content))
+		return s, url, err
+	}
+
+	if info == nil {
+		return nil, "", errors.New("unknown OS, can't look for the kernel in the archive")
+	}
+
+	s, err := loadArchive(url, resp.Body, info)
+	return s, url, err
+}
+
+func isArchive(path string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz", ".tar.xz"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func decompress(path string, r io.Reader) (io.Reader, error) {
+	switch {
+	case strings.HasSuffix(path, ".gz"), strings.HasSuffix(path, ".tgz"):
+		return gzip.NewReader(r)
+	case strings.HasSuffix(path, ".xz"):
+		return xz.NewReader(r)
+	}
+	return r, nil
+}
+
+func loadFromArchive(path string, info *osInfo) (*btf.Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return loadArchive(path, f, info)
+}
+
+// loadArchive loads the BTF of the kernel from the archive named name read
+// from r
+func loadArchive(name string, r io.Reader, info *osInfo) (*btf.Spec, error) {
+	r, err := decompress(name, r)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing %s: %w", name, err)
+	}
+
+	content, err := findInArchive(r, info)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	return btf.LoadSpecFromReader(bytes.NewReader(content))
+}
+
+// findInArchive returns the content of the BTF file of the kernel in the tar
+// archive read from r. BTFHub archives for a single kernel are also looked
+// into if the archive contains them.
+func findInArchive(r io.Reader, info *osInfo) ([]byte, error) {
+	btfName := info.Kernel + ".btf"
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found", btfName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := filepath.Base(hdr.Name)
+		switch {
+		case name == btfName:
+			return io.ReadAll(tr)
+		case name == btfName+".tar.xz":
+			nested, err := xz.NewReader(tr)
+			if err != nil {
+				return nil, fmt.Errorf("decompressing %s: %w", hdr.Name, err)
+			}
+			return findInArchive(nested, info)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btfgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/btf"
+)
+
+// Minimize returns a spec containing only the kernel types whose names are
+// used by the BTF of the given specs, along with the types they depend on.
+// Flavors (e.g. task_struct___old) match the type without suffix, as they do
+// for CO-RE relocations.
+func Minimize(kernel *btf.Spec, specs ...*ebpf.CollectionSpec) (*btf.Spec, error) {
+	names := make(map[string]struct{})
+	for _, spec := range specs {
+		if spec == nil || spec.Types == nil {
+			continue
+		}
+
+		iter := spec.Types.Iterate()
+		for iter.Next() {
+			switch iter.Type.(type) {
+			case *btf.Struct, *btf.Union, *btf.Enum, *btf.Typedef, *btf.Fwd, *btf.Int:
+			default:
+				continue
+			}
+			if name := essentialName(iter.Type.TypeName()); name != "" {
+				names[name] = struct{}{}
+			}
+		}
+	}
+
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	var types []btf.Type
+	for _, name := range sortedNames {
+		typs, err := kernel.AnyTypesByName(name)
+		if err != nil {
+			if errors.Is(err, btf.ErrNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("looking up %q: %w", name, err)
+		}
+		types = append(types, typs...)
+	}
+
+	builder, err := btf.NewBuilder(types)
+	if err != nil {
+		return nil, fmt.Errorf("creating BTF builder: %w", err)
+	}
+
+	// Marshalling also adds all the types the given ones refer to
+	raw, err := builder.Marshal(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling BTF: %w", err)
+	}
+
+	return btf.LoadSpecFromReader(bytes.NewReader(raw))
+}
+
+func essentialName(name string) string {
+	if i := strings.LastIndex(name, "___"); i > 0 {
+		return name[:i]
+	}
+	return name
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btfgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/cilium/ebpf/btf"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+// vmlinuxPaths are the locations where distributions install vmlinux images
+// and debuginfo packages, the same ones libbpf looks into. %s is replaced by
+// the kernel release.
+var vmlinuxPaths = []string{
+	"/boot/vmlinux-%s",
+	"/lib/modules/%s/vmlinux-%s",
+	"/lib/modules/%s/build/vmlinux",
+	"/usr/lib/modules/%s/kernel/vmlinux",
+	"/usr/lib/debug/boot/vmlinux-%s",
+	"/usr/lib/debug/boot/vmlinux-%s.debug",
+	"/usr/lib/debug/lib/modules/%s/vmlinux",
+}
+
+// loadVmlinux extracts the BTF of the running kernel from a vmlinux image
+// installed on the host.
+func loadVmlinux(info *osInfo) (*btf.Spec, string, error) {
+	release := ""
+	if info != nil {
+		release = info.Kernel
+	} else {
+		var err error
+		if release, err = KernelRelease(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var errs []string
+	for _, pattern := range vmlinuxPaths {
+		path := filepath.Join(host.HostRoot, strings.ReplaceAll(pattern, "%s", release))
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		s, err := loadVmlinuxFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", path, err))
+			continue
+		}
+		return s, path, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, "", fmt.Errorf("no vmlinux image found for kernel %s", release)
+	}
+	return nil, "", fmt.Errorf("no usable vmlinux image found: %s", strings.Join(errs, "; "))
+}
+
+// loadVmlinuxFile loads the BTF from the .BTF section of a vmlinux image.
+// Images built without CONFIG_DEBUG_INFO_BTF only contain DWARF, it's converted
+// to BTF with pahole, as the kernel build does.
+func loadVmlinuxFile(path string) (*btf.Spec, error) {
+	s, err := btf.LoadSpec(path)
+	if !errors.Is(err, btf.ErrNotFound) {
+		return s, err
+	}
+
+	pahole, err := exec.LookPath("pahole")
+	if err != nil {
+		return nil, fmt.Errorf("no .BTF section and pahole isn't available to convert DWARF: %w", err)
+	}
+
+	f, err := os.CreateTemp("", "vmlinux-*.btf")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary file: %w", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	out, err := exec.Command(pahole, "--btf_encode_detached="+f.Name(), path).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("converting DWARF to BTF with pahole: %w: %s", err, bytes.TrimSpace(out))
+	}
+
+	s, err = btf.LoadSpec(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("loading BTF generated by pahole: %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parquet
+
+type Compression int32
+
+// Values match the CompressionCodec enum of the Parquet format
+const (
+	CompressionNone   Compression = 0
+	CompressionSnappy Compression = 1
+)
+
+type Option func(*Options)
+
+type Options struct {
+	// Number of rows after which a row group is written
+	rowGroupSize int
+	// Compression used for data pages
+	compression Compression
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		rowGroupSize: 64 * 1024,
+		compression:  CompressionSnappy,
+	}
+}
+
+// WithRowGroupSize sets the number of rows kept in memory before they are
+// written to the file as a row group
+func WithRowGroupSize(rows int) func(*Options) {
+	return func(o *Options) {
+		if rows > 0 {
+			o.rowGroupSize = rows
+		}
+	}
+}
+
+// WithCompression sets the compression codec used for data pages
+func WithCompression(compression Compression) func(*Options) {
+	return func(o *Options) {
+		o.compression = compression
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package parquet writes entries of columns.Columns[T] to Apache Parquet files,
+// e.g. to load traces into DuckDB or pandas. Every column becomes a typed,
+// required Parquet column; columns of embedded structs are flattened and named
+// after their full column name with "." replaced by "_" (e.g. k8s_namespace).
+// Columns using the "timestamp" template are stored as nanosecond timestamps.
+//
+// Entries are buffered in memory and written as a row group every
+// WithRowGroupSize() entries; the file is only readable once Close() wrote its
+// footer.
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	parquetgo "github.com/parquet-go/parquet-go"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+)
+
+type column[T any] struct {
+	name  string
+	node  parquetgo.Node
+	value func(entry *T) parquetgo.Value
+}
+
+// field is a column of the schema. parquetgo.Group sorts its fields by name,
+// so the schema is built from fields to keep the order of the columns.
+type field struct {
+	parquetgo.Node
+	name string
+}
+
+func (f field) Name() string {
+	return f.name
+}
+
+func (f field) Value(base reflect.Value) reflect.Value {
+	return base.MapIndex(reflect.ValueOf(f.name))
+}
+
+type group struct {
+	parquetgo.Group
+	fields []parquetgo.Field
+}
+
+func (g group) Fields() []parquetgo.Field {
+	return g.fields
+}
+
+type Formatter[T any] struct {
+	options *Options
+	columns []*column[T]
+	writer  *parquetgo.Writer
+	row     parquetgo.Row
+	rows    int
+	closed  bool
+}
+
+// NewFormatter returns a Formatter that writes entries of type T to w in the
+// Parquet format. Columns of unsupported types are skipped.
+func NewFormatter[T any](cols columns.ColumnMap[T], w io.Writer, options ...Option) *Formatter[T] {
+	opts := DefaultOptions()
+	for _, o := range options {
+		o(opts)
+	}
+
+	ncols := make([]*column[T], 0)
+	for _, col := range cols.GetOrderedColumns() {
+		c := &column[T]{
+			name: strings.ReplaceAll(col.Name, ".", "_"),
+		}
+
+		switch col.Kind() {
+		default:
+			continue
+		case reflect.Int8, reflect.Int16, reflect.Int32:
+			ff := columns.GetFieldAsNumberFunc[int32, T](col)
+			c.node = parquetgo.Int(int(col.Type().Size()) * 8)
+			c.value = func(entry *T) parquetgo.Value {
+				return parquetgo.Int32Value(ff(entry))
+			}
+		case reflect.Int, reflect.Int64:
+			ff := columns.GetFieldAsNumberFunc[int64, T](col)
+			c.node = parquetgo.Int(64)
+			if col.Template == "timestamp" {
+				c.node = parquetgo.Timestamp(parquetgo.Nanosecond)
+			}
+			c.value = func(entry *T) parquetgo.Value {
+				return parquetgo.Int64Value(ff(entry))
+			}
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			ff := columns.GetFieldAsNumberFunc[uint32, T](col)
+			c.node = parquetgo.Uint(int(col.Type().Size()) * 8)
+			c.value = func(entry *T) parquetgo.Value {
+				return parquetgo.Int32Value(int32(ff(entry)))
+			}
+		case reflect.Uint, reflect.Uint64:
+			ff := columns.GetFieldAsNumberFunc[uint64, T](col)
+			c.node = parquetgo.Uint(64)
+			c.value = func(entry *T) parquetgo.Value {
+				return parquetgo.Int64Value(int64(ff(entry)))
+			}
+		case reflect.Bool:
+			ff := columns.GetFieldFunc[bool, T](col)
+			c.node = parquetgo.Leaf(parquetgo.BooleanType)
+			c.value = func(entry *T) parquetgo.Value {
+				return parquetgo.BooleanValue(ff(entry))
+			}
+		case reflect.Float32:
+			ff := columns.GetFieldAsNumberFunc[float32, T](col)
+			c.node = parquetgo.Leaf(parquetgo.FloatType)
+			c.value = func(entry *T) parquetgo.Value {
+				return parquetgo.FloatValue(ff(entry))
+			}
+		case reflect.Float64:
+			ff := columns.GetFieldAsNumberFunc[float64, T](col)
+			c.node = parquetgo.Leaf(parquetgo.DoubleType)
+			c.value = func(entry *T) parquetgo.Value {
+				return parquetgo.DoubleValue(ff(entry))
+			}
+		case reflect.Array:
+			ff := columns.GetFieldAsString[T](col)
+			c.node = parquetgo.String()
+			c.value = func(entry *T) parquetgo.Value {
+				return parquetgo.ByteArrayValue([]byte(ff(entry)))
+			}
+		case reflect.String:
+			ff := columns.GetFieldFunc[string, T](col)
+			c.node = parquetgo.String()
+			c.value = func(entry *T) parquetgo.Value {
+				return parquetgo.ByteArrayValue([]byte(ff(entry)))
+			}
+		}
+
+		ncols = append(ncols, c)
+	}
+
+	root := group{Group: parquetgo.Group{}}
+	for _, c := range ncols {
+		root.Group[c.name] = c.node
+		root.fields = append(root.fields, field{Node: c.node, name: c.name})
+	}
+
+	codec := parquetgo.Compression(&parquetgo.Uncompressed)
+	if opts.compression == CompressionSnappy {
+		codec = parquetgo.Compression(&parquetgo.Snappy)
+	}
+
+	return &Formatter[T]{
+		options: opts,
+		columns: ncols,
+		writer: parquetgo.NewWriter(w,
+			parquetgo.NewSchema("schema", root),
+			codec,
+		),
+		row: make(parquetgo.Row, len(ncols)),
+	}
+}
+
+// ColumnNames returns the names of the Parquet columns in the order they are
+// written
+func (f *Formatter[T]) ColumnNames() []string {
+	names := make([]string, 0, len(f.columns))
+	for _, c := range f.columns {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// WriteEntry buffers the given entry and writes a row group if enough entries
+// were buffered
+func (f *Formatter[T]) WriteEntry(entry *T) error {
+	if f.closed {
+		return errors.New("formatter closed")
+	}
+	for i, c := range f.columns {
+		f.row[i] = c.value(entry).Level(0, 0, i)
+	}
+	if _, err := f.writer.WriteRows([]parquetgo.Row{f.row}); err != nil {
+		return fmt.Errorf("writing row: %w", err)
+	}
+	f.rows++
+	if f.rows >= f.options.rowGroupSize {
+		return f.Flush()
+	}
+	return nil
+}
+
+// WriteEntries calls WriteEntry for all given entries
+func (f *Formatter[T]) WriteEntries(entries []*T) error {
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		if err := f.WriteEntry(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes the buffered entries as a row group
+func (f *Formatter[T]) Flush() error {
+	if f.rows == 0 {
+		return nil
+	}
+	if err := f.writer.Flush(); err != nil {
+		return fmt.Errorf("writing row group: %w", err)
+	}
+	f.rows = 0
+	return nil
+}
+
+// Close writes the remaining entries and the footer of the file. It doesn't
+// close the underlying writer.
+func (f *Formatter[T]) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	if err := f.writer.Close(); err != nil {
+		return fmt.Errorf("writing footer: %w", err)
+	}
+	return nil
+}
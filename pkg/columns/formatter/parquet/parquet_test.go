@@ -0,0 +1,177 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parquet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+
+	parquetgo "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+)
+
+type testCommon struct {
+	Namespace string `column:"namespace"`
+	Node      string `column:"node"`
+}
+
+type testEntry struct {
+	Common    testCommon `column:"common" columnTags:"kubernetes"`
+	Timestamp int64      `column:"timestamp,template:timestamp"`
+	Comm      string     `column:"comm"`
+	Pid       uint32     `column:"pid"`
+	Ret       int32      `column:"ret"`
+	Count     uint64     `column:"count"`
+	Delta     int64      `column:"delta"`
+	Ratio     float64    `column:"ratio"`
+	Share     float32    `column:"share"`
+	Ok        bool       `column:"ok"`
+	Flags     uint8      `column:"flags"`
+	Raw       [4]byte    `column:"raw"`
+	Skipped   []string   `column:"skipped"`
+}
+
+func init() {
+	columns.MustRegisterTemplate("timestamp", "width:35")
+}
+
+func openFile(t *testing.T, data []byte) *parquetgo.File {
+	f, err := parquetgo.OpenFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	return f
+}
+
+func TestFormatter(t *testing.T) {
+	cols := columns.MustCreateColumns[testEntry]()
+
+	var out bytes.Buffer
+	formatter := NewFormatter(cols.GetColumnMap(), &out, WithRowGroupSize(2))
+
+	names := []string{
+		"common_namespace", "common_node", "timestamp", "comm", "pid", "ret", "count",
+		"delta", "ratio", "share", "ok", "flags", "raw",
+	}
+	require.Equal(t, names, formatter.ColumnNames())
+
+	entries := make([]*testEntry, 0)
+	for i := 0; i < 5; i++ {
+		entries = append(entries, &testEntry{
+			Common:    testCommon{Namespace: "ns", Node: fmt.Sprintf("node-%d", i)},
+			Timestamp: 1700000000000000000 + int64(i),
+			Comm:      fmt.Sprintf("comm-%d", i),
+			Pid:       math.MaxUint32 - uint32(i),
+			Ret:       -int32(i),
+			Count:     math.MaxUint64,
+			Delta:     -1,
+			Ratio:     float64(i) / 2,
+			Share:     float32(i) / 4,
+			Ok:        i%2 == 0,
+			Flags:     uint8(i),
+			Raw:       [4]byte{'a', 'b', 'c', 0},
+		})
+	}
+	require.NoError(t, formatter.WriteEntries(entries[:3]))
+	require.NoError(t, formatter.WriteEntry(entries[3]))
+	require.NoError(t, formatter.WriteEntry(entries[4]))
+	require.NoError(t, formatter.Close())
+	require.Error(t, formatter.WriteEntry(entries[0]))
+
+	f := openFile(t, out.Bytes())
+	metadata := f.Metadata()
+	require.Equal(t, int64(5), metadata.NumRows)
+	require.Len(t, metadata.RowGroups, 3)
+	for _, rowGroup := range metadata.RowGroups {
+		for _, chunk := range rowGroup.Columns {
+			require.Equal(t, format.Snappy, chunk.MetaData.Codec)
+		}
+	}
+
+	// The columns keep their order and are all required
+	schema := map[string]format.SchemaElement{}
+	var schemaNames []string
+	for _, element := range metadata.Schema[1:] {
+		require.Equal(t, format.Required, *element.RepetitionType)
+		schema[element.Name] = element
+		schemaNames = append(schemaNames, element.Name)
+	}
+	require.Equal(t, names, schemaNames)
+
+	require.Equal(t, format.Int64, *schema["timestamp"].Type)
+	require.Equal(t, &format.TimestampType{IsAdjustedToUTC: true, Unit: format.TimeUnit{Nanos: &format.NanoSeconds{}}},
+		schema["timestamp"].LogicalType.Timestamp)
+	require.Equal(t, format.Int32, *schema["pid"].Type)
+	require.Equal(t, &format.IntType{BitWidth: 32, IsSigned: false}, schema["pid"].LogicalType.Integer)
+	require.Equal(t, &format.IntType{BitWidth: 8, IsSigned: false}, schema["flags"].LogicalType.Integer)
+	require.Equal(t, &format.IntType{BitWidth: 64, IsSigned: false}, schema["count"].LogicalType.Integer)
+	require.Equal(t, &format.IntType{BitWidth: 32, IsSigned: true}, schema["ret"].LogicalType.Integer)
+	require.Equal(t, format.Boolean, *schema["ok"].Type)
+	require.Equal(t, format.Float, *schema["share"].Type)
+	require.Equal(t, format.Double, *schema["ratio"].Type)
+	require.Equal(t, format.ByteArray, *schema["raw"].Type)
+	require.NotNil(t, schema["raw"].LogicalType.UTF8)
+
+	var rows []parquetgo.Row
+	reader := parquetgo.NewReader(bytes.NewReader(out.Bytes()))
+	for {
+		buf := make([]parquetgo.Row, len(entries))
+		n, err := reader.ReadRows(buf)
+		// The values reference the buffers of the reader
+		for _, row := range buf[:n] {
+			rows = append(rows, row.Clone())
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+	require.Len(t, rows, len(entries))
+
+	for i, entry := range entries {
+		row := rows[i]
+		require.Equal(t, entry.Common.Namespace, string(row[0].ByteArray()))
+		require.Equal(t, entry.Common.Node, string(row[1].ByteArray()))
+		require.Equal(t, entry.Timestamp, row[2].Int64())
+		require.Equal(t, entry.Comm, string(row[3].ByteArray()))
+		require.Equal(t, entry.Pid, row[4].Uint32())
+		require.Equal(t, entry.Ret, row[5].Int32())
+		require.Equal(t, entry.Count, row[6].Uint64())
+		require.Equal(t, entry.Delta, row[7].Int64())
+		require.Equal(t, entry.Ratio, row[8].Double())
+		require.Equal(t, entry.Share, row[9].Float())
+		require.Equal(t, entry.Ok, row[10].Boolean())
+		require.Equal(t, uint32(entry.Flags), row[11].Uint32())
+		require.Equal(t, "abc", string(row[12].ByteArray()))
+	}
+}
+
+func TestFormatterEmpty(t *testing.T) {
+	cols := columns.MustCreateColumns[testEntry]()
+
+	var out bytes.Buffer
+	formatter := NewFormatter(cols.GetColumnMap(), &out, WithCompression(CompressionNone))
+	require.NoError(t, formatter.Close())
+
+	f := openFile(t, out.Bytes())
+	require.Equal(t, int64(0), f.NumRows())
+	require.Empty(t, f.RowGroups())
+	require.Len(t, f.Schema().Fields(), len(formatter.ColumnNames()))
+}
@@ -27,6 +27,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
@@ -63,6 +64,12 @@ type ContainerCollection struct {
 	// the container is meant to be dropped.
 	containerEnrichers []func(container *Container) (ok bool)
 
+	// exitEnrichers are functions that get how the container terminated
+	// upon RemoveContainer. The functions return nil if they don't know it.
+	// They are called from another goroutine, after the container was
+	// removed, and must not modify the container.
+	exitEnrichers []func(container *Container) *ContainerExit
+
 	// initialContainers is used during the initialization process to
 	// gather initial containers and then call the enrichers
 	initialContainers []*Container
@@ -73,6 +80,9 @@ type ContainerCollection struct {
 	// initialized tells if Initialize() has been called.
 	initialized bool
 
+	// systemdUnits tells if WithSystemdUnits() has been used.
+	systemdUnits bool
+
 	// closed tells if Close() has been called.
 	closed bool
 	done   chan struct{}
@@ -82,6 +92,10 @@ type ContainerCollection struct {
 
 	// disableContainerRuntimeWarnings is used to disable warnings about container runtimes.
 	disableContainerRuntimeWarnings bool
+
+	// ownerClient is set by WithOwnerReferenceEnrichment() to look up the
+	// owners of the containers when they are added
+	ownerClient dynamic.Interface
 }
 
 // ContainerCollectionOption are options to pass to
@@ -118,6 +132,12 @@ func (cc *ContainerCollection) Initialize(options ...ContainerCollectionOption)
 	return nil
 }
 
+// HasSystemdUnits tells if the collection includes the systemd units of the
+// host, see WithSystemdUnits().
+func (cc *ContainerCollection) HasSystemdUnits() bool {
+	return cc.systemdUnits
+}
+
 // GetContainer looks up a container by the container id and return it if
 // found, or return nil if not found.
 func (cc *ContainerCollection) GetContainer(id string) *Container {
@@ -129,6 +149,30 @@ func (cc *ContainerCollection) GetContainer(id string) *Container {
 	return container
 }
 
+// enrichExit gets how the container terminated using the exit enrichers. The
+// runtimes could need some time to report it, so they are run in the
+// background to avoid delaying the removal of the container. Use WaitExit() to
+// get the result.
+func (cc *ContainerCollection) enrichExit(container *Container) {
+	if container.exit == nil {
+		return
+	}
+	if len(cc.exitEnrichers) == 0 || container.GetExit() != nil {
+		container.exitDone()
+		return
+	}
+
+	go func() {
+		defer container.exitDone()
+		for _, enricher := range cc.exitEnrichers {
+			if exit := enricher(container); exit != nil {
+				container.setExit(exit)
+				return
+			}
+		}
+	}()
+}
+
 // RemoveContainer removes a container from the collection, but only after
 // notifying all the subscribers.
 func (cc *ContainerCollection) RemoveContainer(id string) {
@@ -139,6 +183,8 @@ func (cc *ContainerCollection) RemoveContainer(id string) {
 
 	container := v.(*Container)
 
+	cc.enrichExit(container)
+
 	if cc.pubsub != nil {
 		cc.pubsub.Publish(EventTypeRemoveContainer, container)
 	}
@@ -156,6 +202,10 @@ func (cc *ContainerCollection) RemoveContainer(id string) {
 	// present.
 	cc.containers.Delete(id)
 
+	if container.ambiguousMntns {
+		return
+	}
+
 	// Make this operation atomic, as RemoveContainer() could be called concurrently, which could result in
 	// dirty map contents
 	cc.mu.Lock()
@@ -210,10 +260,20 @@ func (cc *ContainerCollection) AddContainer(container *Container) {
 		}
 	}
 
+	if container.exit == nil {
+		container.exit = newExitState()
+	}
+
 	_, loaded := cc.containers.LoadOrStore(container.Runtime.ContainerID, container)
 	if loaded {
 		return
 	}
+	if container.ambiguousMntns {
+		if cc.pubsub != nil {
+			cc.pubsub.Publish(EventTypeAddContainer, container)
+		}
+		return
+	}
 	cc.mu.Lock()
 	cc.containersByMntNs.Store(container.Mntns, container)
 	arr, ok := cc.containersByNetNs.Load(container.Netns)
@@ -250,7 +310,7 @@ func lookupContainerByMntns(m *sync.Map, mntnsid uint64) *Container {
 
 	m.Range(func(key, value interface{}) bool {
 		c := value.(*Container)
-		if c.Mntns == mntnsid {
+		if c.Mntns == mntnsid && !c.ambiguousMntns {
 			container = c
 			// container found, stop iterating
 			return false
@@ -284,7 +344,7 @@ func (cc *ContainerCollection) LookupContainersByNetns(netnsid uint64) []*Contai
 func lookupContainersByNetns(m *sync.Map, netnsid uint64) (containers []*Container) {
 	m.Range(func(key, value interface{}) bool {
 		c := value.(*Container)
-		if c.Netns == netnsid {
+		if c.Netns == netnsid && !c.ambiguousMntns {
 			containers = append(containers, c)
 		}
 		return true
@@ -409,6 +469,23 @@ func (cc *ContainerCollection) ContainerRangeWithSelector(
 	})
 }
 
+// enrichOwnerReference looks up the owner of the pod of the container, if not
+// done yet
+func (cc *ContainerCollection) enrichOwnerReference(container *Container) {
+	if container.K8s.PodName == "" {
+		return
+	}
+	if _, done := container.ownerReferenceIfDone(); done {
+		return
+	}
+
+	// Don't drop the container: it just won't match owner selectors
+	if err := ownerReferenceEnrichment(cc.ownerClient, container, nil); err != nil {
+		log.Warnf("owner reference enricher: pod %s/%s: %s",
+			container.K8s.Namespace, container.K8s.PodName, err)
+	}
+}
+
 func (cc *ContainerCollection) EnrichNode(event *eventtypes.CommonData) {
 	event.K8s.Node = cc.nodeName
 }
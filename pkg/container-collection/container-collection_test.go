@@ -21,6 +21,10 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 
 	utilstest "github.com/inspektor-gadget/inspektor-gadget/internal/test"
 	types "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
@@ -241,3 +245,108 @@ func TestWithTracerCollection(t *testing.T) {
 	cc.EnrichByNetNs(&ev, containers[0].Netns)
 	require.Equal(t, expected, ev, "events should be equal")
 }
+
+func TestRemoveContainerExit(t *testing.T) {
+	t.Parallel()
+
+	const slowEnricherDelay = 100 * time.Millisecond
+
+	exit := &ContainerExit{Code: 1, Reason: "Error"}
+	table := []struct {
+		description  string
+		enrichers    []func(*Container) *ContainerExit
+		expectedExit *ContainerExit
+	}{
+		{
+			description: "First enricher knowing the exit wins",
+			enrichers: []func(*Container) *ContainerExit{
+				func(*Container) *ContainerExit { return nil },
+				func(*Container) *ContainerExit { return exit },
+				func(*Container) *ContainerExit { return &ContainerExit{Code: 2} },
+			},
+			expectedExit: exit,
+		},
+		{
+			description: "Slow enricher doesn't delay the removal",
+			enrichers: []func(*Container) *ContainerExit{
+				func(*Container) *ContainerExit {
+					time.Sleep(slowEnricherDelay)
+					return exit
+				},
+			},
+			expectedExit: exit,
+		},
+		{
+			description: "Unknown exit",
+			enrichers: []func(*Container) *ContainerExit{
+				func(*Container) *ContainerExit { return nil },
+			},
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+
+			cc := &ContainerCollection{exitEnrichers: entry.enrichers}
+			container := &Container{
+				Runtime: RuntimeMetadata{
+					BasicRuntimeMetadata: types.BasicRuntimeMetadata{ContainerID: "abcde"},
+				},
+				exit: newExitState(),
+			}
+			cc.containers.Store(container.Runtime.ContainerID, container)
+
+			start := time.Now()
+			cc.RemoveContainer(container.Runtime.ContainerID)
+			require.Less(t, time.Since(start), slowEnricherDelay)
+			require.Equal(t, entry.expectedExit, container.WaitExit(10*slowEnricherDelay))
+		})
+	}
+}
+
+func TestOwnerReferenceEnrichment(t *testing.T) {
+	pod := &unstructured.Unstructured{}
+	pod.SetAPIVersion("v1")
+	pod.SetKind("Pod")
+	pod.SetNamespace("default")
+	pod.SetName("nginx-x2bdn")
+	pod.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "nginx"}})
+
+	ds := &unstructured.Unstructured{}
+	ds.SetAPIVersion("apps/v1")
+	ds.SetKind("DaemonSet")
+	ds.SetNamespace("default")
+	ds.SetName("nginx")
+
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), pod, ds)
+
+	cc := &ContainerCollection{ownerClient: client}
+	container := &Container{
+		Runtime: RuntimeMetadata{BasicRuntimeMetadata: types.BasicRuntimeMetadata{ContainerID: "abc"}},
+		K8s: K8sMetadata{BasicK8sMetadata: types.BasicK8sMetadata{
+			Namespace: "default",
+			PodName:   "nginx-x2bdn",
+		}},
+	}
+
+	// The owner is looked up when the container is added
+	cc.enrichOwnerReference(container)
+	ownerRef, done := container.ownerReferenceIfDone()
+	require.True(t, done)
+	require.Equal(t, "nginx", ownerRef.Name)
+	cc.containers.Store(container.Runtime.ContainerID, container)
+
+	// Selectors never query the API server
+	actions := len(client.Actions())
+	selected := cc.GetContainersBySelector(&ContainerSelector{
+		K8s: K8sSelector{OwnerKind: "DaemonSet", OwnerName: "nginx"},
+	})
+	require.Equal(t, []*Container{container}, selected)
+	require.Len(t, client.Actions(), actions)
+
+	// Nor does enriching the container again
+	cc.enrichOwnerReference(container)
+	require.Len(t, client.Actions(), actions)
+}
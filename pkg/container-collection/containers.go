@@ -16,13 +16,16 @@ package containercollection
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/moby/moby/pkg/stringid"
 	ocispec "github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/sys/unix"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 
@@ -69,10 +72,94 @@ type Container struct {
 	mntNsFd int
 	netNsFd int
 
+	// ambiguousMntns is true for the containers whose mount namespace doesn't
+	// identify their processes: systemd units and plain cgroups running in
+	// the mount namespace of the host, and systemd slices as they group other
+	// units. They aren't indexed by namespace, as events from them can't be
+	// told apart from other processes this way, and are filtered by cgroup.
+	ambiguousMntns bool
+
+	// exit tells how the container terminated. It's only available when the
+	// container is removed and the runtime is able to provide it, see
+	// GetExit() and WaitExit(). It's a pointer as containers are copied by
+	// value in several places.
+	exit *exitState
+
 	// when the container was removed. Useful for running cached containers.
 	deletionTimestamp time.Time
 }
 
+type ContainerExit struct {
+	Code int32 `json:"code" column:"code,width:4,hide"`
+	// Reason is "Completed", "Error" or "OOMKilled"
+	Reason string `json:"reason,omitempty" column:"reason,width:9,hide"`
+}
+
+// HasOwnMntns tells if the mount namespace of the container identifies its
+// processes, so it can be used to filter events. It's false for some systemd
+// units, see WithSystemdUnits().
+func (c *Container) HasOwnMntns() bool {
+	return !c.ambiguousMntns
+}
+
+// exitState holds how a container terminated. It's set asynchronously once the
+// container is removed.
+type exitState struct {
+	mu   sync.Mutex
+	exit *ContainerExit
+
+	// done is closed when the exit enrichers are done
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func newExitState() *exitState {
+	return &exitState{done: make(chan struct{})}
+}
+
+// GetExit returns how the container terminated, or nil if it's unknown.
+func (c *Container) GetExit() *ContainerExit {
+	if c.exit == nil {
+		return nil
+	}
+	c.exit.mu.Lock()
+	defer c.exit.mu.Unlock()
+	return c.exit.exit
+}
+
+// WaitExit waits at most timeout for the exit enrichers to get how the removed
+// container terminated. It returns nil if it's unknown.
+func (c *Container) WaitExit(timeout time.Duration) *ContainerExit {
+	if c.exit == nil {
+		return nil
+	}
+	select {
+	case <-c.exit.done:
+	case <-time.After(timeout):
+	}
+	return c.GetExit()
+}
+
+// setExit sets how the container terminated unless it's already known. It
+// returns whether the exit was set.
+func (c *Container) setExit(exit *ContainerExit) bool {
+	if c.exit == nil {
+		return false
+	}
+	c.exit.mu.Lock()
+	defer c.exit.mu.Unlock()
+	if c.exit.exit != nil || exit == nil {
+		return false
+	}
+	c.exit.exit = exit
+	return true
+}
+
+// exitDone tells WaitExit() the exit won't be known more precisely
+func (c *Container) exitDone() {
+	c.exit.doneOnce.Do(func() { close(c.exit.done) })
+}
+
 // close releases any resources (like  file descriptors) the container is using.
 func (c *Container) close() {
 	if c.mntNsFd != 0 {
@@ -94,17 +181,58 @@ type K8sMetadata struct {
 	PodLabels              map[string]string `json:"podLabels,omitempty"`
 	PodUID                 string            `json:"podUID,omitempty"`
 
+	// ownerReference and ownerReferenceDone are protected by
+	// ownerReferenceLock: GetOwnerReference() can set them while selectors
+	// read them.
 	ownerReference *metav1.OwnerReference
+	// ownerReferenceDone is set once the owner reference was looked up, even
+	// if the pod doesn't have any, to avoid querying the API server again.
+	ownerReferenceDone bool
 }
 
 type K8sSelector struct {
+	// Namespace is a comma-separated list of namespaces. PodName supports
+	// glob patterns (e.g. "nginx-*").
 	types.BasicK8sMetadata
+
+	// PodLabels contains the labels the pod must have with these exact
+	// values.
 	PodLabels map[string]string
+
+	// PodLabelSelector is a Kubernetes label selector that also supports
+	// set-based requirements (e.g. "env in (prod,staging),!canary").
+	PodLabelSelector labels.Selector
+
+	// PodNameRegex must match the whole pod name.
+	PodNameRegex *regexp.Regexp
+
+	// OwnerKind and OwnerName select the containers by the top-level
+	// workload owning the pod (e.g. Deployment/nginx). OwnerKind is case
+	// insensitive.
+	OwnerKind string
+	OwnerName string
+
+	// ExcludeNamespace is a comma-separated list of namespaces whose
+	// containers are never selected.
+	ExcludeNamespace string
 }
 
 type RuntimeSelector struct {
-	// TODO: Support filtering by all the fields in BasicRuntimeMetadata
 	ContainerName string
+
+	// ContainerID matches the containers whose ID starts with this value.
+	ContainerID string
+
+	RuntimeName types.RuntimeName
+
+	// ContainerImageName supports glob patterns (e.g.
+	// "docker.io/library/nginx:*").
+	ContainerImageName   string
+	ContainerImageDigest string
+
+	// SystemdUnit selects the systemd unit with this name or, if it's a
+	// slice, all the units in it. See WithSystemdUnits().
+	SystemdUnit string
 }
 
 type ContainerSelector struct {
@@ -118,8 +246,8 @@ type ContainerSelector struct {
 // enrich" this information because this operation is expensive and this
 // information is only needed in some cases.
 func (c *Container) GetOwnerReference() (*metav1.OwnerReference, error) {
-	if c.K8s.ownerReference != nil {
-		return c.K8s.ownerReference, nil
+	if ownerRef, done := c.ownerReferenceIfDone(); ownerRef != nil || done {
+		return ownerRef, nil
 	}
 
 	kubeconfig, err := rest.InClusterConfig()
@@ -137,7 +265,20 @@ func (c *Container) GetOwnerReference() (*metav1.OwnerReference, error) {
 		return nil, fmt.Errorf("enriching owner reference: %w", err)
 	}
 
-	return c.K8s.ownerReference, nil
+	ownerRef, _ := c.ownerReferenceIfDone()
+	return ownerRef, nil
+}
+
+// ownerReferenceLock protects the owner references of all containers. It's
+// not part of the containers as they are copied by value.
+var ownerReferenceLock sync.RWMutex
+
+// ownerReferenceIfDone returns the owner reference of the container and
+// whether it was looked up already
+func (c *Container) ownerReferenceIfDone() (*metav1.OwnerReference, bool) {
+	ownerReferenceLock.RLock()
+	defer ownerReferenceLock.RUnlock()
+	return c.K8s.ownerReference, c.K8s.ownerReferenceDone
 }
 
 func ownerReferenceEnrichment(
@@ -186,6 +327,8 @@ func ownerReferenceEnrichment(
 	}
 
 	// Update container's owner reference (If any)
+	ownerReferenceLock.Lock()
+	defer ownerReferenceLock.Unlock()
 	if highestOwnerRef != nil {
 		container.K8s.ownerReference = &metav1.OwnerReference{
 			APIVersion: highestOwnerRef.APIVersion,
@@ -194,6 +337,7 @@ func ownerReferenceEnrichment(
 			UID:        highestOwnerRef.UID,
 		}
 	}
+	container.K8s.ownerReferenceDone = true
 
 	return nil
 }
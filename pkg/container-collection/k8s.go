@@ -38,6 +38,7 @@ type K8sClient struct {
 	clientset     *kubernetes.Clientset
 	nodeName      string
 	fieldSelector string
+	runtimeName   types.RuntimeName
 	runtimeClient runtimeclient.ContainerRuntimeClient
 }
 
@@ -61,9 +62,10 @@ func NewK8sClient(nodeName string) (*K8sClient, error) {
 	// Get a runtime client to talk to the container runtime handling pods in
 	// this node.
 	list := strings.SplitN(node.Status.NodeInfo.ContainerRuntimeVersion, "://", 2)
+	runtimeName := types.String2RuntimeName(list[0])
 	runtimeClient, err := containerutils.NewContainerRuntimeClient(
 		&containerutilsTypes.RuntimeConfig{
-			Name: types.String2RuntimeName(list[0]),
+			Name: runtimeName,
 		})
 	if err != nil {
 		return nil, err
@@ -73,6 +75,7 @@ func NewK8sClient(nodeName string) (*K8sClient, error) {
 		clientset:     clientset,
 		nodeName:      nodeName,
 		fieldSelector: fieldSelector,
+		runtimeName:   runtimeName,
 		runtimeClient: runtimeClient,
 	}, nil
 }
@@ -113,6 +116,37 @@ func (k *K8sClient) GetNonRunningContainers(pod *v1.Pod) []string {
 	return ret
 }
 
+// GetTerminatedContainersExit returns how the terminated containers of a given
+// Pod exited, indexed by container ID.
+func (k *K8sClient) GetTerminatedContainersExit(pod *v1.Pod) map[string]*ContainerExit {
+	ret := make(map[string]*ContainerExit)
+
+	containerStatuses := append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	containerStatuses = append(containerStatuses, pod.Status.ContainerStatuses...)
+	containerStatuses = append(containerStatuses, pod.Status.EphemeralContainerStatuses...)
+
+	for _, s := range containerStatuses {
+		terminated := s.State.Terminated
+		if terminated == nil {
+			continue
+		}
+		id := trimRuntimePrefix(terminated.ContainerID)
+		if id == "" {
+			id = trimRuntimePrefix(s.ContainerID)
+		}
+		if id == "" {
+			continue
+		}
+
+		ret[id] = &ContainerExit{
+			Code:   terminated.ExitCode,
+			Reason: terminated.Reason,
+		}
+	}
+
+	return ret
+}
+
 // GetRunningContainers returns a list of the containers of a given Pod that are running.
 func (k *K8sClient) GetRunningContainers(pod *v1.Pod) []Container {
 	containers := []Container{}
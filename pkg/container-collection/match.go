@@ -15,8 +15,13 @@
 package containercollection
 
 import (
+	"path"
 	"slices"
 	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/systemd"
 )
 
 // ContainerSelectorMatches tells if a container matches the criteria in a
@@ -25,7 +30,13 @@ func ContainerSelectorMatches(s *ContainerSelector, c *Container) bool {
 	if s.K8s.Namespace != "" && !slices.Contains(strings.Split(s.K8s.Namespace, ","), c.K8s.Namespace) {
 		return false
 	}
-	if s.K8s.PodName != "" && s.K8s.PodName != c.K8s.PodName {
+	if s.K8s.ExcludeNamespace != "" && slices.Contains(strings.Split(s.K8s.ExcludeNamespace, ","), c.K8s.Namespace) {
+		return false
+	}
+	if s.K8s.PodName != "" && !globMatches(s.K8s.PodName, c.K8s.PodName) {
+		return false
+	}
+	if s.K8s.PodNameRegex != nil && !s.K8s.PodNameRegex.MatchString(c.K8s.PodName) {
 		return false
 	}
 	if s.K8s.ContainerName != "" && s.K8s.ContainerName != c.K8s.ContainerName {
@@ -34,10 +45,96 @@ func ContainerSelectorMatches(s *ContainerSelector, c *Container) bool {
 	if s.Runtime.ContainerName != "" && s.Runtime.ContainerName != c.Runtime.ContainerName {
 		return false
 	}
+	if s.Runtime.ContainerID != "" && !strings.HasPrefix(c.Runtime.ContainerID, s.Runtime.ContainerID) {
+		return false
+	}
+	if s.Runtime.RuntimeName != "" && s.Runtime.RuntimeName != c.Runtime.RuntimeName {
+		return false
+	}
+	if s.Runtime.ContainerImageName != "" && !globMatches(s.Runtime.ContainerImageName, c.Runtime.ContainerImageName) {
+		return false
+	}
+	if s.Runtime.ContainerImageDigest != "" && s.Runtime.ContainerImageDigest != c.Runtime.ContainerImageDigest {
+		return false
+	}
+	if s.Runtime.SystemdUnit != "" && !systemdUnitMatches(s.Runtime.SystemdUnit, c) {
+		return false
+	}
 	for sk, sv := range s.K8s.PodLabels {
 		if cv, ok := c.K8s.PodLabels[sk]; !ok || cv != sv {
 			return false
 		}
 	}
+	if s.K8s.PodLabelSelector != nil && !s.K8s.PodLabelSelector.Matches(labels.Set(c.K8s.PodLabels)) {
+		return false
+	}
+	if (s.K8s.OwnerKind != "" || s.K8s.OwnerName != "") && !ownerMatches(&s.K8s, c) {
+		return false
+	}
+	return true
+}
+
+// globMatches tells if name matches the shell pattern. Invalid patterns are
+// compared literally.
+func globMatches(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	if err != nil {
+		return pattern == name
+	}
+	return matched
+}
+
+// systemdUnitMatches tells if the container is the systemd unit or belongs to
+// the slice with the given name
+func systemdUnitMatches(unit string, c *Container) bool {
+	if !isSystemdRuntime(c.Runtime.RuntimeName) {
+		return false
+	}
+	if !strings.HasSuffix(unit, systemd.SliceSuffix) {
+		return unit == c.Runtime.ContainerName
+	}
+	return slices.Contains(strings.Split(c.CgroupV2, "/"), unit)
+}
+
+// ownerMatches tells if the top-level owner of the pod of the container is
+// the one in the selector. The owner is never fetched here, as the selectors
+// are evaluated while holding locks: it's looked up when the container is
+// added, see WithOwnerReferenceEnrichment.
+func ownerMatches(s *K8sSelector, c *Container) bool {
+	if c.K8s.PodName == "" {
+		return false
+	}
+
+	ownerRef, done := c.ownerReferenceIfDone()
+	if !done || ownerRef == nil {
+		return false
+	}
+	if s.OwnerKind != "" && !strings.EqualFold(s.OwnerKind, ownerRef.Kind) {
+		return false
+	}
+	if s.OwnerName != "" && s.OwnerName != ownerRef.Name {
+		return false
+	}
 	return true
 }
+
+// ParseOwnerSelector splits an owner given by the user as "[kind/]name" into
+// the kind and name to use in a K8sSelector.
+func ParseOwnerSelector(owner string) (kind, name string) {
+	kind, name, found := strings.Cut(owner, "/")
+	if !found {
+		return "", owner
+	}
+	return kind, name
+}
+
+// ParseImageSelector splits an image reference given by the user into the
+// image name and digest to use in a RuntimeSelector. It accepts a name
+// ("nginx:*"), a digest ("sha256:...") or both ("nginx@sha256:...").
+func ParseImageSelector(image string) (name, digest string) {
+	if strings.HasPrefix(image, "sha256:") {
+		return "", image
+	}
+	name, digest, _ = strings.Cut(image, "@")
+	return name, digest
+}
@@ -17,9 +17,11 @@ package containercollection
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	k8sTypes "k8s.io/apimachinery/pkg/types"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
@@ -170,6 +172,274 @@ func TestSelector(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "Excluded namespace",
+			match:       false,
+			selector: &ContainerSelector{
+				K8s: K8sSelector{
+					ExcludeNamespace: "kube-system,ns2",
+				},
+			},
+			container: &Container{
+				K8s: K8sMetadata{
+					BasicK8sMetadata: types.BasicK8sMetadata{
+						Namespace: "ns2",
+						PodName:   "this-pod",
+					},
+				},
+			},
+		},
+		{
+			description: "Not excluded namespace",
+			match:       true,
+			selector: &ContainerSelector{
+				K8s: K8sSelector{
+					ExcludeNamespace: "kube-system",
+				},
+			},
+			container: &Container{
+				K8s: K8sMetadata{
+					BasicK8sMetadata: types.BasicK8sMetadata{
+						Namespace: "ns2",
+						PodName:   "this-pod",
+					},
+				},
+			},
+		},
+		{
+			description: "Podname glob",
+			match:       true,
+			selector: &ContainerSelector{
+				K8s: K8sSelector{
+					BasicK8sMetadata: types.BasicK8sMetadata{
+						PodName: "nginx-*",
+					},
+				},
+			},
+			container: &Container{
+				K8s: K8sMetadata{
+					BasicK8sMetadata: types.BasicK8sMetadata{
+						PodName: "nginx-7c5ddbdf54-x2bdn",
+					},
+				},
+			},
+		},
+		{
+			description: "Podname regex does not match",
+			match:       false,
+			selector: &ContainerSelector{
+				K8s: K8sSelector{
+					PodNameRegex: regexp.MustCompile("^nginx-[0-9]+$"),
+				},
+			},
+			container: &Container{
+				K8s: K8sMetadata{
+					BasicK8sMetadata: types.BasicK8sMetadata{
+						PodName: "nginx-7c5ddbdf54-x2bdn",
+					},
+				},
+			},
+		},
+		{
+			description: "Set-based label selector",
+			match:       true,
+			selector: &ContainerSelector{
+				K8s: K8sSelector{
+					PodLabelSelector: mustParseLabelSelector(t, "env in (prod,staging),tier,!canary"),
+				},
+			},
+			container: &Container{
+				K8s: K8sMetadata{
+					PodLabels: map[string]string{
+						"env":  "prod",
+						"tier": "frontend",
+					},
+				},
+			},
+		},
+		{
+			description: "Set-based label selector with notin",
+			match:       false,
+			selector: &ContainerSelector{
+				K8s: K8sSelector{
+					PodLabelSelector: mustParseLabelSelector(t, "env notin (prod)"),
+				},
+			},
+			container: &Container{
+				K8s: K8sMetadata{
+					PodLabels: map[string]string{
+						"env": "prod",
+					},
+				},
+			},
+		},
+		{
+			description: "Image and container ID prefix",
+			match:       true,
+			selector: &ContainerSelector{
+				Runtime: RuntimeSelector{
+					ContainerID:        "3f2b",
+					RuntimeName:        types.RuntimeNameContainerd,
+					ContainerImageName: "docker.io/library/nginx:*",
+				},
+			},
+			container: &Container{
+				Runtime: RuntimeMetadata{
+					BasicRuntimeMetadata: types.BasicRuntimeMetadata{
+						ContainerID:        "3f2b8a0c9d1e",
+						RuntimeName:        types.RuntimeNameContainerd,
+						ContainerImageName: "docker.io/library/nginx:1.25",
+					},
+				},
+			},
+		},
+		{
+			description: "Image digest does not match",
+			match:       false,
+			selector: &ContainerSelector{
+				Runtime: RuntimeSelector{
+					ContainerImageDigest: "sha256:1234",
+				},
+			},
+			container: &Container{
+				Runtime: RuntimeMetadata{
+					BasicRuntimeMetadata: types.BasicRuntimeMetadata{
+						ContainerImageDigest: "sha256:5678",
+					},
+				},
+			},
+		},
+		{
+			description: "Owner",
+			match:       true,
+			selector: &ContainerSelector{
+				K8s: K8sSelector{
+					OwnerKind: "deployment",
+					OwnerName: "nginx",
+				},
+			},
+			container: &Container{
+				K8s: K8sMetadata{
+					BasicK8sMetadata: types.BasicK8sMetadata{
+						PodName: "nginx-7c5ddbdf54-x2bdn",
+					},
+					ownerReference:     &metav1.OwnerReference{Kind: "Deployment", Name: "nginx"},
+					ownerReferenceDone: true,
+				},
+			},
+		},
+		{
+			description: "Owner does not match",
+			match:       false,
+			selector: &ContainerSelector{
+				K8s: K8sSelector{
+					OwnerName: "nginx",
+				},
+			},
+			container: &Container{
+				K8s: K8sMetadata{
+					BasicK8sMetadata: types.BasicK8sMetadata{
+						PodName: "standalone",
+					},
+					ownerReferenceDone: true,
+				},
+			},
+		},
+		{
+			description: "Owner not looked up",
+			match:       false,
+			selector: &ContainerSelector{
+				K8s: K8sSelector{
+					OwnerName: "nginx",
+				},
+			},
+			container: &Container{
+				K8s: K8sMetadata{
+					BasicK8sMetadata: types.BasicK8sMetadata{
+						PodName: "nginx-7c5ddbdf54-x2bdn",
+					},
+				},
+			},
+		},
+		{
+			description: "Runtime name",
+			match:       true,
+			selector: &ContainerSelector{
+				Runtime: RuntimeSelector{
+					RuntimeName: types.RuntimeNameContainerd,
+				},
+			},
+			container: &Container{
+				Runtime: RuntimeMetadata{
+					BasicRuntimeMetadata: types.BasicRuntimeMetadata{
+						RuntimeName: types.RuntimeNameContainerd,
+					},
+				},
+			},
+		},
+		{
+			description: "Runtime name does not match",
+			match:       false,
+			selector: &ContainerSelector{
+				Runtime: RuntimeSelector{
+					RuntimeName: types.RuntimeNameCrio,
+				},
+			},
+			container: &Container{
+				Runtime: RuntimeMetadata{
+					BasicRuntimeMetadata: types.BasicRuntimeMetadata{
+						RuntimeName: types.RuntimeNameContainerd,
+					},
+				},
+			},
+		},
+		{
+			description: "Systemd unit matches",
+			match:       true,
+			selector: &ContainerSelector{
+				Runtime: RuntimeSelector{
+					SystemdUnit: "nginx.service",
+				},
+			},
+			container: systemdContainer("nginx.service", "/system.slice/nginx.service"),
+		},
+		{
+			description: "Systemd slice matches",
+			match:       true,
+			selector: &ContainerSelector{
+				Runtime: RuntimeSelector{
+					SystemdUnit: "system.slice",
+				},
+			},
+			container: systemdContainer("nginx.service", "/system.slice/nginx.service"),
+		},
+		{
+			description: "Systemd slice does not match",
+			match:       false,
+			selector: &ContainerSelector{
+				Runtime: RuntimeSelector{
+					SystemdUnit: "user.slice",
+				},
+			},
+			container: systemdContainer("nginx.service", "/system.slice/nginx.service"),
+		},
+		{
+			description: "Systemd unit does not match other runtimes",
+			match:       false,
+			selector: &ContainerSelector{
+				Runtime: RuntimeSelector{
+					SystemdUnit: "nginx.service",
+				},
+			},
+			container: &Container{
+				Runtime: RuntimeMetadata{
+					BasicRuntimeMetadata: types.BasicRuntimeMetadata{
+						RuntimeName:   types.RuntimeNameDocker,
+						ContainerName: "nginx.service",
+					},
+				},
+			},
+		},
 	}
 
 	for i, entry := range table {
@@ -181,6 +451,27 @@ func TestSelector(t *testing.T) {
 	}
 }
 
+func systemdContainer(name, cgroupV2 string) *Container {
+	return &Container{
+		Runtime: RuntimeMetadata{
+			BasicRuntimeMetadata: types.BasicRuntimeMetadata{
+				RuntimeName:   types.RuntimeNameSystemd,
+				ContainerID:   cgroupV2,
+				ContainerName: name,
+			},
+		},
+		CgroupV2: cgroupV2,
+	}
+}
+
+func mustParseLabelSelector(t *testing.T, selector string) labels.Selector {
+	s, err := labels.Parse(selector)
+	if err != nil {
+		t.Fatalf("parsing label selector %q: %s", selector, err)
+	}
+	return s
+}
+
 func TestContainerResolver(t *testing.T) {
 	opts := []ContainerCollectionOption{}
 
@@ -567,3 +858,45 @@ func TestContainerResolver(t *testing.T) {
 		t.Fatalf("Error while looking up containers in a non-existent namespace")
 	}
 }
+
+func TestParseImageSelector(t *testing.T) {
+	table := []struct {
+		image  string
+		name   string
+		digest string
+	}{
+		{"", "", ""},
+		{"nginx:*", "nginx:*", ""},
+		{"sha256:1234", "", "sha256:1234"},
+		{"docker.io/library/nginx@sha256:1234", "docker.io/library/nginx", "sha256:1234"},
+	}
+
+	for _, entry := range table {
+		name, digest := ParseImageSelector(entry.image)
+		if name != entry.name || digest != entry.digest {
+			t.Fatalf("ParseImageSelector(%q) = (%q, %q), expected (%q, %q)",
+				entry.image, name, digest, entry.name, entry.digest)
+		}
+	}
+}
+
+func TestParseOwnerSelector(t *testing.T) {
+	table := []struct {
+		owner string
+		kind  string
+		name  string
+	}{
+		{"", "", ""},
+		{"nginx", "", "nginx"},
+		{"deployment/nginx", "deployment", "nginx"},
+		{"daemonset/", "daemonset", ""},
+	}
+
+	for _, entry := range table {
+		kind, name := ParseOwnerSelector(entry.owner)
+		if kind != entry.kind || name != entry.name {
+			t.Fatalf("ParseOwnerSelector(%q) = (%q, %q), expected (%q, %q)",
+				entry.owner, kind, name, entry.kind, entry.name)
+		}
+	}
+}
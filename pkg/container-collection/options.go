@@ -66,6 +66,11 @@ func containerRuntimeEnricher(
 	runtimeClient runtimeclient.ContainerRuntimeClient,
 	container *Container,
 ) bool {
+	// systemd units and plain cgroups aren't managed by container runtimes
+	if isSystemdRuntime(container.Runtime.RuntimeName) {
+		return true
+	}
+
 	// If the container is already enriched with the metadata a runtime client
 	// is able to provide, skip it.
 	if runtimeclient.IsEnrichedWithK8sMetadata(container.K8s.BasicK8sMetadata) &&
@@ -100,6 +105,41 @@ func WithDisableContainerRuntimeWarnings() ContainerCollectionOption {
 	}
 }
 
+const (
+	exitEnricherRetries = 5
+	exitEnricherDelay   = 20 * time.Millisecond
+)
+
+// containerExitEnricher gets how the container terminated from the runtime.
+// The container could be removed before the runtime collects its exit status,
+// so it retries a few times.
+func containerExitEnricher(
+	runtimeName types.RuntimeName,
+	exitGetter runtimeclient.ContainerExitGetter,
+	container *Container,
+) *ContainerExit {
+	if container.Runtime.RuntimeName != runtimeName || container.Runtime.ContainerID == "" {
+		return nil
+	}
+
+	for i := 0; i < exitEnricherRetries; i++ {
+		exit, err := exitGetter.GetContainerExit(container.Runtime.ContainerID)
+		if err == nil {
+			return &ContainerExit{
+				Code:   exit.ExitCode,
+				Reason: exit.Reason,
+			}
+		}
+		if !errors.Is(err, runtimeclient.ErrContainerNotExited) {
+			log.Debugf("Runtime enricher (%s): couldn't get exit status of container %q: %s",
+				runtimeName, container.Runtime.ContainerID, err)
+			return nil
+		}
+		time.Sleep(exitEnricherDelay)
+	}
+	return nil
+}
+
 // WithMultipleContainerRuntimesEnrichment is a wrapper for
 // WithContainerRuntimeEnrichment() to allow caller to add multiple runtimes in
 // one single call.
@@ -160,6 +200,12 @@ func WithContainerRuntimeEnrichment(runtime *containerutilsTypes.RuntimeConfig)
 			})
 		}
 
+		if exitGetter, ok := runtimeClient.(runtimeclient.ContainerExitGetter); ok {
+			cc.exitEnrichers = append(cc.exitEnrichers, func(container *Container) *ContainerExit {
+				return containerExitEnricher(runtime.Name, exitGetter, container)
+			})
+		}
+
 		cc.cleanUpFuncs = append(cc.cleanUpFuncs, func() {
 			if err := runtimeClient.Close(); err != nil {
 				log.Warnf("failed to close container runtime %s: %s", runtime.Name, err)
@@ -274,11 +320,15 @@ func withPodInformer(nodeName string, fallbackMode bool) ContainerCollectionOpti
 
 					// first: remove containers that are not running anymore
 					nonrunning := k8sClient.GetNonRunningContainers(pod)
+					exits := k8sClient.GetTerminatedContainersExit(pod)
 					for _, id := range nonrunning {
 						// container had not been added, no need to remove it
 						if _, ok := containerIDs[id]; !ok {
 							continue
 						}
+						if c := cc.GetContainer(id); c != nil {
+							c.setExit(exits[id])
+						}
 						cc.RemoveContainer(id)
 					}
 
@@ -356,6 +406,33 @@ func WithInitialKubernetesContainers(nodeName string) ContainerCollectionOption
 	}
 }
 
+// WithKubernetesContainerExitEnrichment adds how the containers terminated
+// using the container runtime handling the pods in the node.
+func WithKubernetesContainerExitEnrichment(nodeName string) ContainerCollectionOption {
+	return func(cc *ContainerCollection) error {
+		k8sClient, err := NewK8sClient(nodeName)
+		if err != nil {
+			// The exit status is only informative: don't fail
+			log.Warnf("container exit enricher: creating Kubernetes client: %s", err)
+			return nil
+		}
+
+		exitGetter, ok := k8sClient.runtimeClient.(runtimeclient.ContainerExitGetter)
+		if !ok {
+			k8sClient.Close()
+			log.Debugf("container runtime %s doesn't provide the exit status of containers", k8sClient.runtimeName)
+			return nil
+		}
+
+		cc.exitEnrichers = append(cc.exitEnrichers, func(container *Container) *ContainerExit {
+			return containerExitEnricher(k8sClient.runtimeName, exitGetter, container)
+		})
+		cc.cleanUpFuncs = append(cc.cleanUpFuncs, k8sClient.Close)
+
+		return nil
+	}
+}
+
 // WithPubSub enables subscription with container events with Subscribe().
 // Optionally, a list of callbacks can be registered from the beginning, so
 // they would get called for initial containers too.
@@ -551,6 +628,35 @@ func WithKubernetesEnrichment(nodeName string, kubeconfig *rest.Config) Containe
 	}
 }
 
+// WithOwnerReferenceEnrichment looks up the top-level owner of the pod of
+// each container when it's added, so selecting containers by owner doesn't
+// need to query the API server. It has to be used after the options that set
+// the pod name and namespace of the containers.
+//
+// ContainerCollection.Initialize(WithOwnerReferenceEnrichment(nil))
+func WithOwnerReferenceEnrichment(kubeconfig *rest.Config) ContainerCollectionOption {
+	return func(cc *ContainerCollection) error {
+		if kubeconfig == nil {
+			var err error
+			kubeconfig, err = rest.InClusterConfig()
+			if err != nil {
+				return fmt.Errorf("getting Kubernetes config: %w", err)
+			}
+		}
+		dynamicClient, err := dynamic.NewForConfig(kubeconfig)
+		if err != nil {
+			return fmt.Errorf("getting dynamic Kubernetes client: %w", err)
+		}
+		cc.ownerClient = dynamicClient
+
+		cc.containerEnrichers = append(cc.containerEnrichers, func(container *Container) bool {
+			cc.enrichOwnerReference(container)
+			return true
+		})
+		return nil
+	}
+}
+
 // WithRuncFanotify uses fanotify to detect when containers are created and add
 // them in the ContainerCollection.
 //
@@ -656,6 +762,12 @@ func WithCgroupEnrichment() ContainerCollectionOption {
 				return true
 			}
 
+			// The cgroup of systemd units is known upfront and the one of
+			// their main process could be a child of it
+			if container.CgroupID != 0 && container.CgroupV2 != "" {
+				return true
+			}
+
 			cgroupPathV1, cgroupPathV2, err := cgroups.GetCgroupPaths(pid)
 			if err != nil {
 				log.Errorf("cgroup enricher: failed to get cgroup paths on container %s: %s", container.Runtime.ContainerID, err)
@@ -15,9 +15,13 @@
 package containercollection
 
 import (
+	"errors"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
 func TestGetExpectedOwnerReference(t *testing.T) {
@@ -268,3 +272,72 @@ func TestGetExpectedOwnerReference(t *testing.T) {
 		}
 	}
 }
+
+// fakeExitGetter reports the container as running for the first notExitedCalls
+// calls
+type fakeExitGetter struct {
+	notExitedCalls int
+	err            error
+	calls          int
+}
+
+func (f *fakeExitGetter) GetContainerExit(containerID string) (*runtimeclient.ContainerExitData, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.calls <= f.notExitedCalls {
+		return nil, runtimeclient.ErrContainerNotExited
+	}
+	return &runtimeclient.ContainerExitData{ExitCode: 137, Reason: runtimeclient.ExitReasonOOMKilled}, nil
+}
+
+func TestContainerExitEnricher(t *testing.T) {
+	table := []struct {
+		description  string
+		runtimeName  types.RuntimeName
+		getter       *fakeExitGetter
+		expectedExit *ContainerExit
+	}{
+		{
+			description:  "Exited",
+			runtimeName:  types.RuntimeNameDocker,
+			getter:       &fakeExitGetter{},
+			expectedExit: &ContainerExit{Code: 137, Reason: "OOMKilled"},
+		},
+		{
+			description:  "Exited after retries",
+			runtimeName:  types.RuntimeNameDocker,
+			getter:       &fakeExitGetter{notExitedCalls: 2},
+			expectedExit: &ContainerExit{Code: 137, Reason: "OOMKilled"},
+		},
+		{
+			description: "Never exited",
+			runtimeName: types.RuntimeNameDocker,
+			getter:      &fakeExitGetter{notExitedCalls: exitEnricherRetries},
+		},
+		{
+			description: "Error",
+			runtimeName: types.RuntimeNameDocker,
+			getter:      &fakeExitGetter{err: errors.New("not found")},
+		},
+		{
+			description: "Other runtime",
+			runtimeName: types.RuntimeNameContainerd,
+			getter:      &fakeExitGetter{},
+		},
+	}
+
+	for i, entry := range table {
+		container := &Container{}
+		container.Runtime.RuntimeName = types.RuntimeNameDocker
+		container.Runtime.ContainerID = "abcde"
+
+		exit := containerExitEnricher(entry.runtimeName, entry.getter, container)
+		if (entry.expectedExit == nil && exit != nil) ||
+			(entry.expectedExit != nil && (exit == nil || *exit != *entry.expectedExit)) {
+			t.Fatalf("Failed test %q (index %d): exit %+v expected %+v",
+				entry.description, i, exit, entry.expectedExit)
+		}
+	}
+}
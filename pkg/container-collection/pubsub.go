@@ -63,6 +63,11 @@ type PubSubEvent struct {
 	Timestamp string     `json:"timestamp,omitempty" column:"timestamp,maxWidth:30" columnTags:"runtime"`
 	Type      EventType  `json:"event" column:"event,maxWidth:10" columnTags:"runtime"`
 	Container *Container `json:"container"`
+
+	// Exit tells how the container terminated in EventTypeRemoveContainer
+	// events. The event is published without waiting for it, subscribers
+	// interested in it fill it with Container.WaitExit().
+	Exit *ContainerExit `json:"exit,omitempty" column:"exit"`
 }
 
 // GadgetPubSub provides a synchronous publish subscribe mechanism for gadgets
@@ -0,0 +1,125 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containercollection
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/systemd"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func containerFromUnit(unit *systemd.Unit) *Container {
+	runtimeName := types.RuntimeNameSystemd
+	if unit.Type == systemd.UnitTypeCgroup {
+		runtimeName = types.RuntimeNameCgroup
+	}
+
+	return &Container{
+		Runtime: RuntimeMetadata{
+			BasicRuntimeMetadata: types.BasicRuntimeMetadata{
+				RuntimeName:   runtimeName,
+				ContainerID:   unit.CgroupPath,
+				ContainerName: unit.Name,
+			},
+		},
+		Pid:        unit.MainPID,
+		CgroupPath: unit.CgroupPathWithMountpoint,
+		CgroupID:   unit.CgroupID,
+		CgroupV2:   unit.CgroupPath,
+
+		// Slices group other units, their first process belongs to one of them
+		ambiguousMntns: unit.Type == systemd.UnitTypeSlice,
+	}
+}
+
+func isSystemdRuntime(name types.RuntimeName) bool {
+	return name == types.RuntimeNameSystemd || name == types.RuntimeNameCgroup
+}
+
+// WithSystemdUnits adds the systemd services and slices of the host as
+// containers and watches their creation and removal from the cgroup v2
+// hierarchy. The containers use the "systemd" runtime name, the unit name as
+// container name and the path of their cgroup as container ID. The plain
+// cgroups created directly below the root or a slice, outside of systemd, are
+// added as well with the "cgroup" runtime name and their path as container
+// name.
+//
+// Gadgets filter and enrich events by mount namespace. Units sharing the mount
+// namespace of the host can't be told apart from other host processes this
+// way, and slices group other units. They are listed and can be selected, but
+// they aren't used to enrich events, see Container.HasOwnMntns(). The tracer
+// collection filters the events of the units by cgroup instead.
+//
+// It must be used after WithLinuxNamespaceEnrichment() if it's used.
+func WithSystemdUnits() ContainerCollectionOption {
+	return func(cc *ContainerCollection) error {
+		discoverer, err := systemd.NewDiscoverer()
+		if err != nil {
+			return fmt.Errorf("creating systemd unit discoverer: %w", err)
+		}
+
+		hostMntns, err := containerutils.GetMntNs(1)
+		if err != nil {
+			discoverer.Close()
+			return fmt.Errorf("getting host mnt ns inode: %w", err)
+		}
+
+		cc.containerEnrichers = append(cc.containerEnrichers, func(container *Container) bool {
+			if isSystemdRuntime(container.Runtime.RuntimeName) && container.Mntns == hostMntns {
+				container.ambiguousMntns = true
+			}
+			return true
+		})
+
+		cc.systemdUnits = true
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cc.cleanUpFuncs = append(cc.cleanUpFuncs, func() {
+			cancel()
+			discoverer.Close()
+		})
+
+		// Watch before listing to don't miss units started meanwhile.
+		// AddContainer() ignores the ones added twice.
+		err = discoverer.Watch(ctx, func(event systemd.Event) {
+			switch event.Type {
+			case systemd.EventTypeAddUnit:
+				cc.AddContainer(containerFromUnit(event.Unit))
+			case systemd.EventTypeRemoveUnit:
+				cc.RemoveContainer(event.Unit.CgroupPath)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("watching systemd units: %w", err)
+		}
+
+		units, err := discoverer.ListUnits()
+		if err != nil {
+			return fmt.Errorf("listing systemd units: %w", err)
+		}
+		log.Debugf("systemd: found %d units", len(units))
+
+		for _, unit := range units {
+			cc.initialContainers = append(cc.initialContainers, containerFromUnit(unit))
+		}
+
+		return nil
+	}
+}
@@ -88,7 +88,7 @@ func readPrivateDataFromFd(fd int) (uint64, error) {
 
 	opts := ebpf.CollectionOptions{
 		Programs: ebpf.ProgramOptions{
-			KernelTypes: btfgen.GetBTFSpec(),
+			KernelTypes: btfgen.GetBTFSpec(spec),
 		},
 	}
 	if err := spec.LoadAndAssign(&objs, &opts); err != nil {
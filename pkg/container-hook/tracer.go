@@ -213,7 +213,7 @@ func (n *ContainerNotifier) installEbpf(fanotifyFd int) error {
 
 	opts := ebpf.CollectionOptions{
 		Programs: ebpf.ProgramOptions{
-			KernelTypes: btfgen.GetBTFSpec(),
+			KernelTypes: btfgen.GetBTFSpec(spec),
 		},
 	}
 
@@ -166,6 +166,44 @@ func (c *ContainerdClient) GetContainerDetails(containerID string) (*runtimeclie
 	}, nil
 }
 
+func (c *ContainerdClient) GetContainerExit(containerID string) (*runtimeclient.ContainerExitData, error) {
+	containerID, err := runtimeclient.ParseContainerID(types.RuntimeNameContainerd, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := c.getContainer(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := container.Task(c.ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting task for container %q: %w", containerID, err)
+	}
+
+	status, err := task.Status(c.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting status of task for container %q: %w", containerID, err)
+	}
+	return containerExit(status)
+}
+
+// containerExit returns how the container terminated from the status of its
+// task.
+func containerExit(status containerd.Status) (*runtimeclient.ContainerExitData, error) {
+	if status.Status != containerd.Stopped {
+		return nil, runtimeclient.ErrContainerNotExited
+	}
+
+	// containerd doesn't know if the container was killed by the OOM killer
+	exitCode := int32(status.ExitStatus)
+	return &runtimeclient.ContainerExitData{
+		ExitCode: exitCode,
+		Reason:   runtimeclient.ExitReason(exitCode, false),
+	}, nil
+}
+
 func (c *ContainerdClient) getContainerDataAndContainerAndTask(containerID string) (*runtimeclient.ContainerData, containerd.Container, *containerTask, error) {
 	container, err := c.getContainer(containerID)
 	if err != nil {
@@ -3,9 +3,11 @@ package containerd
 import (
 	"testing"
 
+	"github.com/containerd/containerd"
 	"github.com/stretchr/testify/require"
 
 	"github.com/inspektor-gadget/inspektor-gadget/internal/test"
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/testutils"
 	containerutilsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/types"
 )
@@ -70,3 +72,44 @@ func TestNamespace(t *testing.T) {
 	require.NotNil(t, containers)
 	require.Len(t, containers, 0)
 }
+
+func TestContainerExit(t *testing.T) {
+	table := []struct {
+		description string
+		status      containerd.Status
+		expected    *runtimeclient.ContainerExitData
+		expectedErr error
+	}{
+		{
+			description: "Completed",
+			status:      containerd.Status{Status: containerd.Stopped},
+			expected:    &runtimeclient.ContainerExitData{ExitCode: 0, Reason: runtimeclient.ExitReasonCompleted},
+		},
+		{
+			// containerd doesn't report OOM kills
+			description: "Killed",
+			status:      containerd.Status{Status: containerd.Stopped, ExitStatus: 137},
+			expected:    &runtimeclient.ContainerExitData{ExitCode: 137, Reason: runtimeclient.ExitReasonError},
+		},
+		{
+			description: "Running",
+			status:      containerd.Status{Status: containerd.Running},
+			expectedErr: runtimeclient.ErrContainerNotExited,
+		},
+		{
+			description: "Paused",
+			status:      containerd.Status{Status: containerd.Paused},
+			expectedErr: runtimeclient.ErrContainerNotExited,
+		},
+	}
+
+	for _, entry := range table {
+		exit, err := containerExit(entry.status)
+		if entry.expectedErr != nil {
+			require.ErrorIs(t, err, entry.expectedErr, entry.description)
+			continue
+		}
+		require.NoError(t, err, entry.description)
+		require.Equal(t, entry.expected, exit, entry.description)
+	}
+}
@@ -31,6 +31,8 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/containerd"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/crio"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/docker"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/lxd"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/machined"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/podman"
 	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
 	containerutilsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/types"
@@ -39,13 +41,21 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
 )
 
-var AvailableRuntimes = []string{
+// DefaultRuntimes are the runtimes used when none is specified. LXD and
+// systemd-machined aren't part of it: probing them means connecting to the LXD
+// socket and possibly D-Bus activating systemd-machined on every host.
+var DefaultRuntimes = []string{
 	types.RuntimeNameDocker.String(),
 	types.RuntimeNameContainerd.String(),
 	types.RuntimeNameCrio.String(),
 	types.RuntimeNamePodman.String(),
 }
 
+var AvailableRuntimes = append(DefaultRuntimes[:len(DefaultRuntimes):len(DefaultRuntimes)],
+	types.RuntimeNameLXD.String(),
+	types.RuntimeNameMachined.String(),
+)
+
 func NewContainerRuntimeClient(runtime *containerutilsTypes.RuntimeConfig) (runtimeclient.ContainerRuntimeClient, error) {
 	switch runtime.Name {
 	case types.RuntimeNameDocker:
@@ -72,6 +82,18 @@ func NewContainerRuntimeClient(runtime *containerutilsTypes.RuntimeConfig) (runt
 			socketPath = filepath.Join(host.HostRoot, envsp)
 		}
 		return podman.NewPodmanClient(socketPath), nil
+	case types.RuntimeNameLXD:
+		socketPath := runtime.SocketPath
+		if envsp := os.Getenv("INSPEKTOR_GADGET_LXD_SOCKETPATH"); envsp != "" && socketPath == "" {
+			socketPath = filepath.Join(host.HostRoot, envsp)
+		}
+		return lxd.NewLXDClient(socketPath), nil
+	case types.RuntimeNameMachined:
+		socketPath := runtime.SocketPath
+		if envsp := os.Getenv("INSPEKTOR_GADGET_MACHINED_SOCKETPATH"); envsp != "" && socketPath == "" {
+			socketPath = filepath.Join(host.HostRoot, envsp)
+		}
+		return machined.NewMachinedClient(socketPath), nil
 	default:
 		return nil, fmt.Errorf("unknown container runtime: %s (available %s)",
 			runtime.Name, strings.Join(AvailableRuntimes, ", "))
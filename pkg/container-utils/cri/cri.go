@@ -135,6 +135,40 @@ func (c *CRIClient) GetContainerDetails(containerID string) (*runtimeclient.Cont
 	return parseContainerDetailsData(c.Name, res.Status, res.Info)
 }
 
+func (c *CRIClient) GetContainerExit(containerID string) (*runtimeclient.ContainerExitData, error) {
+	containerID, err := runtimeclient.ParseContainerID(c.Name, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &runtime.ContainerStatusRequest{
+		ContainerId: containerID,
+	}
+
+	res, err := c.client.ContainerStatus(context.Background(), request)
+	if err != nil {
+		return nil, err
+	}
+
+	return containerExit(res.Status)
+}
+
+// containerExit returns how the container terminated from its CRI status.
+func containerExit(status *runtime.ContainerStatus) (*runtimeclient.ContainerExitData, error) {
+	if status.GetState() != runtime.ContainerState_CONTAINER_EXITED {
+		return nil, runtimeclient.ErrContainerNotExited
+	}
+
+	exit := &runtimeclient.ContainerExitData{
+		ExitCode: status.GetExitCode(),
+		Reason:   status.GetReason(),
+	}
+	if exit.Reason == "" {
+		exit.Reason = runtimeclient.ExitReason(exit.ExitCode, false)
+	}
+	return exit, nil
+}
+
 func (c *CRIClient) Close() error {
 	if c.conn != nil {
 		return c.conn.Close()
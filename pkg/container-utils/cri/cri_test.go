@@ -15,10 +15,13 @@
 package cri
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+
 	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
 )
 
@@ -202,3 +205,52 @@ func TestParseExtraInfo(t *testing.T) {
 		}
 	}
 }
+
+func TestContainerExit(t *testing.T) {
+	table := []struct {
+		description string
+		status      *runtime.ContainerStatus
+		expected    *runtimeclient.ContainerExitData
+		expectedErr error
+	}{
+		{
+			description: "Completed without reason",
+			status:      &runtime.ContainerStatus{State: runtime.ContainerState_CONTAINER_EXITED},
+			expected:    &runtimeclient.ContainerExitData{ExitCode: 0, Reason: runtimeclient.ExitReasonCompleted},
+		},
+		{
+			description: "Error without reason",
+			status:      &runtime.ContainerStatus{State: runtime.ContainerState_CONTAINER_EXITED, ExitCode: 1},
+			expected:    &runtimeclient.ContainerExitData{ExitCode: 1, Reason: runtimeclient.ExitReasonError},
+		},
+		{
+			description: "Reason from the runtime",
+			status: &runtime.ContainerStatus{
+				State:    runtime.ContainerState_CONTAINER_EXITED,
+				ExitCode: 137,
+				Reason:   "OOMKilled",
+			},
+			expected: &runtimeclient.ContainerExitData{ExitCode: 137, Reason: runtimeclient.ExitReasonOOMKilled},
+		},
+		{
+			description: "Running",
+			status:      &runtime.ContainerStatus{State: runtime.ContainerState_CONTAINER_RUNNING},
+			expectedErr: runtimeclient.ErrContainerNotExited,
+		},
+		{
+			description: "Nil status",
+			expectedErr: runtimeclient.ErrContainerNotExited,
+		},
+	}
+
+	for _, entry := range table {
+		exit, err := containerExit(entry.status)
+		if !errors.Is(err, entry.expectedErr) {
+			t.Fatalf("%q: unexpected error %v, expected %v", entry.description, err, entry.expectedErr)
+		}
+		if !reflect.DeepEqual(exit, entry.expected) {
+			t.Fatalf("%q: exit doesn't match:\n%s", entry.description,
+				cmp.Diff(entry.expected, exit))
+		}
+	}
+}
@@ -206,6 +206,36 @@ func (c *DockerClient) GetContainerDetails(containerID string) (*runtimeclient.C
 	return &containerDetailsData, nil
 }
 
+func (c *DockerClient) GetContainerExit(containerID string) (*runtimeclient.ContainerExitData, error) {
+	containerID, err := runtimeclient.ParseContainerID(types.RuntimeNameDocker, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	containerJSON, err := c.client.ContainerInspect(context.Background(), containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return containerExit(containerJSON.State)
+}
+
+// containerExit returns how the container terminated from its inspected state.
+func containerExit(state *dockertypes.ContainerState) (*runtimeclient.ContainerExitData, error) {
+	if state == nil {
+		return nil, errors.New("container state is nil")
+	}
+	if state.Status != "exited" && state.Status != "dead" {
+		return nil, runtimeclient.ErrContainerNotExited
+	}
+
+	exitCode := int32(state.ExitCode)
+	return &runtimeclient.ContainerExitData{
+		ExitCode: exitCode,
+		Reason:   runtimeclient.ExitReason(exitCode, state.OOMKilled),
+	}, nil
+}
+
 func (c *DockerClient) Close() error {
 	if c.client != nil {
 		return c.client.Close()
@@ -0,0 +1,72 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"testing"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/require"
+
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+)
+
+func TestContainerExit(t *testing.T) {
+	table := []struct {
+		description string
+		state       *dockertypes.ContainerState
+		expected    *runtimeclient.ContainerExitData
+		expectedErr error
+	}{
+		{
+			description: "Completed",
+			state:       &dockertypes.ContainerState{Status: "exited"},
+			expected:    &runtimeclient.ContainerExitData{ExitCode: 0, Reason: runtimeclient.ExitReasonCompleted},
+		},
+		{
+			description: "Error",
+			state:       &dockertypes.ContainerState{Status: "exited", ExitCode: 2},
+			expected:    &runtimeclient.ContainerExitData{ExitCode: 2, Reason: runtimeclient.ExitReasonError},
+		},
+		{
+			description: "OOM killed",
+			state:       &dockertypes.ContainerState{Status: "dead", ExitCode: 137, OOMKilled: true},
+			expected:    &runtimeclient.ContainerExitData{ExitCode: 137, Reason: runtimeclient.ExitReasonOOMKilled},
+		},
+		{
+			description: "Running",
+			state:       &dockertypes.ContainerState{Status: "running", Running: true},
+			expectedErr: runtimeclient.ErrContainerNotExited,
+		},
+		{
+			description: "Created",
+			state:       &dockertypes.ContainerState{Status: "created"},
+			expectedErr: runtimeclient.ErrContainerNotExited,
+		},
+	}
+
+	for _, entry := range table {
+		exit, err := containerExit(entry.state)
+		if entry.expectedErr != nil {
+			require.ErrorIs(t, err, entry.expectedErr, entry.description)
+			continue
+		}
+		require.NoError(t, err, entry.description)
+		require.Equal(t, entry.expected, exit, entry.description)
+	}
+
+	_, err := containerExit(nil)
+	require.Error(t, err)
+}
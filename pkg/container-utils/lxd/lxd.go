@@ -0,0 +1,259 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lxd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cgroups"
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	defaultConnectionTimeout = 2 * time.Second
+	instancesListURL         = "http://lxd/1.0/instances?recursion=1&all-projects=true"
+	instanceURL              = "http://lxd/1.0/instances/%s?project=%s"
+	instanceStateURL         = "http://lxd/1.0/instances/%s/state?project=%s"
+
+	defaultProject = "default"
+)
+
+// LXDClient talks to the REST API of LXD through its Unix socket. Virtual
+// machines are ignored.
+type LXDClient struct {
+	client http.Client
+}
+
+func NewLXDClient(socketPath string) runtimeclient.ContainerRuntimeClient {
+	if socketPath == "" {
+		socketPath = runtimeclient.LXDDefaultSocketPath
+	}
+
+	return &LXDClient{
+		client: http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (conn net.Conn, err error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+				},
+			},
+			Timeout: defaultConnectionTimeout,
+		},
+	}
+}
+
+// response is the envelope of all the responses of the LXD API
+type response struct {
+	Type     string          `json:"type"`
+	Error    string          `json:"error"`
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+type instance struct {
+	Name            string                       `json:"name"`
+	Project         string                       `json:"project"`
+	Type            string                       `json:"type"`
+	Status          string                       `json:"status"`
+	Config          map[string]string            `json:"config"`
+	ExpandedDevices map[string]map[string]string `json:"expanded_devices"`
+}
+
+type instanceState struct {
+	Status string `json:"status"`
+	Pid    int    `json:"pid"`
+}
+
+func (l *LXDClient) get(url string, metadata any) error {
+	resp, err := l.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	if r.Type == "error" || resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rest api: %s: %s", resp.Status, r.Error)
+	}
+
+	if err := json.Unmarshal(r.Metadata, metadata); err != nil {
+		return fmt.Errorf("decoding metadata: %w", err)
+	}
+	return nil
+}
+
+// containerID returns the ID used for the instance. LXD doesn't have IDs,
+// instances are identified by their name in a project. The project is added
+// like LXD does for its cgroups and AppArmor profiles, if it's not the default
+// one.
+func containerID(project, name string) string {
+	if project == "" || project == defaultProject {
+		return name
+	}
+	return project + "_" + name
+}
+
+func parseContainerID(containerID string) (project, name string) {
+	// Instance names are hostnames, they can't contain underscores
+	i := strings.LastIndex(containerID, "_")
+	if i == -1 {
+		return defaultProject, containerID
+	}
+	return containerID[:i], containerID[i+1:]
+}
+
+func (i *instance) containerData() *runtimeclient.ContainerData {
+	data := &runtimeclient.ContainerData{
+		Runtime: runtimeclient.RuntimeContainerData{
+			BasicRuntimeMetadata: types.BasicRuntimeMetadata{
+				ContainerID:   containerID(i.Project, i.Name),
+				ContainerName: i.Name,
+				RuntimeName:   types.RuntimeNameLXD,
+			},
+			State: statusToRuntimeClientState(i.Status),
+		},
+	}
+
+	if description := i.Config["image.description"]; description != "" {
+		data.Runtime.ContainerImageName = description
+	} else if os := i.Config["image.os"]; os != "" {
+		data.Runtime.ContainerImageName = strings.TrimSpace(os + " " + i.Config["image.release"])
+	}
+	if fingerprint := i.Config["volatile.base_image"]; fingerprint != "" {
+		data.Runtime.ContainerImageDigest = "sha256:" + fingerprint
+	}
+
+	return data
+}
+
+func (l *LXDClient) GetContainers() ([]*runtimeclient.ContainerData, error) {
+	var instances []instance
+	if err := l.get(instancesListURL, &instances); err != nil {
+		return nil, fmt.Errorf("listing instances: %w", err)
+	}
+
+	ret := make([]*runtimeclient.ContainerData, 0, len(instances))
+	for _, i := range instances {
+		if i.Type != "container" {
+			continue
+		}
+		ret = append(ret, i.containerData())
+	}
+	return ret, nil
+}
+
+func (l *LXDClient) getInstance(containerID string) (*instance, error) {
+	project, name := parseContainerID(containerID)
+
+	var i instance
+	err := l.get(fmt.Sprintf(instanceURL, url.PathEscape(name), url.QueryEscape(project)), &i)
+	if err != nil {
+		return nil, fmt.Errorf("getting instance %q: %w", containerID, err)
+	}
+	if i.Type != "container" {
+		return nil, fmt.Errorf("instance %q is a %s", containerID, i.Type)
+	}
+	if i.Project == "" {
+		i.Project = project
+	}
+	return &i, nil
+}
+
+func (l *LXDClient) GetContainer(containerID string) (*runtimeclient.ContainerData, error) {
+	i, err := l.getInstance(containerID)
+	if err != nil {
+		return nil, err
+	}
+	return i.containerData(), nil
+}
+
+func (l *LXDClient) GetContainerDetails(containerID string) (*runtimeclient.ContainerDetailsData, error) {
+	i, err := l.getInstance(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var state instanceState
+	err = l.get(fmt.Sprintf(instanceStateURL, url.PathEscape(i.Name), url.QueryEscape(i.Project)), &state)
+	if err != nil {
+		return nil, fmt.Errorf("getting state of instance %q: %w", containerID, err)
+	}
+
+	details := &runtimeclient.ContainerDetailsData{
+		ContainerData: *i.containerData(),
+		Pid:           state.Pid,
+		Mounts:        mounts(i.ExpandedDevices),
+	}
+	details.Runtime.State = statusToRuntimeClientState(state.Status)
+
+	// The API doesn't expose the cgroup of the instance, it depends on the
+	// LXD version and the cgroup layout of the host
+	if state.Pid != 0 {
+		if _, cgroupV2, err := cgroups.GetCgroupPaths(state.Pid); err == nil {
+			details.CgroupsPath = cgroupV2
+		}
+	}
+
+	return details, nil
+}
+
+// mounts returns the disk devices of the instance that are bind mounts of
+// the host. Disks from storage pools, like the root one, aren't.
+func mounts(devices map[string]map[string]string) []runtimeclient.ContainerMountData {
+	names := make([]string, 0, len(devices))
+	for name := range devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ret []runtimeclient.ContainerMountData
+	for _, name := range names {
+		device := devices[name]
+		if device["type"] != "disk" || device["source"] == "" || device["pool"] != "" {
+			continue
+		}
+		ret = append(ret, runtimeclient.ContainerMountData{
+			Source:      device["source"],
+			Destination: device["path"],
+		})
+	}
+	return ret
+}
+
+func (l *LXDClient) Close() error {
+	l.client.CloseIdleConnections()
+	return nil
+}
+
+func statusToRuntimeClientState(status string) string {
+	switch status {
+	case "Running", "Frozen":
+		return runtimeclient.StateRunning
+	case "Stopped":
+		return runtimeclient.StateExited
+	default:
+		return runtimeclient.StateUnknown
+	}
+}
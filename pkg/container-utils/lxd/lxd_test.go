@@ -0,0 +1,173 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lxd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	instancesResponse = `{
+	"type": "sync",
+	"metadata": [
+		{
+			"name": "web",
+			"project": "default",
+			"type": "container",
+			"status": "Running",
+			"config": {
+				"image.description": "Ubuntu jammy amd64",
+				"volatile.base_image": "abcdef"
+			}
+		},
+		{
+			"name": "db",
+			"project": "prod",
+			"type": "container",
+			"status": "Stopped",
+			"config": {
+				"image.os": "Debian",
+				"image.release": "bookworm"
+			}
+		},
+		{
+			"name": "vm",
+			"project": "default",
+			"type": "virtual-machine",
+			"status": "Running"
+		}
+	]
+}`
+	instanceResponse = `{
+	"type": "sync",
+	"metadata": {
+		"name": "db",
+		"project": "prod",
+		"type": "container",
+		"status": "Running",
+		"expanded_devices": {
+			"root": {"type": "disk", "path": "/", "pool": "default"},
+			"data": {"type": "disk", "path": "/data", "source": "/srv/data"},
+			"eth0": {"type": "nic", "network": "lxdbr0"}
+		}
+	}
+}`
+	stateResponse = `{
+	"type": "sync",
+	"metadata": {"status": "Running", "pid": 0}
+}`
+	notFoundResponse = `{
+	"type": "error",
+	"error": "Instance not found",
+	"error_code": 404
+}`
+)
+
+func newTestClient(t *testing.T) runtimeclient.ContainerRuntimeClient {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/1.0/instances", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(instancesResponse))
+	})
+	mux.HandleFunc("/1.0/instances/db", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("project") != "prod" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(notFoundResponse))
+			return
+		}
+		w.Write([]byte(instanceResponse))
+	})
+	mux.HandleFunc("/1.0/instances/db/state", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(stateResponse))
+	})
+
+	socketPath := filepath.Join(t.TempDir(), "unix.socket")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(mux)
+	server.Listener = l
+	server.Start()
+	t.Cleanup(server.Close)
+
+	client := NewLXDClient(socketPath)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestGetContainers(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t)
+
+	containers, err := client.GetContainers()
+	require.NoError(t, err)
+	require.Len(t, containers, 2)
+
+	require.Equal(t, types.BasicRuntimeMetadata{
+		RuntimeName:          types.RuntimeNameLXD,
+		ContainerID:          "web",
+		ContainerName:        "web",
+		ContainerImageName:   "Ubuntu jammy amd64",
+		ContainerImageDigest: "sha256:abcdef",
+	}, containers[0].Runtime.BasicRuntimeMetadata)
+	require.Equal(t, runtimeclient.StateRunning, containers[0].Runtime.State)
+
+	require.Equal(t, types.BasicRuntimeMetadata{
+		RuntimeName:        types.RuntimeNameLXD,
+		ContainerID:        "prod_db",
+		ContainerName:      "db",
+		ContainerImageName: "Debian bookworm",
+	}, containers[1].Runtime.BasicRuntimeMetadata)
+	require.Equal(t, runtimeclient.StateExited, containers[1].Runtime.State)
+}
+
+func TestGetContainerDetails(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t)
+
+	details, err := client.GetContainerDetails("prod_db")
+	require.NoError(t, err)
+	require.Equal(t, "prod_db", details.Runtime.ContainerID)
+	require.Equal(t, runtimeclient.StateRunning, details.Runtime.State)
+	require.Equal(t, []runtimeclient.ContainerMountData{
+		{Source: "/srv/data", Destination: "/data"},
+	}, details.Mounts)
+
+	_, err = client.GetContainerDetails("db")
+	require.ErrorContains(t, err, "Instance not found")
+}
+
+func TestParseContainerID(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"web", "prod_db", "my_project_db"} {
+		project, instance := parseContainerID(name)
+		require.Equal(t, name, containerID(project, instance))
+	}
+
+	project, instance := parseContainerID("my_project_db")
+	require.Equal(t, "my_project", project)
+	require.Equal(t, "db", instance)
+}
@@ -0,0 +1,254 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package machined implements a runtime client for the containers registered
+// in systemd-machined, like the ones started with systemd-nspawn.
+package machined
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cgroups"
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	machinedDest     = "org.freedesktop.machine1"
+	machinedPath     = "/org/freedesktop/machine1"
+	managerInterface = "org.freedesktop.machine1.Manager"
+	machineInterface = "org.freedesktop.machine1.Machine"
+	propertiesGetAll = "org.freedesktop.DBus.Properties.GetAll"
+	classContainer   = "container"
+)
+
+// MachinedClient talks to systemd-machined through the D-Bus system bus.
+// Virtual machines are ignored. Containers are identified by their machine
+// name.
+type MachinedClient struct {
+	socketPath string
+
+	mu   sync.Mutex
+	conn *dbus.Conn
+}
+
+func NewMachinedClient(socketPath string) runtimeclient.ContainerRuntimeClient {
+	if socketPath == "" {
+		socketPath = runtimeclient.MachinedDefaultSocketPath
+	}
+
+	// Connect lazily, as for the other runtimes, to don't fail if machined
+	// isn't running
+	return &MachinedClient{
+		socketPath: socketPath,
+	}
+}
+
+func (m *MachinedClient) getConn() (*dbus.Conn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.conn != nil && m.conn.Connected() {
+		return m.conn, nil
+	}
+
+	conn, err := dbus.Dial("unix:path=" + m.socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to system bus: %w", err)
+	}
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("authenticating to system bus: %w", err)
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("registering to system bus: %w", err)
+	}
+
+	m.conn = conn
+	return conn, nil
+}
+
+// machine contains the properties of org.freedesktop.machine1.Machine
+type machine struct {
+	Name          string
+	Class         string
+	Service       string
+	Leader        uint32
+	RootDirectory string
+	Unit          string
+	State         string
+}
+
+func (m *MachinedClient) getMachine(conn *dbus.Conn, path dbus.ObjectPath) (*machine, error) {
+	var props map[string]dbus.Variant
+	err := conn.Object(machinedDest, path).Call(propertiesGetAll, 0, machineInterface).Store(&props)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &machine{}
+	for key, dst := range map[string]any{
+		"Name":          &ret.Name,
+		"Class":         &ret.Class,
+		"Service":       &ret.Service,
+		"Leader":        &ret.Leader,
+		"RootDirectory": &ret.RootDirectory,
+		"Unit":          &ret.Unit,
+		"State":         &ret.State,
+	} {
+		if v, ok := props[key]; ok {
+			// Ignore type mismatches, the property stays empty
+			_ = v.Store(dst)
+		}
+	}
+	return ret, nil
+}
+
+func (m *machine) containerData() *runtimeclient.ContainerData {
+	return &runtimeclient.ContainerData{
+		Runtime: runtimeclient.RuntimeContainerData{
+			BasicRuntimeMetadata: types.BasicRuntimeMetadata{
+				ContainerID:   m.Name,
+				ContainerName: m.Name,
+				RuntimeName:   types.RuntimeNameMachined,
+				// machined doesn't know about images, the root directory is
+				// the closest thing
+				ContainerImageName: m.RootDirectory,
+			},
+			State: stateToRuntimeClientState(m.State),
+		},
+	}
+}
+
+func (m *MachinedClient) GetContainers() ([]*runtimeclient.ContainerData, error) {
+	conn, err := m.getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []struct {
+		Name    string
+		Class   string
+		Service string
+		Path    dbus.ObjectPath
+	}
+	err = conn.Object(machinedDest, machinedPath).Call(managerInterface+".ListMachines", 0).Store(&list)
+	if err != nil {
+		return nil, fmt.Errorf("listing machines: %w", err)
+	}
+
+	var ret []*runtimeclient.ContainerData
+	for _, entry := range list {
+		if entry.Class != classContainer {
+			continue
+		}
+
+		machine, err := m.getMachine(conn, entry.Path)
+		if err != nil {
+			// The machine could have terminated meanwhile
+			continue
+		}
+		ret = append(ret, machine.containerData())
+	}
+	return ret, nil
+}
+
+func (m *MachinedClient) lookupMachine(containerID string) (*machine, error) {
+	conn, err := m.getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var path dbus.ObjectPath
+	err = conn.Object(machinedDest, machinedPath).Call(managerInterface+".GetMachine", 0, containerID).Store(&path)
+	if err != nil {
+		return nil, fmt.Errorf("getting machine %q: %w", containerID, err)
+	}
+
+	machine, err := m.getMachine(conn, path)
+	if err != nil {
+		return nil, fmt.Errorf("getting properties of machine %q: %w", containerID, err)
+	}
+	if machine.Class != classContainer {
+		return nil, fmt.Errorf("machine %q is a %s", containerID, machine.Class)
+	}
+	return machine, nil
+}
+
+func (m *MachinedClient) GetContainer(containerID string) (*runtimeclient.ContainerData, error) {
+	machine, err := m.lookupMachine(containerID)
+	if err != nil {
+		return nil, err
+	}
+	return machine.containerData(), nil
+}
+
+func (m *MachinedClient) GetContainerDetails(containerID string) (*runtimeclient.ContainerDetailsData, error) {
+	machine, err := m.lookupMachine(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &runtimeclient.ContainerDetailsData{
+		ContainerData: *machine.containerData(),
+		Pid:           int(machine.Leader),
+	}
+
+	// Bind mounts aren't exposed by machined, only the root directory is
+	if machine.RootDirectory != "" {
+		details.Mounts = []runtimeclient.ContainerMountData{
+			{
+				Source:      machine.RootDirectory,
+				Destination: "/",
+			},
+		}
+	}
+
+	if machine.Leader != 0 {
+		if _, cgroupV2, err := cgroups.GetCgroupPaths(int(machine.Leader)); err == nil {
+			details.CgroupsPath = cgroupV2
+		}
+	}
+
+	return details, nil
+}
+
+func (m *MachinedClient) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.conn == nil {
+		return nil
+	}
+	err := m.conn.Close()
+	m.conn = nil
+	return err
+}
+
+func stateToRuntimeClientState(state string) string {
+	switch state {
+	case "opening":
+		return runtimeclient.StateCreated
+	case "running":
+		return runtimeclient.StateRunning
+	case "closing":
+		return runtimeclient.StateExited
+	default:
+		return runtimeclient.StateUnknown
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package machined
+
+import (
+	"bufio"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+	"github.com/stretchr/testify/require"
+
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type listEntry struct {
+	Name    string
+	Class   string
+	Service string
+	Path    dbus.ObjectPath
+}
+
+// fakeManager implements the subset of org.freedesktop.machine1.Manager used
+// by the client
+type fakeManager struct {
+	machines []listEntry
+}
+
+func (f *fakeManager) ListMachines() ([]listEntry, *dbus.Error) {
+	return f.machines, nil
+}
+
+func (f *fakeManager) GetMachine(name string) (dbus.ObjectPath, *dbus.Error) {
+	for _, m := range f.machines {
+		if m.Name == name {
+			return m.Path, nil
+		}
+	}
+	return "", dbus.NewError("org.freedesktop.machine1.NoSuchMachine", []any{"No machine " + name})
+}
+
+// startBus starts a private bus and returns the path of its socket. The test
+// is skipped if dbus-daemon isn't available.
+func startBus(t *testing.T) string {
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not available")
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "bus.socket")
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address",
+		"--address=unix:path="+socketPath)
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	// The address is printed once the bus is listening
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(address, "unix:path="+socketPath))
+
+	return socketPath
+}
+
+func newTestClient(t *testing.T) runtimeclient.ContainerRuntimeClient {
+	socketPath := startBus(t)
+
+	conn, err := dbus.Dial("unix:path=" + socketPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.Auth(nil))
+	require.NoError(t, conn.Hello())
+
+	machines := []struct {
+		listEntry
+		root  string
+		state string
+	}{
+		{listEntry{"debian", classContainer, "systemd-nspawn", "/org/freedesktop/machine1/machine/debian"}, "/var/lib/machines/debian", "running"},
+		{listEntry{"fedora", classContainer, "systemd-nspawn", "/org/freedesktop/machine1/machine/fedora"}, "", "opening"},
+		{listEntry{"vm", "vm", "libvirt-qemu", "/org/freedesktop/machine1/machine/vm"}, "", "running"},
+	}
+
+	manager := &fakeManager{}
+	for _, m := range machines {
+		manager.machines = append(manager.machines, m.listEntry)
+		_, err := prop.Export(conn, m.Path, prop.Map{
+			machineInterface: {
+				"Name":          {Value: m.Name},
+				"Class":         {Value: m.Class},
+				"Service":       {Value: m.Service},
+				"Leader":        {Value: uint32(0)},
+				"RootDirectory": {Value: m.root},
+				"Unit":          {Value: "machine-" + m.Name + ".scope"},
+				"State":         {Value: m.state},
+			},
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, conn.Export(manager, machinedPath, managerInterface))
+
+	reply, err := conn.RequestName(machinedDest, dbus.NameFlagDoNotQueue)
+	require.NoError(t, err)
+	require.Equal(t, dbus.RequestNameReplyPrimaryOwner, reply)
+
+	client := NewMachinedClient(socketPath)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestGetContainers(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t)
+
+	containers, err := client.GetContainers()
+	require.NoError(t, err)
+	require.Len(t, containers, 2)
+
+	require.Equal(t, types.BasicRuntimeMetadata{
+		RuntimeName:        types.RuntimeNameMachined,
+		ContainerID:        "debian",
+		ContainerName:      "debian",
+		ContainerImageName: "/var/lib/machines/debian",
+	}, containers[0].Runtime.BasicRuntimeMetadata)
+	require.Equal(t, runtimeclient.StateRunning, containers[0].Runtime.State)
+
+	require.Equal(t, "fedora", containers[1].Runtime.ContainerID)
+	require.Equal(t, runtimeclient.StateCreated, containers[1].Runtime.State)
+}
+
+func TestGetContainerDetails(t *testing.T) {
+	t.Parallel()
+
+	client := newTestClient(t)
+
+	details, err := client.GetContainerDetails("debian")
+	require.NoError(t, err)
+	require.Equal(t, "debian", details.Runtime.ContainerID)
+	require.Equal(t, runtimeclient.StateRunning, details.Runtime.State)
+	require.Equal(t, []runtimeclient.ContainerMountData{
+		{Source: "/var/lib/machines/debian", Destination: "/"},
+	}, details.Mounts)
+
+	_, err = client.GetContainerDetails("vm")
+	require.ErrorContains(t, err, "is a vm")
+
+	_, err = client.GetContainer("unknown")
+	require.ErrorContains(t, err, "No machine unknown")
+}
+
+func TestNonExistingSocketPath(t *testing.T) {
+	t.Parallel()
+
+	// The connection is only established when needed
+	client := NewMachinedClient(filepath.Join(t.TempDir(), "non-existing-socket"))
+	t.Cleanup(func() { client.Close() })
+
+	_, err := client.GetContainers()
+	require.ErrorContains(t, err, "connecting to system bus")
+}
+
+func TestStateToRuntimeClientState(t *testing.T) {
+	t.Parallel()
+
+	for state, expected := range map[string]string{
+		"opening": runtimeclient.StateCreated,
+		"running": runtimeclient.StateRunning,
+		"closing": runtimeclient.StateExited,
+		"":        runtimeclient.StateUnknown,
+	} {
+		require.Equal(t, expected, stateToRuntimeClientState(state))
+	}
+}
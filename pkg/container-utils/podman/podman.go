@@ -17,7 +17,9 @@ package podman
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -161,6 +163,49 @@ func (p *PodmanClient) GetContainerDetails(containerID string) (*runtimeclient.C
 	}, nil
 }
 
+func (p *PodmanClient) GetContainerExit(containerID string) (*runtimeclient.ContainerExitData, error) {
+	resp, err := p.client.Get(fmt.Sprintf(containerInspectURL, containerID))
+	if err != nil {
+		return nil, fmt.Errorf("inspecting container %q: %w", containerID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("inspecting container via rest api %q: %s", containerID, resp.Status)
+	}
+
+	exit, err := containerExit(resp.Body)
+	if err != nil && !errors.Is(err, runtimeclient.ErrContainerNotExited) {
+		return nil, fmt.Errorf("decoding container %q: %w", containerID, err)
+	}
+	return exit, err
+}
+
+// containerExit returns how the container terminated from the response of the
+// container inspect endpoint.
+func containerExit(r io.Reader) (*runtimeclient.ContainerExitData, error) {
+	var container struct {
+		State struct {
+			Status    string `json:"Status"`
+			ExitCode  int32  `json:"ExitCode"`
+			OOMKilled bool   `json:"OOMKilled"`
+		} `json:"State"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&container); err != nil {
+		return nil, err
+	}
+
+	if containerStatusStateToRuntimeClientState(container.State.Status) != runtimeclient.StateExited {
+		return nil, runtimeclient.ErrContainerNotExited
+	}
+
+	return &runtimeclient.ContainerExitData{
+		ExitCode: container.State.ExitCode,
+		Reason:   runtimeclient.ExitReason(container.State.ExitCode, container.State.OOMKilled),
+	}, nil
+}
+
 func (p *PodmanClient) Close() error {
 	return nil
 }
@@ -171,7 +216,7 @@ func containerStatusStateToRuntimeClientState(containerState string) string {
 		return runtimeclient.StateCreated
 	case "running":
 		return runtimeclient.StateRunning
-	case "exited":
+	case "exited", "stopped":
 		return runtimeclient.StateExited
 	case "dead":
 		return runtimeclient.StateExited
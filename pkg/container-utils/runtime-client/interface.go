@@ -28,9 +28,14 @@ const (
 	PodmanDefaultSocketPath     = "/run/podman/podman.sock"
 	ContainerdDefaultSocketPath = "/run/containerd/containerd.sock"
 	DockerDefaultSocketPath     = "/run/docker.sock"
+	LXDDefaultSocketPath        = "/var/snap/lxd/common/lxd/unix.socket"
+	MachinedDefaultSocketPath   = "/run/dbus/system_bus_socket"
 )
 
-var ErrPauseContainer = errors.New("it is a pause container")
+var (
+	ErrPauseContainer     = errors.New("it is a pause container")
+	ErrContainerNotExited = errors.New("container has not exited yet")
+)
 
 type K8sContainerData struct {
 	types.BasicK8sMetadata
@@ -82,6 +87,35 @@ type ContainerMountData struct {
 	Destination string
 }
 
+// ContainerExitData contains how a container terminated.
+type ContainerExitData struct {
+	// Exit code of the container process.
+	ExitCode int32
+
+	// Reason of the termination, using the same values as Kubernetes:
+	// "Completed", "Error" or "OOMKilled".
+	Reason string
+}
+
+const (
+	ExitReasonCompleted = "Completed"
+	ExitReasonError     = "Error"
+	ExitReasonOOMKilled = "OOMKilled"
+)
+
+// ExitReason returns the reason of a termination for the runtimes that don't
+// provide it.
+func ExitReason(exitCode int32, oomKilled bool) string {
+	switch {
+	case oomKilled:
+		return ExitReasonOOMKilled
+	case exitCode == 0:
+		return ExitReasonCompleted
+	default:
+		return ExitReasonError
+	}
+}
+
 const (
 	// Container was created but has not started running.
 	StateCreated = "created"
@@ -123,6 +157,15 @@ type ContainerRuntimeClient interface {
 	Close() error
 }
 
+// ContainerExitGetter is implemented by the container runtime clients that
+// can tell how a container terminated.
+type ContainerExitGetter interface {
+	// GetContainerExit returns the exit data of the container identified by
+	// the provided ID. It returns ErrContainerNotExited if the runtime still
+	// considers the container as running.
+	GetContainerExit(containerID string) (*ContainerExitData, error)
+}
+
 func ParseContainerID(expectedRuntime types.RuntimeName, containerID string) (string, error) {
 	// If ID contains a prefix, it must match the format "<runtime>://<ID>"
 	split := strings.SplitN(containerID, "://", 2)
@@ -0,0 +1,461 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package systemd discovers the systemd services and slices running on the
+// host, as well as the plain cgroups created outside of systemd, from the
+// cgroup v2 hierarchy and watches their creation and removal.
+package systemd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	systemdDbus "github.com/coreos/go-systemd/v22/dbus"
+	"github.com/fsnotify/fsnotify"
+	"github.com/godbus/dbus/v5"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cgroups"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const (
+	ServiceSuffix = ".service"
+	SliceSuffix   = ".slice"
+	ScopeSuffix   = ".scope"
+
+	// A cgroup is created before the processes of the service are moved
+	// into it, wait for them up to pidRetries * pidRetryDelay
+	pidRetries    = 20
+	pidRetryDelay = 50 * time.Millisecond
+)
+
+// UnitType is the kind of cgroup a Unit was discovered from
+type UnitType string
+
+const (
+	UnitTypeService UnitType = "service"
+	UnitTypeSlice   UnitType = "slice"
+	// UnitTypeCgroup is a cgroup that doesn't belong to a systemd unit, e.g.
+	// one created by hand directly below the root or a slice
+	UnitTypeCgroup UnitType = "cgroup"
+)
+
+// unitSuffixes are the suffixes of the cgroups of the other systemd unit types.
+// Scopes are used for sessions and containers, which are handled elsewhere,
+// and the other ones don't have long running processes.
+var unitSuffixes = []string{ScopeSuffix, ".mount", ".socket", ".swap"}
+
+// Unit is a systemd service or slice, or a plain cgroup, running on the host
+type Unit struct {
+	Type UnitType
+
+	// Name is the name of the unit, e.g. "nginx.service", or CgroupPath for
+	// plain cgroups
+	Name string
+
+	// CgroupPath is the path of the cgroup of the unit, relative to the
+	// root of the cgroup v2 hierarchy, e.g. "/system.slice/nginx.service"
+	CgroupPath string
+
+	// CgroupPathWithMountpoint is CgroupPath with the mountpoint of the
+	// cgroup v2 hierarchy
+	CgroupPathWithMountpoint string
+
+	CgroupID uint64
+
+	// MainPID is the main process of the service as reported by systemd or,
+	// if systemd can't be reached or for slices and plain cgroups, the first
+	// process of its cgroup
+	MainPID uint32
+}
+
+// NormalizeUnitName adds the ".service" suffix to name if it doesn't have
+// the suffix of a unit type
+func NormalizeUnitName(name string) string {
+	if name == "" || strings.HasSuffix(name, ServiceSuffix) || strings.HasSuffix(name, SliceSuffix) {
+		return name
+	}
+	return name + ServiceSuffix
+}
+
+// CgroupRoot returns the mountpoint of the cgroup v2 hierarchy
+func CgroupRoot() (string, error) {
+	root, err := cgroups.CgroupPathV2AddMountpoint("/")
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 not found at %q: %w", root, err)
+	}
+	return root, nil
+}
+
+// Discoverer lists and watches the systemd services and slices and the plain
+// cgroups of the host
+type Discoverer struct {
+	root string
+
+	mu   sync.Mutex
+	conn *systemdDbus.Conn
+}
+
+func NewDiscoverer() (*Discoverer, error) {
+	root, err := CgroupRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Discoverer{root: root}
+
+	// The main PID is only known by systemd, fallback to the cgroup if it
+	// can't be reached
+	d.conn, err = newConnection()
+	if err != nil {
+		log.Debugf("systemd: connecting to systemd, main PIDs will be guessed from cgroups: %s", err)
+	}
+
+	return d, nil
+}
+
+func newConnection() (*systemdDbus.Conn, error) {
+	socketPath := filepath.Join(host.HostRoot, "/run/systemd/private")
+	if _, err := os.Stat(socketPath); err != nil {
+		return nil, err
+	}
+
+	return systemdDbus.NewConnection(func() (*dbus.Conn, error) {
+		conn, err := dbus.Dial("unix:path=" + socketPath)
+		if err != nil {
+			return nil, err
+		}
+
+		methods := []dbus.Auth{dbus.AuthExternal(strconv.Itoa(os.Getuid()))}
+		if err := conn.Auth(methods); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	})
+}
+
+func (d *Discoverer) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+	}
+}
+
+// ListUnits returns the services, slices and plain cgroups that currently
+// exist
+func (d *Discoverer) ListUnits() ([]*Unit, error) {
+	var units []*Unit
+
+	err := filepath.WalkDir(d.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// The cgroup could have been removed meanwhile
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(entry.Name(), ScopeSuffix) {
+			return fs.SkipDir
+		}
+		if unitType, ok := d.classify(path); ok {
+			if unit, err := d.getUnit(path, unitType); err == nil {
+				units = append(units, unit)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking cgroup hierarchy: %w", err)
+	}
+
+	return units, nil
+}
+
+// classify returns the type of the unit of the cgroup at path. ok is false if
+// the cgroup isn't the one of a unit, e.g. the root or a child cgroup of a
+// service.
+func (d *Discoverer) classify(path string) (unitType UnitType, ok bool) {
+	if path == d.root {
+		return "", false
+	}
+
+	name := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(name, ServiceSuffix):
+		return UnitTypeService, true
+	case strings.HasSuffix(name, SliceSuffix):
+		return UnitTypeSlice, true
+	}
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return "", false
+		}
+	}
+
+	// Cgroups below services belong to them
+	parent := filepath.Dir(path)
+	if parent == d.root || strings.HasSuffix(parent, SliceSuffix) {
+		return UnitTypeCgroup, true
+	}
+	return "", false
+}
+
+func (d *Discoverer) cgroupPath(path string) string {
+	return "/" + strings.TrimPrefix(strings.TrimPrefix(path, d.root), "/")
+}
+
+func (d *Discoverer) newUnit(path string, unitType UnitType) *Unit {
+	unit := &Unit{
+		Type:                     unitType,
+		Name:                     filepath.Base(path),
+		CgroupPath:               d.cgroupPath(path),
+		CgroupPathWithMountpoint: path,
+	}
+	if unitType == UnitTypeCgroup {
+		unit.Name = unit.CgroupPath
+	}
+	return unit
+}
+
+func (d *Discoverer) getUnit(path string, unitType UnitType) (*Unit, error) {
+	cgroupID, err := cgroups.GetCgroupID(path)
+	if err != nil {
+		return nil, err
+	}
+
+	unit := d.newUnit(path, unitType)
+	unit.CgroupID = cgroupID
+
+	unit.MainPID = d.getMainPID(unit)
+	if unit.MainPID == 0 {
+		return nil, fmt.Errorf("no process found in %q", path)
+	}
+
+	return unit, nil
+}
+
+func (d *Discoverer) getMainPID(unit *Unit) uint32 {
+	d.mu.Lock()
+	conn := d.conn
+	d.mu.Unlock()
+
+	// User services aren't known by the system instance of systemd
+	if conn != nil && unit.Type == UnitTypeService && !strings.Contains(unit.CgroupPath, "/user@") {
+		prop, err := conn.GetUnitTypePropertyContext(context.TODO(), unit.Name, "Service", "MainPID")
+		if err == nil {
+			if pid, ok := prop.Value.Value().(uint32); ok && pid != 0 {
+				return pid
+			}
+		}
+	}
+
+	// The child cgroups of plain cgroups can be managed by other tools, e.g.
+	// the ones of the pods with the cgroupfs driver of the kubelet. Only
+	// consider the processes of the cgroup itself.
+	if unit.Type == UnitTypeCgroup {
+		return readFirstPID(unit.CgroupPathWithMountpoint)
+	}
+
+	return firstPID(unit.CgroupPathWithMountpoint)
+}
+
+// readFirstPID returns the first process of the cgroup at path, without the
+// ones of its children
+func readFirstPID(path string) uint32 {
+	f, err := os.Open(filepath.Join(path, "cgroup.procs"))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if scanner.Scan() {
+		val, err := strconv.ParseUint(scanner.Text(), 10, 32)
+		if err == nil {
+			return uint32(val)
+		}
+	}
+	return 0
+}
+
+// firstPID returns the first process found in the cgroup at path or in its
+// children, except the scopes as their processes belong to sessions and
+// containers
+func firstPID(path string) uint32 {
+	var pid uint32
+
+	filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(entry.Name(), ScopeSuffix) {
+			return fs.SkipDir
+		}
+
+		if pid = readFirstPID(path); pid != 0 {
+			return fs.SkipAll
+		}
+		return nil
+	})
+
+	return pid
+}
+
+// EventType is the type of a unit event
+type EventType int
+
+const (
+	EventTypeAddUnit EventType = iota
+	EventTypeRemoveUnit
+)
+
+// Event is sent when a unit starts or stops. The unit of EventTypeRemoveUnit
+// events doesn't have CgroupID and MainPID set.
+type Event struct {
+	Type EventType
+	Unit *Unit
+}
+
+// Watch calls callback for the units started or stopped until ctx is done.
+// Units are found by watching the creation and the removal of cgroups.
+func (d *Discoverer) Watch(ctx context.Context, callback func(Event)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating watcher: %w", err)
+	}
+
+	if err := d.addWatches(watcher, d.root); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warnf("systemd: watching cgroups: %s", err)
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				d.handleEvent(ctx, watcher, event, callback)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (d *Discoverer) handleEvent(ctx context.Context, watcher *fsnotify.Watcher, event fsnotify.Event, callback func(Event)) {
+	unitType, isUnit := d.classify(event.Name)
+
+	switch {
+	case event.Has(fsnotify.Create):
+		info, err := os.Stat(event.Name)
+		if err != nil || !info.IsDir() {
+			return
+		}
+
+		// Services started under a new slice are caught by the watch
+		// added here
+		if err := d.addWatches(watcher, event.Name); err != nil {
+			log.Debugf("systemd: watching %q: %s", event.Name, err)
+		}
+
+		if isUnit {
+			go d.waitForUnit(ctx, event.Name, unitType, callback)
+		}
+	case event.Has(fsnotify.Remove):
+		if isUnit {
+			callback(Event{
+				Type: EventTypeRemoveUnit,
+				Unit: d.newUnit(event.Name, unitType),
+			})
+		}
+	}
+}
+
+// waitForUnit waits for the processes of the unit to be moved into its cgroup
+// before notifying it
+func (d *Discoverer) waitForUnit(ctx context.Context, path string, unitType UnitType, callback func(Event)) {
+	for i := 0; i < pidRetries; i++ {
+		unit, err := d.getUnit(path, unitType)
+		if err == nil {
+			callback(Event{
+				Type: EventTypeAddUnit,
+				Unit: unit,
+			})
+			return
+		}
+		if _, err := os.Stat(path); err != nil {
+			// The service already stopped
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pidRetryDelay):
+		}
+	}
+
+	log.Debugf("systemd: no process found in %q", path)
+}
+
+// addWatches watches path and all the cgroups below it, except the ones of
+// scopes as they are used for sessions and containers
+func (d *Discoverer) addWatches(watcher *fsnotify.Watcher, path string) error {
+	return filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(entry.Name(), ScopeSuffix) {
+			return fs.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
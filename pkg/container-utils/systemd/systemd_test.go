@@ -0,0 +1,79 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package systemd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeUnitName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "", NormalizeUnitName(""))
+	require.Equal(t, "nginx.service", NormalizeUnitName("nginx"))
+	require.Equal(t, "nginx.service", NormalizeUnitName("nginx.service"))
+	require.Equal(t, "system.slice", NormalizeUnitName("system.slice"))
+}
+
+func TestFirstPID(t *testing.T) {
+	t.Parallel()
+
+	// The main process of the service lives in a child cgroup
+	root := t.TempDir()
+	child := filepath.Join(root, "payload")
+	require.NoError(t, os.Mkdir(child, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "cgroup.procs"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(child, "cgroup.procs"), []byte("1234\n5678\n"), 0o644))
+
+	require.Equal(t, uint32(1234), firstPID(root))
+	require.Equal(t, uint32(0), firstPID(t.TempDir()))
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	d := &Discoverer{root: "/sys/fs/cgroup"}
+
+	tests := map[string]struct {
+		path     string
+		unitType UnitType
+	}{
+		"root":            {path: "/sys/fs/cgroup"},
+		"service":         {path: "/sys/fs/cgroup/system.slice/nginx.service", unitType: UnitTypeService},
+		"user_service":    {path: "/sys/fs/cgroup/user.slice/user-1000.slice/user@1000.service/app.slice/foo.service", unitType: UnitTypeService},
+		"slice":           {path: "/sys/fs/cgroup/system.slice", unitType: UnitTypeSlice},
+		"scope":           {path: "/sys/fs/cgroup/system.slice/docker-1234.scope"},
+		"mount":           {path: "/sys/fs/cgroup/system.slice/boot.mount"},
+		"cgroup_in_root":  {path: "/sys/fs/cgroup/batch", unitType: UnitTypeCgroup},
+		"cgroup_in_slice": {path: "/sys/fs/cgroup/system.slice/batch", unitType: UnitTypeCgroup},
+		"service_child":   {path: "/sys/fs/cgroup/system.slice/nginx.service/payload"},
+		"cgroup_child":    {path: "/sys/fs/cgroup/batch/job1"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			unitType, ok := d.classify(test.path)
+			require.Equal(t, test.unitType != "", ok)
+			require.Equal(t, test.unitType, unitType)
+		})
+	}
+}
@@ -31,6 +31,7 @@ import (
 
 	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/run"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgettracermanager"
 )
 
@@ -52,6 +53,8 @@ type TraceReconciler struct {
 	// TraceFactories contains the trace factories keyed by the gadget name
 	TraceFactories map[string]gadgets.TraceFactory
 	TracerManager  *gadgettracermanager.GadgetTracerManager
+
+	scheduler *traceScheduler
 }
 
 func updateTraceStatus(ctx context.Context, cli client.Client,
@@ -87,6 +90,7 @@ func setTraceOpError(ctx context.Context, cli client.Client,
 //+kubebuilder:rbac:groups=gadget.kinvolk.io,resources=traces,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=gadget.kinvolk.io,resources=traces/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=gadget.kinvolk.io,resources=traces/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -114,6 +118,8 @@ func (r *TraceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		return ctrl.Result{}, nil
 	}
 
+	trace.Spec.Gadget = traceGadget(trace)
+
 	log.Infof("Reconcile trace %s (gadget %s, node %s)",
 		req.NamespacedName,
 		trace.Spec.Gadget,
@@ -123,6 +129,10 @@ func (r *TraceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	// checking the Trace specs to avoid blocking the deletion.
 	if !trace.ObjectMeta.DeletionTimestamp.IsZero() {
 		if controllerutil.ContainsFinalizer(trace, GadgetFinalizer) {
+			if r.scheduler != nil {
+				r.scheduler.Remove(req.NamespacedName)
+			}
+
 			// Inform the factory (if valid gadget) that the trace is being deleted
 			factory, ok := r.TraceFactories[trace.Spec.Gadget]
 			if ok {
@@ -168,6 +178,18 @@ func (r *TraceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 
 		return ctrl.Result{}, nil
 	}
+	if err := gadgets.ValidateContainerFilter(trace.Spec.Filter); err != nil {
+		setTraceOpError(ctx, r.Client, req.NamespacedName.String(),
+			trace, fmt.Sprintf("Invalid filter: %s", err))
+
+		return ctrl.Result{}, nil
+	}
+	if err := validateSchedule(trace.Spec.Schedule); err != nil {
+		setTraceOpError(ctx, r.Client, req.NamespacedName.String(),
+			trace, fmt.Sprintf("Invalid schedule: %s", err))
+
+		return ctrl.Result{}, nil
+	}
 	outputModes := factory.OutputModesSupported()
 	if _, ok := outputModes[trace.Spec.OutputMode]; !ok {
 		setTraceOpError(ctx, r.Client, req.NamespacedName.String(),
@@ -197,6 +219,15 @@ func (r *TraceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		}
 	}
 
+	// Register the schedule after the tracer: the OOMKill trigger uses its
+	// mount ns map
+	if r.scheduler != nil {
+		if err := r.scheduler.Update(trace); err != nil {
+			setTraceOpError(ctx, r.Client, req.NamespacedName.String(),
+				trace, fmt.Sprintf("Failed to schedule trace: %s", err))
+		}
+	}
+
 	// Lookup annotations
 	if trace.ObjectMeta.Annotations == nil {
 		log.Info("No annotations. Nothing to do.")
@@ -262,8 +293,22 @@ func (r *TraceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	return ctrl.Result{}, nil
 }
 
+// traceGadget returns the name of the gadget handling the trace. Image-based
+// gadgets are handled by the run gadget.
+func traceGadget(trace *gadgetv1alpha1.Trace) string {
+	if trace.Spec.Gadget == "" && trace.Spec.Image != "" {
+		return run.GadgetName
+	}
+	return trace.Spec.Gadget
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *TraceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.scheduler = newTraceScheduler(r.Client, r.TraceFactories, r.TracerManager)
+	if err := mgr.Add(r.scheduler); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&gadgetv1alpha1.Trace{}).
 		Complete(r)
@@ -0,0 +1,452 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	oomkilltracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/oomkill/tracer"
+	oomkilltypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/oomkill/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgettracermanager"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	// defaultRunDuration is used when TraceSchedule.Duration is not set
+	defaultRunDuration = 5 * time.Minute
+
+	// defaultHistoryLimit is used when TraceSchedule.HistoryLimit is not set
+	defaultHistoryLimit = 10
+
+	// maxRunOutputSize is the size of the output kept for each run in the
+	// history, so that it can't make the Trace exceed the etcd size limit
+	maxRunOutputSize = 4096
+)
+
+// traceScheduler runs the traces having a Spec.Schedule. Runs are started by
+// a cron schedule or by triggers, and are driven through the same operations
+// as the gadget.kinvolk.io/operation annotation.
+type traceScheduler struct {
+	client        client.Client
+	factories     map[string]gadgets.TraceFactory
+	tracerManager *gadgettracermanager.GadgetTracerManager
+
+	cron *cron.Cron
+
+	mu     sync.Mutex
+	traces map[k8stypes.NamespacedName]*scheduledTrace
+}
+
+type scheduledTrace struct {
+	generation int64
+	schedule   *gadgetv1alpha1.TraceSchedule
+
+	cronID     cron.EntryID
+	subscribed bool
+	oomTracer  *oomkilltracer.Tracer
+
+	running  bool
+	runTimer *time.Timer
+	run      gadgetv1alpha1.TraceRun
+}
+
+func newTraceScheduler(
+	cli client.Client,
+	factories map[string]gadgets.TraceFactory,
+	tracerManager *gadgettracermanager.GadgetTracerManager,
+) *traceScheduler {
+	return &traceScheduler{
+		client:        cli,
+		factories:     factories,
+		tracerManager: tracerManager,
+		cron:          cron.New(),
+		traces:        make(map[k8stypes.NamespacedName]*scheduledTrace),
+	}
+}
+
+// Start implements manager.Runnable. It runs the cron scheduler until the
+// context is cancelled.
+func (s *traceScheduler) Start(ctx context.Context) error {
+	s.cron.Start()
+	<-ctx.Done()
+	<-s.cron.Stop().Done()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.traces {
+		s.removeLocked(key)
+	}
+	return nil
+}
+
+// validateSchedule checks the schedule without registering it.
+func validateSchedule(schedule *gadgetv1alpha1.TraceSchedule) error {
+	if schedule == nil {
+		return nil
+	}
+	if schedule.Cron == "" && len(schedule.Triggers) == 0 {
+		return errors.New("schedule needs a cron expression or triggers")
+	}
+	if schedule.Cron != "" {
+		if _, err := cron.ParseStandard(schedule.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression %q: %w", schedule.Cron, err)
+		}
+	}
+	if schedule.Duration != nil && schedule.Duration.Duration <= 0 {
+		return fmt.Errorf("invalid duration %s", schedule.Duration.Duration)
+	}
+	for _, trigger := range schedule.Triggers {
+		switch trigger {
+		case gadgetv1alpha1.TraceTriggerPodStart, gadgetv1alpha1.TraceTriggerOOMKill:
+		default:
+			return fmt.Errorf("unknown trigger %q", trigger)
+		}
+	}
+	return nil
+}
+
+// Update registers, updates or removes the schedule of the trace. It does
+// nothing if the spec of the trace didn't change since the last call.
+func (s *traceScheduler) Update(trace *gadgetv1alpha1.Trace) error {
+	key := k8stypes.NamespacedName{Namespace: trace.Namespace, Name: trace.Name}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if trace.Spec.Schedule == nil {
+		s.removeLocked(key)
+		return nil
+	}
+	old, ok := s.traces[key]
+	if ok && old.generation == trace.Generation {
+		return nil
+	}
+
+	if err := validateSchedule(trace.Spec.Schedule); err != nil {
+		s.removeLocked(key)
+		return err
+	}
+
+	st := &scheduledTrace{
+		generation: trace.Generation,
+		schedule:   trace.Spec.Schedule.DeepCopy(),
+	}
+
+	// A run in progress continues with the new schedule
+	if ok {
+		s.stopTriggers(key, old)
+		st.running, st.runTimer, st.run = old.running, old.runTimer, old.run
+		delete(s.traces, key)
+	}
+
+	if st.schedule.Cron != "" {
+		schedule, err := cron.ParseStandard(st.schedule.Cron)
+		if err != nil {
+			return fmt.Errorf("invalid cron expression %q: %w", st.schedule.Cron, err)
+		}
+		st.cronID = s.cron.Schedule(schedule, cron.FuncJob(func() {
+			s.startRun(key, gadgetv1alpha1.TraceRunReasonSchedule, "")
+		}))
+	}
+
+	for _, trigger := range st.schedule.Triggers {
+		if err := s.addTrigger(key, trace, st, trigger); err != nil {
+			s.stopTriggers(key, st)
+			if st.runTimer != nil {
+				st.runTimer.Stop()
+			}
+			return err
+		}
+	}
+
+	s.traces[key] = st
+	return nil
+}
+
+func (s *traceScheduler) addTrigger(
+	key k8stypes.NamespacedName,
+	trace *gadgetv1alpha1.Trace,
+	st *scheduledTrace,
+	trigger gadgetv1alpha1.TraceTrigger,
+) error {
+	if s.tracerManager == nil {
+		return fmt.Errorf("trigger %q needs the gadget tracer manager", trigger)
+	}
+
+	switch trigger {
+	case gadgetv1alpha1.TraceTriggerPodStart:
+		// Existing containers returned by Subscribe() don't trigger a run
+		s.tracerManager.Subscribe(
+			schedulerPubSubKey(key),
+			*gadgets.ContainerSelectorFromContainerFilter(trace.Spec.Filter),
+			func(event containercollection.PubSubEvent) {
+				if event.Type != containercollection.EventTypeAddContainer {
+					return
+				}
+				c := event.Container
+				go s.startRun(key, string(trigger),
+					fmt.Sprintf("container %s/%s/%s started", c.K8s.Namespace, c.K8s.PodName, c.K8s.ContainerName))
+			},
+		)
+		st.subscribed = true
+	case gadgetv1alpha1.TraceTriggerOOMKill:
+		// The mount ns map of the trace is registered by the reconciler
+		// with the filter of the trace.
+		mountNsMap, err := s.tracerManager.TracerMountNsMap(gadgets.TraceNameFromNamespacedName(key))
+		if err != nil {
+			return fmt.Errorf("finding tracer's mount ns map: %w", err)
+		}
+		eventCallback := func(event *oomkilltypes.Event) {
+			if event.Type != eventtypes.NORMAL {
+				return
+			}
+			go s.startRun(key, string(trigger),
+				fmt.Sprintf("process %s (%d) of %s/%s/%s OOM-killed", event.KilledComm, event.KilledPid,
+					event.K8s.Namespace, event.K8s.PodName, event.K8s.ContainerName))
+		}
+		st.oomTracer, err = oomkilltracer.NewTracer(&oomkilltracer.Config{MountnsMap: mountNsMap},
+			s.tracerManager, eventCallback)
+		if err != nil {
+			return fmt.Errorf("creating oomkill tracer: %w", err)
+		}
+	}
+	return nil
+}
+
+// Remove unregisters the schedule of the trace. A run in progress is not
+// finished: the trace is expected to be deleted.
+func (s *traceScheduler) Remove(key k8stypes.NamespacedName) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(key)
+}
+
+func (s *traceScheduler) removeLocked(key k8stypes.NamespacedName) {
+	st, ok := s.traces[key]
+	if !ok {
+		return
+	}
+	s.stopTriggers(key, st)
+	if st.runTimer != nil {
+		st.runTimer.Stop()
+	}
+	delete(s.traces, key)
+}
+
+// stopTriggers stops the cron schedule and the triggers of the trace.
+func (s *traceScheduler) stopTriggers(key k8stypes.NamespacedName, st *scheduledTrace) {
+	if st.cronID != 0 {
+		s.cron.Remove(st.cronID)
+	}
+	if st.subscribed {
+		s.tracerManager.Unsubscribe(schedulerPubSubKey(key))
+	}
+	if st.oomTracer != nil {
+		st.oomTracer.Stop()
+	}
+}
+
+type pubSubKey string
+
+func schedulerPubSubKey(key k8stypes.NamespacedName) pubSubKey {
+	return pubSubKey("trace-scheduler/" + key.String())
+}
+
+// startRun starts a run of the trace, unless one is already in progress.
+func (s *traceScheduler) startRun(key k8stypes.NamespacedName, reason, details string) {
+	s.mu.Lock()
+	st, ok := s.traces[key]
+	if !ok || st.running {
+		s.mu.Unlock()
+		return
+	}
+	st.running = true
+	st.run = gadgetv1alpha1.TraceRun{
+		Reason:    reason,
+		Details:   details,
+		StartTime: metav1.Now(),
+	}
+	duration := defaultRunDuration
+	if st.schedule.Duration != nil {
+		duration = st.schedule.Duration.Duration
+	}
+	s.mu.Unlock()
+
+	log.Infof("Starting scheduled run of trace %s (%s)", key, reason)
+
+	operations, err := s.operations(key)
+	if err != nil {
+		s.finishRun(key, nil, err.Error())
+		return
+	}
+
+	// Gadgets without start operation, like snapshot ones, run at once
+	if _, ok := operations[gadgetv1alpha1.OperationStart]; !ok {
+		trace, opErr := s.applyOperation(key, gadgetv1alpha1.OperationCollect)
+		s.finishRun(key, trace, opErr)
+		return
+	}
+
+	trace, opErr := s.applyOperation(key, gadgetv1alpha1.OperationStart)
+	if opErr != "" {
+		s.finishRun(key, trace, opErr)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.traces[key]; ok && st.running {
+		st.runTimer = time.AfterFunc(duration, func() {
+			s.endRun(key)
+		})
+	}
+}
+
+// endRun finishes a run started with the start operation.
+func (s *traceScheduler) endRun(key k8stypes.NamespacedName) {
+	log.Infof("Finishing scheduled run of trace %s", key)
+
+	var errs []string
+	operations, err := s.operations(key)
+	if err != nil {
+		s.finishRun(key, nil, err.Error())
+		return
+	}
+	if _, ok := operations[gadgetv1alpha1.OperationGenerate]; ok {
+		if _, opErr := s.applyOperation(key, gadgetv1alpha1.OperationGenerate); opErr != "" {
+			errs = append(errs, opErr)
+		}
+	}
+	trace, opErr := s.applyOperation(key, gadgetv1alpha1.OperationStop)
+	if opErr != "" {
+		errs = append(errs, opErr)
+	}
+	s.finishRun(key, trace, strings.Join(errs, "; "))
+}
+
+// finishRun records the run in the history of the trace.
+func (s *traceScheduler) finishRun(key k8stypes.NamespacedName, trace *gadgetv1alpha1.Trace, runErr string) {
+	s.mu.Lock()
+	st, ok := s.traces[key]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	run := st.run
+	st.running = false
+	st.runTimer = nil
+	limit := int(st.schedule.HistoryLimit)
+	s.mu.Unlock()
+
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+
+	now := metav1.Now()
+	run.EndTime = &now
+	run.Error = runErr
+	run.Result = gadgetv1alpha1.TraceRunResultSucceeded
+	if runErr != "" {
+		run.Result = gadgetv1alpha1.TraceRunResultFailed
+	}
+
+	if trace == nil {
+		trace = &gadgetv1alpha1.Trace{}
+		if err := s.client.Get(context.TODO(), key, trace); err != nil {
+			log.Errorf("Failed to get trace %q: %s", key, err)
+			return
+		}
+	}
+	run.Output = truncateOutput(trace.Status.Output, maxRunOutputSize)
+	run.OutputSize = len(trace.Status.Output)
+
+	patch := client.MergeFrom(trace.DeepCopy())
+	trace.Status.History = appendRunHistory(trace.Status.History, run, limit)
+	updateTraceStatus(context.TODO(), s.client, key.String(), trace, patch)
+}
+
+// appendRunHistory appends run to history, keeping at most limit runs.
+func appendRunHistory(history []gadgetv1alpha1.TraceRun, run gadgetv1alpha1.TraceRun, limit int) []gadgetv1alpha1.TraceRun {
+	history = append(history, run)
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history
+}
+
+// truncateOutput returns the first bytes of output, at most size, without
+// splitting a UTF-8 character.
+func truncateOutput(output string, size int) string {
+	if len(output) <= size {
+		return output
+	}
+	for size > 0 && !utf8.RuneStart(output[size]) {
+		size--
+	}
+	return output[:size]
+}
+
+func (s *traceScheduler) operations(key k8stypes.NamespacedName) (map[gadgetv1alpha1.Operation]gadgets.TraceOperation, error) {
+	trace := &gadgetv1alpha1.Trace{}
+	if err := s.client.Get(context.TODO(), key, trace); err != nil {
+		return nil, fmt.Errorf("getting trace: %w", err)
+	}
+	factory, ok := s.factories[traceGadget(trace)]
+	if !ok {
+		return nil, fmt.Errorf("unknown gadget %q", trace.Spec.Gadget)
+	}
+	return factory.Operations(), nil
+}
+
+// applyOperation calls the operation of the gadget on the latest version of
+// the trace, as if it was requested with the gadget.kinvolk.io/operation
+// annotation. It returns the updated trace and the operation error.
+func (s *traceScheduler) applyOperation(key k8stypes.NamespacedName, op gadgetv1alpha1.Operation) (*gadgetv1alpha1.Trace, string) {
+	trace := &gadgetv1alpha1.Trace{}
+	if err := s.client.Get(context.TODO(), key, trace); err != nil {
+		return nil, fmt.Sprintf("getting trace: %s", err)
+	}
+	factory, ok := s.factories[traceGadget(trace)]
+	if !ok {
+		return trace, fmt.Sprintf("Unknown gadget %q", trace.Spec.Gadget)
+	}
+	gadgetOperation, ok := factory.Operations()[op]
+	if !ok {
+		return trace, fmt.Sprintf("Unsupported operation %q for gadget %q", op, trace.Spec.Gadget)
+	}
+
+	patch := client.MergeFrom(trace.DeepCopy())
+	trace.Status.OperationError = ""
+	trace.Status.OperationWarning = ""
+	gadgetOperation.Operation(key.String(), trace)
+	updateTraceStatus(context.TODO(), s.client, key.String(), trace, patch)
+
+	return trace, trace.Status.OperationError
+}
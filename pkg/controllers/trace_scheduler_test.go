@@ -0,0 +1,129 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		schedule *gadgetv1alpha1.TraceSchedule
+		valid    bool
+	}{
+		"nil": {
+			valid: true,
+		},
+		"empty": {
+			schedule: &gadgetv1alpha1.TraceSchedule{},
+		},
+		"cron": {
+			schedule: &gadgetv1alpha1.TraceSchedule{Cron: "0 2 * * *"},
+			valid:    true,
+		},
+		"cron_descriptor": {
+			schedule: &gadgetv1alpha1.TraceSchedule{Cron: "@hourly"},
+			valid:    true,
+		},
+		"invalid_cron": {
+			schedule: &gadgetv1alpha1.TraceSchedule{Cron: "every day"},
+		},
+		"triggers": {
+			schedule: &gadgetv1alpha1.TraceSchedule{
+				Triggers: []gadgetv1alpha1.TraceTrigger{
+					gadgetv1alpha1.TraceTriggerPodStart,
+					gadgetv1alpha1.TraceTriggerOOMKill,
+				},
+				Duration: &metav1.Duration{Duration: time.Minute},
+			},
+			valid: true,
+		},
+		"unknown_trigger": {
+			schedule: &gadgetv1alpha1.TraceSchedule{
+				Triggers: []gadgetv1alpha1.TraceTrigger{"NodeReboot"},
+			},
+		},
+		"negative_duration": {
+			schedule: &gadgetv1alpha1.TraceSchedule{
+				Cron:     "@daily",
+				Duration: &metav1.Duration{Duration: -time.Minute},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateSchedule(test.schedule)
+			if test.valid && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !test.valid && err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+func TestAppendRunHistory(t *testing.T) {
+	t.Parallel()
+
+	var history []gadgetv1alpha1.TraceRun
+	for i := 0; i < 5; i++ {
+		history = appendRunHistory(history, gadgetv1alpha1.TraceRun{
+			Reason:     gadgetv1alpha1.TraceRunReasonSchedule,
+			OutputSize: i,
+		}, 3)
+	}
+
+	if len(history) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(history))
+	}
+	if history[0].OutputSize != 2 || history[2].OutputSize != 4 {
+		t.Fatalf("expected the last runs to be kept, got %+v", history)
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		output   string
+		size     int
+		expected string
+	}{
+		{output: "short", size: 10, expected: "short"},
+		{output: "exactly", size: 7, expected: "exactly"},
+		{output: "too long", size: 3, expected: "too"},
+		// "é" takes two bytes and isn't split
+		{output: "café", size: 4, expected: "caf"},
+		{output: "café", size: 5, expected: "café"},
+	}
+
+	for _, test := range tests {
+		if got := truncateOutput(test.output, test.size); got != test.expected {
+			t.Fatalf("truncateOutput(%q, %d): expected %q, got %q", test.output, test.size, test.expected, got)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	auditseccomp "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/audit/seccomp"
 	biolatency "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/profile/block-io"
 	profile "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/profile/cpu"
+	run "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/run"
 	processcollector "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/snapshot/process"
 	socketcollector "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/snapshot/socket"
 	biotop "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/top/block-io"
@@ -60,6 +61,7 @@ func TraceFactories() map[string]gadgets.TraceFactory {
 		"oomkill":           oomkill.NewFactory(),
 		"process-collector": processcollector.NewFactory(),
 		"profile":           profile.NewFactory(),
+		run.GadgetName:      run.NewFactory(),
 		"seccomp":           seccomp.NewFactory(),
 		"sigsnoop":          sigsnoop.NewFactory(),
 		"snisnoop":          snisnoop.NewFactory(),
@@ -35,6 +35,7 @@ import (
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
 	seccomptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
+	seccomptypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/types"
 )
 
 type Trace struct {
@@ -298,7 +299,7 @@ func getSeccompProfileNsName(
 
 // generateSeccompPolicy generates a seccomp policy which is ready to be
 // created.
-func generateSeccompPolicy(client client.Client, trace *gadgetv1alpha1.Trace, syscallNames []string, podname, containername, fullPodName string, ownerReference *metav1.OwnerReference) (*seccompprofile.SeccompProfile, error) {
+func generateSeccompPolicy(client client.Client, trace *gadgetv1alpha1.Trace, syscalls seccomptypes.Syscalls, podname, containername, fullPodName string, ownerReference *metav1.OwnerReference) (*seccompprofile.SeccompProfile, error) {
 	profileName, err := getSeccompProfileNsName(
 		client,
 		trace.ObjectMeta.Namespace,
@@ -309,7 +310,7 @@ func generateSeccompPolicy(client client.Client, trace *gadgetv1alpha1.Trace, sy
 		return nil, fmt.Errorf("getting the profile name: %w", err)
 	}
 
-	r := syscallNamesToSeccompPolicy(profileName, syscallNames)
+	r := syscallsToSeccompPolicy(profileName, syscalls)
 	seccompProfileAddLabelsAndAnnotations(r, trace, fullPodName, containername, ownerReference)
 
 	return r, nil
@@ -331,8 +332,8 @@ func (t *Trace) containerTerminated(trace *gadgetv1alpha1.Trace, event container
 
 	traceName := fmt.Sprintf("%s/%s", trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
 
-	// Get the list of syscallNames from the BPF hash map
-	syscallNames, err := traceSingleton.tracer.Peek(event.Container.Mntns)
+	// Get the syscalls from the BPF hash map
+	syscalls, err := traceSingleton.tracer.Peek(event.Container.Mntns)
 	if err != nil {
 		log.Errorf("peeking syscalls for mntns %d: %s", event.Container.Mntns, err)
 		return
@@ -346,7 +347,7 @@ func (t *Trace) containerTerminated(trace *gadgetv1alpha1.Trace, event container
 	// This field was fetched when the container was created
 	ownerReference := getContainerOwnerReference(event.Container)
 
-	r, err := generateSeccompPolicy(t.client, trace, syscallNames, event.Container.K8s.PodName,
+	r, err := generateSeccompPolicy(t.client, trace, syscalls, event.Container.K8s.PodName,
 		event.Container.K8s.ContainerName, namespacedName, ownerReference)
 	if err != nil {
 		log.Errorf("Trace %s: %v", traceName, err)
@@ -519,8 +520,8 @@ func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
 		}
 	}
 
-	// Get the list of syscallNames from the BPF hash map
-	syscallNames, err := traceSingleton.tracer.Peek(mntns)
+	// Get the syscalls from the BPF hash map
+	syscalls, err := traceSingleton.tracer.Peek(mntns)
 	if err != nil {
 		trace.Status.OperationError = fmt.Sprintf("peeking syscalls for mntns %d: %s", mntns, err)
 		return
@@ -528,7 +529,7 @@ func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
 
 	switch trace.Spec.OutputMode {
 	case gadgetv1alpha1.TraceOutputModeStatus:
-		policy := seccomptracer.SyscallNamesToLinuxSeccomp(syscallNames)
+		policy := seccomptracer.SyscallsToLinuxSeccomp(syscalls)
 		output, err := json.MarshalIndent(policy, "", "  ")
 		if err != nil {
 			trace.Status.OperationError = fmt.Sprintf("Failed to marshal seccomp policy: %s", err)
@@ -541,7 +542,7 @@ func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
 
 		ownerReference := t.helpers.LookupOwnerReferenceByMntns(mntns)
 
-		r, err := generateSeccompPolicy(t.client, trace, syscallNames, trace.Spec.Filter.Podname, containerName, podName, ownerReference)
+		r, err := generateSeccompPolicy(t.client, trace, syscalls, trace.Spec.Filter.Podname, containerName, podName, ownerReference)
 		if err != nil {
 			trace.Status.OperationError = err.Error()
 			return
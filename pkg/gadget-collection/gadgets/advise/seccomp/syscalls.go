@@ -20,14 +20,15 @@ package seccomp
 import (
 	commonseccomp "github.com/containers/common/pkg/seccomp"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	seccompprofile "sigs.k8s.io/security-profiles-operator/api/seccompprofile/v1beta1"
 )
 
-func syscallNamesToSeccompPolicy(profileName *SeccompProfileNsName, syscallNames []string) *seccompprofile.SeccompProfile {
+func syscallsToSeccompPolicy(profileName *SeccompProfileNsName, s types.Syscalls) *seccompprofile.SeccompProfile {
 	syscalls := []*seccompprofile.Syscall{
 		{
-			Names:  syscallNames,
+			Names:  s.Names(),
 			Action: commonseccomp.ActAllow,
 			Args:   []*seccompprofile.Arg{},
 		},
@@ -53,7 +54,11 @@ func syscallNamesToSeccompPolicy(profileName *SeccompProfileNsName, syscallNames
 		ret.ObjectMeta.Name = profileName.name
 	}
 
-	for _, a := range tracer.Arches() {
+	arches := tracer.Arches()
+	if len(s) > 0 {
+		arches = tracer.SeccompArches(s.Arches())
+	}
+	for _, a := range arches {
 		arch := seccompprofile.Arch(a)
 		ret.Spec.Architectures = append(ret.Spec.Architectures, arch)
 	}
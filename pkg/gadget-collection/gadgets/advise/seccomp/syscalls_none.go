@@ -19,9 +19,11 @@ package seccomp
 
 import (
 	seccompprofile "sigs.k8s.io/security-profiles-operator/api/seccompprofile/v1beta1"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/types"
 )
 
-func syscallNamesToSeccompPolicy(profileName *SeccompProfileNsName, syscallNames []string) *seccompprofile.SeccompProfile {
+func syscallsToSeccompPolicy(profileName *SeccompProfileNsName, s types.Syscalls) *seccompprofile.SeccompProfile {
 	panic("Not implemented")
 	return nil
 }
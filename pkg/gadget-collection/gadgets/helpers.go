@@ -15,6 +15,10 @@
 package gadgets
 
 import (
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/labels"
 	k8sTypes "k8s.io/apimachinery/pkg/types"
 
 	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
@@ -30,22 +34,51 @@ func TraceNameFromNamespacedName(n k8sTypes.NamespacedName) string {
 	return TraceName(n.Namespace, n.Name)
 }
 
+// ValidateContainerFilter checks the fields of the filter that need to be
+// parsed. ContainerSelectorFromContainerFilter ignores invalid values, so the
+// controller calls this before starting any gadget.
+func ValidateContainerFilter(f *gadgetv1alpha1.ContainerFilter) error {
+	if f == nil {
+		return nil
+	}
+	if _, err := labels.Parse(f.Selector); err != nil {
+		return fmt.Errorf("invalid selector %q: %w", f.Selector, err)
+	}
+	if f.PodnameRegex != "" {
+		if _, err := regexp.Compile(f.PodnameRegex); err != nil {
+			return fmt.Errorf("invalid podname regex %q: %w", f.PodnameRegex, err)
+		}
+	}
+	return nil
+}
+
 func ContainerSelectorFromContainerFilter(f *gadgetv1alpha1.ContainerFilter) *containercollection.ContainerSelector {
 	if f == nil {
 		return &containercollection.ContainerSelector{}
 	}
-	labels := map[string]string{}
+	podLabels := map[string]string{}
 	for k, v := range f.Labels {
-		labels[k] = v
+		podLabels[k] = v
 	}
-	return &containercollection.ContainerSelector{
+	selector := &containercollection.ContainerSelector{
 		K8s: containercollection.K8sSelector{
 			BasicK8sMetadata: types.BasicK8sMetadata{
 				Namespace:     f.Namespace,
 				PodName:       f.Podname,
 				ContainerName: f.ContainerName,
 			},
-			PodLabels: labels,
+			PodLabels:        podLabels,
+			ExcludeNamespace: f.ExcludeNamespace,
 		},
 	}
+	if f.Selector != "" {
+		selector.K8s.PodLabelSelector, _ = labels.Parse(f.Selector)
+	}
+	if f.PodnameRegex != "" {
+		selector.K8s.PodNameRegex, _ = regexp.Compile("^(?:" + f.PodnameRegex + ")$")
+	}
+	selector.K8s.OwnerKind, selector.K8s.OwnerName = containercollection.ParseOwnerSelector(f.Owner)
+	selector.Runtime.ContainerImageName, selector.Runtime.ContainerImageDigest =
+		containercollection.ParseImageSelector(f.ContainerImage)
+	return selector
 }
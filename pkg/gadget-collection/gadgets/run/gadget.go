@@ -0,0 +1,525 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package run allows the Trace CRD to run image-based gadgets. Gadgets are
+// run through the same runtime and operators pipeline as "kubectl gadget
+// run", so all the parameters accepted by the CLI can be given in
+// Trace.Spec.Parameters.
+package run
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	igadgets "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	runTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/run/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/kubemanager"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime/local"
+)
+
+// GadgetName is the name of this gadget in Trace.Spec.Gadget. The controller
+// uses it by default when Trace.Spec.Image is set.
+const GadgetName = "run"
+
+// maxStatusLines is the number of output lines kept for the Status and
+// ExternalResource output modes. Older lines are discarded to keep the
+// resources below the etcd object size limit.
+const maxStatusLines = 1000
+
+// outputKey is the key of the ConfigMap data holding the output of the gadget
+// with OutputMode=ExternalResource.
+const outputKey = "output"
+
+type Trace struct {
+	helpers gadgets.GadgetHelpers
+	client  client.Client
+	runtime *local.Runtime
+
+	mu      sync.Mutex
+	started bool
+	cancel  func()
+	done    chan struct{}
+	runErr  error
+
+	header string
+	lines  []string
+}
+
+type TraceFactory struct {
+	gadgets.BaseFactory
+
+	runtimeOnce sync.Once
+	runtime     *local.Runtime
+	runtimeErr  error
+}
+
+func NewFactory() gadgets.TraceFactory {
+	return &TraceFactory{
+		BaseFactory: gadgets.BaseFactory{DeleteTrace: deleteTrace},
+	}
+}
+
+func (f *TraceFactory) Description() string {
+	return `The run gadget runs image-based gadgets. The image is given in
+Trace.Spec.Image and is run with the same runtime and operators as "kubectl
+gadget run". Trace.Spec.Parameters accepts the same parameters as the CLI,
+operator parameters being prefixed with "operator.<name>.". The filter is
+translated into parameters of the KubeManager operator.
+
+With OutputMode=Status, the last events are written to Trace.Status.Output
+by the generate and stop operations. With OutputMode=ExternalResource, they
+are written to the ConfigMap named in Trace.Spec.Output, in the namespace of
+the trace. Trace.Spec.OutputFormat selects "JSON" or "Columns".`
+}
+
+func (f *TraceFactory) OutputModesSupported() map[gadgetv1alpha1.TraceOutputMode]struct{} {
+	return map[gadgetv1alpha1.TraceOutputMode]struct{}{
+		gadgetv1alpha1.TraceOutputModeStatus:           {},
+		gadgetv1alpha1.TraceOutputModeStream:           {},
+		gadgetv1alpha1.TraceOutputModeExternalResource: {},
+	}
+}
+
+func deleteTrace(name string, t interface{}) {
+	trace := t.(*Trace)
+	trace.stop()
+}
+
+func (f *TraceFactory) localRuntime() (*local.Runtime, error) {
+	f.runtimeOnce.Do(func() {
+		f.runtime = local.New()
+		f.runtimeErr = f.runtime.Init(f.runtime.GlobalParamDescs().ToParams())
+	})
+	return f.runtime, f.runtimeErr
+}
+
+func (f *TraceFactory) Operations() map[gadgetv1alpha1.Operation]gadgets.TraceOperation {
+	n := func() interface{} {
+		return &Trace{
+			client:  f.Client,
+			helpers: f.Helpers,
+		}
+	}
+	return map[gadgetv1alpha1.Operation]gadgets.TraceOperation{
+		gadgetv1alpha1.OperationStart: {
+			Doc: "Start the image-based gadget",
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				t := f.LookupOrCreate(name, n).(*Trace)
+				if t.runtime == nil {
+					runtime, err := f.localRuntime()
+					if err != nil {
+						trace.Status.OperationError = fmt.Sprintf("initializing runtime: %s", err)
+						return
+					}
+					t.runtime = runtime
+				}
+				t.Start(trace)
+			},
+			Order: 1,
+		},
+		gadgetv1alpha1.OperationGenerate: {
+			Doc: `Write the last events to Trace.Status.Output or to the ConfigMap named in
+Trace.Spec.Output, depending on the OutputMode.`,
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Generate(trace)
+			},
+			Order: 2,
+		},
+		gadgetv1alpha1.OperationStop: {
+			Doc: "Stop the image-based gadget and write its last events",
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Stop(trace)
+			},
+			Order: 3,
+		},
+	}
+}
+
+// ParamsFromTrace returns the parameters used to run the gadget of the trace,
+// as expected by gadgets.ParamsFromMap(). The filter is translated into
+// parameters of the KubeManager operator; explicit parameters take
+// precedence over it.
+func ParamsFromTrace(trace *gadgetv1alpha1.Trace) map[string]string {
+	paramMap := make(map[string]string)
+
+	if f := trace.Spec.Filter; f != nil {
+		prefix := "operator." + kubemanager.OperatorName + "."
+		set := func(key, value string) {
+			if value != "" {
+				paramMap[prefix+key] = value
+			}
+		}
+
+		selectors := make([]string, 0, len(f.Labels)+1)
+		for k, v := range f.Labels {
+			selectors = append(selectors, k+"="+v)
+		}
+		sort.Strings(selectors)
+		if f.Selector != "" {
+			selectors = append(selectors, f.Selector)
+		}
+
+		set(kubemanager.ParamNamespace, f.Namespace)
+		set(kubemanager.ParamPodName, f.Podname)
+		set(kubemanager.ParamContainerName, f.ContainerName)
+		set(kubemanager.ParamSelector, strings.Join(selectors, ","))
+		set(kubemanager.ParamPodNameRegex, f.PodnameRegex)
+		set(kubemanager.ParamOwner, f.Owner)
+		set(kubemanager.ParamImage, f.ContainerImage)
+		set(kubemanager.ParamExcludeNs, f.ExcludeNamespace)
+	}
+
+	for k, v := range trace.Spec.Parameters {
+		paramMap[k] = v
+	}
+
+	return paramMap
+}
+
+func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.started {
+		trace.Status.State = gadgetv1alpha1.TraceStateStarted
+		return
+	}
+
+	if trace.Spec.Image == "" {
+		trace.Status.OperationError = "Missing image"
+		return
+	}
+
+	gadgetDesc := gadgetregistry.Get(igadgets.CategoryNone, GadgetName)
+	if gadgetDesc == nil {
+		trace.Status.OperationError = "run gadget not available"
+		return
+	}
+	runDesc, ok := gadgetDesc.(runTypes.RunGadgetDesc)
+	if !ok {
+		trace.Status.OperationError = "run gadget does not support images"
+		return
+	}
+
+	err := operators.GetAll().Init(operators.GlobalParamsCollection())
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("initializing operators: %s", err)
+		return
+	}
+
+	// Same steps as RunGadget() of the gadget service
+	ops := operators.GetOperatorsForGadget(gadgetDesc)
+	operatorParams := ops.ParamCollection()
+	runtimeParams := t.runtime.ParamDescs().ToParams()
+	gadgetParamDescs := gadgetDesc.ParamDescs()
+	gadgetParamDescs.Add(igadgets.GadgetParams(gadgetDesc, gadgetDesc.Type(), gadgetDesc.Parser())...)
+	gadgetParams := gadgetParamDescs.ToParams()
+
+	paramMap := ParamsFromTrace(trace)
+	err = igadgets.ParamsFromMap(paramMap, gadgetParams, runtimeParams, operatorParams)
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("setting parameters: %s", err)
+		return
+	}
+
+	args := []string{trace.Spec.Image}
+
+	gadgetInfo, err := t.runtime.GetGadgetInfo(context.TODO(), gadgetDesc, gadgetParams, args)
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("getting gadget info: %s", err)
+		return
+	}
+	parser, err := runDesc.CustomParser(gadgetInfo)
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("calling custom parser: %s", err)
+		return
+	}
+
+	for _, p := range gadgetInfo.GadgetMetadata.EBPFParams {
+		p := p
+		gadgetParamDescs.Add(&p.ParamDesc)
+	}
+	gadgetParamDescs.Add(igadgets.GadgetParams(gadgetDesc, gadgetInfo.GadgetType, parser)...)
+	gadgetParams = gadgetParamDescs.ToParams()
+	err = gadgetParams.CopyFromMap(paramMap, "")
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("setting parameters: %s", err)
+		return
+	}
+
+	traceName := gadgets.TraceName(trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
+	output := t.appendLine
+	if trace.Spec.OutputMode == gadgetv1alpha1.TraceOutputModeStream {
+		output = func(line string) {
+			t.helpers.PublishEvent(traceName, line)
+		}
+	}
+	t.header = ""
+	t.lines = nil
+
+	if parser != nil {
+		parser.SetLogCallback(log.StandardLogger().Logf)
+
+		switch trace.Spec.OutputFormat {
+		case gadgetv1alpha1.TraceOutputFormatColumns:
+			formatter := parser.GetTextColumnsFormatter()
+			formatter.SetEventCallback(output)
+			formatter.SetEnableExtraLines(true)
+			t.header = formatter.FormatHeader()
+			if trace.Spec.OutputMode == gadgetv1alpha1.TraceOutputModeStream {
+				output(t.header)
+			}
+			if gadgetInfo.GadgetType.IsPeriodic() {
+				// Only keep the last interval
+				parser.SetEventCallback(formatter.EventHandlerFuncArray(t.resetLines))
+			} else {
+				parser.SetEventCallback(formatter.EventHandlerFunc())
+			}
+		case gadgetv1alpha1.TraceOutputFormatJSON, "":
+			parser.SetEventCallback(runDesc.JSONConverter(gadgetInfo, printerFunc(output)))
+		default:
+			trace.Status.OperationError = fmt.Sprintf("Unsupported OutputFormat %q", trace.Spec.OutputFormat)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gadgetCtx := gadgetcontext.New(
+		ctx,
+		uuid.New().String(),
+		t.runtime,
+		runtimeParams,
+		gadgetDesc,
+		gadgetParams,
+		args,
+		operatorParams,
+		parser,
+		logger.DefaultLogger(),
+		0,
+		gadgetInfo,
+	)
+
+	t.cancel = cancel
+	t.done = make(chan struct{})
+	t.runErr = nil
+	t.started = true
+
+	image := trace.Spec.Image
+	go func(done chan struct{}) {
+		defer close(done)
+		defer gadgetCtx.Cancel()
+
+		_, err := t.runtime.RunGadget(gadgetCtx)
+		if err != nil {
+			log.Errorf("Trace %s: running gadget %s: %s", traceName, image, err)
+		}
+
+		t.mu.Lock()
+		t.runErr = err
+		t.mu.Unlock()
+	}(t.done)
+
+	trace.Status.State = gadgetv1alpha1.TraceStateStarted
+}
+
+func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
+	t.mu.Lock()
+	started := t.started
+	t.mu.Unlock()
+
+	if !started {
+		trace.Status.OperationError = "Not started"
+		return
+	}
+
+	t.publish(trace)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		trace.Status.State = gadgetv1alpha1.TraceStateCompleted
+		if t.runErr != nil {
+			trace.Status.OperationError = fmt.Sprintf("running gadget: %s", t.runErr)
+		}
+	default:
+	}
+}
+
+func (t *Trace) Stop(trace *gadgetv1alpha1.Trace) {
+	t.mu.Lock()
+	started := t.started
+	t.mu.Unlock()
+
+	if !started {
+		trace.Status.OperationError = "Not started"
+		return
+	}
+
+	t.stop()
+	t.publish(trace)
+
+	t.mu.Lock()
+	if t.runErr != nil {
+		trace.Status.OperationError = fmt.Sprintf("running gadget: %s", t.runErr)
+	}
+	t.mu.Unlock()
+
+	trace.Status.State = gadgetv1alpha1.TraceStateStopped
+}
+
+// stop cancels the gadget and waits for it to terminate.
+func (t *Trace) stop() {
+	t.mu.Lock()
+	if !t.started {
+		t.mu.Unlock()
+		return
+	}
+	t.started = false
+	cancel, done := t.cancel, t.done
+	t.mu.Unlock()
+
+	cancel()
+	<-done
+}
+
+// publish writes the buffered output according to the OutputMode.
+func (t *Trace) publish(trace *gadgetv1alpha1.Trace) {
+	output := t.output()
+
+	switch trace.Spec.OutputMode {
+	case gadgetv1alpha1.TraceOutputModeStatus:
+		trace.Status.Output = output
+	case gadgetv1alpha1.TraceOutputModeExternalResource:
+		if err := writeConfigMap(t.client, trace, output); err != nil {
+			trace.Status.OperationError = err.Error()
+		}
+	}
+}
+
+func (t *Trace) output() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := t.lines
+	if t.header != "" {
+		lines = append([]string{t.header}, lines...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t *Trace) appendLine(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lines = append(t.lines, line)
+	if len(t.lines) > maxStatusLines {
+		t.lines = t.lines[len(t.lines)-maxStatusLines:]
+	}
+}
+
+func (t *Trace) resetLines() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lines = nil
+}
+
+// printerFunc implements runTypes.Printer for the JSON converter of the run
+// gadget.
+type printerFunc func(string)
+
+func (p printerFunc) Output(payload string) {
+	p(payload)
+}
+
+func (p printerFunc) Logf(severity logger.Level, format string, params ...any) {
+	log.StandardLogger().Logf(severity, format, params...)
+}
+
+// isOwnedBy returns whether obj has an owner reference to trace
+func isOwnedBy(obj metav1.Object, trace *gadgetv1alpha1.Trace) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Kind == "Trace" && ref.UID == trace.ObjectMeta.UID {
+			return true
+		}
+	}
+	return false
+}
+
+func writeConfigMap(c client.Client, trace *gadgetv1alpha1.Trace, output string) error {
+	if trace.Spec.Output == "" {
+		return fmt.Errorf("missing ConfigMap name in Trace.Spec.Output")
+	}
+
+	key := client.ObjectKey{Namespace: trace.ObjectMeta.Namespace, Name: trace.Spec.Output}
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(context.TODO(), key, configMap)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("getting ConfigMap %s: %w", key, err)
+	}
+	if err == nil {
+		// Spec.Output is set by the creator of the Trace: don't let it
+		// overwrite ConfigMaps that weren't created for this Trace
+		if !isOwnedBy(configMap, trace) {
+			return fmt.Errorf("ConfigMap %s already exists and isn't owned by Trace %s", key, trace.ObjectMeta.Name)
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[outputKey] = output
+		if err := c.Update(context.TODO(), configMap); err != nil {
+			return fmt.Errorf("updating ConfigMap %s: %w", key, err)
+		}
+		return nil
+	}
+
+	configMap = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: key.Namespace,
+			Name:      key.Name,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: gadgetv1alpha1.SchemeGroupVersion.String(),
+					Kind:       "Trace",
+					Name:       trace.ObjectMeta.Name,
+					UID:        trace.ObjectMeta.UID,
+				},
+			},
+		},
+		Data: map[string]string{
+			outputKey: output,
+		},
+	}
+	if err := c.Create(context.TODO(), configMap); err != nil {
+		return fmt.Errorf("creating ConfigMap %s: %w", key, err)
+	}
+	return nil
+}
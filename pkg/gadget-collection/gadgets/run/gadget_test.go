@@ -0,0 +1,105 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package run
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+)
+
+func TestParamsFromTrace(t *testing.T) {
+	trace := &gadgetv1alpha1.Trace{
+		Spec: gadgetv1alpha1.TraceSpec{
+			Image: "ghcr.io/inspektor-gadget/gadget/trace_open:latest",
+			Filter: &gadgetv1alpha1.ContainerFilter{
+				Namespace:        "default",
+				Podname:          "nginx-*",
+				Labels:           map[string]string{"tier": "backend", "app": "nginx"},
+				Selector:         "env notin (dev)",
+				Owner:            "deployment/nginx",
+				ContainerImage:   "docker.io/library/nginx",
+				ExcludeNamespace: "kube-system",
+			},
+			Parameters: map[string]string{
+				"operator.KubeManager.namespace": "prod",
+				"columns":                        "comm,fname",
+			},
+		},
+	}
+
+	expected := map[string]string{
+		"operator.KubeManager.namespace":         "prod",
+		"operator.KubeManager.podname":           "nginx-*",
+		"operator.KubeManager.selector":          "app=nginx,tier=backend,env notin (dev)",
+		"operator.KubeManager.owner":             "deployment/nginx",
+		"operator.KubeManager.image":             "docker.io/library/nginx",
+		"operator.KubeManager.exclude-namespace": "kube-system",
+		"columns":                                "comm,fname",
+	}
+	require.Equal(t, expected, ParamsFromTrace(trace))
+
+	trace.Spec.Filter = nil
+	require.Equal(t, trace.Spec.Parameters, ParamsFromTrace(trace))
+}
+
+func TestTraceOutput(t *testing.T) {
+	tr := &Trace{header: "HEADER"}
+	for i := 0; i < maxStatusLines+10; i++ {
+		tr.appendLine("line")
+	}
+	require.Len(t, tr.lines, maxStatusLines)
+
+	output := tr.output()
+	require.True(t, strings.HasPrefix(output, "HEADER\nline\n"))
+	require.Equal(t, maxStatusLines+1, strings.Count(output, "\n")+1)
+
+	tr.resetLines()
+	require.Equal(t, "HEADER", tr.output())
+}
+
+func TestWriteConfigMap(t *testing.T) {
+	trace := &gadgetv1alpha1.Trace{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "trace", UID: "1234"},
+		Spec:       gadgetv1alpha1.TraceSpec{Output: "out"},
+	}
+	other := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other"},
+		Data:       map[string]string{"config": "keep"},
+	}
+	c := fake.NewClientBuilder().WithObjects(other).Build()
+	ctx := context.Background()
+
+	// Created and then updated by the same Trace
+	require.NoError(t, writeConfigMap(c, trace, "first"))
+	require.NoError(t, writeConfigMap(c, trace, "second"))
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "out"}, configMap))
+	require.Equal(t, "second", configMap.Data[outputKey])
+
+	// ConfigMaps not owned by the Trace are left untouched
+	trace.Spec.Output = "other"
+	require.Error(t, writeConfigMap(c, trace, "overwrite"))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "other"}, configMap))
+	require.Equal(t, map[string]string{"config": "keep"}, configMap.Data)
+}
@@ -20,6 +20,10 @@ const (
 	EventTypeGadgetDone    uint32 = 2
 	EventTypeGadgetJobID   uint32 = 3
 
+	// EventTypeGadgetDropped is sent periodically while events are dropped; its
+	// payload is a JSON encoded types.DroppedEvents with the totals of the run
+	EventTypeGadgetDropped uint32 = 4
+
 	EventLogShift = 16
 )
 
@@ -38,9 +38,15 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime/local"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/sinks"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/experimental"
 )
 
+// droppedEventsInterval is the interval in which the number of dropped events is
+// reported to the client
+const droppedEventsInterval = time.Second
+
 type RunConfig struct {
 	// SocketType can be either unix or tcp
 	SocketType string
@@ -52,6 +58,19 @@ type RunConfig struct {
 	// If SocketGID != 0 and a unix socket is used, the ownership of that socket
 	// will be changed to the given SocketGID
 	SocketGID int
+
+	// Sinks are URLs of output sinks receiving the events of all gadgets run by
+	// the service (see pkg/sinks)
+	Sinks []string
+
+	// SinkFileDir is the directory the file sinks requested by clients for a
+	// single run are confined to. They are rejected if it's empty.
+	SinkFileDir string
+
+	// SinkAllowedHosts are the hosts the network sinks requested by clients for
+	// a single run can send events to, as host or host:port. They are rejected
+	// if it's empty.
+	SinkAllowedHosts []string
 }
 
 type Service struct {
@@ -178,6 +197,9 @@ func (s *Service) RunGadget(runGadget api.GadgetManager_RunGadgetServer) error {
 			p := p
 			gadgetParamDescs.Add(&p.ParamDesc)
 		}
+
+		// Add params matching the actual gadget type, like interval for toppers
+		gadgetParamDescs.Add(gadgets.GadgetParams(gadgetDesc, gadgetInfo.GadgetType, parser)...)
 		gadgetParams = gadgetParamDescs.ToParams()
 		err = gadgetParams.CopyFromMap(request.Params, "")
 		if err != nil {
@@ -192,10 +214,15 @@ func (s *Service) RunGadget(runGadget api.GadgetManager_RunGadgetServer) error {
 	seq := uint32(0)
 	var seqLock sync.Mutex
 
+	// Number of events dropped because outputBuffer was full; guarded by seqLock
+	bufferDropped := uint64(0)
+
 	if parser != nil {
 		outputDone := make(chan bool)
+		pumpDone := make(chan struct{})
 		defer func() {
 			outputDone <- true
+			<-pumpDone
 		}()
 
 		parser.SetLogCallback(logger.Logf)
@@ -219,17 +246,44 @@ func (s *Service) RunGadget(runGadget api.GadgetManager_RunGadgetServer) error {
 			select {
 			case outputBuffer <- event:
 			default:
+				bufferDropped++
 			}
 			seqLock.Unlock()
 		})
 
+		// sendDropped reports the events dropped so far to the client, if they changed since the last report
+		var lastDropped types.DroppedEvents
+		sendDropped := func() {
+			dropped := parser.DroppedEvents()
+			seqLock.Lock()
+			dropped.Buffer += bufferDropped
+			seqLock.Unlock()
+			if dropped == lastDropped {
+				return
+			}
+			lastDropped = dropped
+			data, _ := json.Marshal(dropped)
+			runGadget.Send(&api.GadgetEvent{
+				Type:    api.EventTypeGadgetDropped,
+				Payload: data,
+			})
+		}
+
 		go func() {
+			defer close(pumpDone)
+
+			ticker := time.NewTicker(droppedEventsInterval)
+			defer ticker.Stop()
+
 			// Message pump to handle slow readers
 			for {
 				select {
 				case ev := <-outputBuffer:
 					runGadget.Send(ev)
+				case <-ticker.C:
+					sendDropped()
 				case <-outputDone:
+					sendDropped()
 					return
 				}
 			}
@@ -343,7 +397,8 @@ func newUnixListener(address string, gid int) (net.Listener, error) {
 }
 
 func (s *Service) Run(runConfig RunConfig, serverOptions ...grpc.ServerOption) error {
-	s.runtime = local.New()
+	localRuntime := local.New()
+	s.runtime = localRuntime
 	defer s.runtime.Close()
 
 	// Use defaults for now - this will become more important when we fan-out requests also to other
@@ -353,6 +408,15 @@ func (s *Service) Run(runConfig RunConfig, serverOptions ...grpc.ServerOption) e
 		return fmt.Errorf("initializing runtime: %w", err)
 	}
 
+	defaultSinks, err := sinks.Open(runConfig.Sinks)
+	if err != nil {
+		return fmt.Errorf("opening sinks: %w", err)
+	}
+	defer defaultSinks.Close()
+	localRuntime.SetDefaultSinks(defaultSinks)
+	// Clients can be less privileged than the service
+	localRuntime.RestrictRunSinks(runConfig.SinkFileDir, runConfig.SinkAllowedHosts)
+
 	switch runConfig.SocketType {
 	case "unix":
 		listener, err := newUnixListener(runConfig.SocketPath, runConfig.SocketGID)
@@ -2,9 +2,13 @@
 #define GADGET_SECCOMP_COMMON_H
 
 // Please update these values also in ../tracer.go
+// The map values hold one byte per native syscall, followed by one byte per
+// 32-bit compat syscall and the footer.
 #define SYSCALLS_COUNT 500
+#define SYSCALLS_COMPAT_OFFSET SYSCALLS_COUNT
+#define SYSCALLS_MAP_VALUE_FOOTER_OFFSET (2 * SYSCALLS_COUNT)
 #define SYSCALLS_MAP_VALUE_FOOTER_SIZE 1
 #define SYSCALLS_MAP_VALUE_SIZE \
-	(SYSCALLS_COUNT + SYSCALLS_MAP_VALUE_FOOTER_SIZE)
+	(SYSCALLS_MAP_VALUE_FOOTER_OFFSET + SYSCALLS_MAP_VALUE_FOOTER_SIZE)
 
 #endif
@@ -69,15 +69,14 @@ int ig_seccomp_e(struct bpf_raw_tracepoint_args *ctx)
 {
 	struct pt_regs regs = {};
 	unsigned int id;
+	int compat = 0;
 	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
 
 	bpf_probe_read(&regs, sizeof(struct pt_regs), (void *)ctx->args[0]);
 	id = ctx->args[1];
 
 #ifdef __TARGET_ARCH_x86
-	if (is_x86_compat(task)) {
-		return 0;
-	}
+	compat = is_x86_compat(task);
 #endif
 
 	if (id < 0 || id >= SYSCALLS_COUNT)
@@ -118,12 +117,14 @@ int ig_seccomp_e(struct bpf_raw_tracepoint_args *ctx)
 	// is a safe place right before all the seccomp() calls that will be always
 	// executed during the runc initialisation:
 	// https://github.com/opencontainers/runc/blob/8b4a8f093d0dbdf45100597f710d16777845ee83/libcontainer/standard_init_linux.go#L148
-	if (is_runc) {
-		if (syscall_bitmap[SYSCALLS_COUNT] == 0) {
+	// runc is a native binary, the numbers of the compat syscalls don't
+	// match the ones checked below.
+	if (is_runc && !compat) {
+		if (syscall_bitmap[SYSCALLS_MAP_VALUE_FOOTER_OFFSET] == 0) {
 			if (id == __NR_prctl &&
 			    PT_REGS_PARM1(&regs) == PR_GET_PDEATHSIG) {
 				// Start recording the runc syscalls from now on.
-				syscall_bitmap[SYSCALLS_COUNT] = 1;
+				syscall_bitmap[SYSCALLS_MAP_VALUE_FOOTER_OFFSET] = 1;
 			}
 
 			return 0;
@@ -139,8 +140,15 @@ int ig_seccomp_e(struct bpf_raw_tracepoint_args *ctx)
 		}
 	}
 
-	// Record the syscall
-	syscall_bitmap[id] = 0x01;
+	// Record the syscall. The compiler can't drop the check of the offset
+	// after barrier_var(), it's needed by the verifier.
+	__u32 offset = id;
+	if (compat)
+		offset += SYSCALLS_COMPAT_OFFSET;
+	barrier_var(offset);
+	if (offset >= SYSCALLS_MAP_VALUE_FOOTER_OFFSET)
+		return 0;
+	syscall_bitmap[offset] = 0x01;
 
 	return 0;
 }
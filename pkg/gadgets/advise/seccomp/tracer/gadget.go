@@ -15,8 +15,14 @@
 package tracer
 
 import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
 	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
 )
@@ -51,6 +57,42 @@ func (g *GadgetDesc) EventPrototype() any {
 	return nil
 }
 
+func (g *GadgetDesc) OutputFormats() (gadgets.OutputFormats, string) {
+	return gadgets.OutputFormats{
+		"seccomp-profile": gadgets.OutputFormat{
+			Name: "Seccomp profile",
+			Description: "One seccomp profile per container, merging the syscalls recorded on all nodes. " +
+				"The profiles cover the architectures of all those nodes",
+			RequiresCombinedResult: true,
+			Transform: func(data any) ([]byte, error) {
+				report := types.Report{}
+				switch results := data.(type) {
+				case map[string][]byte:
+					for node, b := range results {
+						var nodeReport types.Report
+						if err := json.Unmarshal(b, &nodeReport); err != nil {
+							return nil, fmt.Errorf("decoding result of %q: %w", node, err)
+						}
+						report.Merge(nodeReport)
+					}
+				case []byte:
+					if err := json.Unmarshal(results, &report); err != nil {
+						return nil, err
+					}
+				default:
+					return nil, fmt.Errorf("type must be map[string][]byte or []byte and is: %T", data)
+				}
+
+				profiles := make(map[string]*specs.LinuxSeccomp, len(report))
+				for container, syscalls := range report {
+					profiles[container] = SyscallsToLinuxSeccomp(syscalls)
+				}
+				return json.MarshalIndent(profiles, "", "  ")
+			},
+		},
+	}, "json"
+}
+
 func init() {
 	gadgetregistry.Register(&GadgetDesc{})
 }
@@ -18,19 +18,45 @@
 package tracer
 
 import (
-	"runtime"
-
 	"github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/syscalls"
 )
 
-/* Function arches() under the Apache License, Version 2.0 by the containerd authors:
+// Arches returns the seccomp architectures of the architecture this binary
+// runs on
+func Arches() []specs.Arch {
+	return archesOf(syscalls.NativeArch())
+}
+
+// SeccompArches returns the seccomp architectures covering the given syscall
+// architectures, without duplicates
+func SeccompArches(arches []syscalls.Arch) []specs.Arch {
+	ret := []specs.Arch{}
+	seen := make(map[specs.Arch]struct{})
+	for _, arch := range arches {
+		for _, a := range archesOf(arch) {
+			if _, ok := seen[a]; ok {
+				continue
+			}
+			seen[a] = struct{}{}
+			ret = append(ret, a)
+		}
+	}
+	return ret
+}
+
+/* Function archesOf() under the Apache License, Version 2.0 by the containerd authors:
  * https://github.com/containerd/containerd/blob/66fec3bbbf91520a1433faa16e99e5a314a61902/contrib/seccomp/seccomp_default.go#L29
  */
-func Arches() []specs.Arch {
-	switch runtime.GOARCH {
-	case "amd64":
+func archesOf(arch syscalls.Arch) []specs.Arch {
+	switch arch {
+	case syscalls.ArchI386:
+		return []specs.Arch{specs.ArchX86}
+	case syscalls.ArchAMD64:
 		return []specs.Arch{specs.ArchX86_64, specs.ArchX86, specs.ArchX32}
-	case "arm64":
+	case syscalls.ArchARM64:
 		return []specs.Arch{specs.ArchARM, specs.ArchAARCH64}
 	case "mips64":
 		return []specs.Arch{specs.ArchMIPS, specs.ArchMIPS64, specs.ArchMIPS64N32}
@@ -63,3 +89,14 @@ func SyscallNamesToLinuxSeccomp(syscallNames []string) *specs.LinuxSeccomp {
 	}
 	return s
 }
+
+// SyscallsToLinuxSeccomp returns a profile allowing the given syscalls on all
+// the architectures they were executed with. It allows to generate a single
+// profile for nodes of different architectures.
+func SyscallsToLinuxSeccomp(s types.Syscalls) *specs.LinuxSeccomp {
+	profile := SyscallNamesToLinuxSeccomp(s.Names())
+	if len(s) > 0 {
+		profile.Architectures = SeccompArches(s.Arches())
+	}
+	return profile
+}
@@ -19,9 +19,22 @@ package tracer
 
 import (
 	"github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/syscalls"
 )
 
+func SeccompArches(arches []syscalls.Arch) []specs.Arch {
+	panic("Not implemented")
+	return nil
+}
+
 func SyscallNamesToLinuxSeccomp(syscallNames []string) *specs.LinuxSeccomp {
 	panic("Not implemented")
 	return nil
 }
+
+func SyscallsToLinuxSeccomp(s types.Syscalls) *specs.LinuxSeccomp {
+	panic("Not implemented")
+	return nil
+}
@@ -18,6 +18,7 @@ package tracer
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 
@@ -28,6 +29,7 @@ import (
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/syscalls"
 )
 
@@ -35,11 +37,17 @@ import (
 
 const (
 	// Please update these values also in bpf/seccomp-common.h
-	syscallsCount              = 500
-	syscallsMapValueFooterSize = 1
-	syscallsMapValueSize       = syscallsCount + syscallsMapValueFooterSize
+	syscallsCount                = 500
+	syscallsCompatOffset         = syscallsCount
+	syscallsMapValueFooterOffset = 2 * syscallsCount
+	syscallsMapValueFooterSize   = 1
+	syscallsMapValueSize         = syscallsMapValueFooterOffset + syscallsMapValueFooterSize
 )
 
+// ErrNoSyscalls is returned by Peek when no syscall was executed in the mount
+// namespace
+var ErrNoSyscalls = errors.New("no syscall found")
+
 type Tracer struct {
 	objs seccompObjects
 
@@ -51,7 +59,7 @@ type Tracer struct {
 
 	// We keep references to mountns of containers we attach to, so we
 	// can collect information afterwards
-	containers map[*containercollection.Container][]string
+	containers map[*containercollection.Container]types.Syscalls
 }
 
 func NewTracer() (*Tracer, error) {
@@ -73,7 +81,7 @@ func (t *Tracer) install() error {
 
 	opts := ebpf.CollectionOptions{
 		Programs: ebpf.ProgramOptions{
-			KernelTypes: btfgen.GetBTFSpec(),
+			KernelTypes: btfgen.GetBTFSpec(spec),
 		},
 	}
 
@@ -81,7 +89,9 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("loading ebpf program: %w", err)
 	}
 
-	t.objs.SyscallsPerMntns.Update(uint64(0), [syscallsMapValueSize]byte{}, ebpf.UpdateAny)
+	if err := t.objs.SyscallsPerMntns.Update(uint64(0), [syscallsMapValueSize]byte{}, ebpf.UpdateAny); err != nil {
+		return fmt.Errorf("initializing the seccomp map: %w", err)
+	}
 
 	t.progLink, err = link.AttachRawTracepoint(link.RawTracepointOptions{
 		Name:    "sys_enter",
@@ -94,13 +104,13 @@ func (t *Tracer) install() error {
 	return nil
 }
 
-func syscallArrToNameList(v []byte) []string {
+func syscallArrToNameList(table *syscalls.Table, v []byte) []string {
 	names := []string{}
 	for i, val := range v {
 		if val == 0 {
 			continue
 		}
-		name, ok := syscalls.GetSyscallNameByNumber(i)
+		name, ok := table.GetSyscallNameByNumber(i)
 		if !ok {
 			name = fmt.Sprintf("syscall%d", i)
 		}
@@ -110,7 +120,9 @@ func syscallArrToNameList(v []byte) []string {
 	return names
 }
 
-func (t *Tracer) Peek(mntns uint64) ([]string, error) {
+// Peek returns the syscalls executed in the given mount namespace, indexed by
+// the architecture of their ABI
+func (t *Tracer) Peek(mntns uint64) (types.Syscalls, error) {
 	b, err := t.objs.SyscallsPerMntns.LookupBytes(mntns)
 	if err != nil {
 		return nil, fmt.Errorf("looking up the seccomp map: %w", err)
@@ -119,12 +131,23 @@ func (t *Tracer) Peek(mntns uint64) ([]string, error) {
 	// we need to test b==nil too
 	if b == nil {
 		// The container just hasn't done any syscall
-		return nil, fmt.Errorf("no syscall found")
+		return nil, ErrNoSyscalls
 	}
-	if len(b) < syscallsCount {
+	if len(b) < syscallsMapValueFooterOffset {
 		return nil, fmt.Errorf("looking up the seccomp map: wrong length: %d", len(b))
 	}
-	return syscallArrToNameList(b[:syscallsCount]), nil
+
+	ret := types.Syscalls{}
+	if names := syscallArrToNameList(syscalls.Native(), b[:syscallsCount]); len(names) > 0 {
+		ret[syscalls.NativeArch()] = names
+	}
+	if compat, ok := syscalls.CompatArch(syscalls.NativeArch()); ok {
+		compatBitmap := b[syscallsCompatOffset : syscallsCompatOffset+syscallsCount]
+		if names := syscallArrToNameList(syscalls.Compat(), compatBitmap); len(names) > 0 {
+			ret[compat] = names
+		}
+	}
+	return ret, nil
 }
 
 func (t *Tracer) Delete(mntns uint64) {
@@ -142,23 +165,28 @@ func (t *Tracer) Close() {
 
 func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	t := &Tracer{
-		containers: make(map[*containercollection.Container][]string),
+		containers: make(map[*containercollection.Container]types.Syscalls),
 	}
 	return t, nil
 }
 
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadSeccomp)
+}
+
 func (t *Tracer) RunWithResult(gadgetCtx gadgets.GadgetContext) ([]byte, error) {
 	defer t.Close()
 	if err := t.install(); err != nil {
 		return nil, fmt.Errorf("installing tracer: %w", err)
 	}
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
 
 	// Notice this Tracer starts collecting data for all containers as soon as
 	// it is installed, and uses the attach/detach mechanism to know which
 	// containers to report data from.
 	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
 
-	return t.collectResult()
+	return t.collectResult(gadgetCtx)
 }
 
 func (t *Tracer) AttachContainer(container *containercollection.Container) error {
@@ -169,17 +197,31 @@ func (t *Tracer) AttachContainer(container *containercollection.Container) error
 func (t *Tracer) DetachContainer(container *containercollection.Container) error {
 	res, err := t.Peek(container.Mntns)
 	if err != nil {
-		t.containers[container] = []string{err.Error()}
-		return nil
+		// The container didn't execute any syscall
+		res = types.Syscalls{}
 	}
 	t.containers[container] = res
 	return nil
 }
 
-func (t *Tracer) collectResult() ([]byte, error) {
-	out := make(map[string][]string)
+func (t *Tracer) collectResult(gadgetCtx gadgets.GadgetContext) ([]byte, error) {
+	out := make(types.Report)
 	for container, result := range t.containers {
-		out[container.K8s.ContainerName] = result
+		// Containers still running haven't been detached yet
+		if result == nil {
+			var err error
+			result, err = t.Peek(container.Mntns)
+			if err != nil {
+				gadgetCtx.Logger().Debugf("getting syscalls of container %q: %s", container.Runtime.ContainerName, err)
+				result = types.Syscalls{}
+			}
+		}
+
+		name := container.K8s.ContainerName
+		if name == "" {
+			name = container.Runtime.ContainerName
+		}
+		out[name] = result
 	}
 	return json.MarshalIndent(out, "", "  ")
 }
@@ -0,0 +1,34 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSyscallsMapValueSize checks that the constants mirroring
+// bpf/seccomp-common.h match the map of the embedded eBPF object
+func TestSyscallsMapValueSize(t *testing.T) {
+	spec, err := loadSeccomp()
+	require.NoError(t, err)
+
+	m, ok := spec.Maps["syscalls_per_mntns"]
+	require.True(t, ok, "map syscalls_per_mntns not found")
+	require.Equal(t, uint32(syscallsMapValueSize), m.ValueSize)
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"sort"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/syscalls"
+)
+
+// Syscalls holds the sorted names of the syscalls executed by a container,
+// indexed by the architecture of their ABI, e.g. "amd64", or "i386" for 32-bit
+// compat syscalls on amd64.
+type Syscalls map[syscalls.Arch][]string
+
+// Report holds the syscalls executed by each container, indexed by container
+// name. It's the result of the gadget.
+type Report map[string]Syscalls
+
+// Arches returns the sorted architectures the syscalls were executed with
+func (s Syscalls) Arches() []syscalls.Arch {
+	arches := make([]syscalls.Arch, 0, len(s))
+	for arch := range s {
+		arches = append(arches, arch)
+	}
+	sort.Slice(arches, func(i, j int) bool { return arches[i] < arches[j] })
+	return arches
+}
+
+// Names returns the sorted names of the syscalls executed with any
+// architecture. Seccomp profiles use the same names for all architectures.
+func (s Syscalls) Names() []string {
+	set := make(map[string]struct{})
+	for _, names := range s {
+		for _, name := range names {
+			set[name] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Merge adds the syscalls of other to s
+func (s Syscalls) Merge(other Syscalls) {
+	for arch, names := range other {
+		s[arch] = Syscalls{arch: append(s[arch], names...)}.Names()
+	}
+}
+
+// Merge adds the syscalls of the containers of other to r, e.g. to combine the
+// results of several nodes
+func (r Report) Merge(other Report) {
+	for container, s := range other {
+		if r[container] == nil {
+			r[container] = make(Syscalls)
+		}
+		r[container].Merge(s)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/syscalls"
+)
+
+func TestReportMerge(t *testing.T) {
+	t.Parallel()
+
+	report := Report{}
+	report.Merge(Report{
+		"nginx": {
+			syscalls.ArchAMD64: {"read", "write"},
+			syscalls.ArchI386:  {"socketcall"},
+		},
+	})
+	report.Merge(Report{
+		"nginx": {
+			syscalls.ArchAMD64: {"close", "read"},
+			syscalls.ArchARM64: {"openat"},
+		},
+		"redis": {
+			syscalls.ArchARM64: {"epoll_pwait"},
+		},
+	})
+
+	require.Equal(t, Report{
+		"nginx": {
+			syscalls.ArchAMD64: {"close", "read", "write"},
+			syscalls.ArchARM64: {"openat"},
+			syscalls.ArchI386:  {"socketcall"},
+		},
+		"redis": {
+			syscalls.ArchARM64: {"epoll_pwait"},
+		},
+	}, report)
+
+	nginx := report["nginx"]
+	require.Equal(t, []syscalls.Arch{syscalls.ArchAMD64, syscalls.ArchARM64, syscalls.ArchI386}, nginx.Arches())
+	require.Equal(t, []string{"close", "openat", "read", "socketcall", "write"}, nginx.Names())
+}
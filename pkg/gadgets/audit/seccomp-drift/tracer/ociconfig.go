@@ -0,0 +1,91 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// bundlePatterns are the locations of the OCI bundles of each runtime,
+// relative to the host root. They are globbed with the container ID.
+var bundlePatterns = map[types.RuntimeName][]string{
+	types.RuntimeNameContainerd: {
+		"run/containerd/io.containerd.runtime.v2.task/*/%s",
+		"run/containerd/io.containerd.runtime.v1.linux/*/%s",
+	},
+	types.RuntimeNameDocker: {
+		"run/containerd/io.containerd.runtime.v2.task/moby/%s",
+		"run/containerd/io.containerd.runtime.v1.linux/moby/%s",
+		"run/docker/containerd/%s",
+	},
+	types.RuntimeNameCrio: {
+		"run/containers/storage/overlay-containers/%s/userdata",
+	},
+	types.RuntimeNamePodman: {
+		"run/containers/storage/overlay-containers/%s/userdata",
+		"var/lib/containers/storage/overlay-containers/%s/userdata",
+	},
+}
+
+// loadOCIConfig returns the OCI config of a container. Containers seen by the
+// runc hooks already have it, the config.json of the others is read from
+// their bundle under hostRoot.
+func loadOCIConfig(hostRoot string, container *containercollection.Container) (*specs.Spec, error) {
+	if container.OciConfig != nil {
+		return container.OciConfig, nil
+	}
+
+	patterns := bundlePatterns[container.Runtime.RuntimeName]
+	id := container.Runtime.ContainerID
+	if container.Bundle == "" && (len(patterns) == 0 || id == "" || filepath.Base(id) != id) {
+		return nil, fmt.Errorf("no OCI bundle known for runtime %q", container.Runtime.RuntimeName)
+	}
+
+	var bundles []string
+	if container.Bundle != "" {
+		// The hooks already prefix the bundle with the host root
+		bundles = append(bundles, container.Bundle)
+	} else {
+		for _, pattern := range patterns {
+			matches, _ := filepath.Glob(filepath.Join(hostRoot, fmt.Sprintf(pattern, id)))
+			bundles = append(bundles, matches...)
+		}
+	}
+
+	for _, bundle := range bundles {
+		b, err := os.ReadFile(filepath.Join(bundle, "config.json"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading OCI config: %w", err)
+		}
+		config := &specs.Spec{}
+		if err := json.Unmarshal(b, config); err != nil {
+			return nil, fmt.Errorf("parsing OCI config %q: %w", bundle, err)
+		}
+		return config, nil
+	}
+	return nil, fmt.Errorf("OCI config not found for container %q", id)
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func TestLoadOCIConfig(t *testing.T) {
+	t.Parallel()
+
+	hostRoot := t.TempDir()
+	bundle := filepath.Join(hostRoot, "run/containerd/io.containerd.runtime.v2.task/k8s.io/abc123")
+	require.NoError(t, os.MkdirAll(bundle, 0o755))
+	config := `{"linux": {"seccomp": {"defaultAction": "SCMP_ACT_ERRNO"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(bundle, "config.json"), []byte(config), 0o644))
+
+	newContainer := func(runtimeName types.RuntimeName, id string) *containercollection.Container {
+		c := &containercollection.Container{}
+		c.Runtime.RuntimeName = runtimeName
+		c.Runtime.ContainerID = id
+		return c
+	}
+
+	spec, err := loadOCIConfig(hostRoot, newContainer(types.RuntimeNameContainerd, "abc123"))
+	require.NoError(t, err)
+	require.Equal(t, specs.ActErrno, spec.Linux.Seccomp.DefaultAction)
+
+	known := newContainer(types.RuntimeNameContainerd, "abc123")
+	known.OciConfig = &specs.Spec{}
+	spec, err = loadOCIConfig(hostRoot, known)
+	require.NoError(t, err)
+	require.Same(t, known.OciConfig, spec)
+
+	_, err = loadOCIConfig(hostRoot, newContainer(types.RuntimeNameCrio, "abc123"))
+	require.ErrorContains(t, err, "OCI config not found")
+
+	_, err = loadOCIConfig(hostRoot, newContainer(types.RuntimeNameContainerd, "../abc123"))
+	require.ErrorContains(t, err, "no OCI bundle known")
+
+	_, err = loadOCIConfig(hostRoot, newContainer(types.RuntimeNameSystemd, "abc123"))
+	require.ErrorContains(t, err, "no OCI bundle known")
+}
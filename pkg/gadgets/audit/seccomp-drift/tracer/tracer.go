@@ -0,0 +1,234 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cilium/ebpf"
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	advisetracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/seccomp-drift/types"
+	audittracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/seccomp/tracer"
+	audittypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/seccomp/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+// containerAudit holds the calls audited for a container
+type containerAudit struct {
+	container *containercollection.Container
+	audit     types.Audit
+
+	// config is the OCI config of the container, loaded when attaching to it
+	// as the bundle is removed when the container terminates. configErr tells
+	// why it's nil.
+	config    *specs.Spec
+	configErr error
+
+	// called holds the syscalls executed by the container, collected when it
+	// is detached. calledErr tells why they couldn't be collected.
+	detached  bool
+	called    []string
+	calledErr error
+}
+
+// Tracer aggregates the events of the audit seccomp tracer by container. The
+// advise seccomp tracer tells which syscalls were executed, as the kernel
+// doesn't audit the syscalls allowed with SCMP_ACT_ALLOW.
+type Tracer struct {
+	mountnsMap *ebpf.Map
+	cgroupMap  *ebpf.Map
+	advisor    *advisetracer.Tracer
+
+	mu sync.Mutex
+	// containers holds the containers we attach to, indexed by mount namespace
+	// ID. They are kept after being detached to report about containers that
+	// terminated during the run.
+	containers map[uint64]*containerAudit
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	t := &Tracer{
+		containers: make(map[uint64]*containerAudit),
+	}
+	return t, nil
+}
+
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	auditSpecs, err := (&audittracer.GadgetDesc{}).EBPFSpecs()
+	if err != nil {
+		return nil, err
+	}
+	adviseSpecs, err := (&advisetracer.GadgetDesc{}).EBPFSpecs()
+	if err != nil {
+		return nil, err
+	}
+	return append(auditSpecs, adviseSpecs...), nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.mountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.cgroupMap = cgroupMap
+}
+
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	// The fake container attached with --host has no mount namespace
+	if container.Mntns == 0 {
+		return nil
+	}
+
+	config, configErr := loadOCIConfig(host.HostRoot, container)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.containers[container.Mntns] = &containerAudit{
+		container: container,
+		audit:     types.Audit{},
+		config:    config,
+		configErr: configErr,
+	}
+	return nil
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.containers[container.Mntns]
+	if !ok || t.advisor == nil {
+		return nil
+	}
+	c.detached = true
+	c.called, c.calledErr = t.calledSyscalls(container.Mntns)
+	return nil
+}
+
+// calledSyscalls returns the syscalls executed in the given mount namespace
+func (t *Tracer) calledSyscalls(mntns uint64) ([]string, error) {
+	syscalls, err := t.advisor.Peek(mntns)
+	if errors.Is(err, advisetracer.ErrNoSyscalls) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return syscalls.Names(), nil
+}
+
+func (t *Tracer) RunWithResult(gadgetCtx gadgets.GadgetContext) ([]byte, error) {
+	eventCallback := func(event *audittypes.Event) {
+		switch event.Type {
+		case eventtypes.ERR:
+			gadgetCtx.Logger().Errorf("%s", event.Message)
+			return
+		case eventtypes.WARN:
+			gadgetCtx.Logger().Warnf("%s", event.Message)
+			return
+		case eventtypes.NORMAL:
+		default:
+			return
+		}
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		c, ok := t.containers[event.MountNsID]
+		if !ok {
+			return
+		}
+		c.audit.Add(event.Code, event.Syscall)
+	}
+
+	advisor, err := advisetracer.NewTracer()
+	if err != nil {
+		return nil, fmt.Errorf("creating advise tracer: %w", err)
+	}
+	defer advisor.Close()
+
+	t.mu.Lock()
+	t.advisor = advisor
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.advisor = nil
+		t.mu.Unlock()
+	}()
+
+	tracer, err := audittracer.NewTracer(&audittracer.Config{
+		MountnsMap: t.mountnsMap,
+		CgroupMap:  t.cgroupMap,
+	}, nil, eventCallback)
+	if err != nil {
+		return nil, fmt.Errorf("creating tracer: %w", err)
+	}
+
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+	tracer.Close()
+
+	return t.collectResult(gadgetCtx)
+}
+
+func (t *Tracer) collectResult(gadgetCtx gadgets.GadgetContext) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make(types.Report, len(t.containers))
+	for _, c := range t.containers {
+		name := containerName(c.container)
+
+		if c.configErr != nil {
+			gadgetCtx.Logger().Warnf("seccomp profile of container %q unknown: %v", name, c.configErr)
+		}
+
+		// Containers still running haven't been detached yet
+		called, err := c.called, c.calledErr
+		if !c.detached {
+			called, err = t.calledSyscalls(c.container.Mntns)
+		}
+		if err != nil {
+			gadgetCtx.Logger().Warnf("getting syscalls of container %q: %v", name, err)
+		}
+
+		id := c.container.Runtime.ContainerID
+		if id == "" {
+			id = name
+		}
+		out[id] = types.Compare(name, c.config, c.audit, called)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// containerName returns "namespace/pod/container" for Kubernetes containers,
+// the runtime name otherwise
+func containerName(container *containercollection.Container) string {
+	if container.K8s.PodName != "" {
+		return fmt.Sprintf("%s/%s/%s", container.K8s.Namespace, container.K8s.PodName, container.K8s.ContainerName)
+	}
+	return container.Runtime.ContainerName
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// ProfileStatus tells whether the seccomp profile of a container is known
+type ProfileStatus string
+
+const (
+	// ProfileApplied means the container runs with the seccomp profile of its
+	// OCI config
+	ProfileApplied ProfileStatus = "applied"
+	// ProfileUnconfined means the OCI config of the container has no seccomp
+	// profile
+	ProfileUnconfined ProfileStatus = "unconfined"
+	// ProfileUnknown means the OCI config of the container isn't available, so
+	// the audited syscalls can't be compared with its profile
+	ProfileUnknown ProfileStatus = "unknown"
+)
+
+// Audit holds the number of audited calls of a container, indexed by the seccomp
+// action (e.g. "errno" or "log", see the code column of audit seccomp) and by
+// syscall name
+type Audit map[string]map[string]uint64
+
+// Add counts an audited call of syscall that resulted in the action code
+func (a Audit) Add(code, syscall string) {
+	if a[code] == nil {
+		a[code] = make(map[string]uint64)
+	}
+	a[code][syscall]++
+}
+
+// ContainerReport compares the syscalls audited for a container with its
+// seccomp profile
+type ContainerReport struct {
+	// Name is "namespace/pod/container" for Kubernetes containers, the
+	// runtime name otherwise
+	Name string `json:"name"`
+
+	Profile       ProfileStatus            `json:"profile"`
+	DefaultAction specs.LinuxSeccompAction `json:"defaultAction,omitempty"`
+
+	// Denied holds the number of calls of the syscalls the profile denied, e.g.
+	// with SCMP_ACT_ERRNO or SCMP_ACT_KILL
+	Denied map[string]uint64 `json:"denied,omitempty"`
+
+	// LoggedAllowed holds the number of calls of the syscalls the profile
+	// allowed with SCMP_ACT_LOG
+	LoggedAllowed map[string]uint64 `json:"loggedAllowed,omitempty"`
+
+	// UnusedAllowed holds the sorted syscalls the profile explicitly allows
+	// with SCMP_ACT_ALLOW or SCMP_ACT_LOG that were never called
+	UnusedAllowed []string `json:"unusedAllowed,omitempty"`
+}
+
+// Report holds the reports of the containers, indexed by container ID
+type Report map[string]*ContainerReport
+
+// Compare builds the report of a container running with the seccomp profile
+// given in its OCI config. config is nil if the OCI config isn't available.
+// called holds the names of the native syscalls the container executed, it's
+// nil if they aren't known. Unused syscalls aren't reported in that case.
+func Compare(name string, config *specs.Spec, audit Audit, called []string) *ContainerReport {
+	r := &ContainerReport{
+		Name:    name,
+		Profile: ProfileUnknown,
+	}
+
+	for code, calls := range audit {
+		switch code {
+		case "allow":
+			// The kernel never audits allowed syscalls, ignore them just in case
+		case "log":
+			r.LoggedAllowed = addCalls(r.LoggedAllowed, calls)
+		default:
+			r.Denied = addCalls(r.Denied, calls)
+		}
+	}
+
+	if config == nil {
+		return r
+	}
+	if config.Linux == nil || config.Linux.Seccomp == nil {
+		r.Profile = ProfileUnconfined
+		return r
+	}
+
+	profile := config.Linux.Seccomp
+	r.Profile = ProfileApplied
+	r.DefaultAction = profile.DefaultAction
+
+	if called == nil {
+		return r
+	}
+
+	used := make(map[string]struct{}, len(called)+len(r.LoggedAllowed))
+	for _, name := range called {
+		used[name] = struct{}{}
+	}
+	for name := range r.LoggedAllowed {
+		used[name] = struct{}{}
+	}
+
+	unused := make(map[string]struct{})
+	for _, rule := range profile.Syscalls {
+		if rule.Action != specs.ActAllow && rule.Action != specs.ActLog {
+			continue
+		}
+		for _, name := range rule.Names {
+			if _, ok := used[name]; !ok {
+				unused[name] = struct{}{}
+			}
+		}
+	}
+	for name := range unused {
+		r.UnusedAllowed = append(r.UnusedAllowed, name)
+	}
+	sort.Strings(r.UnusedAllowed)
+
+	return r
+}
+
+func addCalls(dst, calls map[string]uint64) map[string]uint64 {
+	if dst == nil {
+		dst = make(map[string]uint64, len(calls))
+	}
+	for syscall, count := range calls {
+		dst[syscall] += count
+	}
+	return dst
+}
+
+// String returns the report in a human-readable form, one line per category of
+// syscalls
+func (r *ContainerReport) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "  profile:        %s", r.Profile)
+	if r.DefaultAction != "" {
+		fmt.Fprintf(&sb, " (default action %s)", r.DefaultAction)
+	}
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "  denied:         %s\n", formatCalls(r.Denied))
+	fmt.Fprintf(&sb, "  logged allowed: %s\n", formatCalls(r.LoggedAllowed))
+	if r.Profile == ProfileApplied {
+		unused := "-"
+		if len(r.UnusedAllowed) > 0 {
+			unused = strings.Join(r.UnusedAllowed, ", ")
+		}
+		fmt.Fprintf(&sb, "  unused allowed: %s\n", unused)
+	}
+	return sb.String()
+}
+
+// formatCalls returns the syscalls sorted by decreasing number of calls, e.g.
+// "mount (3), unshare (1)"
+func formatCalls(calls map[string]uint64) string {
+	if len(calls) == 0 {
+		return "-"
+	}
+
+	names := make([]string, 0, len(calls))
+	for name := range calls {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if calls[names[i]] != calls[names[j]] {
+			return calls[names[i]] > calls[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s (%d)", name, calls[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// String returns the reports of all containers, sorted by container name
+func (r Report) String() string {
+	ids := make([]string, 0, len(r))
+	for id := range r {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if r[ids[i]].Name != r[ids[j]].Name {
+			return r[ids[i]].Name < r[ids[j]].Name
+		}
+		return ids[i] < ids[j]
+	})
+
+	var sb strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&sb, "%s (%s)\n%s", r[id].Name, shortID(id), r[id])
+	}
+	return sb.String()
+}
+
+// shortID returns the first 12 characters of a container ID, like the
+// container runtimes do
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
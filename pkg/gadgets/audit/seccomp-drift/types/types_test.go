@@ -0,0 +1,109 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	audit := Audit{}
+	audit.Add("errno", "unshare")
+	audit.Add("kill_thread", "mount")
+	audit.Add("errno", "unshare")
+	audit.Add("log", "chmod")
+
+	// Syscalls allowed without being logged are only known from the calls
+	called := []string{"chmod", "read"}
+
+	config := &specs.Spec{
+		Linux: &specs.Linux{
+			Seccomp: &specs.LinuxSeccomp{
+				DefaultAction: specs.ActErrno,
+				Syscalls: []specs.LinuxSyscall{
+					{Names: []string{"read", "write"}, Action: specs.ActAllow},
+					{Names: []string{"chmod", "chown", "fchown"}, Action: specs.ActLog},
+					{Names: []string{"mount"}, Action: specs.ActKillThread},
+				},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		config   *specs.Spec
+		expected *ContainerReport
+	}{
+		"applied": {
+			config: config,
+			expected: &ContainerReport{
+				Name:          "nginx",
+				Profile:       ProfileApplied,
+				DefaultAction: specs.ActErrno,
+				Denied:        map[string]uint64{"unshare": 2, "mount": 1},
+				LoggedAllowed: map[string]uint64{"chmod": 1},
+				UnusedAllowed: []string{"chown", "fchown", "write"},
+			},
+		},
+		"unconfined": {
+			config: &specs.Spec{Linux: &specs.Linux{}},
+			expected: &ContainerReport{
+				Name:          "nginx",
+				Profile:       ProfileUnconfined,
+				Denied:        map[string]uint64{"unshare": 2, "mount": 1},
+				LoggedAllowed: map[string]uint64{"chmod": 1},
+			},
+		},
+		"unknown": {
+			expected: &ContainerReport{
+				Name:          "nginx",
+				Profile:       ProfileUnknown,
+				Denied:        map[string]uint64{"unshare": 2, "mount": 1},
+				LoggedAllowed: map[string]uint64{"chmod": 1},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, test.expected, Compare("nginx", test.config, audit, called))
+		})
+	}
+
+	// Unused syscalls can't be known without the executed ones
+	require.Empty(t, Compare("nginx", config, audit, nil).UnusedAllowed)
+
+	report := Report{
+		"e9e4cd7e9b1d0f5b8a4c4d6f0b1f1f3e1d0c8b7a6f5e4d3c2b1a0f9e8d7c6b5a": Compare("nginx", config, audit, called),
+		"0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0": Compare("nginx", nil, Audit{}, nil),
+	}
+	require.Equal(t, `nginx (0f1e2d3c4b5a)
+  profile:        unknown
+  denied:         -
+  logged allowed: -
+nginx (e9e4cd7e9b1d)
+  profile:        applied (default action SCMP_ACT_ERRNO)
+  denied:         unshare (2), mount (1)
+  logged allowed: chmod (1)
+  unused allowed: chown, fchown, write
+`, report.String())
+}
@@ -19,6 +19,8 @@ type auditseccompEvent struct {
 	Syscall   uint64
 	Code      uint64
 	Comm      [16]uint8
+	Compat    uint8
+	_         [7]byte
 }
 
 // loadAuditseccomp returns the embedded CollectionSpec for auditseccomp.
@@ -69,9 +71,10 @@ type auditseccompProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type auditseccompMapSpecs struct {
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	TmpEvent             *ebpf.MapSpec `ebpf:"tmp_event"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	TmpEvent              *ebpf.MapSpec `ebpf:"tmp_event"`
 }
 
 // auditseccompObjects contains all objects after they have been loaded into the kernel.
@@ -93,14 +96,16 @@ func (o *auditseccompObjects) Close() error {
 //
 // It can be passed to loadAuditseccompObjects or ebpf.CollectionSpec.LoadAndAssign.
 type auditseccompMaps struct {
-	Events               *ebpf.Map `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	TmpEvent             *ebpf.Map `ebpf:"tmp_event"`
+	Events                *ebpf.Map `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	TmpEvent              *ebpf.Map `ebpf:"tmp_event"`
 }
 
 func (m *auditseccompMaps) Close() error {
 	return _AuditseccompClose(
 		m.Events,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.TmpEvent,
 	)
@@ -12,6 +12,8 @@
 #include "audit-seccomp.h"
 #include <gadget/mntns_filter.h>
 
+#define TS_COMPAT 0x0002
+
 /* The stack is limited, so use a map to build the event */
 struct {
 	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
@@ -24,6 +26,13 @@ struct {
 	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
 } events SEC(".maps");
 
+#ifdef __TARGET_ARCH_x86
+static __always_inline int is_x86_compat(struct task_struct *task)
+{
+	return !!(BPF_CORE_READ(task, thread_info.status) & TS_COMPAT);
+}
+#endif
+
 SEC("kprobe/audit_seccomp")
 int ig_audit_secc(struct pt_regs *ctx)
 {
@@ -48,6 +57,12 @@ int ig_audit_secc(struct pt_regs *ctx)
 	event->syscall = syscall;
 	event->code = code;
 	bpf_get_current_comm(&event->comm, sizeof(event->comm));
+#ifdef __TARGET_ARCH_x86
+	event->compat =
+		is_x86_compat((struct task_struct *)bpf_get_current_task());
+#else
+	event->compat = 0;
+#endif
 
 	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event,
 			      sizeof(*event));
@@ -10,6 +10,8 @@ struct event {
 	__u64 syscall;
 	__u64 code;
 	__u8 comm[TASK_COMM_LEN];
+	/* whether it is a 32-bit compat syscall on x86_64 */
+	__u8 compat;
 };
 
 #endif
@@ -36,12 +36,24 @@ const (
 	SECCOMP_RET_ACTION_FULL  = 0xffff0000
 )
 
-func syscallToName(syscall int) string {
-	name, ok := syscalls.GetSyscallNameByNumber(syscall)
+// syscallToName returns the name of the syscall and the architecture of its
+// ABI. compat is true for 32-bit compat syscalls, e.g. i386 ones on amd64.
+func syscallToName(syscall int, compat bool) (string, syscalls.Arch) {
+	table := syscalls.Native()
+	if compat {
+		table = syscalls.Compat()
+	}
+
+	var arch syscalls.Arch
+	if table != nil {
+		arch = table.Arch()
+	}
+
+	name, ok := table.GetSyscallNameByNumber(syscall)
 	if !ok {
 		name = fmt.Sprintf("syscall%d", syscall)
 	}
-	return name
+	return name, arch
 }
 
 func codeToName(code uint) string {
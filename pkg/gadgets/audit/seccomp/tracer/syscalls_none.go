@@ -17,9 +17,13 @@
 
 package tracer
 
-func syscallToName(syscall int) string {
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/syscalls"
+)
+
+func syscallToName(syscall int, compat bool) (string, syscalls.Arch) {
 	panic("Not implemented")
-	return ""
+	return "", ""
 }
 
 func codeToName(code uint) string {
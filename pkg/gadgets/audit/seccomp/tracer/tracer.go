@@ -51,6 +51,7 @@ type Tracer struct {
 
 type Config struct {
 	MountnsMap *ebpf.Map
+	CgroupMap  *ebpf.Map
 }
 
 func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
@@ -78,11 +79,11 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("loading ebpf program: %w", err)
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, t.config.CgroupMap, spec, nil, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
-	t.reader, err = perf.NewReader(t.objs.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	t.reader, err = gadgets.NewPerfReader(t.objs.Events, gadgets.PerfBufferPages*os.Getpagesize())
 	if err != nil {
 		return fmt.Errorf("getting a perf reader: %w", err)
 	}
@@ -110,12 +111,12 @@ func (t *Tracer) run() {
 		}
 
 		if record.LostSamples > 0 {
-			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
-			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			t.eventCallback(types.Base(eventtypes.Lost(record.LostSamples)))
 			continue
 		}
 
 		eventC := (*auditseccompEvent)(unsafe.Pointer(&record.RawSample[0]))
+		syscall, arch := syscallToName(int(eventC.Syscall), eventC.Compat != 0)
 
 		event := types.Event{
 			Event: eventtypes.Event{
@@ -124,7 +125,8 @@ func (t *Tracer) run() {
 			},
 			Pid:           uint32(eventC.Pid),
 			WithMountNsID: eventtypes.WithMountNsID{MountNsID: eventC.MntnsId},
-			Syscall:       syscallToName(int(eventC.Syscall)),
+			Syscall:       syscall,
+			Arch:          string(arch),
 			Code:          codeToName(uint(eventC.Code)),
 			Comm:          gadgets.FromCString(eventC.Comm[:]),
 		}
@@ -154,6 +156,7 @@ func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
 	}
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
 
 	go t.run()
 	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
@@ -165,6 +168,10 @@ func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
 	t.config.MountnsMap = mountnsMap
 }
 
+func (t *Tracer) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.config.CgroupMap = cgroupMap
+}
+
 func (t *Tracer) SetEventHandler(handler any) {
 	nh, ok := handler.(func(ev *types.Event))
 	if !ok {
@@ -179,3 +186,7 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	}
 	return t, nil
 }
+
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadAuditseccomp)
+}
@@ -0,0 +1,294 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadgets
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/btf"
+)
+
+// bindings describes a Go file generated by bpf2go
+type bindings struct {
+	// object is the path of the embedded eBPF object
+	object string
+	// stem is the prefix of the generated Go types, e.g. processCollector
+	stem string
+	// maps and programs are the names found in the ebpf struct tags of the
+	// *MapSpecs and *ProgramSpecs structs
+	maps     []string
+	programs []string
+	// types are the generated Go types, by name
+	types map[string]ast.Expr
+}
+
+func parseBindings(path string) (*bindings, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bindings{types: map[string]ast.Expr{}}
+
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if object, ok := strings.CutPrefix(comment.Text, "//go:embed "); ok {
+				b.object = filepath.Join(filepath.Dir(path), strings.TrimSpace(object))
+			}
+		}
+	}
+	if b.object == "" {
+		return nil, fmt.Errorf("no go:embed directive")
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			// func loadProcessCollector() (*ebpf.CollectionSpec, error)
+			if name, ok := strings.CutPrefix(decl.Name.Name, "load"); ok && decl.Recv == nil && !strings.HasSuffix(name, "Objects") {
+				b.stem = strings.ToLower(name[:1]) + name[1:]
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				b.types[typeSpec.Name.Name] = typeSpec.Type
+			}
+		}
+	}
+	if b.stem == "" {
+		return nil, fmt.Errorf("no load function")
+	}
+
+	for name, expr := range b.types {
+		var names *[]string
+		switch name {
+		case b.stem + "MapSpecs":
+			names = &b.maps
+		case b.stem + "ProgramSpecs":
+			names = &b.programs
+		default:
+			continue
+		}
+		for _, field := range expr.(*ast.StructType).Fields.List {
+			tag, _ := strconv.Unquote(field.Tag.Value)
+			*names = append(*names, reflect.StructTag(tag).Get("ebpf"))
+		}
+	}
+
+	// Only keep the types generated from BTF
+	for _, suffix := range []string{"Specs", "MapSpecs", "ProgramSpecs", "Objects", "Maps", "Programs"} {
+		delete(b.types, b.stem+suffix)
+	}
+
+	return b, nil
+}
+
+var basicSizes = map[string]uint32{
+	"bool": 1, "byte": 1, "int8": 1, "uint8": 1,
+	"int16": 2, "uint16": 2,
+	"int32": 4, "uint32": 4, "float32": 4,
+	"int64": 8, "uint64": 8, "float64": 8,
+}
+
+// size returns the size of a generated Go type. bpf2go adds explicit padding
+// fields, so the size of a struct is the sum of the sizes of its fields.
+func (b *bindings) size(expr ast.Expr) (uint32, error) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if size, ok := basicSizes[expr.Name]; ok {
+			return size, nil
+		}
+		if typ, ok := b.types[expr.Name]; ok {
+			return b.size(typ)
+		}
+		return 0, fmt.Errorf("unknown type %q", expr.Name)
+	case *ast.ArrayType:
+		lit, ok := expr.Len.(*ast.BasicLit)
+		if !ok {
+			return 0, fmt.Errorf("unsupported array length")
+		}
+		n, err := strconv.ParseUint(lit.Value, 0, 32)
+		if err != nil {
+			return 0, err
+		}
+		size, err := b.size(expr.Elt)
+		return uint32(n) * size, err
+	case *ast.StructType:
+		total := uint32(0)
+		for _, field := range expr.Fields.List {
+			size, err := b.size(field.Type)
+			if err != nil {
+				return 0, err
+			}
+			total += size * uint32(max(len(field.Names), 1))
+		}
+		return total, nil
+	}
+	return 0, fmt.Errorf("unsupported type %T", expr)
+}
+
+// normalize allows to compare the name of a BTF type with the name of the Go
+// type bpf2go generated for it
+func normalize(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+func checkBindings(t *testing.T, b *bindings) {
+	spec, err := ebpf.LoadCollectionSpec(b.object)
+	if err != nil {
+		t.Fatalf("loading %s: %s", b.object, err)
+	}
+
+	for _, name := range b.maps {
+		if _, ok := spec.Maps[name]; !ok {
+			t.Errorf("map %q is missing in %s", name, b.object)
+		}
+	}
+	for _, name := range b.programs {
+		if _, ok := spec.Programs[name]; !ok {
+			t.Errorf("program %q is missing in %s", name, b.object)
+		}
+	}
+
+	btfTypes := map[string]btf.Type{}
+	iter := spec.Types.Iterate()
+	for iter.Next() {
+		name := iter.Type.TypeName()
+		if name == "" {
+			continue
+		}
+		switch iter.Type.(type) {
+		case *btf.Struct, *btf.Union, *btf.Enum, *btf.Typedef, *btf.Int:
+			btfTypes[normalize(name)] = iter.Type
+		}
+	}
+
+	for name, expr := range b.types {
+		btfType, ok := btfTypes[normalize(strings.TrimPrefix(name, b.stem))]
+		if !ok {
+			t.Errorf("type %s has no BTF counterpart in %s", name, b.object)
+			continue
+		}
+		goSize, err := b.size(expr)
+		if err != nil {
+			t.Errorf("computing size of %s: %s", name, err)
+			continue
+		}
+		btfSize, err := btf.Sizeof(btfType)
+		if err != nil {
+			t.Errorf("computing size of %s in %s: %s", btfType.TypeName(), b.object, err)
+			continue
+		}
+		if goSize != uint32(btfSize) {
+			t.Errorf("type %s is %d bytes but %s is %d bytes in %s", name, goSize,
+				btfType.TypeName(), btfSize, b.object)
+		}
+		checkMembers(t, b, name, expr, btfType)
+	}
+}
+
+// addMembers adds the names of the members of a BTF struct or union to
+// members. bpf2go names the fields of anonymous members after their first
+// member, so they are added as well.
+func addMembers(members map[string]bool, btfType btf.Type) {
+	var btfMembers []btf.Member
+	switch btfType := btf.UnderlyingType(btfType).(type) {
+	case *btf.Struct:
+		btfMembers = btfType.Members
+	case *btf.Union:
+		btfMembers = btfType.Members
+	}
+
+	for _, member := range btfMembers {
+		if member.Name == "" {
+			addMembers(members, member.Type)
+			continue
+		}
+		members[normalize(member.Name)] = true
+	}
+}
+
+// checkMembers checks that the named fields of a generated Go struct exist in
+// its BTF counterpart, which catches fields added in place of padding.
+func checkMembers(t *testing.T, b *bindings, name string, expr ast.Expr, btfType btf.Type) {
+	goStruct, ok := expr.(*ast.StructType)
+	if !ok {
+		return
+	}
+
+	members := map[string]bool{}
+	addMembers(members, btfType)
+	if len(members) == 0 {
+		return
+	}
+
+	for _, field := range goStruct.Fields.List {
+		for _, fieldName := range field.Names {
+			if fieldName.Name == "_" {
+				continue
+			}
+			if !members[normalize(fieldName.Name)] {
+				t.Errorf("field %s.%s has no BTF counterpart in %s", name, fieldName.Name, b.object)
+			}
+		}
+	}
+}
+
+// TestBindingsMatchObjects checks that the Go bindings generated by bpf2go
+// match the eBPF objects they embed, i.e. that both were generated from the
+// same sources with 'make ebpf-objects'.
+func TestBindingsMatchObjects(t *testing.T) {
+	root := filepath.Join("..", "..")
+
+	found := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.Contains(d.Name(), "_bpfel") || !strings.HasSuffix(d.Name(), ".go") {
+			return nil
+		}
+
+		found++
+		rel, _ := filepath.Rel(root, path)
+		t.Run(rel, func(t *testing.T) {
+			b, err := parseBindings(path)
+			if err != nil {
+				t.Fatalf("parsing bindings: %s", err)
+			}
+			checkBindings(t, b)
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walking the repository: %s", err)
+	}
+	if found == 0 {
+		t.Fatalf("no bindings found")
+	}
+}
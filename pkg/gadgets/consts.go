@@ -26,4 +26,13 @@ const (
 	// Name of the map that stores the mount namespace inode id to filter on.
 	// Keep in syn with name used in pkg/gadgets/common/mntns_filter.h.
 	MntNsFilterMapName = "gadget_mntns_filter_map"
+
+	// Constant used to enable filtering by cgroup id in eBPF, for the
+	// containers that don't have their own mount namespace.
+	// Keep in sync with variable defined in include/gadget/mntns_filter.h.
+	FilterByCgroupName = "gadget_filter_by_cgroup"
+
+	// Name of the map that stores the cgroup ids to filter on.
+	// Keep in sync with name used in include/gadget/mntns_filter.h.
+	CgroupFilterMapName = "gadget_cgroup_filter_map"
 )
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"os"
 	"time"
 	"unsafe"
 
@@ -28,12 +29,80 @@ import (
 	"github.com/cilium/ebpf/asm"
 	"github.com/cilium/ebpf/features"
 	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
 	"golang.org/x/sys/unix"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfstats"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/btfgen"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
+// RegisterObjects attributes the eBPF programs and maps in objs to the gadget
+// instance running in gadgetCtx, so that top ebpf can report their cost per
+// gadget. It returns a function that removes the attribution; call it before
+// closing the objects.
+func RegisterObjects(gadgetCtx GadgetContext, objs ...any) func() {
+	return bpfstats.RegisterObjects(GadgetInfo(gadgetCtx), objs...)
+}
+
+// NewPerfReader creates a reader for the perf event array m, see
+// NewPerfReaderWithOptions
+func NewPerfReader(m *ebpf.Map, perCPUBuffer int) (*perf.Reader, error) {
+	return NewPerfReaderWithOptions(m, perCPUBuffer, perf.ReaderOptions{})
+}
+
+// NewPerfReaderWithOptions creates a reader for the perf event array m, like
+// perf.NewReaderWithOptions, and records the size of its buffers so that top
+// ebpf can report the memory they use
+func NewPerfReaderWithOptions(m *ebpf.Map, perCPUBuffer int, opts perf.ReaderOptions) (*perf.Reader, error) {
+	rd, err := perf.NewReaderWithOptions(m, perCPUBuffer, opts)
+	if err != nil {
+		return nil, err
+	}
+	bpfstats.SetPerfBufferSize(m, perfBufferSize(perCPUBuffer))
+	return rd, nil
+}
+
+// perfBufferSize returns the memory mapped for each CPU by a perf reader,
+// which rounds the buffer up to a power of two number of pages and adds a
+// metadata page
+func perfBufferSize(perCPUBuffer int) uint64 {
+	pageSize := uint64(os.Getpagesize())
+	nPages := (uint64(perCPUBuffer) + pageSize - 1) / pageSize
+	roundedPages := uint64(1)
+	for roundedPages < nPages {
+		roundedPages <<= 1
+	}
+	return (roundedPages + 1) * pageSize
+}
+
+// GadgetInfo returns the information used to attribute eBPF objects to the
+// gadget instance running in gadgetCtx
+func GadgetInfo(gadgetCtx GadgetContext) bpfstats.GadgetInfo {
+	info := bpfstats.GadgetInfo{ID: gadgetCtx.ID()}
+	if descGetter, ok := gadgetCtx.(interface{ GadgetDesc() GadgetDesc }); ok {
+		desc := descGetter.GadgetDesc()
+		info.Name = desc.Category() + " " + desc.Name()
+		if desc.Category() == CategoryNone {
+			info.Name = desc.Name()
+		}
+	}
+	return info
+}
+
+// LoadSpecs returns the specs created by the given loaders generated by bpf2go
+func LoadSpecs(loaders ...func() (*ebpf.CollectionSpec, error)) ([]*ebpf.CollectionSpec, error) {
+	specs := make([]*ebpf.CollectionSpec, 0, len(loaders))
+	for _, load := range loaders {
+		spec, err := load()
+		if err != nil {
+			return nil, fmt.Errorf("loading asset: %w", err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
 // CloseLink closes l if it's not nil and returns nil
 func CloseLink(l link.Link) link.Link {
 	if l != nil {
@@ -226,10 +295,12 @@ func FixBpfKtimeGetBootNs(programSpecs map[string]*ebpf.ProgramSpec) {
 }
 
 // LoadeBPFSpec is a helper to load an eBPF spec from gadgets.
-// It replaces filter map and calls the necessary functions to load
-// Maps and Programs into the kernel
+// It replaces filter maps and calls the necessary functions to load
+// Maps and Programs into the kernel. cgroupMap is only used together with
+// mountnsMap and can be nil.
 func LoadeBPFSpec(
 	mountnsMap *ebpf.Map,
+	cgroupMap *ebpf.Map,
 	spec *ebpf.CollectionSpec,
 	consts map[string]interface{},
 	objs interface{},
@@ -239,13 +310,19 @@ func LoadeBPFSpec(
 	mapReplacements := map[string]*ebpf.Map{}
 	filterByMntNs := false
 
+	if consts == nil {
+		consts = map[string]interface{}{}
+	}
+
 	if mountnsMap != nil {
 		filterByMntNs = true
 		mapReplacements[MntNsFilterMapName] = mountnsMap
-	}
 
-	if consts == nil {
-		consts = map[string]interface{}{}
+		// Objects built before the cgroup filter was added don't have it
+		if _, ok := spec.Maps[CgroupFilterMapName]; ok && cgroupMap != nil {
+			mapReplacements[CgroupFilterMapName] = cgroupMap
+			consts[FilterByCgroupName] = true
+		}
 	}
 
 	consts[FilterByMntNsName] = filterByMntNs
@@ -257,7 +334,7 @@ func LoadeBPFSpec(
 	opts := ebpf.CollectionOptions{
 		MapReplacements: mapReplacements,
 		Programs: ebpf.ProgramOptions{
-			KernelTypes: btfgen.GetBTFSpec(),
+			KernelTypes: btfgen.GetBTFSpec(spec),
 		},
 	}
 
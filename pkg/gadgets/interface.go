@@ -15,6 +15,8 @@
 package gadgets
 
 import (
+	"github.com/cilium/ebpf"
+
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
 )
@@ -83,7 +85,9 @@ type OutputFormats map[string]OutputFormat
 
 // OutputFormat can hold alternative output formats for a gadget. Whenever
 // such a format is used, the result of the gadget will be passed to the Transform()
-// function and returned to the user.
+// function and returned to the user. For gadgets returning a result, if
+// RequiresCombinedResult is set, the results of all nodes are passed at once
+// to Transform() as a map[string][]byte indexed by node.
 type OutputFormat struct {
 	Name                   string                    `json:"name"`
 	Description            string                    `json:"description"`
@@ -169,6 +173,13 @@ type GadgetInstantiate interface {
 	NewInstance() (Gadget, error)
 }
 
+// EBPFSpecsGetter is implemented by gadgets that can return the specs of the
+// eBPF objects they load without running, e.g. to check whether they can run on
+// the current kernel
+type EBPFSpecsGetter interface {
+	EBPFSpecs() ([]*ebpf.CollectionSpec, error)
+}
+
 // GadgetExperimental allows to mark a gadget as experimental.
 type GadgetExperimental interface {
 	Experimental() bool
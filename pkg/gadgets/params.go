@@ -17,7 +17,9 @@ package gadgets
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/histogram"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
 )
@@ -26,6 +28,17 @@ const (
 	ParamInterval = "interval"
 	ParamSortBy   = "sort"
 	ParamMaxRows  = "max-rows"
+	ParamSink     = "sink"
+
+	ParamSample       = "sample"
+	ParamSampleKey    = "sample-key"
+	ParamSamplePerKey = "sample-per-key"
+	ParamRateLimit    = "rate-limit"
+	ParamRateBurst    = "rate-burst"
+
+	ParamPerContainer    = "per-container"
+	ParamHistogramLayout = "layout"
+	ParamHistogramStep   = "linear-step"
 )
 
 const (
@@ -45,7 +58,8 @@ type DefaultSort interface {
 
 // GadgetParams returns params specific to the gadgets' type - for example, it returns
 // parameters for 'sort' and 'max-rows' for gadgets with sortable results, and 'interval'
-// for periodically called gadgets
+// for periodically called gadgets. Gadgets with a parser also get the 'sink' parameter and
+// tracing gadgets the parameters to sample and rate limit their events.
 func GadgetParams(gadget GadgetDesc, gType GadgetType, parser parser.Parser) params.ParamDescs {
 	p := params.ParamDescs{}
 	if gType.IsPeriodic() {
@@ -54,9 +68,172 @@ func GadgetParams(gadget GadgetDesc, gType GadgetType, parser parser.Parser) par
 	if gType.CanSort() {
 		p.Add(SortableParams(gadget, parser)...)
 	}
+	if parser != nil {
+		p.Add(SinkParams()...)
+		if gType == TypeTrace {
+			p.Add(LimitParams()...)
+		}
+	}
 	return p
 }
 
+// LimitParams returns the parameters used to reduce the number of events of tracing gadgets.
+// Like sinks, the limits are applied where the gadget runs, so fewer events are sent to the
+// client.
+func LimitParams() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamSample,
+			Title:        "Sample",
+			DefaultValue: "1",
+			TypeHint:     params.TypeUint32,
+			Description:  "Only keep every n-th event",
+		},
+		{
+			Key:         ParamSampleKey,
+			Title:       "Sample Key",
+			Description: "Sample events per key: every second, keep a random selection of events of each combination of values of the given columns. Join multiple columns with ','.",
+		},
+		{
+			Key:          ParamSamplePerKey,
+			Title:        "Sample Per Key",
+			DefaultValue: "1",
+			TypeHint:     params.TypeUint32,
+			Description:  "Number of events to keep per key and second when using --" + ParamSampleKey,
+		},
+		{
+			Key:          ParamRateLimit,
+			Title:        "Rate Limit",
+			DefaultValue: "0",
+			TypeHint:     params.TypeFloat64,
+			Description:  "Maximum number of events per second (0: unlimited)",
+		},
+		{
+			Key:          ParamRateBurst,
+			Title:        "Rate Burst",
+			DefaultValue: "0",
+			TypeHint:     params.TypeUint32,
+			Description:  "Number of events that can exceed the rate limit at once (0: same as --" + ParamRateLimit + ")",
+		},
+	}
+}
+
+// LimitsFromParams returns the limits configured using the parameters of LimitParams; it
+// returns no limits if gadgetParams doesn't contain them
+func LimitsFromParams(gadgetParams *params.Params) parser.Limits {
+	limits := parser.Limits{}
+	if gadgetParams == nil || gadgetParams.Get(ParamSample) == nil {
+		return limits
+	}
+	limits.SampleRate = gadgetParams.Get(ParamSample).AsUint32()
+	limits.SampleKeys = gadgetParams.Get(ParamSampleKey).AsStringSlice()
+	limits.ReservoirSize = gadgetParams.Get(ParamSamplePerKey).AsUint32()
+	limits.RateLimit = gadgetParams.Get(ParamRateLimit).AsFloat64()
+	limits.RateBurst = gadgetParams.Get(ParamRateBurst).AsUint32()
+	return limits
+}
+
+// HistogramConfig configures how histogram gadgets collect their data
+type HistogramConfig struct {
+	// Interval is the time between two rows of the heatmap; 0 disables it
+	Interval time.Duration
+
+	// PerContainer creates one histogram per container
+	PerContainer bool
+
+	Layout histogram.Layout
+
+	// Step is the width of the slots of the linear layout
+	Step uint64
+}
+
+// HistogramParams returns the parameters shared by gadgets reporting histograms
+func HistogramParams() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamInterval,
+			Title:        "Interval",
+			DefaultValue: "0",
+			TypeHint:     params.TypeUint32,
+			Description:  "Also collect a histogram every n seconds and show them as a heatmap (0: disabled)",
+		},
+		{
+			Key:          ParamPerContainer,
+			Title:        "Per Container",
+			DefaultValue: "false",
+			TypeHint:     params.TypeBool,
+			Description:  "Create one histogram per container",
+		},
+		{
+			Key:            ParamHistogramLayout,
+			Title:          "Layout",
+			DefaultValue:   string(histogram.LayoutExp2),
+			PossibleValues: histogram.AllLayouts,
+			ValueDescriptions: map[string]string{
+				string(histogram.LayoutExp2):      "One slot per power of two",
+				string(histogram.LayoutLinear):    "Slots of a fixed width, set with the linear step",
+				string(histogram.LayoutLogLinear): "Each power of two split into slots of the same width",
+			},
+			Description: "Layout of the histogram slots",
+		},
+		{
+			Key:          ParamHistogramStep,
+			Title:        "Linear Step",
+			DefaultValue: "100",
+			TypeHint:     params.TypeUint64,
+			Description:  "Width of the slots when using the linear layout",
+		},
+	}
+}
+
+// HistogramConfigFromParams returns the configuration set using the parameters of
+// HistogramParams
+func HistogramConfigFromParams(gadgetParams *params.Params) (*HistogramConfig, error) {
+	config := &HistogramConfig{
+		Interval:     time.Duration(gadgetParams.Get(ParamInterval).AsUint32()) * time.Second,
+		PerContainer: gadgetParams.Get(ParamPerContainer).AsBool(),
+		Layout:       histogram.Layout(gadgetParams.Get(ParamHistogramLayout).AsString()),
+		Step:         gadgetParams.Get(ParamHistogramStep).AsUint64(),
+	}
+	if config.Layout == histogram.LayoutLinear && config.Step == 0 {
+		return nil, fmt.Errorf("--%s must be greater than 0", ParamHistogramStep)
+	}
+	return config, nil
+}
+
+// Consts returns the constants configuring the histogram in eBPF (see
+// include/gadget/histogram.bpf.h), using perContainerConst to enable the
+// per-container histograms. Only values different from the defaults are
+// returned, so that programs not supporting them still load with the
+// default configuration.
+func (c *HistogramConfig) Consts(perContainerConst string) map[string]interface{} {
+	consts := map[string]interface{}{}
+	if c.PerContainer {
+		consts[perContainerConst] = true
+	}
+	switch c.Layout {
+	case histogram.LayoutLinear:
+		consts["hist_layout"] = uint32(1)
+		consts["hist_step"] = c.Step
+	case histogram.LayoutLogLinear:
+		consts["hist_layout"] = uint32(2)
+	}
+	return consts
+}
+
+// SinkParams returns the parameter used to send the events of a single run to
+// output sinks (see pkg/sinks). Sinks are opened where the gadget runs, i.e. on
+// the nodes for remote runtimes.
+func SinkParams() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamSink,
+			Title:       "Sink",
+			Description: "Also send events to the given sinks. Join multiple URLs with ','. Supported: file://, syslog://, syslog+tcp://, otlp-grpc://, otlp-http(s)://, kafka://. Remote runs only allow the sinks enabled on the daemon",
+		},
+	}
+}
+
 func IntervalParams() params.ParamDescs {
 	return params.ParamDescs{
 		{
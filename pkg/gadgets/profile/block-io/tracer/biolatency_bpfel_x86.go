@@ -12,11 +12,17 @@ import (
 	"github.com/cilium/ebpf"
 )
 
-type biolatencyHist struct{ Slots [27]uint32 }
+type biolatencyHist struct{ Slots [64]uint32 }
 
 type biolatencyHistKey struct {
 	CmdFlags uint32
 	Dev      uint32
+	MntnsId  uint64
+}
+
+type biolatencyRqStart struct {
+	Ts      uint64
+	MntnsId uint64
 }
 
 // loadBiolatency returns the embedded CollectionSpec for biolatency.
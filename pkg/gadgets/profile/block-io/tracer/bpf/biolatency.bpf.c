@@ -5,8 +5,9 @@
 #include <bpf/bpf_core_read.h>
 #include <bpf/bpf_tracing.h>
 #include "biolatency.h"
-#include <gadget/bits.bpf.h>
 #include <gadget/core_fixes.bpf.h>
+#include <gadget/histogram.bpf.h>
+#include <gadget/mntns.h>
 
 #define MAX_ENTRIES 10240
 
@@ -17,6 +18,9 @@ const volatile bool targ_queued = false;
 const volatile bool targ_ms = false;
 const volatile bool filter_dev = false;
 const volatile __u32 targ_dev = 0;
+const volatile bool targ_per_mntns = false;
+const volatile __u32 hist_layout = HIST_LAYOUT_EXP2;
+const volatile __u64 hist_step = 1;
 
 extern int LINUX_KERNEL_VERSION __kconfig;
 
@@ -27,11 +31,16 @@ struct {
 	__uint(max_entries, 1);
 } cgroup_map SEC(".maps");
 
+struct rq_start {
+	u64 ts;
+	gadget_mntns_id mntns_id;
+};
+
 struct {
 	__uint(type, BPF_MAP_TYPE_HASH);
 	__uint(max_entries, MAX_ENTRIES);
 	__type(key, struct request *);
-	__type(value, u64);
+	__type(value, struct rq_start);
 } start SEC(".maps");
 
 static struct hist initial_hist;
@@ -48,7 +57,11 @@ static __always_inline int trace_rq_start(struct request *rq, int issue)
 	if (issue && targ_queued && BPF_CORE_READ(rq, q, elevator))
 		return 0;
 
-	u64 ts = bpf_ktime_get_ns();
+	struct rq_start info = {};
+
+	info.ts = bpf_ktime_get_ns();
+	if (targ_per_mntns)
+		info.mntns_id = gadget_get_mntns_id();
 
 	if (filter_dev) {
 		struct gendisk *disk = get_disk(rq);
@@ -60,7 +73,7 @@ static __always_inline int trace_rq_start(struct request *rq, int issue)
 		if (targ_dev != dev)
 			return 0;
 	}
-	bpf_map_update_elem(&start, &rq, &ts, 0);
+	bpf_map_update_elem(&start, &rq, &info, 0);
 	return 0;
 }
 
@@ -105,15 +118,16 @@ int BPF_PROG(ig_profio_done, struct request *rq, int error,
 	if (filter_cg && !bpf_current_task_under_cgroup(&cgroup_map, 0))
 		return 0;
 
-	u64 slot, *tsp, ts = bpf_ktime_get_ns();
+	u64 slot, ts = bpf_ktime_get_ns();
 	struct hist_key hkey = {};
+	struct rq_start *infop;
 	struct hist *histp;
 	s64 delta;
 
-	tsp = bpf_map_lookup_elem(&start, &rq);
-	if (!tsp)
+	infop = bpf_map_lookup_elem(&start, &rq);
+	if (!infop)
 		return 0;
-	delta = (s64)(ts - *tsp);
+	delta = (s64)(ts - infop->ts);
 	if (delta < 0)
 		goto cleanup;
 
@@ -127,6 +141,10 @@ int BPF_PROG(ig_profio_done, struct request *rq, int error,
 	}
 	if (targ_per_flag)
 		hkey.cmd_flags = BPF_CORE_READ(rq, cmd_flags);
+	// Completion runs in interrupt context, so use the mount namespace of the
+	// task that queued the request
+	if (targ_per_mntns)
+		hkey.mntns_id = infop->mntns_id;
 
 	histp = bpf_map_lookup_elem(&hists, &hkey);
 	if (!histp) {
@@ -140,9 +158,7 @@ int BPF_PROG(ig_profio_done, struct request *rq, int error,
 		delta /= 1000000U;
 	else
 		delta /= 1000U;
-	slot = log2l(delta);
-	if (slot >= MAX_SLOTS)
-		slot = MAX_SLOTS - 1;
+	slot = gadget_hist_slot(delta, hist_layout, hist_step, MAX_SLOTS);
 	__sync_fetch_and_add(&histp->slots[slot], 1);
 
 cleanup:
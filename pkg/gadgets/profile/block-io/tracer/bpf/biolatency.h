@@ -3,7 +3,7 @@
 #define __BIOLATENCY_H
 
 #define DISK_NAME_LEN 32
-#define MAX_SLOTS 27
+#define MAX_SLOTS 64
 
 #define MINORBITS 20
 #define MINORMASK ((1U << MINORBITS) - 1)
@@ -13,6 +13,7 @@
 struct hist_key {
 	__u32 cmd_flags;
 	__u32 dev;
+	__u64 mntns_id;
 };
 
 struct hist {
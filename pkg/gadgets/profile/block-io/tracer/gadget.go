@@ -44,7 +44,7 @@ func (g *GadgetDesc) Description() string {
 }
 
 func (g *GadgetDesc) ParamDescs() params.ParamDescs {
-	return nil
+	return gadgets.HistogramParams()
 }
 
 func (g *GadgetDesc) Parser() parser.Parser {
@@ -18,7 +18,10 @@ package tracer
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 	"unsafe"
 
 	"github.com/cilium/ebpf"
@@ -37,10 +40,16 @@ type Tracer struct {
 	blockRqCompleteLink link.Link
 	blockRqInsertLink   link.Link
 	blockRqIssueLink    link.Link
+
+	config       *gadgets.HistogramConfig
+	recorder     *histogram.Recorder[uint64]
+	enricherFunc func(ev any) error
 }
 
 func NewTracer() (*Tracer, error) {
-	t := &Tracer{}
+	t := &Tracer{
+		config: &gadgets.HistogramConfig{Layout: histogram.LayoutExp2},
+	}
 
 	if err := t.install(); err != nil {
 		t.Stop()
@@ -50,18 +59,107 @@ func NewTracer() (*Tracer, error) {
 	return t, nil
 }
 
-func getReport(histMap *ebpf.Map) (*types.Report, error) {
+// readHists returns the slots of the histograms by mount namespace; all
+// histograms are stored with mount namespace 0 if they aren't collected per
+// container
+func readHists(histMap *ebpf.Map) (map[uint64][]uint32, error) {
+	hists := make(map[uint64][]uint32)
+
 	key := biolatencyHistKey{}
-	if err := histMap.NextKey(nil, unsafe.Pointer(&key)); err != nil {
+	err := histMap.NextKey(nil, unsafe.Pointer(&key))
+	for err == nil {
+		hist := biolatencyHist{}
+		if err := histMap.Lookup(unsafe.Pointer(&key), unsafe.Pointer(&hist)); err != nil {
+			return nil, fmt.Errorf("getting histogram: %w", err)
+		}
+
+		slots, ok := hists[key.MntnsId]
+		if !ok {
+			slots = make([]uint32, len(hist.Slots))
+			hists[key.MntnsId] = slots
+		}
+		for i, val := range hist.Slots {
+			slots[i] += val
+		}
+
+		prev := key
+		err = histMap.NextKey(unsafe.Pointer(&prev), unsafe.Pointer(&key))
+	}
+	if !errors.Is(err, ebpf.ErrKeyNotExist) {
 		return nil, fmt.Errorf("getting next key: %w", err)
 	}
 
-	hist := biolatencyHist{}
-	if err := histMap.Lookup(key, unsafe.Pointer(&hist)); err != nil {
-		return nil, fmt.Errorf("getting histogram: %w", err)
+	return hists, nil
+}
+
+func (t *Tracer) getReport(hists map[uint64][]uint32) (*types.Report, error) {
+	total := make([]uint32, len(biolatencyHist{}.Slots))
+	for _, slots := range hists {
+		for i, val := range slots {
+			total[i] += val
+		}
+	}
+
+	h, err := histogram.New(histogram.UnitMicroseconds, t.config.Layout, total, t.config.Step)
+	if err != nil {
+		return nil, err
+	}
+	report := &types.Report{Histogram: h}
+	if t.recorder != nil {
+		report.Heatmap = t.recorder.Heatmap(allContainers)
+	}
+
+	if !t.config.PerContainer {
+		return report, nil
+	}
+
+	for mntnsID, slots := range hists {
+		h, err := histogram.New(histogram.UnitMicroseconds, t.config.Layout, slots, t.config.Step)
+		if err != nil {
+			return nil, err
+		}
+		c := &types.ContainerReport{
+			Histogram: h,
+			MountNsID: mntnsID,
+		}
+		if t.recorder != nil {
+			c.Heatmap = t.recorder.Heatmap(mntnsID)
+		}
+		if t.enricherFunc != nil {
+			t.enricherFunc(c)
+		}
+		report.Containers = append(report.Containers, c)
+	}
+	sort.Slice(report.Containers, func(i, j int) bool {
+		return report.Containers[i].Title() < report.Containers[j].Title()
+	})
+
+	return report, nil
+}
+
+// allContainers is the key of the heatmap of all events in the recorder; mount
+// namespace IDs are never 0
+const allContainers = 0
+
+// record adds a row to the heatmaps with the events since the last call
+func (t *Tracer) record(ts time.Time) error {
+	hists, err := readHists(t.objs.Hists)
+	if err != nil {
+		return err
+	}
+
+	total := make([]uint32, len(biolatencyHist{}.Slots))
+	for _, slots := range hists {
+		for i, val := range slots {
+			total[i] += val
+		}
+	}
+	if !t.config.PerContainer {
+		hists = make(map[uint64][]uint32)
 	}
+	hists[allContainers] = total
 
-	return types.NewReport(histogram.UnitMicroseconds, hist.Slots[:]), nil
+	return t.recorder.Record(ts, hists)
 }
 
 func (t *Tracer) Stop() (string, error) {
@@ -78,7 +176,11 @@ func (t *Tracer) collectResult() ([]byte, error) {
 	if t.objs.Hists == nil {
 		return nil, nil
 	}
-	report, err := getReport(t.objs.Hists)
+	hists, err := readHists(t.objs.Hists)
+	if err != nil {
+		return nil, err
+	}
+	report, err := t.getReport(hists)
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +201,12 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("loading ebpf program: %w", err)
 	}
 
+	if consts := t.config.Consts("targ_per_mntns"); len(consts) > 0 {
+		if err := spec.RewriteConstants(consts); err != nil {
+			return fmt.Errorf("rewriting constants: %w", err)
+		}
+	}
+
 	if err := spec.LoadAndAssign(&t.objs, nil); err != nil {
 		return fmt.Errorf("loading ebpf program: %w", err)
 	}
@@ -131,13 +239,46 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	return t, nil
 }
 
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadBiolatency)
+}
+
+func (t *Tracer) SetEventEnricher(enricher func(ev any) error) {
+	t.enricherFunc = enricher
+}
+
 func (t *Tracer) RunWithResult(gadgetCtx gadgets.GadgetContext) ([]byte, error) {
+	config, err := gadgets.HistogramConfigFromParams(gadgetCtx.GadgetParams())
+	if err != nil {
+		return nil, fmt.Errorf("parsing parameters: %w", err)
+	}
+	t.config = config
+
 	defer t.close()
 	if err := t.install(); err != nil {
 		return nil, fmt.Errorf("installing tracer: %w", err)
 	}
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
 
-	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+	ctx, cancel := gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	defer cancel()
 
-	return t.collectResult()
+	if t.config.Interval == 0 {
+		<-ctx.Done()
+		return t.collectResult()
+	}
+
+	t.recorder = histogram.NewRecorder[uint64](histogram.UnitMicroseconds, t.config.Layout, t.config.Step)
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case ts := <-ticker.C:
+			if err := t.record(ts); err != nil {
+				return nil, fmt.Errorf("recording histogram: %w", err)
+			}
+		case <-ctx.Done():
+			return t.collectResult()
+		}
+	}
 }
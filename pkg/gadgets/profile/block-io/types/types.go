@@ -15,11 +15,49 @@
 package types
 
 import (
+	"fmt"
+	"strings"
+
 	histogram "github.com/inspektor-gadget/inspektor-gadget/pkg/histogram"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
 type Report struct {
 	*histogram.Histogram `json:",inline"`
+
+	// Heatmap contains one histogram per interval, if enabled
+	Heatmap *histogram.Heatmap `json:"heatmap,omitempty"`
+
+	// Containers contains one histogram per container, if enabled
+	Containers []*ContainerReport `json:"containers,omitempty"`
+}
+
+// ContainerReport contains the histogram of the I/O issued by the processes
+// of one mount namespace
+type ContainerReport struct {
+	eventtypes.CommonData
+	*histogram.Histogram `json:",inline"`
+
+	Heatmap *histogram.Heatmap `json:"heatmap,omitempty"`
+
+	MountNsID uint64 `json:"mountnsid,omitempty"`
+}
+
+func (c *ContainerReport) GetMountNSID() uint64 {
+	return c.MountNsID
+}
+
+// Title returns the name of the container or its mount namespace, if it
+// isn't a known container
+func (c *ContainerReport) Title() string {
+	switch {
+	case c.K8s.PodName != "":
+		return fmt.Sprintf("%s/%s/%s", c.K8s.Namespace, c.K8s.PodName, c.K8s.ContainerName)
+	case c.Runtime.ContainerName != "":
+		return c.Runtime.ContainerName
+	default:
+		return fmt.Sprintf("mntns %d", c.MountNsID)
+	}
 }
 
 func NewReport(unit histogram.Unit, slots []uint32) *Report {
@@ -30,3 +68,21 @@ func NewReport(unit histogram.Unit, slots []uint32) *Report {
 		},
 	}
 }
+
+func (r *Report) String() string {
+	var sb strings.Builder
+	sb.WriteString(r.Histogram.String())
+	if r.Heatmap != nil {
+		sb.WriteString("\n")
+		sb.WriteString(r.Heatmap.String())
+	}
+	for _, c := range r.Containers {
+		sb.WriteString(fmt.Sprintf("\n%s:\n", c.Title()))
+		sb.WriteString(c.Histogram.String())
+		if c.Heatmap != nil {
+			sb.WriteString("\n")
+			sb.WriteString(c.Heatmap.String())
+		}
+	}
+	return sb.String()
+}
@@ -39,6 +39,7 @@ import (
 
 type Config struct {
 	MountnsMap      *ebpf.Map
+	CgroupMap       *ebpf.Map
 	UserStackOnly   bool
 	KernelStackOnly bool
 }
@@ -252,7 +253,7 @@ func (t *Tracer) install() error {
 		"user_stacks_only":   t.config.UserStackOnly,
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, t.config.CgroupMap, spec, consts, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
@@ -312,6 +313,7 @@ func (t *TracerWrap) Run(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
 	}
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
 
 	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
 
@@ -356,6 +358,10 @@ func (t *TracerWrap) SetMountNsMap(mountNsMap *ebpf.Map) {
 	t.Tracer.config.MountnsMap = mountNsMap
 }
 
+func (t *TracerWrap) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.Tracer.config.CgroupMap = cgroupMap
+}
+
 func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	tracer := &TracerWrap{
 		Tracer: Tracer{
@@ -364,3 +370,7 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	}
 	return tracer, nil
 }
+
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadProfile)
+}
@@ -7,7 +7,7 @@
 #include <bpf/bpf_tracing.h>
 #include <bpf/bpf_endian.h>
 #include "tcprtt.h"
-#include <gadget/bits.bpf.h>
+#include <gadget/histogram.bpf.h>
 #include <gadget/maps.bpf.h>
 
 /* Taken from kernel include/linux/socket.h. */
@@ -23,6 +23,9 @@ const volatile __u32 targ_daddr = 0;
 const volatile __u8 targ_saddr_v6[IPV6_LEN] = {};
 const volatile __u8 targ_daddr_v6[IPV6_LEN] = {};
 const volatile bool targ_ms = false;
+const volatile bool targ_per_netns = false;
+const volatile __u32 hist_layout = HIST_LAYOUT_EXP2;
+const volatile __u64 hist_step = 1;
 
 #define MAX_ENTRIES 10240
 
@@ -149,6 +152,13 @@ static int handle_tcp_rcv_established(struct sock *sk)
 		key.family = 0;
 	}
 
+	/*
+	 * This runs in softirq context, so the current task is not related to the
+	 * socket. Use the network namespace of the socket instead.
+	 */
+	if (targ_per_netns)
+		key.netns = BPF_CORE_READ(sk, __sk_common.skc_net.net, ns.inum);
+
 	histp = bpf_map_lookup_or_try_init(&hists, &key, &zero);
 	if (!histp)
 		return 0;
@@ -156,9 +166,7 @@ static int handle_tcp_rcv_established(struct sock *sk)
 	srtt = BPF_CORE_READ(ts, srtt_us) >> 3;
 	if (targ_ms)
 		srtt /= 1000U;
-	slot = log2l(srtt);
-	if (slot >= MAX_SLOTS)
-		slot = MAX_SLOTS - 1;
+	slot = gadget_hist_slot(srtt, hist_layout, hist_step, MAX_SLOTS);
 	__sync_fetch_and_add(&histp->slots[slot], 1);
 	__sync_fetch_and_add(&histp->latency, srtt);
 	__sync_fetch_and_add(&histp->cnt, 1);
@@ -2,7 +2,7 @@
 #ifndef __TCPRTT_H
 #define __TCPRTT_H
 
-#define MAX_SLOTS 27
+#define MAX_SLOTS 64
 #define IPV6_LEN 16
 
 struct hist {
@@ -14,6 +14,7 @@ struct hist {
 struct hist_key {
 	__u16 family;
 	__u8 addr[IPV6_LEN];
+	__u32 netns;
 };
 
 #endif /* __TCPRTT_H */
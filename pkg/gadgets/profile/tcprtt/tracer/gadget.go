@@ -57,7 +57,7 @@ func (g *GadgetDesc) Description() string {
 }
 
 func (g *GadgetDesc) ParamDescs() params.ParamDescs {
-	return params.ParamDescs{
+	return append(params.ParamDescs{
 		{
 			Key:          ParamMilliseconds,
 			Alias:        "m",
@@ -121,7 +121,7 @@ func (g *GadgetDesc) ParamDescs() params.ParamDescs {
 			Description:  "Filter for remote address using IPv6",
 			TypeHint:     params.TypeIP,
 		},
-	}
+	}, gadgets.HistogramParams()...)
 }
 
 func (g *GadgetDesc) Parser() parser.Parser {
@@ -149,6 +149,9 @@ func (g *GadgetDesc) OutputFormats() (gadgets.OutputFormats, string) {
 				}
 				var sb strings.Builder
 				for _, h := range report.Histograms {
+					if container := h.Container(); container != "" {
+						sb.WriteString(fmt.Sprintf("%s: ", container))
+					}
 					sb.WriteString(fmt.Sprintf("%s = %s", h.AddressType, h.Address))
 
 					if h.LocalPort > 0 {
@@ -163,6 +166,9 @@ func (g *GadgetDesc) OutputFormats() (gadgets.OutputFormats, string) {
 						sb.WriteString(fmt.Sprintf(" [AVG %f]", h.Average))
 					}
 					sb.WriteString(fmt.Sprintf("\n%s\n", h.Histogram.String()))
+					if h.Heatmap != nil {
+						sb.WriteString(fmt.Sprintf("%s\n", h.Heatmap.String()))
+					}
 				}
 				return []byte(sb.String()), nil
 			},
@@ -15,13 +15,14 @@ import (
 type tcpRTTHist struct {
 	Latency uint64
 	Cnt     uint64
-	Slots   [27]uint32
-	_       [4]byte
+	Slots   [64]uint32
 }
 
 type tcpRTTHistKey struct {
 	Family uint16
 	Addr   [16]uint8
+	_      [2]byte
+	Netns  uint32
 }
 
 // loadTcpRTT returns the embedded CollectionSpec for tcpRTT.
@@ -21,7 +21,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"time"
 	"unsafe"
 
 	"github.com/cilium/ebpf"
@@ -54,8 +56,11 @@ type Tracer struct {
 	objs                tcpRTTObjects
 	tcpRcvEstKprobeLink link.Link
 
-	config *Config
-	logger logger.Logger
+	config       *Config
+	histConfig   *gadgets.HistogramConfig
+	recorder     *histogram.Recorder[tcpRTTHistKey]
+	enricherFunc func(ev any) error
+	logger       logger.Logger
 }
 
 func (t *Tracer) RunWithResult(gadgetCtx gadgets.GadgetContext) ([]byte, error) {
@@ -64,13 +69,39 @@ func (t *Tracer) RunWithResult(gadgetCtx gadgets.GadgetContext) ([]byte, error)
 	if err := t.parseParams(gadgetCtx.GadgetParams()); err != nil {
 		return nil, fmt.Errorf("parsing parameters: %w", err)
 	}
+	histConfig, err := gadgets.HistogramConfigFromParams(gadgetCtx.GadgetParams())
+	if err != nil {
+		return nil, fmt.Errorf("parsing parameters: %w", err)
+	}
+	t.histConfig = histConfig
 
 	defer t.close()
 	if err := t.install(); err != nil {
 		return nil, fmt.Errorf("installing tracer: %w", err)
 	}
-
-	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
+
+	ctx, cancel := gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	defer cancel()
+
+	if t.histConfig.Interval > 0 {
+		t.recorder = histogram.NewRecorder[tcpRTTHistKey](t.unit(), t.histConfig.Layout, t.histConfig.Step)
+		ticker := time.NewTicker(t.histConfig.Interval)
+		defer ticker.Stop()
+	loop:
+		for {
+			select {
+			case ts := <-ticker.C:
+				if err := t.record(ts); err != nil {
+					return nil, fmt.Errorf("recording histogram: %w", err)
+				}
+			case <-ctx.Done():
+				break loop
+			}
+		}
+	} else {
+		<-ctx.Done()
+	}
 
 	result, err := t.collectResult()
 	if err != nil {
@@ -158,23 +189,61 @@ func (t *Tracer) parseParams(params *params.Params) error {
 	return nil
 }
 
-func (t *Tracer) collectResult() ([]byte, error) {
+// readHists returns the histograms stored in the hists map
+func (t *Tracer) readHists() (map[tcpRTTHistKey]tcpRTTHist, error) {
 	histsMap := t.objs.Hists
+	hists := make(map[tcpRTTHistKey]tcpRTTHist)
 
 	var key tcpRTTHistKey
-	if err := histsMap.NextKey(nil, unsafe.Pointer(&key)); err != nil {
-		if errors.Is(err, ebpf.ErrKeyNotExist) {
-			return nil, fmt.Errorf("no data was collected to generate the histogram")
+	err := histsMap.NextKey(nil, unsafe.Pointer(&key))
+	for err == nil {
+		hist := tcpRTTHist{}
+		if err := histsMap.Lookup(unsafe.Pointer(&key), unsafe.Pointer(&hist)); err != nil {
+			return nil, fmt.Errorf("getting data for histogram key %v: %w", key, err)
 		}
-		return nil, fmt.Errorf("getting first histogram key: %w", err)
+		hists[key] = hist
+
+		prev := key
+		err = histsMap.NextKey(unsafe.Pointer(&prev), unsafe.Pointer(&key))
+	}
+	if !errors.Is(err, ebpf.ErrKeyNotExist) {
+		return nil, fmt.Errorf("getting next histogram key: %w", err)
 	}
 
-	var unit histogram.Unit
+	return hists, nil
+}
+
+func (t *Tracer) unit() histogram.Unit {
 	if t.config.useMilliseconds {
-		unit = histogram.UnitMilliseconds
-	} else {
-		unit = histogram.UnitMicroseconds
+		return histogram.UnitMilliseconds
 	}
+	return histogram.UnitMicroseconds
+}
+
+// record adds a row to the heatmaps with the events since the last call
+func (t *Tracer) record(ts time.Time) error {
+	hists, err := t.readHists()
+	if err != nil {
+		return err
+	}
+
+	slots := make(map[tcpRTTHistKey][]uint32, len(hists))
+	for key, hist := range hists {
+		slots[key] = hist.Slots[:]
+	}
+	return t.recorder.Record(ts, slots)
+}
+
+func (t *Tracer) collectResult() ([]byte, error) {
+	hists, err := t.readHists()
+	if err != nil {
+		return nil, err
+	}
+	if len(hists) == 0 {
+		return nil, fmt.Errorf("no data was collected to generate the histogram")
+	}
+
+	unit := t.unit()
 
 	var addressType types.AddressType
 	if t.config.localAddrHist {
@@ -186,11 +255,10 @@ func (t *Tracer) collectResult() ([]byte, error) {
 	}
 
 	report := types.Report{
-		Histograms: make([]*types.ExtendedHistogram, 0),
+		Histograms: make([]*types.ExtendedHistogram, 0, len(hists)),
 	}
 
-	var prev tcpRTTHistKey
-	for {
+	for key, hist := range hists {
 		var addr string
 		if addressType == types.AddressTypeAll {
 			addr = types.WildcardAddress
@@ -198,27 +266,34 @@ func (t *Tracer) collectResult() ([]byte, error) {
 			addr = gadgets.IPStringFromBytes(key.Addr, gadgets.IPVerFromAF(key.Family))
 		}
 
-		hist := tcpRTTHist{}
-		if err := histsMap.Lookup(key, unsafe.Pointer(&hist)); err != nil {
-			return nil, fmt.Errorf("getting data for histogram key %d (%s): %w", key, addr, err)
-		}
-
 		var avg float64
 		if hist.Cnt > 0 {
 			avg = float64(hist.Latency) / float64(hist.Cnt)
 		}
 
-		h := types.NewHistogram(unit, hist.Slots[:], addressType, addr, avg, t.config.filterLocalPort, t.config.filterRemotePort)
-		report.Histograms = append(report.Histograms, h)
-
-		prev = key
-		if err := histsMap.NextKey(unsafe.Pointer(&prev), unsafe.Pointer(&key)); err != nil {
-			if errors.Is(err, ebpf.ErrKeyNotExist) {
-				break
+		h := types.NewHistogram(unit, nil, addressType, addr, avg, t.config.filterLocalPort, t.config.filterRemotePort)
+		h.Histogram, err = histogram.New(unit, t.histConfig.Layout, hist.Slots[:], t.histConfig.Step)
+		if err != nil {
+			return nil, err
+		}
+		if t.recorder != nil {
+			h.Heatmap = t.recorder.Heatmap(key)
+		}
+		if t.histConfig.PerContainer {
+			h.NetNsID = uint64(key.Netns)
+			if t.enricherFunc != nil {
+				t.enricherFunc(h)
 			}
-			return nil, fmt.Errorf("getting next histogram key: %w", err)
 		}
+		report.Histograms = append(report.Histograms, h)
 	}
+	sort.Slice(report.Histograms, func(i, j int) bool {
+		hi, hj := report.Histograms[i], report.Histograms[j]
+		if hi.Container() != hj.Container() {
+			return hi.Container() < hj.Container()
+		}
+		return hi.Address < hj.Address
+	})
 
 	return json.Marshal(report)
 }
@@ -249,13 +324,19 @@ func (t *Tracer) install() error {
 		"targ_daddr_v6":   t.config.filterRemoteAddressV6,
 	}
 
+	if t.histConfig != nil {
+		for name, value := range t.histConfig.Consts("targ_per_netns") {
+			consts[name] = value
+		}
+	}
+
 	if err := spec.RewriteConstants(consts); err != nil {
 		return fmt.Errorf("rewriting constants: %w", err)
 	}
 
 	opts := ebpf.CollectionOptions{
 		Programs: ebpf.ProgramOptions{
-			KernelTypes: btfgen.GetBTFSpec(),
+			KernelTypes: btfgen.GetBTFSpec(spec),
 		},
 	}
 
@@ -272,8 +353,17 @@ func (t *Tracer) install() error {
 	return nil
 }
 
+func (t *Tracer) SetEventEnricher(enricher func(ev any) error) {
+	t.enricherFunc = enricher
+}
+
 func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	return &Tracer{
-		config: &Config{},
+		config:     &Config{},
+		histConfig: &gadgets.HistogramConfig{Layout: histogram.LayoutExp2},
 	}, nil
 }
+
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadTcpRTT)
+}
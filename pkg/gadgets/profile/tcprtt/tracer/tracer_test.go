@@ -34,6 +34,7 @@ import (
 
 	utilstest "github.com/inspektor-gadget/inspektor-gadget/internal/test"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/tcprtt/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/histogram"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
@@ -41,7 +42,8 @@ import (
 
 func createTracer() *Tracer {
 	return &Tracer{
-		config: &Config{},
+		config:     &Config{},
+		histConfig: &gadgets.HistogramConfig{Layout: histogram.LayoutExp2},
 	}
 }
 
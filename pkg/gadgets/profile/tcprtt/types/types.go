@@ -15,7 +15,10 @@
 package types
 
 import (
+	"fmt"
+
 	histogram "github.com/inspektor-gadget/inspektor-gadget/pkg/histogram"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
 type AddressType string
@@ -53,6 +56,37 @@ type ExtendedHistogram struct {
 	// RemotePort is the local port used to filter address.
 	// If it is 0, it means there was not filtering on remote port.
 	RemotePort uint16 `json:"remotePort,omitempty"`
+
+	// Heatmap contains one histogram per interval, if enabled.
+	Heatmap *histogram.Heatmap `json:"heatmap,omitempty"`
+
+	// CommonData contains the container the histogram was created for, if
+	// histograms are created per container.
+	eventtypes.CommonData
+
+	// NetNsID is the network namespace of the sockets, if histograms are
+	// created per container.
+	NetNsID uint64 `json:"netnsid,omitempty"`
+}
+
+func (h *ExtendedHistogram) GetNetNSID() uint64 {
+	return h.NetNsID
+}
+
+// Container returns the name of the container the histogram was created for
+// or its network namespace, if it isn't a known container. It returns an empty
+// string if histograms aren't created per container.
+func (h *ExtendedHistogram) Container() string {
+	switch {
+	case h.K8s.PodName != "":
+		return fmt.Sprintf("%s/%s", h.K8s.Namespace, h.K8s.PodName)
+	case h.Runtime.ContainerName != "":
+		return h.Runtime.ContainerName
+	case h.NetNsID != 0:
+		return fmt.Sprintf("netns %d", h.NetNsID)
+	default:
+		return ""
+	}
 }
 
 type Report struct {
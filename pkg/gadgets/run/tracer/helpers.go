@@ -56,6 +56,13 @@ func getEventTypeBTF(progContent []byte, metadata *types.GadgetMetadata) (*btf.S
 			return nil, err
 		}
 		return btfStruct, nil
+	case len(metadata.Toppers) > 0:
+		var btfStruct *btf.Struct
+		_, topper := getAnyMapElem(metadata.Toppers)
+		if err := spec.Types.TypeByName(topper.StructName, &btfStruct); err != nil {
+			return nil, err
+		}
+		return btfStruct, nil
 	default:
 		return nil, fmt.Errorf("the gadget doesn't provide any compatible way to show information")
 	}
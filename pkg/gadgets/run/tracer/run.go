@@ -134,6 +134,8 @@ func getGadgetType(spec *ebpf.CollectionSpec,
 		return gadgets.TypeTrace, nil
 	case len(gadgetMetadata.Snapshotters) > 0:
 		return gadgets.TypeOneShot, nil
+	case len(gadgetMetadata.Toppers) > 0:
+		return gadgets.TypeTraceIntervals, nil
 	default:
 		return gadgets.TypeUnknown, fmt.Errorf("unknown gadget type")
 	}
@@ -393,6 +395,12 @@ func addL3EndpointColumns(
 	}, func(e *types.Event) any {
 		return getEndpoint(e).Version
 	})
+
+	cols.AddColumn(columns.Attributes{
+		Name: name + ".hostname",
+	}, func(e *types.Event) any {
+		return getEndpoint(e).Hostname
+	})
 }
 
 func addL4EndpointColumns(
@@ -840,6 +848,11 @@ func calculateColumnsForClient(
 			}
 			columns = append(columns, col)
 			continue
+		case types.KernelStackTypeName:
+			// The symbols of the stack are resolved in user space
+			col := types.FactoryAddString(eventFactory, member.Name)
+			columns = append(columns, col)
+			continue
 		}
 
 		rType := typeFromBTF(member.Type)
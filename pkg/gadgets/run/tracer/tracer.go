@@ -17,6 +17,7 @@
 package tracer
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -25,6 +26,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/cilium/ebpf"
@@ -36,10 +38,15 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfstats"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/btfgen"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/run/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/kallsyms"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/netnsenter"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/networktracer"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
@@ -67,6 +74,7 @@ type Config struct {
 	ProgContent []byte
 	Metadata    *types.GadgetMetadata
 	MountnsMap  *ebpf.Map
+	CgroupMap   *ebpf.Map
 
 	// constants to replace in the ebpf program
 	Consts map[string]interface{}
@@ -85,6 +93,8 @@ type Tracer struct {
 
 	spec       *ebpf.CollectionSpec
 	collection *ebpf.Collection
+	// objects attributes the collection to the gadget instance, for top ebpf
+	objects bpfstats.Objects
 	// Type describing the format the gadget uses
 	eventType *btf.Struct
 
@@ -103,6 +113,9 @@ type Tracer struct {
 	// Snapshotters related
 	linksSnapshotters []*linkSnapshotter
 
+	// Toppers related
+	topperColMap columns.ColumnMap[types.Event]
+
 	containers map[string]*containercollection.Container
 	links      []link.Link
 
@@ -156,6 +169,16 @@ func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
 
 	t.config.Metadata = info.GadgetMetadata
 
+	if len(t.config.Metadata.Toppers) > 0 {
+		// Toppers need to sort the results before truncating them to the maximum number of
+		// rows, hence they need the columns.
+		cols, err := (&GadgetDesc{}).getColumns(info)
+		if err != nil {
+			return fmt.Errorf("getting columns: %w", err)
+		}
+		t.topperColMap = cols.GetColumnMap()
+	}
+
 	// Create network tracers, one for each socket filter program.
 	// We need to make this in Init() because AttachContainer() is called before Run().
 	for _, p := range t.spec.Programs {
@@ -204,6 +227,7 @@ func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
 }
 
 func (t *Tracer) Close() {
+	t.objects.Clear()
 	if t.collection != nil {
 		t.collection.Close()
 		t.collection = nil
@@ -311,6 +335,35 @@ func (t *Tracer) handleTracerMapDefinition(tracerMapName string) error {
 	return nil
 }
 
+// shrinkKernelStackMap reduces the kernel stack map to a single entry when
+// no program stores stacks in it, i.e. when the kernel_stacks constant of the
+// gadget is false or no program references the map. The map is part of every
+// gadget including gadget/kernel_stack_map.h and takes around 10MB with the
+// default size. It must be called after the constants are rewritten.
+func (t *Tracer) shrinkKernelStackMap() {
+	stackMap, ok := t.spec.Maps[types.KernelStackMapName]
+	if !ok {
+		return
+	}
+
+	// Programs usually reference the map even when the collection of stacks
+	// is disabled at runtime, e.g. "kernel_stacks ? gadget_get_kernel_stack(ctx) : -1"
+	if enabled, ok := t.config.Consts[types.KernelStacksConstName].(bool); ok && !enabled {
+		stackMap.MaxEntries = 1
+		return
+	}
+
+	for _, p := range t.spec.Programs {
+		for _, ins := range p.Instructions {
+			if ins.IsLoadFromMap() && ins.Reference() == types.KernelStackMapName {
+				return
+			}
+		}
+	}
+
+	stackMap.MaxEntries = 1
+}
+
 type loadingOptions struct {
 	collectionOptions ebpf.CollectionOptions
 	tracerMapName     string
@@ -328,12 +381,15 @@ func (t *Tracer) loadeBPFObjects(opts loadingOptions) error {
 		}
 	}
 
+	t.shrinkKernelStackMap()
+
 	gadgets.FixBpfKtimeGetBootNs(t.spec.Programs)
 
 	t.collection, err = ebpf.NewCollectionWithOptions(t.spec, opts.collectionOptions)
 	if err != nil {
 		return fmt.Errorf("create BPF collection: %w", err)
 	}
+	t.objects.Add(t.collection)
 
 	// Some logic before loading the programs
 	if tracerMapName != "" {
@@ -342,7 +398,7 @@ func (t *Tracer) loadeBPFObjects(opts loadingOptions) error {
 		case ebpf.RingBuf:
 			t.ringbufReader, err = ringbuf.NewReader(t.collection.Maps[tracerMapName])
 		case ebpf.PerfEventArray:
-			t.perfReader, err = perf.NewReader(t.collection.Maps[tracerMapName], gadgets.PerfBufferPages*os.Getpagesize())
+			t.perfReader, err = gadgets.NewPerfReader(t.collection.Maps[tracerMapName], gadgets.PerfBufferPages*os.Getpagesize())
 		}
 		if err != nil {
 			return fmt.Errorf("create BPF map reader: %w", err)
@@ -480,6 +536,14 @@ func (t *Tracer) installTracer(gadgetCtx gadgets.GadgetContext) error {
 
 			mapReplacements[gadgets.MntNsFilterMapName] = t.config.MountnsMap
 			consts[gadgets.FilterByMntNsName] = true
+		// Replace filter cgroup map, only used together with the mount ns one
+		case gadgets.CgroupFilterMapName:
+			if t.config.MountnsMap == nil || t.config.CgroupMap == nil {
+				break
+			}
+
+			mapReplacements[gadgets.CgroupFilterMapName] = t.config.CgroupMap
+			consts[gadgets.FilterByCgroupName] = true
 		}
 	}
 
@@ -489,8 +553,13 @@ func (t *Tracer) installTracer(gadgetCtx gadgets.GadgetContext) error {
 
 	// Load the ebpf objects
 	err = t.loadeBPFObjects(loadingOptions{
-		collectionOptions: ebpf.CollectionOptions{MapReplacements: mapReplacements},
-		tracerMapName:     tracerMapName,
+		collectionOptions: ebpf.CollectionOptions{
+			MapReplacements: mapReplacements,
+			Programs: ebpf.ProgramOptions{
+				KernelTypes: btfgen.GetBTFSpec(t.spec),
+			},
+		},
+		tracerMapName: tracerMapName,
 	})
 	if err != nil {
 		return fmt.Errorf("loading eBPF objects: %w", err)
@@ -555,6 +624,9 @@ func (t *Tracer) processEventFunc(gadgetCtx gadgets.GadgetContext) func(data []b
 	var mntNsIdstart uint32
 	mountNsIdFound := false
 
+	var netNsIdStart uint32
+	netNsIdFound := false
+
 	type endpointType int
 
 	const (
@@ -572,6 +644,7 @@ func (t *Tracer) processEventFunc(gadgetCtx gadgets.GadgetContext) func(data []b
 	endpointDefs := []endpointDef{}
 	timestampsOffsets := []uint32{}
 
+	// setters for the fields that are converted to strings: enums and kernel stacks
 	enumSetters := []func(ev *types.Event, data []byte){}
 
 	// The same same data structure is always sent, so we can precalculate the offsets for
@@ -586,6 +659,9 @@ func (t *Tracer) processEventFunc(gadgetCtx gadgets.GadgetContext) func(data []b
 			}
 			mntNsIdstart = member.Offset.Bytes()
 			mountNsIdFound = true
+		case types.NetNsIdTypeName:
+			netNsIdStart = member.Offset.Bytes()
+			netNsIdFound = true
 		case types.L3EndpointTypeName:
 			typ, ok := member.Type.(*btf.Struct)
 			if !ok {
@@ -620,6 +696,14 @@ func (t *Tracer) processEventFunc(gadgetCtx gadgets.GadgetContext) func(data []b
 				continue
 			}
 			timestampsOffsets = append(timestampsOffsets, member.Offset.Bytes())
+		case types.KernelStackTypeName:
+			setter, err := t.kernelStackSetter(member)
+			if err != nil {
+				logger.Warnf("Kernel stack %s won't be resolved: %s", member.Name, err)
+				continue
+			}
+			enumSetters = append(enumSetters, setter)
+			continue
 		}
 
 		btfSpec, err := btf.LoadKernelSpec()
@@ -702,6 +786,12 @@ func (t *Tracer) processEventFunc(gadgetCtx gadgets.GadgetContext) func(data []b
 			mntNsId = getAsInteger[uint64](data, mntNsIdstart)
 		}
 
+		// get netNsId for enriching the event
+		netNsId := uint64(0)
+		if netNsIdFound {
+			netNsId = uint64(getAsInteger[uint32](data, netNsIdStart))
+		}
+
 		// enrich endpoints
 		l3endpoints := []types.L3Endpoint{}
 		l4endpoints := []types.L4Endpoint{}
@@ -760,6 +850,7 @@ func (t *Tracer) processEventFunc(gadgetCtx gadgets.GadgetContext) func(data []b
 
 		ev.Type = eventtypes.NORMAL
 		ev.MountNsID = mntNsId
+		ev.NetNsID = netNsId
 		ev.L3Endpoints = l3endpoints
 		ev.L4Endpoints = l4endpoints
 		ev.Timestamps = timestamps
@@ -776,9 +867,107 @@ func (t *Tracer) processEventFunc(gadgetCtx gadgets.GadgetContext) func(data []b
 	}
 }
 
+// kernelStackSetter returns a function that sets the symbols of the kernel stack whose id is
+// stored in member
+func (t *Tracer) kernelStackSetter(member btf.Member) (func(ev *types.Event, data []byte), error) {
+	if size, err := btf.Sizeof(member.Type); err != nil || size != 4 {
+		return nil, fmt.Errorf("%s is not a 32 bits integer", member.Name)
+	}
+
+	stackMap, ok := t.collection.Maps[types.KernelStackMapName]
+	if !ok {
+		return nil, fmt.Errorf("map %q not found, is gadget/kernel_stack_map.h included?", types.KernelStackMapName)
+	}
+
+	kAllSyms, err := kallsyms.NewKAllSyms()
+	if err != nil {
+		return nil, fmt.Errorf("loading kernel symbols: %w", err)
+	}
+
+	offset := member.Offset.Bytes()
+	fieldSetter := types.GetSetter[string](t.eventFactory, member.Name)
+
+	return func(ev *types.Event, data []byte) {
+		stackID := getAsInteger[int32](data, offset)
+		if stackID < 0 {
+			fieldSetter(ev, "")
+			return
+		}
+
+		var stack []byte
+		if err := stackMap.Lookup(uint32(stackID), &stack); err != nil {
+			fieldSetter(ev, "")
+			return
+		}
+
+		symbols := []string{}
+		for i := 0; i+8 <= len(stack); i += 8 {
+			ip := binary.NativeEndian.Uint64(stack[i : i+8])
+			if ip == 0 {
+				break
+			}
+			symbols = append(symbols, kAllSyms.LookupByInstructionPointer(ip))
+		}
+		fieldSetter(ev, strings.Join(symbols, "; "))
+	}, nil
+}
+
+func lostEvent(count uint64) *types.Event {
+	ev := eventtypes.Lost(count)
+	return &types.Event{
+		Type:        ev.Type,
+		Message:     ev.Message,
+		LostSamples: count,
+	}
+}
+
+// readLost returns the number of events counted by gadget_count_lost() in
+// buffer.h on all CPUs
+func readLost(lostMap *ebpf.Map) (uint64, error) {
+	var values []uint64
+	if err := lostMap.Lookup(uint32(0), &values); err != nil {
+		return 0, err
+	}
+	total := uint64(0)
+	for _, v := range values {
+		total += v
+	}
+	return total, nil
+}
+
+// reportRingbufLost periodically reports the events the gadget couldn't write
+// to the ring buffer, as counted by gadget_count_lost() in buffer.h
+func (t *Tracer) reportRingbufLost(gadgetCtx gadgets.GadgetContext, lostMap *ebpf.Map) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	reported := uint64(0)
+	for {
+		select {
+		case <-gadgetCtx.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		total, err := readLost(lostMap)
+		if err != nil {
+			gadgetCtx.Logger().Debugf("reading lost samples: %v", err)
+			continue
+		}
+		if total > reported {
+			t.eventCallback(lostEvent(total - reported))
+			reported = total
+		}
+	}
+}
+
 func (t *Tracer) runTracers(gadgetCtx gadgets.GadgetContext) {
 	cb := t.processEventFunc(gadgetCtx)
 
+	if lostMap, ok := t.collection.Maps[types.GadgetLostSamplesMapName]; ok && t.ringbufReader != nil {
+		go t.reportRingbufLost(gadgetCtx, lostMap)
+	}
+
 	for {
 		var rawSample []byte
 
@@ -804,7 +993,7 @@ func (t *Tracer) runTracers(gadgetCtx gadgets.GadgetContext) {
 			}
 
 			if record.LostSamples != 0 {
-				gadgetCtx.Logger().Warnf("lost %d samples", record.LostSamples)
+				t.eventCallback(lostEvent(record.LostSamples))
 				continue
 			}
 			rawSample = record.RawSample
@@ -906,12 +1095,125 @@ func (t *Tracer) runSnapshotter(gadgetCtx gadgets.GadgetContext) error {
 	return nil
 }
 
+// nextStats returns the entries of the topper map and removes them from it.
+func (t *Tracer) nextStats(m *ebpf.Map, cb func([]byte) *types.Event) ([]*types.Event, error) {
+	events := []*types.Event{}
+	keys := [][]byte{}
+
+	// Unmarshaling into a []byte allocates a new slice each time, so it's safe to keep references
+	// to them.
+	var key, value []byte
+
+	it := m.Iterate()
+	for it.Next(&key, &value) {
+		events = append(events, cb(value))
+		keys = append(keys, key)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("iterating map %q: %w", m.String(), err)
+	}
+
+	for _, k := range keys {
+		if err := m.Delete(k); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return nil, fmt.Errorf("deleting key from map %q: %w", m.String(), err)
+		}
+	}
+
+	return events, nil
+}
+
+func (t *Tracer) runToppers(gadgetCtx gadgets.GadgetContext) error {
+	_, topper := getAnyMapElem(t.config.Metadata.Toppers)
+
+	m, ok := t.collection.Maps[topper.MapName]
+	if !ok {
+		return fmt.Errorf("map %q not found", topper.MapName)
+	}
+
+	params := gadgetCtx.GadgetParams()
+	maxRows := params.Get(gadgets.ParamMaxRows).AsInt()
+	sortBy := params.Get(gadgets.ParamSortBy).AsStringSlice()
+	if len(sortBy) == 0 {
+		sortBy = topper.SortBy
+	}
+	interval := time.Second * time.Duration(params.Get(gadgets.ParamInterval).AsInt())
+
+	// Don't use a context with a timeout but a counter to avoid having to deal
+	// with two timers: one for the timeout and another for the ticker.
+	iterations, err := top.ComputeIterations(interval, gadgetCtx.Timeout())
+	if err != nil {
+		return err
+	}
+
+	cb := t.processEventFunc(gadgetCtx)
+
+	// Entries that didn't fit in the map are counted by gadget_count_lost()
+	lostMap := t.collection.Maps[types.GadgetLostSamplesMapName]
+	reportedLost := uint64(0)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-gadgetCtx.Context().Done():
+			return nil
+		case <-ticker.C:
+			events, err := t.nextStats(m, cb)
+			if err != nil {
+				return fmt.Errorf("getting next stats: %w", err)
+			}
+
+			top.SortStats(events, sortBy, &t.topperColMap)
+			if len(events) > maxRows {
+				events = events[:maxRows]
+			}
+			if lostMap != nil {
+				lost, err := readLost(lostMap)
+				if err != nil {
+					gadgetCtx.Logger().Debugf("reading lost samples: %v", err)
+				} else if lost > reportedLost {
+					events = append(events, lostEvent(lost-reportedLost))
+					reportedLost = lost
+				}
+			}
+			t.eventArrayCallback(events)
+
+			// Count down only if user requested a finite number of iterations
+			// through a timeout.
+			if iterations > 0 {
+				iterations--
+				if iterations == 0 {
+					return nil
+				}
+			}
+		}
+	}
+}
+
 func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	// The network tracers and tc handlers load programs each time they attach to a container
+	info := gadgets.GadgetInfo(gadgetCtx)
+	if args := gadgetCtx.Args(); len(args) > 0 {
+		info.Image = args[0]
+	}
+	t.objects.SetOwner(info)
+	for _, networkTracer := range t.networkTracers {
+		networkTracer.SetGadgetInfo(info)
+	}
+	for _, handler := range t.tcHandlers {
+		handler.SetGadgetInfo(info)
+	}
+
 	if err := t.installTracer(gadgetCtx); err != nil {
 		t.Close()
 		return fmt.Errorf("install tracer: %w", err)
 	}
 
+	if len(t.config.Metadata.Toppers) > 0 {
+		return t.runToppers(gadgetCtx)
+	}
+
 	if t.perfReader != nil || t.ringbufReader != nil {
 		go t.runTracers(gadgetCtx)
 	}
@@ -976,6 +1278,10 @@ func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
 	t.config.MountnsMap = mountnsMap
 }
 
+func (t *Tracer) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.config.CgroupMap = cgroupMap
+}
+
 func (t *Tracer) SetEventHandler(handler any) {
 	nh, ok := handler.(func(ev *types.Event))
 	if !ok {
@@ -39,6 +39,9 @@ const (
 	// Prefix used to mark snapshotters structs
 	snapshottersPrefix = "gadget_snapshotter_"
 
+	// Prefix used to mark toppers maps
+	toppersPrefix = "gadget_topper_"
+
 	// Prefix used to mark tracer map created with GADGET_TRACER_MAP() defined in
 	// include/gadget/buffer.h.
 	TracerMapPrefix = "gadget_map_tracer_"
@@ -55,13 +58,28 @@ const (
 	// Name of the type to store a mount namespace inode id
 	MntNsIdTypeName = "gadget_mntns_id"
 
+	// Name of the type to store a network namespace inode id
+	NetNsIdTypeName = "gadget_netns_id"
+
 	// Name of the type to store a timestamp
 	TimestampTypeName = "gadget_timestamp"
+
+	// Name of the type to store the id of a kernel stack
+	KernelStackTypeName = "gadget_kernel_stack"
 )
 
 // Keep this aligned with include/gadget/buffer.h
 const (
-	GadgetHeapMapName = "gadget_heap"
+	GadgetHeapMapName        = "gadget_heap"
+	GadgetLostSamplesMapName = "gadget_lost_samples"
+)
+
+// Keep this aligned with include/gadget/kernel_stack_map.h
+const (
+	KernelStackMapName = "ig_kstack"
+
+	// Name of the optional constant telling whether kernel stacks are collected
+	KernelStacksConstName = "kernel_stacks"
 )
 
 const (
@@ -147,6 +165,16 @@ type Snapshotter struct {
 	StructName string `yaml:"structName"`
 }
 
+// Topper describes the behavior of a gadget that periodically reports the content of a map
+type Topper struct {
+	// Name of the hash map that the gadget uses to accumulate statistics
+	MapName string `yaml:"mapName"`
+	// Name of the structure stored as value in the map
+	StructName string `yaml:"structName"`
+	// Columns used to sort the results when the user doesn't provide any
+	SortBy []string `yaml:"sortBy,omitempty"`
+}
+
 type GadgetMetadata struct {
 	// Gadget name
 	Name string `yaml:"name"`
@@ -157,6 +185,8 @@ type GadgetMetadata struct {
 	Tracers map[string]Tracer `yaml:"tracers,omitempty"`
 	// Snapshotters implemented by the gadget
 	Snapshotters map[string]Snapshotter `yaml:"snapshotters,omitempty"`
+	// Toppers implemented by the gadget
+	Toppers map[string]Topper `yaml:"toppers,omitempty"`
 	// Types generated by the gadget
 	Structs map[string]Struct `yaml:"structs,omitempty"`
 	// Params exposed by the gadget through eBPF constants
@@ -176,6 +206,10 @@ func (m *GadgetMetadata) Validate(spec *ebpf.CollectionSpec) error {
 		result = multierror.Append(result, errors.New("gadget cannot have tracers and snapshotters"))
 	}
 
+	if len(m.Toppers) > 0 && (len(m.Tracers) > 0 || len(m.Snapshotters) > 0) {
+		result = multierror.Append(result, errors.New("gadget cannot have toppers and tracers or snapshotters"))
+	}
+
 	if err := m.validateEbpfParams(spec); err != nil {
 		result = multierror.Append(result, err)
 	}
@@ -188,6 +222,10 @@ func (m *GadgetMetadata) Validate(spec *ebpf.CollectionSpec) error {
 		result = multierror.Append(result, err)
 	}
 
+	if err := m.validateToppers(spec); err != nil {
+		result = multierror.Append(result, err)
+	}
+
 	if err := m.validateStructs(spec); err != nil {
 		result = multierror.Append(result, err)
 	}
@@ -266,6 +304,66 @@ func (m *GadgetMetadata) validateSnapshotters(spec *ebpf.CollectionSpec) error {
 	return result
 }
 
+func (m *GadgetMetadata) validateToppers(spec *ebpf.CollectionSpec) error {
+	var result error
+
+	// Temporary limitation
+	if len(m.Toppers) > 1 {
+		result = multierror.Append(result, errors.New("only one topper is allowed"))
+	}
+
+	for name, topper := range m.Toppers {
+		if topper.MapName == "" {
+			result = multierror.Append(result, fmt.Errorf("topper %q is missing mapName", name))
+		}
+
+		if topper.StructName == "" {
+			result = multierror.Append(result, fmt.Errorf("topper %q is missing structName", name))
+		}
+
+		_, ok := m.Structs[topper.StructName]
+		if !ok {
+			result = multierror.Append(result, fmt.Errorf("topper %q references unknown struct %q", name, topper.StructName))
+		}
+
+		ebpfm, ok := spec.Maps[topper.MapName]
+		if !ok {
+			result = multierror.Append(result, fmt.Errorf("map %q not found in eBPF object", topper.MapName))
+			continue
+		}
+
+		if err := validateTopperMap(ebpfm, topper.StructName); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	return result
+}
+
+func validateTopperMap(topperMap *ebpf.MapSpec, structName string) error {
+	if topperMap.Type != ebpf.Hash && topperMap.Type != ebpf.LRUHash {
+		return fmt.Errorf("map %q has a wrong type, expected: hash or lru hash, got: %s",
+			topperMap.Name, topperMap.Type.String())
+	}
+
+	if topperMap.Value == nil {
+		return fmt.Errorf("map %q does not have BTF information for its value", topperMap.Name)
+	}
+
+	valueStruct, ok := topperMap.Value.(*btf.Struct)
+	if !ok {
+		return fmt.Errorf("map %q value is %q, expected \"struct\"",
+			topperMap.Name, topperMap.Value.TypeName())
+	}
+
+	if structName != "" && valueStruct.Name != structName {
+		return fmt.Errorf("map %q value is %q, expected %q",
+			topperMap.Name, valueStruct.Name, structName)
+	}
+
+	return nil
+}
+
 func (m *GadgetMetadata) validateStructs(spec *ebpf.CollectionSpec) error {
 	var result error
 
@@ -345,6 +443,10 @@ func (m *GadgetMetadata) Populate(spec *ebpf.CollectionSpec) error {
 		return fmt.Errorf("handling snapshotters: %w", err)
 	}
 
+	if err := m.populateToppers(spec); err != nil {
+		return fmt.Errorf("handling toppers: %w", err)
+	}
+
 	if err := m.populateEbpfParams(spec); err != nil {
 		return fmt.Errorf("handling params: %w", err)
 	}
@@ -696,3 +798,56 @@ func (m *GadgetMetadata) populateSnapshotters(spec *ebpf.CollectionSpec) error {
 
 	return nil
 }
+
+func (m *GadgetMetadata) populateToppers(spec *ebpf.CollectionSpec) error {
+	toppersNameAndMap, _ := GetGadgetIdentByPrefix(spec, toppersPrefix)
+	if len(toppersNameAndMap) == 0 {
+		log.Debug("No toppers found")
+		return nil
+	}
+
+	if len(toppersNameAndMap) > 1 {
+		log.Warnf("Multiple toppers found, using %q", toppersNameAndMap[0])
+	}
+
+	topperNameAndMap := toppersNameAndMap[0]
+
+	parts := strings.Split(topperNameAndMap, "___")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid topper annotation: %q", topperNameAndMap)
+	}
+	tname := parts[0]
+	tmap := parts[1]
+
+	topperMap := spec.Maps[tmap]
+	if topperMap == nil {
+		return fmt.Errorf("map %q not found in eBPF object", tmap)
+	}
+
+	if err := validateTopperMap(topperMap, ""); err != nil {
+		return fmt.Errorf("topper map is invalid: %w", err)
+	}
+
+	// validateTopperMap() already checked it's a struct
+	btfStruct := topperMap.Value.(*btf.Struct)
+
+	if m.Toppers == nil {
+		m.Toppers = make(map[string]Topper)
+	}
+
+	if _, ok := m.Toppers[tname]; !ok {
+		log.Debugf("Adding topper %q with map %q and struct %q", tname, topperMap.Name, btfStruct.Name)
+		m.Toppers[tname] = Topper{
+			MapName:    topperMap.Name,
+			StructName: btfStruct.Name,
+		}
+	} else {
+		log.Debugf("Topper %q already defined, skipping", tname)
+	}
+
+	if err := m.populateStruct(btfStruct); err != nil {
+		return fmt.Errorf("populating struct: %w", err)
+	}
+
+	return nil
+}
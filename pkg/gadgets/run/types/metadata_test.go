@@ -287,6 +287,90 @@ func TestValidate(t *testing.T) {
 				},
 			},
 		},
+		"toppers_and_tracers": {
+			objectPath: "../../../../testdata/validate_metadata1.o",
+			metadata: &GadgetMetadata{
+				Name: "foo",
+				Tracers: map[string]Tracer{
+					"foo": {},
+				},
+				Toppers: map[string]Topper{
+					"bar": {},
+				},
+			},
+			expectedErrString: "gadget cannot have toppers and tracers or snapshotters",
+		},
+		"toppers_more_than_one": {
+			objectPath: "../../../../testdata/validate_metadata1.o",
+			metadata: &GadgetMetadata{
+				Name: "foo",
+				Toppers: map[string]Topper{
+					"foo": {},
+					"bar": {},
+				},
+			},
+			expectedErrString: "only one topper is allowed",
+		},
+		"toppers_missing_map_name": {
+			objectPath: "../../../../testdata/validate_metadata1.o",
+			metadata: &GadgetMetadata{
+				Name: "foo",
+				Toppers: map[string]Topper{
+					"foo": {
+						StructName: "event",
+					},
+				},
+			},
+			expectedErrString: "is missing mapName",
+		},
+		"toppers_map_not_found": {
+			objectPath: "../../../../testdata/validate_metadata1.o",
+			metadata: &GadgetMetadata{
+				Name: "foo",
+				Toppers: map[string]Topper{
+					"foo": {
+						MapName:    "nonexistent",
+						StructName: "event",
+					},
+				},
+				Structs: map[string]Struct{
+					"event": {},
+				},
+			},
+			expectedErrString: "map \"nonexistent\" not found in eBPF object",
+		},
+		"toppers_bad_map_type": {
+			objectPath: "../../../../testdata/validate_metadata1.o",
+			metadata: &GadgetMetadata{
+				Name: "foo",
+				Toppers: map[string]Topper{
+					"foo": {
+						MapName:    "events",
+						StructName: "event",
+					},
+				},
+				Structs: map[string]Struct{
+					"event": {},
+				},
+			},
+			expectedErrString: "map \"events\" has a wrong type, expected: hash or lru hash",
+		},
+		"toppers_bad_map_value_type": {
+			objectPath: "../../../../testdata/validate_metadata1.o",
+			metadata: &GadgetMetadata{
+				Name: "foo",
+				Toppers: map[string]Topper{
+					"foo": {
+						MapName:    "myhashmap",
+						StructName: "event",
+					},
+				},
+				Structs: map[string]Struct{
+					"event": {},
+				},
+			},
+			expectedErrString: "map \"myhashmap\" value is \"__u8\", expected \"struct\"",
+		},
 		"sched_cls": {
 			objectPath: "../../../../testdata/validate_metadata_sched_cls.o",
 			metadata: &GadgetMetadata{
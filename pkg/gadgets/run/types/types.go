@@ -49,6 +49,9 @@ type Event struct {
 	// Message when Type is ERR, WARN, DEBUG or INFO
 	Message string `json:"message,omitempty"`
 
+	// LostSamples is set on warnings about events lost by the kernel
+	LostSamples uint64 `json:"lostSamples,omitempty"`
+
 	L3Endpoints []L3Endpoint      `json:"l3endpoints,omitempty"`
 	L4Endpoints []L4Endpoint      `json:"l4endpoints,omitempty"`
 	Timestamps  []eventtypes.Time `json:"timestamps,omitempty"`
@@ -71,6 +74,10 @@ func (ev *Event) GetNetNSID() uint64 {
 	return ev.NetNsID
 }
 
+func (ev *Event) GetLostSamples() uint64 {
+	return ev.LostSamples
+}
+
 func (ev *Event) GetEndpoints() []*eventtypes.L3Endpoint {
 	endpoints := make([]*eventtypes.L3Endpoint, 0, len(ev.L3Endpoints)+len(ev.L4Endpoints))
 
@@ -11,8 +11,15 @@
 
 #include <vmlinux.h>
 #include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
 
 #include <gadget/mntns_filter.h>
+#include <gadget/filesystem.h>
+
+#define EXE_PATH_LEN 512
+
+// From include/linux/sched.h
+#define PFA_NO_NEW_PRIVS 0
 
 const volatile bool show_threads = false;
 
@@ -24,8 +31,33 @@ struct process_entry {
 	__u32 gid;
 	__u64 mntns_id;
 	__u8 comm[TASK_COMM_LEN];
+	__u64 start_time;
+	__u64 cgroup_id;
+	__u64 cap_effective;
+	__u64 cap_permitted;
+	__u32 seccomp_mode;
+	// First extent of the uid mapping of the user namespace
+	__u32 uid_map_first;
+	__u32 uid_map_lower_first;
+	__u32 uid_map_count;
+	__u32 uid_map_nr_extents;
+	__u8 exepath[EXE_PATH_LEN];
+	__u8 no_new_privs;
 };
 
+// process_entry is too big for the stack
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, __u32);
+	__type(value, struct process_entry);
+} tmp_process SEC(".maps");
+
+// start_boottime was named real_start_time before Linux 5.5
+struct task_struct___old {
+	u64 real_start_time;
+} __attribute__((preserve_access_index));
+
 // we need this to make sure the compiler doesn't remove our struct
 const struct process_entry *unused __attribute__((unused));
 
@@ -37,9 +69,12 @@ int ig_snap_proc(struct bpf_iter__task *ctx)
 	__u64 session_id = ctx->meta->session_id;
 	struct task_struct *task = ctx->task;
 	struct task_struct *parent;
+	struct process_entry *process;
+	struct user_namespace *user_ns;
+	struct mm_struct *mm;
 	pid_t parent_pid;
-
-	struct process_entry process = {};
+	__u32 zero = 0;
+	char *exepath;
 
 	if (task == NULL)
 		return 0;
@@ -49,7 +84,7 @@ int ig_snap_proc(struct bpf_iter__task *ctx)
 
 	__u64 mntns_id = task->nsproxy->mnt_ns->ns.inum;
 
-	if (gadget_should_discard_mntns_id(mntns_id))
+	if (gadget_should_discard_task(task))
 		return 0;
 
 	parent = task->real_parent;
@@ -61,15 +96,58 @@ int ig_snap_proc(struct bpf_iter__task *ctx)
 	__u32 uid = task->cred->uid.val;
 	__u32 gid = task->cred->gid.val;
 
-	process.tgid = task->tgid;
-	process.pid = task->pid;
-	process.parent_pid = parent_pid;
-	process.mntns_id = mntns_id;
-	process.uid = uid;
-	process.gid = gid;
-	__builtin_memcpy(process.comm, task->comm, TASK_COMM_LEN);
+	process = bpf_map_lookup_elem(&tmp_process, &zero);
+	if (!process)
+		return 0;
+
+	process->tgid = task->tgid;
+	process->pid = task->pid;
+	process->parent_pid = parent_pid;
+	process->mntns_id = mntns_id;
+	process->uid = uid;
+	process->gid = gid;
+	__builtin_memcpy(process->comm, task->comm, TASK_COMM_LEN);
 
-	bpf_seq_write(seq, &process, sizeof(process));
+	if (bpf_core_field_exists(task->start_boottime))
+		process->start_time = BPF_CORE_READ(task, start_boottime);
+	else
+		process->start_time = BPF_CORE_READ(
+			(struct task_struct___old *)task, real_start_time);
+
+	process->cgroup_id = BPF_CORE_READ(task, cgroups, dfl_cgrp, kn, id);
+
+	// kernel_cap_t is a u64 since Linux 6.3 and two u32 before, which have
+	// the same layout on little endian architectures
+	bpf_probe_read_kernel(&process->cap_effective,
+			      sizeof(process->cap_effective),
+			      &task->cred->cap_effective);
+	bpf_probe_read_kernel(&process->cap_permitted,
+			      sizeof(process->cap_permitted),
+			      &task->cred->cap_permitted);
+
+	process->seccomp_mode = BPF_CORE_READ(task, seccomp.mode);
+	process->no_new_privs =
+		(BPF_CORE_READ(task, atomic_flags) >> PFA_NO_NEW_PRIVS) & 1;
+
+	user_ns = BPF_CORE_READ(task, cred, user_ns);
+	process->uid_map_nr_extents = BPF_CORE_READ(user_ns, uid_map.nr_extents);
+	process->uid_map_first = BPF_CORE_READ(user_ns, uid_map.extent[0].first);
+	process->uid_map_lower_first =
+		BPF_CORE_READ(user_ns, uid_map.extent[0].lower_first);
+	process->uid_map_count = BPF_CORE_READ(user_ns, uid_map.extent[0].count);
+
+	process->exepath[0] = '\0';
+	mm = task->mm;
+	// Kernel threads don't have an executable
+	if (mm && mm->exe_file) {
+		exepath = get_path_str(&mm->exe_file->f_path);
+		if (exepath)
+			bpf_probe_read_kernel_str(process->exepath,
+						  sizeof(process->exepath),
+						  exepath);
+	}
+
+	bpf_seq_write(seq, process, sizeof(*process));
 	return 0;
 }
 
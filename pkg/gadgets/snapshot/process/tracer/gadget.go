@@ -72,7 +72,7 @@ func (g *GadgetDesc) OutputFormats() (gadgets.OutputFormats, string) {
 	return gadgets.OutputFormats{
 		"tree": gadgets.OutputFormat{
 			Name:        "Tree",
-			Description: "A pstree like output",
+			Description: "A pstree like output grouped by container",
 			Transform: func(data any) ([]byte, error) {
 				processes, ok := data.([]*types.Event)
 				if !ok {
@@ -12,15 +12,29 @@ import (
 	"github.com/cilium/ebpf"
 )
 
+type processCollectorBufT struct{ Buf [32768]uint8 }
+
 type processCollectorProcessEntry struct {
-	Tgid      uint32
-	Pid       uint32
-	ParentPid uint32
-	Uid       uint32
-	Gid       uint32
-	_         [4]byte
-	MntnsId   uint64
-	Comm      [16]uint8
+	Tgid             uint32
+	Pid              uint32
+	ParentPid        uint32
+	Uid              uint32
+	Gid              uint32
+	_                [4]byte
+	MntnsId          uint64
+	Comm             [16]uint8
+	StartTime        uint64
+	CgroupId         uint64
+	CapEffective     uint64
+	CapPermitted     uint64
+	SeccompMode      uint32
+	UidMapFirst      uint32
+	UidMapLowerFirst uint32
+	UidMapCount      uint32
+	UidMapNrExtents  uint32
+	Exepath          [512]uint8
+	NoNewPrivs       uint8
+	_                [3]byte
 }
 
 // loadProcessCollector returns the embedded CollectionSpec for processCollector.
@@ -71,7 +85,10 @@ type processCollectorProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type processCollectorMapSpecs struct {
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Bufs                  *ebpf.MapSpec `ebpf:"bufs"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	TmpProcess            *ebpf.MapSpec `ebpf:"tmp_process"`
 }
 
 // processCollectorObjects contains all objects after they have been loaded into the kernel.
@@ -93,12 +110,18 @@ func (o *processCollectorObjects) Close() error {
 //
 // It can be passed to loadProcessCollectorObjects or ebpf.CollectionSpec.LoadAndAssign.
 type processCollectorMaps struct {
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Bufs                  *ebpf.Map `ebpf:"bufs"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	TmpProcess            *ebpf.Map `ebpf:"tmp_process"`
 }
 
 func (m *processCollectorMaps) Close() error {
 	return _ProcessCollectorClose(
+		m.Bufs,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
+		m.TmpProcess,
 	)
 }
 
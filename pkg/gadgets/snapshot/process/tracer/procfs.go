@@ -0,0 +1,205 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	processcollectortypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/process/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+// clockTicks is the value of USER_HZ used by the start time in
+// /proc/<pid>/stat. It's 100 on all the architectures supported by Linux.
+const clockTicks = 100
+
+// enrichFromProcfs sets the extended fields of the event from
+// /proc/<pid>/task/<tid>. It's used by the procfs collector, the eBPF one
+// gets them from the task iterator.
+func enrichFromProcfs(event *processcollectortypes.Event, pid, tid int) error {
+	taskPath := filepath.Join(host.HostProcFs, fmt.Sprint(pid), "task", fmt.Sprint(tid))
+
+	// Kernel threads don't have an executable, ignore the error
+	event.ExePath, _ = os.Readlink(filepath.Join(taskPath, "exe"))
+	event.Args = getProcArgs(pid)
+	event.CgroupPath = getProcCgroupPath(pid)
+	event.CgroupID = getCgroupID(event.CgroupPath)
+	event.UidMap = getUidMap(taskPath)
+
+	if err := enrichFromStat(event, taskPath); err != nil {
+		return fmt.Errorf("reading stat of process: %w", err)
+	}
+	if err := enrichFromStatus(event, taskPath); err != nil {
+		return fmt.Errorf("reading status of process: %w", err)
+	}
+	return nil
+}
+
+// getProcArgs returns the command-line arguments of the process. They are
+// read from procfs for both collectors, as reading the memory of another
+// process from the task iterator requires a sleepable program.
+func getProcArgs(pid int) []string {
+	args := host.GetProcCmdline(pid)
+	for len(args) > 0 && args[len(args)-1] == "" {
+		args = args[:len(args)-1]
+	}
+	return args
+}
+
+// getProcCgroupPath returns the path of the process in the cgroup v2
+// hierarchy, or an empty string if it isn't using cgroup v2
+func getProcCgroupPath(pid int) string {
+	content, err := os.ReadFile(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "cgroup"))
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return path
+		}
+	}
+	return ""
+}
+
+// getCgroupID returns the ID of the cgroup v2 at the given path, which is
+// the inode number of its directory
+func getCgroupID(path string) uint64 {
+	if path == "" {
+		return 0
+	}
+
+	info, err := os.Stat(filepath.Join(host.HostRoot, "sys/fs/cgroup", path))
+	if err != nil {
+		return 0
+	}
+	return info.Sys().(*syscall.Stat_t).Ino
+}
+
+// enrichFromStat sets the parent PID and the start time of the event from
+// /proc/<pid>/task/<tid>/stat
+func enrichFromStat(event *processcollectortypes.Event, taskPath string) error {
+	ppid, startTime, err := readStat(taskPath)
+	if err != nil {
+		return err
+	}
+
+	event.ParentPid = ppid
+	event.StartTime = gadgets.WallTimeFromBootTime(startTime * (1_000_000_000 / clockTicks))
+	return nil
+}
+
+// readStat returns the parent PID and the start time, in clock ticks since
+// boot, from /proc/<pid>/task/<tid>/stat
+func readStat(taskPath string) (int, uint64, error) {
+	content, err := os.ReadFile(filepath.Join(taskPath, "stat"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The command can contain spaces and parentheses, skip it
+	stat := string(content)
+	i := strings.LastIndex(stat, ")")
+	if i == -1 {
+		return 0, 0, fmt.Errorf("invalid stat format: %q", stat)
+	}
+
+	// Fields after the command, starting with the state (field 3)
+	fields := strings.Fields(stat[i+1:])
+	if len(fields) < 20 {
+		return 0, 0, fmt.Errorf("invalid stat format: %q", stat)
+	}
+
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing ppid: %w", err)
+	}
+
+	// starttime is field 22
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing start time: %w", err)
+	}
+
+	return ppid, startTime, nil
+}
+
+// enrichFromStatus sets the capabilities, the seccomp mode and the
+// no_new_privs flag of the event from /proc/<pid>/task/<tid>/status
+func enrichFromStatus(event *processcollectortypes.Event, taskPath string) error {
+	f, err := os.Open(filepath.Join(taskPath, "status"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "CapEff":
+			event.CapEffective, _ = strconv.ParseUint(value, 16, 64)
+		case "CapPrm":
+			event.CapPermitted, _ = strconv.ParseUint(value, 16, 64)
+		case "Seccomp":
+			mode, _ := strconv.ParseUint(value, 10, 32)
+			event.SeccompMode = processcollectortypes.SeccompMode(mode)
+		case "NoNewPrivs":
+			event.NoNewPrivs = value == "1"
+		}
+	}
+
+	return scanner.Err()
+}
+
+// getUidMap returns the uid mapping of the user namespace of the process
+// from /proc/<pid>/task/<tid>/uid_map
+func getUidMap(taskPath string) string {
+	content, err := os.ReadFile(filepath.Join(taskPath, "uid_map"))
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	fields := strings.Fields(lines[0])
+	if len(fields) != 3 {
+		return ""
+	}
+
+	var extent [3]uint32
+	for i, field := range fields {
+		val, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return ""
+		}
+		extent[i] = uint32(val)
+	}
+
+	return processcollectortypes.FormatUidMap(extent[0], extent[1], extent[2], uint32(len(lines)))
+}
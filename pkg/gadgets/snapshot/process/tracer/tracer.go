@@ -40,6 +40,7 @@ import (
 
 type Config struct {
 	MountnsMap  *ebpf.Map
+	CgroupMap   *ebpf.Map
 	ShowThreads bool
 }
 
@@ -72,7 +73,7 @@ func runeBPFCollector(config *Config, enricher gadgets.DataEnricherByMntNs) ([]*
 	}
 	objs := processCollectorObjects{}
 
-	if err := gadgets.LoadeBPFSpec(config.MountnsMap, spec, consts, &objs); err != nil {
+	if err := gadgets.LoadeBPFSpec(config.MountnsMap, config.CgroupMap, spec, consts, &objs); err != nil {
 		return nil, fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
@@ -109,6 +110,23 @@ func runeBPFCollector(config *Config, enricher gadgets.DataEnricherByMntNs) ([]*
 			Command:       gadgets.FromCString(entry.Comm[:]),
 			ParentPid:     int(entry.ParentPid),
 			WithMountNsID: eventtypes.WithMountNsID{MountNsID: entry.MntnsId},
+			ExePath:       gadgets.FromCString(entry.Exepath[:]),
+			StartTime:     gadgets.WallTimeFromBootTime(entry.StartTime),
+			CgroupID:      entry.CgroupId,
+			CapEffective:  entry.CapEffective,
+			CapPermitted:  entry.CapPermitted,
+			SeccompMode:   processcollectortypes.SeccompMode(entry.SeccompMode),
+			NoNewPrivs:    entry.NoNewPrivs != 0,
+			UidMap: processcollectortypes.FormatUidMap(entry.UidMapFirst,
+				entry.UidMapLowerFirst, entry.UidMapCount, entry.UidMapNrExtents),
+		}
+
+		// The arguments and the cgroup path aren't provided by the iterator.
+		// Don't read them if the task exited and its ID was reused since.
+		taskPath := filepath.Join(host.HostProcFs, fmt.Sprint(event.Pid), "task", fmt.Sprint(event.Tid))
+		if _, startTime, err := readStat(taskPath); err == nil && startTime == entry.StartTime/(1_000_000_000/clockTicks) {
+			event.Args = getProcArgs(event.Pid)
+			event.CgroupPath = getProcCgroupPath(event.Pid)
 		}
 
 		if enricher != nil {
@@ -159,6 +177,10 @@ func getTidEvent(config *Config, enricher gadgets.DataEnricherByMntNs, pid, tid
 		WithMountNsID: eventtypes.WithMountNsID{MountNsID: mntnsid},
 	}
 
+	if err := enrichFromProcfs(event, pid, tid); err != nil {
+		return nil, err
+	}
+
 	if enricher != nil {
 		enricher.EnrichByMntNs(&event.CommonData, event.WithMountNsID.MountNsID)
 	}
@@ -246,6 +268,10 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	return tracer, nil
 }
 
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadProcessCollector)
+}
+
 func (t *Tracer) SetEventHandlerArray(handler any) {
 	nh, ok := handler.(func(ev []*processcollectortypes.Event))
 	if !ok {
@@ -258,6 +284,10 @@ func (t *Tracer) SetMountNsMap(mntnsMap *ebpf.Map) {
 	t.config.MountnsMap = mntnsMap
 }
 
+func (t *Tracer) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.config.CgroupMap = cgroupMap
+}
+
 func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
 	t.config.ShowThreads = gadgetCtx.GadgetParams().Get(ParamThreads).AsBool()
 
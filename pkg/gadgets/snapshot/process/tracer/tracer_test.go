@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -66,6 +67,13 @@ func testTracer(t *testing.T, runCollector collectorFunc) {
 	utilstest.RequireRoot(t)
 	utilstest.HostInit(t)
 
+	// The executable path is reported with symlinks like /bin -> /usr/bin
+	// resolved
+	exePath, err := filepath.EvalSymlinks("/bin/sleep")
+	if err != nil {
+		t.Fatalf("resolving sleep path: %s", err)
+	}
+
 	type testDefinition struct {
 		getTracerConfig func(info *utilstest.RunnerInfo) *Config
 		runnerConfig    *utilstest.RunnerConfig
@@ -88,6 +96,8 @@ func testTracer(t *testing.T, runCollector collectorFunc) {
 					Pid:           sleepPid,
 					Tid:           sleepPid,
 					ParentPid:     0,
+					ExePath:       exePath,
+					Args:          []string{"/bin/sleep", "5"},
 					WithMountNsID: eventtypes.WithMountNsID{MountNsID: info.MountNsID},
 				}
 			}),
@@ -123,6 +133,8 @@ func testTracer(t *testing.T, runCollector collectorFunc) {
 					Command:       "sleep",
 					Pid:           sleepPid,
 					Tid:           sleepPid,
+					ExePath:       exePath,
+					Args:          []string{"/bin/sleep", "5"},
 					WithMountNsID: eventtypes.WithMountNsID{MountNsID: info.MountNsID},
 				}
 			}),
@@ -154,6 +166,8 @@ func testTracer(t *testing.T, runCollector collectorFunc) {
 					Pid:           sleepPid,
 					Tid:           sleepPid,
 					ParentPid:     0,
+					ExePath:       exePath,
+					Args:          []string{"/bin/sleep", "5"},
 					WithMountNsID: eventtypes.WithMountNsID{MountNsID: info.MountNsID},
 				}
 
@@ -210,6 +224,15 @@ func testTracer(t *testing.T, runCollector collectorFunc) {
 				// guess the parent PID.
 				event.ParentPid = 0
 
+				// Normalize the fields that depend on the host and on when the
+				// process was started
+				event.StartTime = 0
+				event.CgroupID = 0
+				event.CgroupPath = ""
+				event.CapEffective = 0
+				event.CapPermitted = 0
+				event.UidMap = ""
+
 				validateEvents = append(validateEvents, *event)
 			}
 
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
@@ -38,10 +39,73 @@ type Event struct {
 	Uid       uint32 `json:"uid" column:"uid,template:uid"`
 	Gid       uint32 `json:"gid" column:"gid,template:gid"`
 	ParentPid int    `json:"ppid" column:"ppid,template:pid,hide"`
+
+	ExePath      string          `json:"exepath,omitempty" column:"exepath,width:32,hide"`
+	Args         []string        `json:"args,omitempty" column:"args,width:40,hide"`
+	StartTime    eventtypes.Time `json:"startTime,omitempty" column:"starttime,width:35,hide,stringer"`
+	CgroupID     uint64          `json:"cgroupID,omitempty" column:"cgroupid,minWidth:10,hide"`
+	CgroupPath   string          `json:"cgroupPath,omitempty" column:"cgroup,width:40,hide"`
+	CapEffective uint64          `json:"capEffective" column:"capeff,width:16,fixed,hide"`
+	CapPermitted uint64          `json:"capPermitted" column:"capprm,width:16,fixed,hide"`
+	SeccompMode  SeccompMode     `json:"seccompMode" column:"seccomp,width:8,fixed,hide,stringer"`
+	NoNewPrivs   bool            `json:"noNewPrivs" column:"nonewprivs,width:10,fixed,hide"`
+	UidMap       string          `json:"uidMap,omitempty" column:"uidmap,width:24,hide"`
+}
+
+// SeccompMode is the seccomp mode of a process, as defined in
+// include/uapi/linux/seccomp.h
+type SeccompMode uint32
+
+const (
+	SeccompModeDisabled SeccompMode = iota
+	SeccompModeStrict
+	SeccompModeFilter
+)
+
+func (m SeccompMode) String() string {
+	switch m {
+	case SeccompModeDisabled:
+		return "disabled"
+	case SeccompModeStrict:
+		return "strict"
+	case SeccompModeFilter:
+		return "filter"
+	}
+	return fmt.Sprintf("unknown(%d)", uint32(m))
+}
+
+func (m SeccompMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// FormatUidMap formats the first extent of the uid mapping of a user
+// namespace like /proc/<pid>/uid_map does. Additional extents are only
+// counted.
+func FormatUidMap(first, lowerFirst, count, nrExtents uint32) string {
+	if nrExtents == 0 {
+		return ""
+	}
+	uidMap := fmt.Sprintf("%d %d %d", first, lowerFirst, count)
+	if nrExtents > 1 {
+		uidMap += fmt.Sprintf(" (+%d)", nrExtents-1)
+	}
+	return uidMap
 }
 
 func GetColumns() *columns.Columns[Event] {
-	return columns.MustCreateColumns[Event]()
+	processColumns := columns.MustCreateColumns[Event]()
+
+	processColumns.MustSetExtractor("args", func(event *Event) any {
+		return strings.Join(event.Args, " ")
+	})
+	processColumns.MustSetExtractor("capeff", func(event *Event) any {
+		return fmt.Sprintf("%016x", event.CapEffective)
+	})
+	processColumns.MustSetExtractor("capprm", func(event *Event) any {
+		return fmt.Sprintf("%016x", event.CapPermitted)
+	})
+
+	return processColumns
 }
 
 type processTree struct {
@@ -49,8 +113,10 @@ type processTree struct {
 	children []*processTree
 }
 
-func createTree(processes []*Event) (*processTree, error) {
-	var root *processTree
+// createForest links the processes to their parent and returns the processes
+// whose parent isn't part of processes, in the order they were given
+func createForest(processes []*Event) []*processTree {
+	var roots []*processTree
 
 	nodes := make(map[int]*processTree, len(processes))
 	// Create a node for each process.
@@ -65,20 +131,21 @@ func createTree(processes []*Event) (*processTree, error) {
 	for _, process := range processes {
 		node := nodes[process.Pid]
 		ppid := node.process.ParentPid
-		if _, ok := nodes[ppid]; !ok {
-			if root != nil {
-				return nil, fmt.Errorf("tree has two root processes: %v and %v", root, node)
-			}
-
-			root = node
-
+		if _, ok := nodes[ppid]; !ok || ppid == process.Pid {
+			roots = append(roots, node)
 			continue
 		}
 
 		nodes[ppid].children = append(nodes[ppid].children, node)
 	}
 
-	if root == nil {
+	return roots
+}
+
+func createTree(processes []*Event) (*processTree, error) {
+	roots := createForest(processes)
+
+	if len(roots) == 0 {
 		// Even if there are orphan process, they should have a parent process
 		// as they will get the reaper as parent process:
 		// https://elixir.bootlin.com/linux/v6.1.3/source/kernel/exit.c#L653
@@ -87,7 +154,11 @@ func createTree(processes []*Event) (*processTree, error) {
 		return nil, fmt.Errorf("container has no root process")
 	}
 
-	return root, nil
+	if len(roots) > 1 {
+		return nil, fmt.Errorf("tree has two root processes: %v and %v", roots[0], roots[1])
+	}
+
+	return roots[0], nil
 }
 
 func (t *processTree) String() string {
@@ -98,33 +169,64 @@ func (t *processTree) String() string {
 }
 
 func treeToStringBuilder(node *processTree, builder *strings.Builder, depth int) {
-	fmt.Fprintf(builder, "%s|-%s(%d)\n", strings.Repeat("\t", depth), node.process.Command, node.process.Pid)
+	fmt.Fprintf(builder, "%s|-%s(%d)", strings.Repeat("\t", depth), node.process.Command, node.process.Pid)
+	if len(node.process.Args) > 0 {
+		fmt.Fprintf(builder, " %s", strings.Join(node.process.Args, " "))
+	}
+	builder.WriteString("\n")
 	for _, child := range node.children {
 		treeToStringBuilder(child, builder, depth+1)
 	}
 }
 
+// containerName returns the name used to group the processes of the same
+// container in the tree output
+func containerName(process *Event) string {
+	if process.K8s.ContainerName != "" {
+		name := process.K8s.ContainerName
+		if process.K8s.PodName != "" && process.K8s.PodName != process.K8s.ContainerName {
+			name = process.K8s.PodName + "/" + name
+		}
+		if process.K8s.Namespace != "" {
+			name = process.K8s.Namespace + "/" + name
+		}
+		return name
+	}
+	if process.Runtime.ContainerName != "" {
+		return process.Runtime.ContainerName
+	}
+	return "host"
+}
+
+// WriteTree writes the processes as a tree of parent and child processes,
+// grouped by container. Containers are sorted by name and processes by PID.
+// Threads are skipped.
 func WriteTree(output io.Writer, processes []*Event) error {
-	containers := make(map[string][]*Event, len(processes))
+	containers := make(map[string][]*Event)
 	for _, process := range processes {
-		containers[process.K8s.ContainerName] = append(containers[process.K8s.ContainerName], process)
+		if process.Tid != 0 && process.Tid != process.Pid {
+			continue
+		}
+		name := containerName(process)
+		containers[name] = append(containers[name], process)
 	}
 
-	for _, container := range containers {
-		tree, err := createTree(container)
-		if err != nil {
-			return err
-		}
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-		if tree.process.K8s.Namespace != "" {
-			fmt.Fprintf(output, "%s/", tree.process.K8s.Namespace)
-		}
-		if tree.process.K8s.PodName != "" && tree.process.K8s.PodName != tree.process.K8s.ContainerName {
-			fmt.Fprintf(output, "%s/", tree.process.K8s.PodName)
-		}
-		fmt.Fprintln(output, tree.process.K8s.ContainerName)
+	for _, name := range names {
+		container := containers[name]
+		sort.SliceStable(container, func(i, j int) bool {
+			return container[i].Pid < container[j].Pid
+		})
 
-		fmt.Fprint(output, tree)
+		fmt.Fprintln(output, name)
+		for _, tree := range createForest(container) {
+			fmt.Fprint(output, tree)
+		}
 	}
 
 	return nil
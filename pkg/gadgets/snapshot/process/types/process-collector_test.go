@@ -179,3 +179,61 @@ func TestTreeToString(t *testing.T) {
 		i++
 	}
 }
+
+func TestWriteTree(t *testing.T) {
+	t.Parallel()
+
+	newEvent := func(container string, pid, ppid int, comm string, args ...string) *Event {
+		event := &Event{
+			Command:   comm,
+			Pid:       pid,
+			Tid:       pid,
+			ParentPid: ppid,
+			Args:      args,
+		}
+		event.K8s.Namespace = "default"
+		event.K8s.PodName = "mypod"
+		event.K8s.ContainerName = container
+		return event
+	}
+
+	thread := newEvent("nginx", 11, 1, "nginx")
+	thread.Tid = 12
+
+	host := &Event{Command: "systemd", Pid: 1}
+
+	events := []*Event{
+		newEvent("nginx", 20, 10, "nginx"),
+		newEvent("sidecar", 30, 1, "sleep", "sleep", "1000"),
+		newEvent("nginx", 11, 10, "nginx"),
+		newEvent("nginx", 10, 1, "nginx"),
+		thread,
+		host,
+		// Orphan processes whose parent isn't in the container are shown as
+		// additional roots
+		newEvent("sidecar", 31, 2, "sh"),
+	}
+
+	var builder strings.Builder
+	require.NoError(t, WriteTree(&builder, events))
+
+	expected := `default/mypod/nginx
+|-nginx(10)
+	|-nginx(11)
+	|-nginx(20)
+default/mypod/sidecar
+|-sleep(30) sleep 1000
+|-sh(31)
+host
+|-systemd(1)
+`
+	require.Equal(t, expected, builder.String())
+}
+
+func TestFormatUidMap(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "", FormatUidMap(0, 0, 0, 0))
+	require.Equal(t, "0 0 4294967295", FormatUidMap(0, 0, 4294967295, 1))
+	require.Equal(t, "0 100000 65536 (+1)", FormatUidMap(0, 100000, 65536, 2))
+}
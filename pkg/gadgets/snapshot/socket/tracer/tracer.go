@@ -182,6 +182,10 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	}, nil
 }
 
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadSocket)
+}
+
 func (t *Tracer) AttachContainer(container *containercollection.Container) error {
 	if _, ok := t.visitedNamespaces[container.Netns]; ok {
 		return nil
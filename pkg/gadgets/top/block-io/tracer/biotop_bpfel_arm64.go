@@ -90,10 +90,11 @@ type biotopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type biotopMapSpecs struct {
-	Counts               *ebpf.MapSpec `ebpf:"counts"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	Start                *ebpf.MapSpec `ebpf:"start"`
-	Whobyreq             *ebpf.MapSpec `ebpf:"whobyreq"`
+	Counts                *ebpf.MapSpec `ebpf:"counts"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Start                 *ebpf.MapSpec `ebpf:"start"`
+	Whobyreq              *ebpf.MapSpec `ebpf:"whobyreq"`
 }
 
 // biotopObjects contains all objects after they have been loaded into the kernel.
@@ -115,15 +116,17 @@ func (o *biotopObjects) Close() error {
 //
 // It can be passed to loadBiotopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type biotopMaps struct {
-	Counts               *ebpf.Map `ebpf:"counts"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	Start                *ebpf.Map `ebpf:"start"`
-	Whobyreq             *ebpf.Map `ebpf:"whobyreq"`
+	Counts                *ebpf.Map `ebpf:"counts"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Start                 *ebpf.Map `ebpf:"start"`
+	Whobyreq              *ebpf.Map `ebpf:"whobyreq"`
 }
 
 func (m *biotopMaps) Close() error {
 	return _BiotopClose(
 		m.Counts,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.Start,
 		m.Whobyreq,
@@ -42,6 +42,7 @@ type Config struct {
 	Iterations int
 	SortBy     []string
 	MountnsMap *ebpf.Map
+	CgroupMap  *ebpf.Map
 }
 
 type Tracer struct {
@@ -105,7 +106,7 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("loading ebpf program: %w", err)
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, t.config.CgroupMap, spec, nil, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
@@ -267,6 +268,7 @@ func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
 	}
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
 
 	return t.run(gadgetCtx.Context())
 }
@@ -290,6 +292,10 @@ func (t *Tracer) SetMountNsMap(mntnsMap *ebpf.Map) {
 	t.config.MountnsMap = mntnsMap
 }
 
+func (t *Tracer) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.config.CgroupMap = cgroupMap
+}
+
 func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	tracer := &Tracer{
 		config: &Config{
@@ -302,6 +308,10 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	return tracer, nil
 }
 
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadBiotop)
+}
+
 func (t *Tracer) init(gadgetCtx gadgets.GadgetContext) error {
 	params := gadgetCtx.GadgetParams()
 	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
@@ -21,6 +21,7 @@ import (
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/link"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfstats"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/kallsyms"
 	bpfiterns "github.com/inspektor-gadget/inspektor-gadget/pkg/utils/bpf-iter-ns"
@@ -29,8 +30,9 @@ import (
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -cflags ${CFLAGS} -type pid_iter_entry piditer ./bpf/pid_iter.bpf.c -- -I./bpf/
 
 type PidIter struct {
-	objs piditerObjects
-	iter *link.Iter
+	objs    piditerObjects
+	iter    *link.Iter
+	objects bpfstats.Objects
 }
 
 type PidIterEntry struct {
@@ -48,6 +50,7 @@ func NewTracer() (iter *PidIter, err error) {
 			if p.iter != nil {
 				p.iter.Close()
 			}
+			p.objects.Clear()
 			p.objs.Close()
 		}
 	}()
@@ -66,6 +69,7 @@ func NewTracer() (iter *PidIter, err error) {
 	if err = spec.LoadAndAssign(&p.objs, &opts); err != nil {
 		return nil, fmt.Errorf("loading ebpf program: %w", err)
 	}
+	p.objects.Add(&p.objs)
 
 	p.iter, err = link.AttachIter(link.IterOptions{
 		Program: p.objs.IgTopEbpfIt,
@@ -103,7 +107,14 @@ func (p *PidIter) DumpPids() ([]*PidIterEntry, error) {
 	return res, nil
 }
 
+// SetGadgetInfo attributes the iterator to the given gadget instance
+func (p *PidIter) SetGadgetInfo(info bpfstats.GadgetInfo) {
+	p.objects.SetOwner(info)
+}
+
 func (p *PidIter) Close() (err error) {
+	p.objects.Clear()
+
 	// If there's an error, return the last one
 	if tmpErr := p.iter.Close(); tmpErr != nil {
 		err = tmpErr
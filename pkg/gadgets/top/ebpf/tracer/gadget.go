@@ -41,7 +41,15 @@ func (g *GadgetDesc) Description() string {
 }
 
 func (g *GadgetDesc) ParamDescs() params.ParamDescs {
-	return nil
+	return params.ParamDescs{
+		{
+			Key:          types.AggregateParam,
+			Title:        "Aggregate",
+			DefaultValue: "false",
+			Description:  "report one row per gadget instance summing the cost of all its programs, maps and buffers",
+			TypeHint:     params.TypeBool,
+		},
+	}
 }
 
 func (g *GadgetDesc) Parser() parser.Parser {
@@ -45,6 +45,7 @@ type Config struct {
 	Interval   time.Duration
 	Iterations int
 	SortBy     []string
+	Aggregate  bool
 }
 
 type programStats struct {
@@ -228,6 +229,107 @@ func getMemoryUsage(m *ebpf.Map) (uint64, error) {
 	return 0, fmt.Errorf("finding memlock in fdinfo")
 }
 
+// getBufferMemory returns the memory used by the buffers of ring buffer and
+// perf event array maps. The size of perf buffers is only known for the
+// readers created by gadgets, as it's chosen by the reader and not by the map.
+func getBufferMemory(id ebpf.MapID, m *ebpf.Map, numOnlineCPUs int) uint64 {
+	info, err := m.Info()
+	if err != nil {
+		return 0
+	}
+
+	switch info.Type {
+	case ebpf.RingBuf:
+		return uint64(info.MaxEntries)
+	case ebpf.PerfEventArray:
+		perCPUSize, ok := bpfstats.PerfBufferSize(id)
+		if !ok {
+			return 0
+		}
+		nCPUs := uint64(numOnlineCPUs)
+		if uint64(info.MaxEntries) < nCPUs {
+			nCPUs = uint64(info.MaxEntries)
+		}
+		return nCPUs * perCPUSize
+	}
+	return 0
+}
+
+// aggregateStats merges the stats of the programs that belong to the same
+// gadget instance. Programs that weren't loaded by a gadget are dropped. Maps
+// shared by several programs of a gadget are only counted once.
+func aggregateStats(
+	stats []*types.Stats,
+	progMapIDs map[uint32][]ebpf.MapID,
+	mapSizes, bufferSizes map[ebpf.MapID]uint64,
+) []*types.Stats {
+	aggregated := make([]*types.Stats, 0)
+	byGadget := make(map[string]*types.Stats)
+	gadgetMaps := make(map[string]map[ebpf.MapID]struct{})
+	gadgetPids := make(map[string]map[uint32]struct{})
+
+	for _, stat := range stats {
+		if stat.GadgetID == "" {
+			continue
+		}
+
+		agg, ok := byGadget[stat.GadgetID]
+		if !ok {
+			agg = &types.Stats{
+				CommonData:  stat.CommonData,
+				Gadget:      stat.Gadget,
+				GadgetID:    stat.GadgetID,
+				GadgetImage: stat.GadgetImage,
+			}
+			byGadget[stat.GadgetID] = agg
+			gadgetMaps[stat.GadgetID] = make(map[ebpf.MapID]struct{})
+			gadgetPids[stat.GadgetID] = make(map[uint32]struct{})
+			aggregated = append(aggregated, agg)
+		}
+
+		agg.ProgramCount++
+		agg.CurrentRuntime += stat.CurrentRuntime
+		agg.CurrentRunCount += stat.CurrentRunCount
+		agg.CumulativeRuntime += stat.CumulativeRuntime
+		agg.CumulativeRunCount += stat.CumulativeRunCount
+		agg.TotalRuntime += stat.TotalRuntime
+		agg.TotalRunCount += stat.TotalRunCount
+		agg.TotalCpuUsage += stat.TotalCpuUsage
+		agg.PerCpuUsage += stat.PerCpuUsage
+
+		for _, mapID := range progMapIDs[stat.ProgramID] {
+			gadgetMaps[stat.GadgetID][mapID] = struct{}{}
+		}
+		for _, process := range stat.Processes {
+			if _, ok := gadgetPids[stat.GadgetID][process.Pid]; !ok {
+				gadgetPids[stat.GadgetID][process.Pid] = struct{}{}
+				agg.Processes = append(agg.Processes, process)
+			}
+		}
+	}
+
+	// Maps that aren't used by any program, e.g. the ones only updated from
+	// user space, still count towards the gadget
+	for mapID := range mapSizes {
+		if owner, ok := bpfstats.MapOwner(mapID); ok {
+			if maps, ok := gadgetMaps[owner.ID]; ok {
+				maps[mapID] = struct{}{}
+			}
+		}
+	}
+
+	for gadgetID, maps := range gadgetMaps {
+		agg := byGadget[gadgetID]
+		agg.MapCount = uint32(len(maps))
+		for mapID := range maps {
+			agg.MapMemory += mapSizes[mapID]
+			agg.BufferMemory += bufferSizes[mapID]
+		}
+	}
+
+	return aggregated
+}
+
 func (t *Tracer) nextStats() ([]*types.Stats, error) {
 	stats := make([]*types.Stats, 0)
 
@@ -244,6 +346,8 @@ func (t *Tracer) nextStats() ([]*types.Stats, error) {
 	curStats := make(map[string]programStats)
 
 	mapSizes := make(map[ebpf.MapID]uint64)
+	bufferSizes := make(map[ebpf.MapID]uint64)
+	progMapIDs := make(map[uint32][]ebpf.MapID)
 
 	numOnlineCPUs, err := numcpus.GetOnline()
 	if err != nil {
@@ -269,6 +373,9 @@ func (t *Tracer) nextStats() ([]*types.Stats, error) {
 		}
 
 		mapSizes[curMapID], err = getMemoryUsage(mapData)
+		if size := getBufferMemory(curMapID, mapData, numOnlineCPUs); size > 0 {
+			bufferSizes[curMapID] = size
+		}
 		mapData.Close()
 		if err != nil {
 			return nil, fmt.Errorf("getting memory usage of map ID (%d): %w", curMapID, err)
@@ -298,12 +405,15 @@ func (t *Tracer) nextStats() ([]*types.Stats, error) {
 		}
 
 		totalMapMemory := uint64(0)
+		totalBufferMemory := uint64(0)
 		mapIDs, _ := pi.MapIDs()
 		for _, mapID := range mapIDs {
 			if size, ok := mapSizes[mapID]; ok {
 				totalMapMemory += size
 			}
+			totalBufferMemory += bufferSizes[mapID]
 		}
+		progMapIDs[uint32(curID)] = mapIDs
 
 		totalRuntime, _ := pi.Runtime()
 		totalRunCount, _ := pi.RunCount()
@@ -351,6 +461,14 @@ func (t *Tracer) nextStats() ([]*types.Stats, error) {
 			MapCount:           uint32(len(mapIDs)),
 			TotalCpuUsage:      totalCpuUsage,
 			PerCpuUsage:        totalCpuUsage / float64(numOnlineCPUs),
+			BufferMemory:       totalBufferMemory,
+			ProgramCount:       1,
+		}
+
+		if owner, ok := bpfstats.ProgramOwner(curID); ok {
+			stat.GadgetID = owner.ID
+			stat.Gadget = owner.Name
+			stat.GadgetImage = owner.Image
 		}
 
 		if t.enricher != nil {
@@ -390,6 +508,10 @@ func (t *Tracer) nextStats() ([]*types.Stats, error) {
 		}
 	}
 
+	if t.config.Aggregate {
+		stats = aggregateStats(stats, progMapIDs, mapSizes, bufferSizes)
+	}
+
 	top.SortStats(stats, t.config.SortBy, &t.colMap)
 
 	return stats, nil
@@ -445,6 +567,9 @@ func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
 	}
+	if t.iter != nil {
+		t.iter.SetGadgetInfo(gadgets.GadgetInfo(gadgetCtx))
+	}
 
 	return t.run(gadgetCtx.Context())
 }
@@ -478,6 +603,7 @@ func (t *Tracer) init(gadgetCtx gadgets.GadgetContext) error {
 	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
 	t.config.SortBy = params.Get(gadgets.ParamSortBy).AsStringSlice()
 	t.config.Interval = time.Second * time.Duration(params.Get(gadgets.ParamInterval).AsInt())
+	t.config.Aggregate = params.Get(types.AggregateParam).AsBool()
 
 	var err error
 	if t.config.Iterations, err = top.ComputeIterations(t.config.Interval, gadgetCtx.Timeout()); err != nil {
@@ -28,6 +28,10 @@ import (
 
 var SortByDefault = []string{"-runtime", "-runcount"}
 
+// AggregateParam is the name of the parameter to report one row per gadget
+// instance instead of one row per program
+const AggregateParam = "aggregate"
+
 type Process struct {
 	Pid  uint32 `json:"pid,omitempty"`
 	Comm string `json:"comm,omitempty"`
@@ -38,6 +42,10 @@ type Stats struct {
 	ProgramID          uint32     `json:"progid" column:"progid"`
 	Type               string     `json:"type,omitempty" column:"type"`
 	Name               string     `json:"name,omitempty" column:"name"`
+	Gadget             string     `json:"gadget,omitempty" column:"gadget,width:16"`
+	GadgetID           string     `json:"gadgetID,omitempty" column:"gadgetid,width:36,hide"`
+	GadgetImage        string     `json:"gadgetImage,omitempty" column:"gadgetimage,width:32,hide"`
+	ProgramCount       uint32     `json:"progCount,omitempty" column:"progcount,hide"`
 	Processes          []*Process `json:"processes,omitempty"`
 	CurrentRuntime     int64      `json:"currentRuntime,omitempty" column:"runtime,order:1001,align:right"`
 	CurrentRunCount    uint64     `json:"currentRunCount,omitempty" column:"runcount,order:1002,width:10"`
@@ -49,6 +57,7 @@ type Stats struct {
 	MapCount           uint32     `json:"mapCount,omitempty" column:"mapcount,order:1008"`
 	TotalCpuUsage      float64    `json:"totalCpuUsage,omitempty" column:"totalcpu,order:1009,align:right,hide,precision:4"`
 	PerCpuUsage        float64    `json:"perCpuUsage,omitempty" column:"percpu,order:1010,align:right,hide,precision:4"`
+	BufferMemory       uint64     `json:"bufferMemory,omitempty" column:"buffermemory,order:1011,align:right"`
 }
 
 func GetColumns() *columns.Columns[Stats] {
@@ -106,6 +115,9 @@ func GetColumns() *columns.Columns[Stats] {
 	cols.MustSetExtractor("mapmemory", func(stats *Stats) any {
 		return fmt.Sprint(units.BytesSize(float64(stats.MapMemory)))
 	})
+	cols.MustSetExtractor("buffermemory", func(stats *Stats) any {
+		return fmt.Sprint(units.BytesSize(float64(stats.BufferMemory)))
+	})
 
 	return cols
 }
@@ -83,8 +83,9 @@ type filetopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type filetopMapSpecs struct {
-	Entries              *ebpf.MapSpec `ebpf:"entries"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Entries               *ebpf.MapSpec `ebpf:"entries"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
 }
 
 // filetopObjects contains all objects after they have been loaded into the kernel.
@@ -106,13 +107,15 @@ func (o *filetopObjects) Close() error {
 //
 // It can be passed to loadFiletopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type filetopMaps struct {
-	Entries              *ebpf.Map `ebpf:"entries"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Entries               *ebpf.Map `ebpf:"entries"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
 }
 
 func (m *filetopMaps) Close() error {
 	return _FiletopClose(
 		m.Entries,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 	)
 }
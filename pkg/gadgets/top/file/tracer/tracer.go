@@ -37,6 +37,7 @@ import (
 
 type Config struct {
 	MountnsMap *ebpf.Map
+	CgroupMap  *ebpf.Map
 	TargetPid  int
 	AllFiles   bool
 	MaxRows    int
@@ -109,7 +110,7 @@ func (t *Tracer) install() error {
 		"regular_file_only": !t.config.AllFiles,
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, t.config.CgroupMap, spec, consts, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
@@ -250,6 +251,7 @@ func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
 	}
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
 
 	return t.run(gadgetCtx.Context())
 }
@@ -273,6 +275,10 @@ func (t *Tracer) SetMountNsMap(mntnsMap *ebpf.Map) {
 	t.config.MountnsMap = mntnsMap
 }
 
+func (t *Tracer) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.config.CgroupMap = cgroupMap
+}
+
 func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	tracer := &Tracer{
 		config: &Config{},
@@ -281,6 +287,10 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	return tracer, nil
 }
 
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadFiletop)
+}
+
 func (t *Tracer) init(gadgetCtx gadgets.GadgetContext) error {
 	params := gadgetCtx.GadgetParams()
 	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
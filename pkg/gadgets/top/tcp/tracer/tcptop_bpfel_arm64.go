@@ -78,8 +78,9 @@ type tcptopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type tcptopMapSpecs struct {
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	IpMap                *ebpf.MapSpec `ebpf:"ip_map"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	IpMap                 *ebpf.MapSpec `ebpf:"ip_map"`
 }
 
 // tcptopObjects contains all objects after they have been loaded into the kernel.
@@ -101,12 +102,14 @@ func (o *tcptopObjects) Close() error {
 //
 // It can be passed to loadTcptopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type tcptopMaps struct {
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	IpMap                *ebpf.Map `ebpf:"ip_map"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	IpMap                 *ebpf.Map `ebpf:"ip_map"`
 }
 
 func (m *tcptopMaps) Close() error {
 	return _TcptopClose(
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.IpMap,
 	)
@@ -81,10 +81,11 @@ type bindsnoopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type bindsnoopMapSpecs struct {
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	Ports                *ebpf.MapSpec `ebpf:"ports"`
-	Sockets              *ebpf.MapSpec `ebpf:"sockets"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Ports                 *ebpf.MapSpec `ebpf:"ports"`
+	Sockets               *ebpf.MapSpec `ebpf:"sockets"`
 }
 
 // bindsnoopObjects contains all objects after they have been loaded into the kernel.
@@ -106,15 +107,17 @@ func (o *bindsnoopObjects) Close() error {
 //
 // It can be passed to loadBindsnoopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type bindsnoopMaps struct {
-	Events               *ebpf.Map `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	Ports                *ebpf.Map `ebpf:"ports"`
-	Sockets              *ebpf.Map `ebpf:"sockets"`
+	Events                *ebpf.Map `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Ports                 *ebpf.Map `ebpf:"ports"`
+	Sockets               *ebpf.Map `ebpf:"sockets"`
 }
 
 func (m *bindsnoopMaps) Close() error {
 	return _BindsnoopClose(
 		m.Events,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.Ports,
 		m.Sockets,
@@ -37,6 +37,7 @@ import (
 
 type Config struct {
 	MountnsMap   *ebpf.Map
+	CgroupMap    *ebpf.Map
 	TargetPid    int32
 	TargetPorts  []uint16
 	IgnoreErrors bool
@@ -115,7 +116,7 @@ func (t *Tracer) install() error {
 		"ignore_errors":  t.config.IgnoreErrors,
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, t.config.CgroupMap, spec, consts, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
@@ -139,7 +140,7 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("attaching ipv6 kprobe: %w", err)
 	}
 
-	t.reader, err = perf.NewReader(t.objs.bindsnoopMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	t.reader, err = gadgets.NewPerfReader(t.objs.bindsnoopMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
 	if err != nil {
 		return fmt.Errorf("creating perf ring buffer: %w", err)
 	}
@@ -225,8 +226,7 @@ func (t *Tracer) run() {
 		}
 
 		if record.LostSamples > 0 {
-			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
-			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			t.eventCallback(types.Base(eventtypes.Lost(record.LostSamples)))
 			continue
 		}
 
@@ -288,6 +288,7 @@ func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
 	}
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
 
 	// TODO: Rework this to be able to stop the gadget when an error occurs in
 	// run(). Notice it is the same for most of gadgets in the trace category.
@@ -301,6 +302,10 @@ func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
 	t.config.MountnsMap = mountnsMap
 }
 
+func (t *Tracer) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.config.CgroupMap = cgroupMap
+}
+
 func (t *Tracer) SetEventHandler(handler any) {
 	nh, ok := handler.(func(ev *types.Event))
 	if !ok {
@@ -315,3 +320,7 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	}
 	return tracer, nil
 }
+
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadBindsnoop)
+}
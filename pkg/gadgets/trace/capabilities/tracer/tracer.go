@@ -38,6 +38,7 @@ import (
 
 type Config struct {
 	MountnsMap *ebpf.Map
+	CgroupMap  *ebpf.Map
 	AuditOnly  bool
 	Unique     bool
 }
@@ -147,7 +148,7 @@ func (t *Tracer) install() error {
 		"unique":     t.config.Unique,
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, t.config.CgroupMap, spec, consts, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
@@ -181,7 +182,7 @@ func (t *Tracer) install() error {
 	}
 	t.capExitLink = kretprobe
 
-	reader, err := perf.NewReader(t.objs.capabilitiesMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	reader, err := gadgets.NewPerfReader(t.objs.capabilitiesMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
 	if err != nil {
 		return fmt.Errorf("creating perf ring buffer: %w", err)
 	}
@@ -220,8 +221,7 @@ func (t *Tracer) run() {
 		}
 
 		if record.LostSamples > 0 {
-			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
-			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			t.eventCallback(types.Base(eventtypes.Lost(record.LostSamples)))
 			continue
 		}
 
@@ -298,6 +298,7 @@ func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
 	}
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
 
 	go t.run()
 	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
@@ -309,6 +310,10 @@ func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
 	t.config.MountnsMap = mountnsMap
 }
 
+func (t *Tracer) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.config.CgroupMap = cgroupMap
+}
+
 func (t *Tracer) SetEventHandler(handler any) {
 	nh, ok := handler.(func(ev *types.Event))
 	if !ok {
@@ -323,3 +328,7 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	}
 	return tracer, nil
 }
+
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadCapabilities)
+}
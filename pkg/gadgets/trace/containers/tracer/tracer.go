@@ -0,0 +1,142 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracer implements a gadget that doesn't use eBPF: it generates an event each time the
+// operator managing the containers (LocalManager or KubeManager) attaches or detaches it from a
+// container.
+package tracer
+
+import (
+	"sync"
+	"time"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/containers/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// exitTimeout is how long the DELETED events wait for the runtime to tell how
+// the container terminated
+const exitTimeout = 500 * time.Millisecond
+
+type Tracer struct {
+	eventCallback func(*types.Event)
+	skipExisting  bool
+
+	// pending tracks the DELETED events waiting for the exit of the container
+	pending sync.WaitGroup
+
+	mu sync.Mutex
+	// running is true while Run() is executing. Containers attached before are the existing
+	// ones, and containers detached after are only detached because the gadget is stopping.
+	running bool
+	stopped bool
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{}, nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	t.skipExisting = gadgetCtx.GadgetParams().Get(ParamSkipExisting).AsBool()
+	return nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	t.mu.Lock()
+	t.running = true
+	t.mu.Unlock()
+
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	t.mu.Lock()
+	t.running = false
+	t.stopped = true
+	t.mu.Unlock()
+
+	t.pending.Wait()
+
+	return nil
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	t.mu.Lock()
+	skip := t.stopped || (t.skipExisting && !t.running)
+	t.mu.Unlock()
+	if skip {
+		return nil
+	}
+
+	t.emit(types.OperationCreated, container)
+	return nil
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	t.mu.Lock()
+	skip := t.stopped
+	if !skip {
+		t.pending.Add(1)
+	}
+	t.mu.Unlock()
+	if skip {
+		return nil
+	}
+
+	// Don't delay the removal of the container while the runtime reports how
+	// it terminated
+	go func() {
+		defer t.pending.Done()
+		t.emit(types.OperationDeleted, container)
+	}()
+	return nil
+}
+
+func (t *Tracer) emit(operation string, container *containercollection.Container) {
+	if t.eventCallback == nil {
+		return
+	}
+
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: eventtypes.Time(time.Now().UnixNano()),
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: container.Mntns},
+		Operation:     operation,
+		Pid:           container.Pid,
+		Netns:         container.Netns,
+		HostNetwork:   container.HostNetwork,
+		CgroupPath:    container.CgroupPath,
+		CgroupID:      container.CgroupID,
+		Bundle:        container.Bundle,
+		OciConfig:     container.OciConfig,
+	}
+	event.SetContainerMetadata(&container.K8s.BasicK8sMetadata, &container.Runtime.BasicRuntimeMetadata)
+
+	if operation == types.OperationDeleted {
+		event.Exit = container.WaitExit(exitTimeout)
+	}
+
+	t.eventCallback(event)
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	ocispec "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperationCreated = "CREATED"
+	OperationDeleted = "DELETED"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Operation string `json:"operation,omitempty" column:"operation,width:9,fixed"`
+
+	Pid         uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Netns       uint64 `json:"netns,omitempty" column:"netns,template:ns,hide"`
+	HostNetwork bool   `json:"hostNetwork,omitempty" column:"hostNetwork,width:11,fixed,hide"`
+	CgroupPath  string `json:"cgroupPath,omitempty" column:"cgroupPath,width:40,hide"`
+	CgroupID    uint64 `json:"cgroupID,omitempty" column:"cgroupID,hide"`
+
+	// Exit is only set for the DELETED events, and only if the container runtime provides it
+	Exit *containercollection.ContainerExit `json:"exit,omitempty" column:"exit"`
+
+	Bundle    string        `json:"bundle,omitempty"`
+	OciConfig *ocispec.Spec `json:"ociConfig,omitempty"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	for _, name := range []string{"exit.code", "exit.reason"} {
+		col, _ := cols.GetColumn(name)
+		col.Visible = true
+	}
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
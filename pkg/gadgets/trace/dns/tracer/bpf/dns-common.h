@@ -50,6 +50,9 @@ struct event_t {
 
 	__u64 latency_ns; // Set only if qr is 1 (response) and pkt_type is 0 (Host).
 
+	// TTL in seconds of each address in anaddr
+	__u32 anaddrttl[MAX_ADDR_ANSWERS];
+
 	__u8 name[MAX_DNS_NAME];
 
 	__u16 ancount;
@@ -161,6 +161,8 @@ static __always_inline int load_addresses(struct __sk_buff *skb, int ancount,
 								   class));
 		__u16 rdlength = load_half(
 			skb, rroffset + offsetof(struct dnsrr, rdlength));
+		__u32 ttl =
+			load_word(skb, rroffset + offsetof(struct dnsrr, ttl));
 
 		if (rrtype == DNS_TYPE_A && rrclass == DNS_CLASS_IN &&
 		    rdlength == 4) {
@@ -171,12 +173,14 @@ static __always_inline int load_addresses(struct __sk_buff *skb, int ancount,
 			__builtin_memset(&event->anaddr[index][10], 0xff, 2);
 			bpf_skb_load_bytes(skb, rroffset + sizeof(struct dnsrr),
 					   &event->anaddr[index][12], rdlength);
+			event->anaddrttl[index] = ttl;
 			index++;
 		} else if (rrtype == DNS_TYPE_AAAA && rrclass == DNS_CLASS_IN &&
 			   rdlength == 16) {
 			// AAAA record contains an IPv6 address.
 			bpf_skb_load_bytes(skb, rroffset + sizeof(struct dnsrr),
 					   &event->anaddr[index][0], rdlength);
+			event->anaddrttl[index] = ttl;
 			index++;
 		}
 		rroffset += sizeof(struct dnsrr) + rdlength;
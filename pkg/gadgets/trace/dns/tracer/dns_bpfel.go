@@ -36,6 +36,7 @@ type dnsEventT struct {
 	Rcode       uint8
 	_           [1]byte
 	LatencyNs   uint64
+	Anaddrttl   [8]uint32
 	Name        [255]uint8
 	_           [1]byte
 	Ancount     uint16
@@ -23,6 +23,7 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/cilium/ebpf"
 	log "github.com/sirupsen/logrus"
 
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
@@ -65,6 +66,7 @@ type dnsEventTAbbrev struct {
 	Rcode       uint8
 	_           [1]byte
 	LatencyNs   uint64
+	Anaddrttl   [8]uint32
 	Name        [255]uint8
 	_           [1]byte
 	Ancount     uint16
@@ -301,6 +303,7 @@ func bpfEventToDNSEvent(bpfEvent *dnsEventTAbbrev, answers []byte, netns uint64)
 		// IPv4-mapped-IPv6, which netip.Addr.Unmap() converts back to IPv4.
 		addr := netip.AddrFrom16([16]byte(answers[i*16 : i*16+16])).Unmap().String()
 		event.Addresses = append(event.Addresses, addr)
+		event.AddressTTLs = append(event.AddressTTLs, bpfEvent.Anaddrttl[i])
 	}
 
 	return &event, nil
@@ -312,6 +315,10 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	return &Tracer{}, nil
 }
 
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadDns)
+}
+
 func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		t.Close()
@@ -375,6 +382,7 @@ func (t *Tracer) run(ctx context.Context, logger logger.Logger, dnsTimeout time.
 func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
 	dnsTimeout := gadgetCtx.GadgetParams().Get(ParamDNSTimeout).AsDuration()
 
+	t.Tracer.SetGadgetInfo(gadgets.GadgetInfo(gadgetCtx))
 	if err := t.run(t.ctx, gadgetCtx.Logger(), dnsTimeout); err != nil {
 		return err
 	}
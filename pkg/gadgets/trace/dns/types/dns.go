@@ -15,6 +15,7 @@
 package types
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
@@ -48,16 +49,17 @@ type Event struct {
 	DstPort  uint16 `json:"dstPort,omitempty" column:"dstPort,template:ipport,hide"`
 	Protocol string `json:"protocol,omitempty" column:"proto,maxWidth:5,hide"`
 
-	ID         string        `json:"id,omitempty" column:"id,width:4,fixed,hide"`
-	Qr         DNSPktType    `json:"qr,omitempty" column:"qr,width:2,fixed"`
-	Nameserver string        `json:"nameserver,omitempty" column:"nameserver,template:ipaddr,hide"`
-	PktType    string        `json:"pktType,omitempty" column:"type,minWidth:7,maxWidth:9"`
-	QType      string        `json:"qtype,omitempty" column:"qtype,minWidth:5,maxWidth:10"`
-	DNSName    string        `json:"name,omitempty" column:"name,width:30"`
-	Rcode      string        `json:"rcode,omitempty" column:"rcode,minWidth:8"`
-	Latency    time.Duration `json:"latency,omitempty" column:"latency,hide"`
-	NumAnswers int           `json:"numAnswers,omitempty" column:"numAnswers,width:8,maxWidth:8" columnDesc:"Number of addresses contained in the response."`
-	Addresses  []string      `json:"addresses,omitempty" column:"addresses,width:32,hide" columnDesc:"Addresses in the response. Maximum 8 are reported. Only available if the response is compressed."`
+	ID          string        `json:"id,omitempty" column:"id,width:4,fixed,hide"`
+	Qr          DNSPktType    `json:"qr,omitempty" column:"qr,width:2,fixed"`
+	Nameserver  string        `json:"nameserver,omitempty" column:"nameserver,template:ipaddr,hide"`
+	PktType     string        `json:"pktType,omitempty" column:"type,minWidth:7,maxWidth:9"`
+	QType       string        `json:"qtype,omitempty" column:"qtype,minWidth:5,maxWidth:10"`
+	DNSName     string        `json:"name,omitempty" column:"name,width:30"`
+	Rcode       string        `json:"rcode,omitempty" column:"rcode,minWidth:8"`
+	Latency     time.Duration `json:"latency,omitempty" column:"latency,hide"`
+	NumAnswers  int           `json:"numAnswers,omitempty" column:"numAnswers,width:8,maxWidth:8" columnDesc:"Number of addresses contained in the response."`
+	Addresses   []string      `json:"addresses,omitempty" column:"addresses,width:32,hide" columnDesc:"Addresses in the response. Maximum 8 are reported. Only available if the response is compressed."`
+	AddressTTLs []uint32      `json:"addressTTLs,omitempty" column:"addressTTLs,width:16,hide" columnDesc:"TTL in seconds of each of the addresses."`
 }
 
 func GetColumns() *columns.Columns[Event] {
@@ -91,6 +93,14 @@ func GetColumns() *columns.Columns[Event] {
 		return strings.Join(event.Addresses, ",")
 	})
 
+	cols.MustSetExtractor("addressTTLs", func(event *Event) any {
+		ttls := make([]string, 0, len(event.AddressTTLs))
+		for _, ttl := range event.AddressTTLs {
+			ttls = append(ttls, strconv.FormatUint(uint64(ttl), 10))
+		}
+		return strings.Join(ttls, ",")
+	})
+
 	return cols
 }
 
@@ -79,10 +79,11 @@ type execsnoopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type execsnoopMapSpecs struct {
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	Execs                *ebpf.MapSpec `ebpf:"execs"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	PidByTgid            *ebpf.MapSpec `ebpf:"pid_by_tgid"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	Execs                 *ebpf.MapSpec `ebpf:"execs"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	PidByTgid             *ebpf.MapSpec `ebpf:"pid_by_tgid"`
 }
 
 // execsnoopObjects contains all objects after they have been loaded into the kernel.
@@ -104,16 +105,18 @@ func (o *execsnoopObjects) Close() error {
 //
 // It can be passed to loadExecsnoopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type execsnoopMaps struct {
-	Events               *ebpf.Map `ebpf:"events"`
-	Execs                *ebpf.Map `ebpf:"execs"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	PidByTgid            *ebpf.Map `ebpf:"pid_by_tgid"`
+	Events                *ebpf.Map `ebpf:"events"`
+	Execs                 *ebpf.Map `ebpf:"execs"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	PidByTgid             *ebpf.Map `ebpf:"pid_by_tgid"`
 }
 
 func (m *execsnoopMaps) Close() error {
 	return _ExecsnoopClose(
 		m.Events,
 		m.Execs,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.PidByTgid,
 	)
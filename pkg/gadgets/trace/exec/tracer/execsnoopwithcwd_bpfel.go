@@ -82,11 +82,12 @@ type execsnoopWithCwdProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type execsnoopWithCwdMapSpecs struct {
-	Bufs                 *ebpf.MapSpec `ebpf:"bufs"`
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	Execs                *ebpf.MapSpec `ebpf:"execs"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	PidByTgid            *ebpf.MapSpec `ebpf:"pid_by_tgid"`
+	Bufs                  *ebpf.MapSpec `ebpf:"bufs"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	Execs                 *ebpf.MapSpec `ebpf:"execs"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	PidByTgid             *ebpf.MapSpec `ebpf:"pid_by_tgid"`
 }
 
 // execsnoopWithCwdObjects contains all objects after they have been loaded into the kernel.
@@ -108,11 +109,12 @@ func (o *execsnoopWithCwdObjects) Close() error {
 //
 // It can be passed to loadExecsnoopWithCwdObjects or ebpf.CollectionSpec.LoadAndAssign.
 type execsnoopWithCwdMaps struct {
-	Bufs                 *ebpf.Map `ebpf:"bufs"`
-	Events               *ebpf.Map `ebpf:"events"`
-	Execs                *ebpf.Map `ebpf:"execs"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	PidByTgid            *ebpf.Map `ebpf:"pid_by_tgid"`
+	Bufs                  *ebpf.Map `ebpf:"bufs"`
+	Events                *ebpf.Map `ebpf:"events"`
+	Execs                 *ebpf.Map `ebpf:"execs"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	PidByTgid             *ebpf.Map `ebpf:"pid_by_tgid"`
 }
 
 func (m *execsnoopWithCwdMaps) Close() error {
@@ -120,6 +122,7 @@ func (m *execsnoopWithCwdMaps) Close() error {
 		m.Bufs,
 		m.Events,
 		m.Execs,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.PidByTgid,
 	)
@@ -74,6 +74,7 @@ type execsnoopWithCwdEventAbbrev struct {
 
 type Config struct {
 	MountnsMap   *ebpf.Map
+	CgroupMap    *ebpf.Map
 	GetCwd       bool
 	IgnoreErrors bool
 }
@@ -142,7 +143,7 @@ func (t *Tracer) install() error {
 		"ignore_failed": t.config.IgnoreErrors,
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, t.config.CgroupMap, spec, consts, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
@@ -156,7 +157,7 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("attaching exit tracepoint: %w", err)
 	}
 
-	reader, err := perf.NewReader(t.objs.execsnoopMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	reader, err := gadgets.NewPerfReader(t.objs.execsnoopMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
 	if err != nil {
 		return fmt.Errorf("creating perf ring buffer: %w", err)
 	}
@@ -180,8 +181,7 @@ func (t *Tracer) run() {
 		}
 
 		if record.LostSamples > 0 {
-			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
-			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			t.eventCallback(types.Base(eventtypes.Lost(record.LostSamples)))
 			continue
 		}
 
@@ -245,6 +245,7 @@ func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
 	}
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
 
 	go t.run()
 	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
@@ -256,6 +257,10 @@ func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
 	t.config.MountnsMap = mountnsMap
 }
 
+func (t *Tracer) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.config.CgroupMap = cgroupMap
+}
+
 func (t *Tracer) SetEventHandler(handler any) {
 	nh, ok := handler.(func(ev *types.Event))
 	if !ok {
@@ -270,3 +275,7 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	}
 	return tracer, nil
 }
+
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadExecsnoop, loadExecsnoopWithCwd)
+}
@@ -82,9 +82,10 @@ type fsslowerProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type fsslowerMapSpecs struct {
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	Starts               *ebpf.MapSpec `ebpf:"starts"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Starts                *ebpf.MapSpec `ebpf:"starts"`
 }
 
 // fsslowerObjects contains all objects after they have been loaded into the kernel.
@@ -106,14 +107,16 @@ func (o *fsslowerObjects) Close() error {
 //
 // It can be passed to loadFsslowerObjects or ebpf.CollectionSpec.LoadAndAssign.
 type fsslowerMaps struct {
-	Events               *ebpf.Map `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	Starts               *ebpf.Map `ebpf:"starts"`
+	Events                *ebpf.Map `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Starts                *ebpf.Map `ebpf:"starts"`
 }
 
 func (m *fsslowerMaps) Close() error {
 	return _FsslowerClose(
 		m.Events,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.Starts,
 	)
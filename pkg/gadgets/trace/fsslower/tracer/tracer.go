@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"unsafe"
 
 	"github.com/cilium/ebpf"
@@ -35,6 +36,7 @@ import (
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -no-global-types -target $TARGET -cc clang -cflags ${CFLAGS} -type event fsslower ./bpf/fsslower.bpf.c -- -I./bpf/
 type Config struct {
 	MountnsMap *ebpf.Map
+	CgroupMap  *ebpf.Map
 
 	Filesystem string
 	MinLatency uint
@@ -163,7 +165,7 @@ func (t *Tracer) install() error {
 		"min_lat_ns": uint64(t.config.MinLatency * 1000 * 1000),
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, t.config.CgroupMap, spec, consts, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
@@ -223,7 +225,7 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("attaching kretprobe: %w", err)
 	}
 
-	t.reader, err = perf.NewReader(t.objs.fsslowerMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	t.reader, err = gadgets.NewPerfReader(t.objs.fsslowerMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
 	if err != nil {
 		return fmt.Errorf("creating perf ring buffer: %w", err)
 	}
@@ -247,8 +249,7 @@ func (t *Tracer) run() {
 		}
 
 		if record.LostSamples > 0 {
-			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
-			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			t.eventCallback(types.Base(eventtypes.Lost(record.LostSamples)))
 			continue
 		}
 
@@ -288,6 +289,7 @@ func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
 	}
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
 
 	go t.run()
 	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
@@ -299,6 +301,10 @@ func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
 	t.config.MountnsMap = mountnsMap
 }
 
+func (t *Tracer) SetCgroupMap(cgroupMap *ebpf.Map) {
+	t.config.CgroupMap = cgroupMap
+}
+
 func (t *Tracer) SetEventHandler(handler any) {
 	nh, ok := handler.(func(ev *types.Event))
 	if !ok {
@@ -313,3 +319,30 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	}
 	return tracer, nil
 }
+
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	spec, err := loadFsslower()
+	if err != nil {
+		return nil, fmt.Errorf("loading asset: %w", err)
+	}
+
+	// Programs are attached to the functions of the filesystem chosen by the
+	// user, report the ones of the default filesystem
+	conf := fsConfMap["ext4"]
+	funcs := map[string]string{
+		"_read_":   conf.read,
+		"_wr_":     conf.write,
+		"_open_":   conf.open,
+		"_sync_":   conf.fsync,
+		"_statfs_": conf.statfs,
+	}
+	for name, prog := range spec.Programs {
+		for op, fn := range funcs {
+			if strings.Contains(name, op) {
+				prog.AttachTo = fn
+			}
+		}
+	}
+
+	return []*ebpf.CollectionSpec{spec}, nil
+}
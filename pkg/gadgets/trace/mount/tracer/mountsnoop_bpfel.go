@@ -86,10 +86,11 @@ type mountsnoopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type mountsnoopMapSpecs struct {
-	Args                 *ebpf.MapSpec `ebpf:"args"`
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	Heap                 *ebpf.MapSpec `ebpf:"heap"`
+	Args                  *ebpf.MapSpec `ebpf:"args"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Heap                  *ebpf.MapSpec `ebpf:"heap"`
 }
 
 // mountsnoopObjects contains all objects after they have been loaded into the kernel.
@@ -111,16 +112,18 @@ func (o *mountsnoopObjects) Close() error {
 //
 // It can be passed to loadMountsnoopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type mountsnoopMaps struct {
-	Args                 *ebpf.Map `ebpf:"args"`
-	Events               *ebpf.Map `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	Heap                 *ebpf.Map `ebpf:"heap"`
+	Args                  *ebpf.Map `ebpf:"args"`
+	Events                *ebpf.Map `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Heap                  *ebpf.Map `ebpf:"heap"`
 }
 
 func (m *mountsnoopMaps) Close() error {
 	return _MountsnoopClose(
 		m.Args,
 		m.Events,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.Heap,
 	)
@@ -24,6 +24,8 @@ import (
 	"net"
 	"unsafe"
 
+	"github.com/cilium/ebpf"
+
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
@@ -124,6 +126,10 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	return &Tracer{}, nil
 }
 
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadNetwork)
+}
+
 func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
 	if err := t.install(); err != nil {
 		t.Close()
@@ -159,6 +165,7 @@ func (t *Tracer) run() error {
 }
 
 func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	t.Tracer.SetGadgetInfo(gadgets.GadgetInfo(gadgetCtx))
 	if err := t.run(); err != nil {
 		return err
 	}
@@ -15,6 +15,8 @@
 package types
 
 import (
+	"syscall"
+
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/ellipsis"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/environment"
@@ -44,6 +46,11 @@ type Event struct {
 	PodLabels map[string]string `json:"podLabels,omitempty" column:"podlabels,hide"`
 
 	DstEndpoint eventtypes.L3Endpoint `json:"dst,omitempty" column:"dst"`
+
+	// NATDstEndpoint is the destination selected by the kernel when the flow
+	// was translated, e.g. the backend of a Kubernetes service. It's filled by
+	// the ConntrackResolver operator.
+	NATDstEndpoint *eventtypes.L4Endpoint `json:"natDst,omitempty" column:"natdst"`
 }
 
 func (e *Event) SetLocalPodDetails(owner, hostIP, podIP string, labels map[string]string) {
@@ -54,7 +61,41 @@ func (e *Event) SetLocalPodDetails(owner, hostIP, podIP string, labels map[strin
 }
 
 func (e *Event) GetEndpoints() []*eventtypes.L3Endpoint {
-	return []*eventtypes.L3Endpoint{&e.DstEndpoint}
+	endpoints := []*eventtypes.L3Endpoint{&e.DstEndpoint}
+	if e.NATDstEndpoint != nil {
+		endpoints = append(endpoints, &e.NATDstEndpoint.L3Endpoint)
+	}
+	return endpoints
+}
+
+func (e *Event) GetConntrackFlow() (src, dst eventtypes.L4Endpoint, ok bool) {
+	// Only outgoing flows can have their destination translated
+	if e.PktType != "OUTGOING" {
+		return src, dst, false
+	}
+
+	switch e.Proto {
+	case "TCP":
+		dst.Proto = syscall.IPPROTO_TCP
+	case "UDP":
+		dst.Proto = syscall.IPPROTO_UDP
+	default:
+		return src, dst, false
+	}
+
+	// The source port isn't known: the flow is only resolved if all the flows
+	// from the pod to the destination were translated the same way
+	if e.PodIP == "" {
+		return src, dst, false
+	}
+	src.Addr = e.PodIP
+	dst.L3Endpoint = e.DstEndpoint
+	dst.Port = e.Port
+	return src, dst, true
+}
+
+func (e *Event) SetNATDstEndpoint(endpoint eventtypes.L4Endpoint) {
+	e.NATDstEndpoint = &endpoint
 }
 
 func GetColumns() *columns.Columns[Event] {
@@ -71,6 +112,19 @@ func GetColumns() *columns.Columns[Event] {
 			EllipsisType: ellipsis.Start,
 		},
 		func(e *Event) eventtypes.L3Endpoint { return e.DstEndpoint })
+	// Not visible by default, it's only filled when --resolve-nat is set
+	cols.MustAddColumn(
+		columns.Attributes{
+			Name:     "natdst",
+			Template: "ipaddrport",
+			Order:    1001,
+		},
+		func(e *Event) any {
+			if e.NATDstEndpoint == nil {
+				return ""
+			}
+			return e.NATDstEndpoint.String()
+		})
 
 	// Hide container column for kubernetes environment
 	if environment.Environment == environment.Kubernetes {
@@ -85,13 +85,14 @@ type opensnoopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type opensnoopMapSpecs struct {
-	Bufs                 *ebpf.MapSpec `ebpf:"bufs"`
-	EmptyEvent           *ebpf.MapSpec `ebpf:"empty_event"`
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	PrefixKeys           *ebpf.MapSpec `ebpf:"prefix_keys"`
-	Prefixes             *ebpf.MapSpec `ebpf:"prefixes"`
-	Start                *ebpf.MapSpec `ebpf:"start"`
+	Bufs                  *ebpf.MapSpec `ebpf:"bufs"`
+	EmptyEvent            *ebpf.MapSpec `ebpf:"empty_event"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	PrefixKeys            *ebpf.MapSpec `ebpf:"prefix_keys"`
+	Prefixes              *ebpf.MapSpec `ebpf:"prefixes"`
+	Start                 *ebpf.MapSpec `ebpf:"start"`
 }
 
 // opensnoopObjects contains all objects after they have been loaded into the kernel.
@@ -113,13 +114,14 @@ func (o *opensnoopObjects) Close() error {
 //
 // It can be passed to loadOpensnoopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type opensnoopMaps struct {
-	Bufs                 *ebpf.Map `ebpf:"bufs"`
-	EmptyEvent           *ebpf.Map `ebpf:"empty_event"`
-	Events               *ebpf.Map `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	PrefixKeys           *ebpf.Map `ebpf:"prefix_keys"`
-	Prefixes             *ebpf.Map `ebpf:"prefixes"`
-	Start                *ebpf.Map `ebpf:"start"`
+	Bufs                  *ebpf.Map `ebpf:"bufs"`
+	EmptyEvent            *ebpf.Map `ebpf:"empty_event"`
+	Events                *ebpf.Map `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	PrefixKeys            *ebpf.Map `ebpf:"prefix_keys"`
+	Prefixes              *ebpf.Map `ebpf:"prefixes"`
+	Start                 *ebpf.Map `ebpf:"start"`
 }
 
 func (m *opensnoopMaps) Close() error {
@@ -127,6 +129,7 @@ func (m *opensnoopMaps) Close() error {
 		m.Bufs,
 		m.EmptyEvent,
 		m.Events,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.PrefixKeys,
 		m.Prefixes,
@@ -78,9 +78,10 @@ type sigsnoopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type sigsnoopMapSpecs struct {
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	Values               *ebpf.MapSpec `ebpf:"values"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Values                *ebpf.MapSpec `ebpf:"values"`
 }
 
 // sigsnoopObjects contains all objects after they have been loaded into the kernel.
@@ -102,14 +103,16 @@ func (o *sigsnoopObjects) Close() error {
 //
 // It can be passed to loadSigsnoopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type sigsnoopMaps struct {
-	Events               *ebpf.Map `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	Values               *ebpf.Map `ebpf:"values"`
+	Events                *ebpf.Map `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Values                *ebpf.Map `ebpf:"values"`
 }
 
 func (m *sigsnoopMaps) Close() error {
 	return _SigsnoopClose(
 		m.Events,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.Values,
 	)
@@ -15,6 +15,8 @@
 package types
 
 import (
+	"syscall"
+
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
@@ -32,10 +34,33 @@ type Event struct {
 
 	SrcEndpoint eventtypes.L4Endpoint `json:"src,omitempty" column:"src"`
 	DstEndpoint eventtypes.L4Endpoint `json:"dst,omitempty" column:"dst"`
+
+	// NATDstEndpoint is the destination selected by the kernel when the
+	// connection was translated, e.g. the backend of a Kubernetes service. It's
+	// filled by the ConntrackResolver operator.
+	NATDstEndpoint *eventtypes.L4Endpoint `json:"natDst,omitempty" column:"natdst"`
 }
 
 func (e *Event) GetEndpoints() []*eventtypes.L3Endpoint {
-	return []*eventtypes.L3Endpoint{&e.SrcEndpoint.L3Endpoint, &e.DstEndpoint.L3Endpoint}
+	endpoints := []*eventtypes.L3Endpoint{&e.SrcEndpoint.L3Endpoint, &e.DstEndpoint.L3Endpoint}
+	if e.NATDstEndpoint != nil {
+		endpoints = append(endpoints, &e.NATDstEndpoint.L3Endpoint)
+	}
+	return endpoints
+}
+
+func (e *Event) GetConntrackFlow() (src, dst eventtypes.L4Endpoint, ok bool) {
+	// Only the client side of the connection can be translated
+	if e.Operation == "accept" {
+		return src, dst, false
+	}
+	src, dst = e.SrcEndpoint, e.DstEndpoint
+	dst.Proto = syscall.IPPROTO_TCP
+	return src, dst, true
+}
+
+func (e *Event) SetNATDstEndpoint(endpoint eventtypes.L4Endpoint) {
+	e.NATDstEndpoint = &endpoint
 }
 
 func GetColumns() *columns.Columns[Event] {
@@ -76,6 +101,20 @@ func GetColumns() *columns.Columns[Event] {
 		},
 		func(e *Event) eventtypes.L4Endpoint { return e.DstEndpoint },
 	)
+	// Not visible by default, it's only filled when --resolve-nat is set
+	tcpColumns.MustAddColumn(
+		columns.Attributes{
+			Name:     "natdst",
+			Template: "ipaddrport",
+			Order:    3001,
+		},
+		func(e *Event) any {
+			if e.NATDstEndpoint == nil {
+				return ""
+			}
+			return e.NATDstEndpoint.String()
+		},
+	)
 
 	return tcpColumns
 }
@@ -102,12 +102,13 @@ type tcpconnectProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type tcpconnectMapSpecs struct {
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	Ipv4Count            *ebpf.MapSpec `ebpf:"ipv4_count"`
-	Ipv6Count            *ebpf.MapSpec `ebpf:"ipv6_count"`
-	SocketsLatency       *ebpf.MapSpec `ebpf:"sockets_latency"`
-	SocketsPerProcess    *ebpf.MapSpec `ebpf:"sockets_per_process"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Ipv4Count             *ebpf.MapSpec `ebpf:"ipv4_count"`
+	Ipv6Count             *ebpf.MapSpec `ebpf:"ipv6_count"`
+	SocketsLatency        *ebpf.MapSpec `ebpf:"sockets_latency"`
+	SocketsPerProcess     *ebpf.MapSpec `ebpf:"sockets_per_process"`
 }
 
 // tcpconnectObjects contains all objects after they have been loaded into the kernel.
@@ -129,17 +130,19 @@ func (o *tcpconnectObjects) Close() error {
 //
 // It can be passed to loadTcpconnectObjects or ebpf.CollectionSpec.LoadAndAssign.
 type tcpconnectMaps struct {
-	Events               *ebpf.Map `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	Ipv4Count            *ebpf.Map `ebpf:"ipv4_count"`
-	Ipv6Count            *ebpf.Map `ebpf:"ipv6_count"`
-	SocketsLatency       *ebpf.Map `ebpf:"sockets_latency"`
-	SocketsPerProcess    *ebpf.Map `ebpf:"sockets_per_process"`
+	Events                *ebpf.Map `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Ipv4Count             *ebpf.Map `ebpf:"ipv4_count"`
+	Ipv6Count             *ebpf.Map `ebpf:"ipv6_count"`
+	SocketsLatency        *ebpf.Map `ebpf:"sockets_latency"`
+	SocketsPerProcess     *ebpf.Map `ebpf:"sockets_per_process"`
 }
 
 func (m *tcpconnectMaps) Close() error {
 	return _TcpconnectClose(
 		m.Events,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.Ipv4Count,
 		m.Ipv6Count,
@@ -18,6 +18,12 @@
 #define __NR_rt_sigreturn 15
 #define __NR_exit_group 231
 #define __NR_exit 60
+/* i386 numbers, used by 32-bit compat syscalls */
+#define __NR_compat_rt_sigreturn 173
+#define __NR_compat_sigreturn 119
+#define __NR_compat_exit_group 252
+#define __NR_compat_exit 1
+#define TS_COMPAT 0x0002
 #else
 #error "Traceloop is not supported on your architecture."
 #endif
@@ -107,8 +113,32 @@ struct {
 	__uint(max_entries, 1024);
 } regs_map SEC(".maps");
 
-static __always_inline int skip_exit_probe(int nr)
+static __always_inline bool is_compat_syscall(void)
 {
+#if defined(__TARGET_ARCH_x86)
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+
+	return !!(BPF_CORE_READ(task, thread_info.status) & TS_COMPAT);
+#else
+	return false;
+#endif
+}
+
+/* Key of the syscall in the syscalls and syscall_filters maps. */
+static __always_inline u64 syscall_key(u64 nr, bool compat)
+{
+	return compat ? nr | SYSCALL_COMPAT_BIT : nr;
+}
+
+static __always_inline int skip_exit_probe(int nr, bool compat)
+{
+#if defined(__TARGET_ARCH_x86)
+	if (compat)
+		return !!(nr == __NR_compat_exit ||
+			  nr == __NR_compat_exit_group ||
+			  nr == __NR_compat_rt_sigreturn ||
+			  nr == __NR_compat_sigreturn);
+#endif
 	return !!(nr == __NR_exit || nr == __NR_exit_group ||
 		  nr == __NR_rt_sigreturn);
 }
@@ -117,8 +147,33 @@ static __always_inline int skip_exit_probe(int nr)
  * Highly inspired from ksnoop.bpf.c:
  * https://github.com/iovisor/bcc/blob/f90126bb3770ea1bdd915ff3b47e451c6dde5c40/libbpf-tools/ksnoop.bpf.c#L280
  */
-static __always_inline u64 get_arg(struct pt_regs *regs, int i)
+static __always_inline u64 get_arg(struct pt_regs *regs, int i, bool compat)
 {
+#if defined(__TARGET_ARCH_x86)
+	/*
+	 * 32-bit compat syscalls use the i386 calling convention:
+	 * https://elixir.bootlin.com/linux/v6.6/source/arch/x86/entry/entry_64_compat.S#L31
+	 */
+	if (compat) {
+		switch (i) {
+		case 1:
+			return (u32)BPF_CORE_READ(regs, bx);
+		case 2:
+			return (u32)BPF_CORE_READ(regs, cx);
+		case 3:
+			return (u32)BPF_CORE_READ(regs, dx);
+		case 4:
+			return (u32)BPF_CORE_READ(regs, si);
+		case 5:
+			return (u32)BPF_CORE_READ(regs, di);
+		case 6:
+			return (u32)BPF_CORE_READ(regs, bp);
+		default:
+			return 0;
+		}
+	}
+#endif
+
 	switch (i) {
 	case 1:
 		return PT_REGS_PARM1_CORE_SYSCALL(regs);
@@ -165,13 +220,15 @@ int ig_traceloop_e(struct bpf_raw_tracepoint_args *ctx)
 	struct syscall_event_t sc = {};
 	struct task_struct *task;
 	u64 nr = ctx->args[1];
+	bool compat = is_compat_syscall();
+	u64 key = syscall_key(nr, compat);
 	struct pt_regs *args;
 	void *perf_buffer;
 	u64 mntns_id;
 	int ret;
 	int i;
 
-	if (should_filter_out_syscall(nr))
+	if (should_filter_out_syscall(key))
 		return 0;
 
 	/* The boot time timestamp is used to give the timestamp to users. It
@@ -194,11 +251,12 @@ int ig_traceloop_e(struct bpf_raw_tracepoint_args *ctx)
 	sc.pid = pid >> 32;
 	sc.typ = SYSCALL_EVENT_TYPE_ENTER;
 	sc.id = nr;
+	sc.compat = compat;
 
 	remembered.monotonic_timestamp = monotonic_ts;
 	remembered.nr = nr;
 
-	syscall_def = bpf_map_lookup_elem(&syscalls, &nr);
+	syscall_def = bpf_map_lookup_elem(&syscalls, &key);
 	/*
 	 * syscalls map contains definition for specific syscall like read or
 	 * write.
@@ -239,7 +297,7 @@ int ig_traceloop_e(struct bpf_raw_tracepoint_args *ctx)
 
 	for (i = 0; i < SYSCALL_ARGS; i++) {
 		/* + 1 because PT_REGS_PARM begins from 1. */
-		u64 arg = get_arg(args, i + 1);
+		u64 arg = get_arg(args, i + 1, compat);
 		sc.args[i] = arg;
 		remembered.args[i] = arg;
 		if (syscall_def->args_len[i])
@@ -259,7 +317,7 @@ int ig_traceloop_e(struct bpf_raw_tracepoint_args *ctx)
 	// would not be called and the map would not be cleaned up and would get full.
 	// Note that a process can still get killed in the middle, so we would need
 	// a userspace cleaner for this case (TODO).
-	if (!skip_exit_probe(nr))
+	if (!skip_exit_probe(nr, compat))
 		bpf_map_update_elem(&probe_at_sys_exit, &pid, &remembered,
 				    BPF_ANY);
 
@@ -316,7 +374,7 @@ int ig_traceloop_e(struct bpf_raw_tracepoint_args *ctx)
 			sc_cont.length = arg_len;
 
 		/* + 1 because PT_REGS_PARM begins from 1. */
-		u64 arg = get_arg(args, i + 1);
+		u64 arg = get_arg(args, i + 1, compat);
 
 		if (!arg_len &&
 		    null_terminated /* NULL terminated argument like string */
@@ -385,11 +443,13 @@ int ig_traceloop_x(struct bpf_raw_tracepoint_args *ctx)
 	struct syscall_def_t *syscall_def;
 	struct task_struct *task;
 	long ret = ctx->args[1];
+	bool compat = is_compat_syscall();
 	struct pt_regs *args;
 	void *perf_buffer;
 	u64 mntns_id;
 	int i, r;
 	u64 nr;
+	u64 key;
 
 	r = bpf_map_update_elem(&regs_map, &pid, &empty, BPF_NOEXIST);
 	if (r) {
@@ -420,10 +480,12 @@ int ig_traceloop_x(struct bpf_raw_tracepoint_args *ctx)
 		.pid = pid >> 32,
 		.typ = SYSCALL_EVENT_TYPE_EXIT,
 		.id = nr,
+		.compat = compat,
 	};
 	sc.args[0] = ret;
 
-	syscall_def = bpf_map_lookup_elem(&syscalls, &nr);
+	key = syscall_key(nr, compat);
+	syscall_def = bpf_map_lookup_elem(&syscalls, &key);
 	if (syscall_def == NULL)
 		syscall_def = &default_definition;
 
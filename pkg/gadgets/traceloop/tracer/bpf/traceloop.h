@@ -11,8 +11,11 @@
 /* The syscall can have max 6 arguments. */
 #define SYSCALL_ARGS 6
 
-/* 16 syscalls should be enough to filter out. */
-#define SYSCALL_FILTERS 16
+/*
+ * 16 syscalls should be enough to filter out, each one can have a native and a
+ * compat entry.
+ */
+#define SYSCALL_FILTERS 32
 
 const __u64 PARAM_PROBE_AT_EXIT_MASK = 0xf000000000000000ULL;
 const __u64 USE_RET_AS_PARAM_LENGTH = 0x0ffffffffffffffeULL;
@@ -31,6 +34,13 @@ const __u64 USE_NULL_BYTE_LENGTH = 0x0fffffffffffffffULL;
 const __u64 USE_ARG_INDEX_AS_PARAM_LENGTH = 0x0ffffffffffffff0ULL;
 const __u64 USE_ARG_INDEX_AS_PARAM_LENGTH_MASK = 0xfULL;
 
+/*
+ * Added to the syscall number to build the key of the syscalls and
+ * syscall_filters maps for 32-bit compat syscalls, as their numbers overlap
+ * with the native ones.
+ */
+const __u64 SYSCALL_COMPAT_BIT = 0x100000000ULL;
+
 const __u8 SYSCALL_EVENT_TYPE_ENTER = 0;
 const __u8 SYSCALL_EVENT_TYPE_EXIT = 1;
 
@@ -47,6 +57,8 @@ struct syscall_event_t {
 	/* how many syscall_event_cont_t messages to expect after */
 	__u8 cont_nr;
 	__u8 typ;
+	/* whether it is a 32-bit compat syscall on x86_64 */
+	__u8 compat;
 };
 
 struct syscall_event_cont_t {
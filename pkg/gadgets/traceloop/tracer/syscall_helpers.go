@@ -40,8 +40,17 @@ type syscallDeclaration struct {
 	params []param
 }
 
-func syscallGetName(nr uint16) string {
-	name, ok := syscalls.GetSyscallNameByNumber(int(nr))
+// syscallTable returns the table of the native syscalls or, if compat is true,
+// the one of the 32-bit compat syscalls
+func syscallTable(compat bool) *syscalls.Table {
+	if compat {
+		return syscalls.Compat()
+	}
+	return syscalls.Native()
+}
+
+func syscallGetName(nr uint16, compat bool) string {
+	name, ok := syscallTable(compat).GetSyscallNameByNumber(int(nr))
 	// Just do like strace (https://man7.org/linux/man-pages/man1/strace.1.html):
 	// Syscalls unknown to strace are printed raw
 	if !ok {
@@ -51,6 +60,27 @@ func syscallGetName(nr uint16) string {
 	return name
 }
 
+func syscallGetArch(compat bool) syscalls.Arch {
+	if table := syscallTable(compat); table != nil {
+		return table.Arch()
+	}
+	return ""
+}
+
+// syscallKeys returns the keys of the syscall with the given name in the
+// syscalls and syscall_filters maps, one for the native syscall and one for the
+// compat syscall if they exist.
+func syscallKeys(name string) []uint64 {
+	var keys []uint64
+	if number, ok := syscallTable(false).GetSyscallNumberByName(name); ok {
+		keys = append(keys, uint64(number))
+	}
+	if number, ok := syscallTable(true).GetSyscallNumberByName(name); ok {
+		keys = append(keys, uint64(number)|syscallCompatBit)
+	}
+	return keys
+}
+
 // TODO Find all syscalls which take a char * as argument and add them there.
 var syscallDefs = map[string][6]uint64{
 	"execve":      {useNullByteLength, 0, 0, 0, 0, 0},
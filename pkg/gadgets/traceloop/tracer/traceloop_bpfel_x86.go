@@ -31,7 +31,8 @@ type traceloopSyscallEventT struct {
 	Comm               [16]uint8
 	ContNr             uint8
 	Typ                uint8
-	_                  [6]byte
+	Compat             uint8
+	_                  [5]byte
 }
 
 // loadTraceloop returns the embedded CollectionSpec for traceloop.
@@ -83,12 +84,13 @@ type traceloopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type traceloopMapSpecs struct {
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	MapOfPerfBuffers     *ebpf.MapSpec `ebpf:"map_of_perf_buffers"`
-	ProbeAtSysExit       *ebpf.MapSpec `ebpf:"probe_at_sys_exit"`
-	RegsMap              *ebpf.MapSpec `ebpf:"regs_map"`
-	SyscallFilters       *ebpf.MapSpec `ebpf:"syscall_filters"`
-	Syscalls             *ebpf.MapSpec `ebpf:"syscalls"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	MapOfPerfBuffers      *ebpf.MapSpec `ebpf:"map_of_perf_buffers"`
+	ProbeAtSysExit        *ebpf.MapSpec `ebpf:"probe_at_sys_exit"`
+	RegsMap               *ebpf.MapSpec `ebpf:"regs_map"`
+	SyscallFilters        *ebpf.MapSpec `ebpf:"syscall_filters"`
+	Syscalls              *ebpf.MapSpec `ebpf:"syscalls"`
 }
 
 // traceloopObjects contains all objects after they have been loaded into the kernel.
@@ -110,16 +112,18 @@ func (o *traceloopObjects) Close() error {
 //
 // It can be passed to loadTraceloopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type traceloopMaps struct {
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	MapOfPerfBuffers     *ebpf.Map `ebpf:"map_of_perf_buffers"`
-	ProbeAtSysExit       *ebpf.Map `ebpf:"probe_at_sys_exit"`
-	RegsMap              *ebpf.Map `ebpf:"regs_map"`
-	SyscallFilters       *ebpf.Map `ebpf:"syscall_filters"`
-	Syscalls             *ebpf.Map `ebpf:"syscalls"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	MapOfPerfBuffers      *ebpf.Map `ebpf:"map_of_perf_buffers"`
+	ProbeAtSysExit        *ebpf.Map `ebpf:"probe_at_sys_exit"`
+	RegsMap               *ebpf.Map `ebpf:"regs_map"`
+	SyscallFilters        *ebpf.Map `ebpf:"syscall_filters"`
+	Syscalls              *ebpf.Map `ebpf:"syscalls"`
 }
 
 func (m *traceloopMaps) Close() error {
 	return _TraceloopClose(
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.MapOfPerfBuffers,
 		m.ProbeAtSysExit,
@@ -38,7 +38,6 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/traceloop/types"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
-	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/syscalls"
 )
 
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -type syscall_event_t -type syscall_event_cont_t -target ${TARGET} -cc clang -cflags ${CFLAGS} traceloop ./bpf/traceloop.bpf.c -- -I./bpf/
@@ -50,6 +49,8 @@ const (
 	useArgIndexAsParamLength uint64 = 0x0ffffffffffffff0
 	paramProbeAtExitMask     uint64 = 0xf000000000000000
 
+	syscallCompatBit uint64 = 0x100000000
+
 	syscallEventTypeEnter uint8 = 0
 	syscallEventTypeExit  uint8 = 1
 
@@ -95,6 +96,7 @@ type syscallEvent struct {
 	contNr             uint8
 	cpu                uint16
 	id                 uint16
+	compat             bool
 	pid                uint32
 	comm               string
 	args               []uint64
@@ -138,18 +140,14 @@ func (t *Tracer) install() error {
 	// Fill the syscall map with specific syscall signatures.
 	syscallsMapSpec := spec.Maps["syscalls"]
 	for name, def := range syscallDefs {
-		number, ok := syscalls.GetSyscallNumberByName(name)
-		if !ok {
-			// It's possible that the syscall doesn't exist for this architecture, skip it
-			continue
+		for _, key := range syscallKeys(name) {
+			// We need to do so to avoid taking each time the same address.
+			def := def
+			syscallsMapSpec.Contents = append(syscallsMapSpec.Contents, ebpf.MapKV{
+				Key:   key,
+				Value: def,
+			})
 		}
-
-		// We need to do so to avoid taking each time the same address.
-		def := def
-		syscallsMapSpec.Contents = append(syscallsMapSpec.Contents, ebpf.MapKV{
-			Key:   uint64(number),
-			Value: def,
-		})
 	}
 
 	// Fill the syscall filter map with the corresponding syscall numbers.
@@ -159,21 +157,23 @@ func (t *Tracer) install() error {
 			continue
 		}
 
-		number, ok := syscalls.GetSyscallNumberByName(name)
-		if !ok {
+		keys := syscallKeys(name)
+		if len(keys) == 0 {
 			return fmt.Errorf("syscall %q does not exist", name)
 		}
 
-		syscallFiltersMapSpec.Contents = append(syscallFiltersMapSpec.Contents, ebpf.MapKV{
-			Key: uint64(number),
-			// We do not care about the value itself but we need to provide one.
-			Value: true,
-		})
+		for _, key := range keys {
+			syscallFiltersMapSpec.Contents = append(syscallFiltersMapSpec.Contents, ebpf.MapKV{
+				Key: key,
+				// We do not care about the value itself but we need to provide one.
+				Value: true,
+			})
+		}
 	}
 
 	consts := make(map[string]interface{})
 	consts["filter_syscall"] = len(syscallFiltersMapSpec.Contents) > 0
-	if err := gadgets.LoadeBPFSpec(nil, spec, consts, &t.objs); err != nil {
+	if err := gadgets.LoadeBPFSpec(nil, nil, spec, consts, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf program: %w", err)
 	}
 
@@ -228,7 +228,7 @@ func (t *Tracer) Attach(containerID string, mntnsID uint64) error {
 	}
 
 	// 2. Use this inner Map to create the perf reader.
-	perfReader, err := perf.NewReaderWithOptions(innerBuffer, gadgets.PerfBufferPages*os.Getpagesize(), perf.ReaderOptions{Overwritable: true})
+	perfReader, err := gadgets.NewPerfReaderWithOptions(innerBuffer, gadgets.PerfBufferPages*os.Getpagesize(), perf.ReaderOptions{Overwritable: true})
 	if err != nil {
 		innerBuffer.Close()
 
@@ -392,6 +392,7 @@ func (t *Tracer) Read(containerID string) ([]*types.Event, error) {
 				contNr:             sysEvent.ContNr,
 				cpu:                sysEvent.Cpu,
 				id:                 sysEvent.Id,
+				compat:             sysEvent.Compat != 0,
 				pid:                sysEvent.Pid,
 				comm:               gadgets.FromCString(sysEvent.Comm[:]),
 				mountNsID:          reader.mntnsID,
@@ -470,12 +471,18 @@ func (t *Tracer) Read(containerID string) ([]*types.Event, error) {
 				Pid:           enterEvent.pid,
 				Comm:          enterEvent.comm,
 				WithMountNsID: eventtypes.WithMountNsID{MountNsID: enterEvent.mountNsID},
-				Syscall:       syscallGetName(enterEvent.id),
+				Syscall:       syscallGetName(enterEvent.id, enterEvent.compat),
+				Arch:          string(syscallGetArch(enterEvent.compat)),
 			}
 
 			syscallDeclaration, err := getSyscallDeclaration(syscallsDeclarations, event.Syscall)
 			if err != nil {
-				return nil, fmt.Errorf("getting syscall definition")
+				if !enterEvent.compat {
+					return nil, fmt.Errorf("getting syscall definition")
+				}
+				// Some compat syscalls, like socketcall, don't exist natively,
+				// so their parameters are unknown
+				log.Debugf("no definition for compat syscall %q", event.Syscall)
 			}
 
 			parametersNumber := syscallDeclaration.getParameterCount()
@@ -547,7 +554,7 @@ func (t *Tracer) Read(containerID string) ([]*types.Event, error) {
 			}
 
 			for _, exitEvent := range exitTimestampEvents {
-				if enterEvent.id != exitEvent.id || enterEvent.pid != exitEvent.pid {
+				if enterEvent.id != exitEvent.id || enterEvent.compat != exitEvent.compat || enterEvent.pid != exitEvent.pid {
 					continue
 				}
 
@@ -578,7 +585,7 @@ func (t *Tracer) Read(containerID string) ([]*types.Event, error) {
 	// events to be published.
 	for _, enterTimestampEvents := range syscallEnterEventsMap {
 		for _, enterEvent := range enterTimestampEvents {
-			syscallName := syscallGetName(enterEvent.id)
+			syscallName := syscallGetName(enterEvent.id, enterEvent.compat)
 
 			incompleteEnterEvent := &types.Event{
 				Event: eventtypes.Event{
@@ -590,6 +597,7 @@ func (t *Tracer) Read(containerID string) ([]*types.Event, error) {
 				Comm:          enterEvent.comm,
 				WithMountNsID: eventtypes.WithMountNsID{MountNsID: enterEvent.mountNsID},
 				Syscall:       syscallName,
+				Arch:          string(syscallGetArch(enterEvent.compat)),
 				Retval:        "unfinished",
 			}
 
@@ -605,7 +613,7 @@ func (t *Tracer) Read(containerID string) ([]*types.Event, error) {
 
 	for _, exitTimestampEvents := range syscallExitEventsMap {
 		for _, exitEvent := range exitTimestampEvents {
-			syscallName := syscallGetName(exitEvent.id)
+			syscallName := syscallGetName(exitEvent.id, exitEvent.compat)
 
 			incompleteExitEvent := &types.Event{
 				Event: eventtypes.Event{
@@ -617,6 +625,7 @@ func (t *Tracer) Read(containerID string) ([]*types.Event, error) {
 				Comm:          exitEvent.comm,
 				WithMountNsID: eventtypes.WithMountNsID{MountNsID: exitEvent.mountNsID},
 				Syscall:       syscallName,
+				Arch:          string(syscallGetArch(exitEvent.compat)),
 				Retval:        retToStr(exitEvent.retval),
 			}
 
@@ -673,6 +682,10 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 	return &Tracer{}, nil
 }
 
+func (g *GadgetDesc) EBPFSpecs() ([]*ebpf.CollectionSpec, error) {
+	return gadgets.LoadSpecs(loadTraceloop)
+}
+
 func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
 	t.syscallFilters = gadgetCtx.GadgetParams().Get(ParamSyscallFilters).AsStringSlice()
 
@@ -723,6 +736,8 @@ func (t *Tracer) DetachContainer(container *containercollection.Container) error
 }
 
 func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer gadgets.RegisterObjects(gadgetCtx, &t.objs)()
+
 	<-t.ctx.Done()
 	t.waitGroup.Wait()
 	return nil
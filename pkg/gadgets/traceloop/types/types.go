@@ -36,6 +36,7 @@ type Event struct {
 	Pid        uint32         `json:"pid,omitempty" column:"pid,template:pid"`
 	Comm       string         `json:"comm,omitempty" column:"comm,template:comm"`
 	Syscall    string         `json:"syscall,omitempty" column:"syscall,template:syscall"`
+	Arch       string         `json:"arch,omitempty" column:"arch,width:6,fixed,hide"`
 	Parameters []SyscallParam `json:"parameters,omitempty" column:"params,width:40"`
 	Retval     string         `json:"ret,omitempty" column:"ret,width:3"`
 }
@@ -269,6 +269,8 @@ func NewServer(conf *Conf) (*GadgetTracerManager, error) {
 		opts = append(opts, containercollection.WithCgroupEnrichment())
 		opts = append(opts, containercollection.WithLinuxNamespaceEnrichment())
 		opts = append(opts, containercollection.WithKubernetesEnrichment(g.nodeName, nil))
+		opts = append(opts, containercollection.WithOwnerReferenceEnrichment(nil))
+		opts = append(opts, containercollection.WithKubernetesContainerExitEnrichment(g.nodeName))
 		opts = append(opts, containercollection.WithTracerCollection(g.tracerCollection))
 	}
 
@@ -0,0 +1,161 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// heatmapShades are used to represent the number of events in an interval,
+// from none to the maximum of the heatmap
+const heatmapShades = " .:-=+*#%@"
+
+// HeatmapRow is the histogram of the events that occurred in one tick
+type HeatmapRow struct {
+	Timestamp   time.Time    `json:"timestamp"`
+	Intervals   []Interval   `json:"intervals,omitempty"`
+	Percentiles *Percentiles `json:"percentiles,omitempty"`
+}
+
+// Heatmap represents the evolution of a histogram over time, with one row per
+// tick
+type Heatmap struct {
+	Unit Unit         `json:"unit,omitempty"`
+	Rows []HeatmapRow `json:"rows,omitempty"`
+}
+
+// AddRow appends the histogram of the events that occurred in the last tick
+func (h *Heatmap) AddRow(ts time.Time, intervals []Interval) {
+	h.Rows = append(h.Rows, HeatmapRow{
+		Timestamp:   ts,
+		Intervals:   intervals,
+		Percentiles: NewPercentiles(intervals),
+	})
+}
+
+// Recorder builds heatmaps from consecutive readings of cumulative histograms,
+// e.g. read from an eBPF map, identified by a key
+type Recorder[K comparable] struct {
+	unit   Unit
+	layout Layout
+	step   uint64
+
+	prev     map[K][]uint32
+	heatmaps map[K]*Heatmap
+}
+
+// NewRecorder creates a Recorder for histograms using the given layout
+func NewRecorder[K comparable](unit Unit, layout Layout, step uint64) *Recorder[K] {
+	return &Recorder[K]{
+		unit:     unit,
+		layout:   layout,
+		step:     step,
+		prev:     make(map[K][]uint32),
+		heatmaps: make(map[K]*Heatmap),
+	}
+}
+
+// Record adds a row with the events that occurred since the previous reading
+// to the heatmap of each key. Keys missing in slots get an empty row.
+func (r *Recorder[K]) Record(ts time.Time, slots map[K][]uint32) error {
+	for key := range r.prev {
+		if _, ok := slots[key]; !ok {
+			r.heatmaps[key].AddRow(ts, nil)
+		}
+	}
+	for key, cur := range slots {
+		intervals, err := NewIntervals(r.layout, DeltaSlots(r.prev[key], cur), r.step)
+		if err != nil {
+			return err
+		}
+		heatmap, ok := r.heatmaps[key]
+		if !ok {
+			heatmap = &Heatmap{Unit: r.unit}
+			r.heatmaps[key] = heatmap
+		}
+		heatmap.AddRow(ts, intervals)
+		r.prev[key] = cur
+	}
+	return nil
+}
+
+// Heatmap returns the heatmap of key or nil if no histogram was recorded for it
+func (r *Recorder[K]) Heatmap(key K) *Heatmap {
+	return r.heatmaps[key]
+}
+
+// String returns a string representation of the heatmap with one line per
+// tick and one column per interval. The darker the shade, the more events
+// occurred in that interval.
+func (h *Heatmap) String() string {
+	if len(h.Rows) == 0 {
+		return ""
+	}
+
+	// All rows use the same layout, so the longest one contains the intervals
+	// of all others
+	var columns []Interval
+	valMax := uint64(0)
+	for _, row := range h.Rows {
+		if len(row.Intervals) > len(columns) {
+			columns = row.Intervals
+		}
+		for _, interval := range row.Intervals {
+			if interval.Count > valMax {
+				valMax = interval.Count
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-12s  %d%s -> %d%s (max %d events per cell)\n", "time",
+		columns[0].Start, h.Unit, columns[len(columns)-1].End, h.Unit, valMax))
+
+	for _, row := range h.Rows {
+		sb.WriteString(row.Timestamp.Format("15:04:05.000"))
+		sb.WriteString(" |")
+		for i := range columns {
+			count := uint64(0)
+			if i < len(row.Intervals) {
+				count = row.Intervals[i].Count
+			}
+			sb.WriteByte(shade(count, valMax))
+		}
+		sb.WriteString("| ")
+		sb.WriteString(row.Percentiles.String())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// shade returns the character representing val; values other than 0 always
+// get a visible shade
+func shade(val, valMax uint64) byte {
+	if val == 0 || valMax == 0 {
+		return heatmapShades[0]
+	}
+	levels := uint64(len(heatmapShades) - 1)
+	idx := val * levels / valMax
+	if idx == 0 {
+		idx = 1
+	}
+	return heatmapShades[idx]
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeatmap_String(t *testing.T) {
+	t.Parallel()
+
+	heatmap := &Heatmap{Unit: UnitMicroseconds}
+	require.Empty(t, heatmap.String())
+
+	ts := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	heatmap.AddRow(ts, NewIntervalsFromExp2Slots([]uint32{9, 1}))
+	heatmap.AddRow(ts.Add(time.Second), nil)
+	heatmap.AddRow(ts.Add(2*time.Second), NewIntervalsFromExp2Slots([]uint32{0, 0, 4}))
+
+	require.Len(t, heatmap.Rows, 3)
+	require.Nil(t, heatmap.Rows[1].Percentiles)
+	require.Equal(t, uint64(7), heatmap.Rows[2].Percentiles.Max)
+
+	expected := "" +
+		"time          0µs -> 7µs (max 9 events per cell)\n" +
+		"10:00:00.000 |@. | p50=1.1 p90=2.0 p99=3.8 max=3\n" +
+		"10:00:01.000 |   | \n" +
+		"10:00:02.000 |  =| p50=6.0 p90=7.6 p99=8.0 max=7\n"
+	require.Equal(t, expected, heatmap.String())
+}
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecorder[string](UnitMicroseconds, LayoutLinear, 10)
+	ts := time.Now()
+
+	require.NoError(t, r.Record(ts, map[string][]uint32{"a": {1, 2}}))
+	require.NoError(t, r.Record(ts.Add(time.Second), map[string][]uint32{"a": {1, 5}, "b": {3}}))
+	require.NoError(t, r.Record(ts.Add(2*time.Second), map[string][]uint32{"b": {4}}))
+
+	a := r.Heatmap("a")
+	require.Len(t, a.Rows, 3)
+	require.Equal(t, []Interval{{Count: 1, Start: 0, End: 9}, {Count: 2, Start: 10, End: 19}}, a.Rows[0].Intervals)
+	require.Equal(t, []Interval{{Count: 0, Start: 0, End: 9}, {Count: 3, Start: 10, End: 19}}, a.Rows[1].Intervals)
+	require.Nil(t, a.Rows[2].Intervals)
+
+	b := r.Heatmap("b")
+	require.Len(t, b.Rows, 2)
+	require.Equal(t, []Interval{{Count: 1, Start: 0, End: 9}}, b.Rows[1].Intervals)
+
+	require.Nil(t, r.Heatmap("c"))
+}
@@ -15,7 +15,8 @@
 // Package histogram provides a Histogram struct that represents a histogram of
 // the number of events that occurred in each interval. It also provides a way
 // to transform a Histogram struct into a graphical representation. In addition,
-// it allows to create a Histogram struct from an exp-2 histogram.
+// it allows to create a Histogram struct from an exp-2, linear or log-linear
+// histogram and to compute percentiles from it.
 package histogram
 
 import (
@@ -30,6 +31,33 @@ const (
 	UnitMicroseconds Unit = "µs"
 )
 
+// Layout defines how values are mapped to the slots of a histogram
+type Layout string
+
+const (
+	// LayoutExp2 uses one slot per power of two
+	LayoutExp2 Layout = "exp2"
+
+	// LayoutLinear uses slots of a fixed width (step)
+	LayoutLinear Layout = "linear"
+
+	// LayoutLogLinear splits each power of two into LogLinearSubBuckets slots
+	// of the same width
+	LayoutLogLinear Layout = "log-linear"
+)
+
+// LogLinearSubBucketBits is the number of bits used to split each power of two
+// in the log-linear layout. It must match HIST_LOG_LINEAR_SUB_BITS in
+// include/gadget/histogram.bpf.h.
+const LogLinearSubBucketBits = 1
+
+// LogLinearSubBuckets is the number of slots per power of two in the log-linear
+// layout
+const LogLinearSubBuckets = 1 << LogLinearSubBucketBits
+
+// AllLayouts contains all supported histogram layouts
+var AllLayouts = []string{string(LayoutExp2), string(LayoutLinear), string(LayoutLogLinear)}
+
 type Interval struct {
 	Count uint64 `json:"count"`
 	Start uint64 `json:"start"`
@@ -39,8 +67,42 @@ type Interval struct {
 // Histogram represents a histogram of the number of events that occurred in
 // each interval.
 type Histogram struct {
-	Unit      Unit       `json:"unit,omitempty"`
-	Intervals []Interval `json:"intervals,omitempty"`
+	Unit        Unit         `json:"unit,omitempty"`
+	Intervals   []Interval   `json:"intervals,omitempty"`
+	Percentiles *Percentiles `json:"percentiles,omitempty"`
+}
+
+// New creates a histogram from slots using the given layout and computes its
+// percentiles. step is only used by LayoutLinear.
+func New(unit Unit, layout Layout, slots []uint32, step uint64) (*Histogram, error) {
+	intervals, err := NewIntervals(layout, slots, step)
+	if err != nil {
+		return nil, err
+	}
+	h := &Histogram{
+		Unit:      unit,
+		Intervals: intervals,
+	}
+	h.ComputePercentiles()
+	return h, nil
+}
+
+// NewIntervals creates a new Interval array from a histogram represented in
+// slots using the given layout. step is only used by LayoutLinear.
+func NewIntervals(layout Layout, slots []uint32, step uint64) ([]Interval, error) {
+	switch layout {
+	case LayoutExp2, "":
+		return NewIntervalsFromExp2Slots(slots), nil
+	case LayoutLinear:
+		if step == 0 {
+			return nil, fmt.Errorf("step of linear histogram must be greater than 0")
+		}
+		return NewIntervalsFromLinearSlots(slots, step), nil
+	case LayoutLogLinear:
+		return NewIntervalsFromLogLinearSlots(slots), nil
+	default:
+		return nil, fmt.Errorf("unknown histogram layout %q", layout)
+	}
 }
 
 // NewIntervalsFromExp2Slots creates a new Interval array from an exp-2
@@ -76,6 +138,66 @@ func NewIntervalsFromExp2Slots(slots []uint32) []Interval {
 	return intervals[:indexMax+1]
 }
 
+// NewIntervalsFromLinearSlots creates a new Interval array from a linear
+// histogram represented in slots, each of them step wide.
+func NewIntervalsFromLinearSlots(slots []uint32, step uint64) []Interval {
+	return newIntervals(slots, func(i int) (uint64, uint64) {
+		start := uint64(i) * step
+		return start, start + step - 1
+	})
+}
+
+// NewIntervalsFromLogLinearSlots creates a new Interval array from a
+// log-linear histogram represented in slots. The first LogLinearSubBuckets
+// slots hold the values from 0 to LogLinearSubBuckets-1, after that every
+// power of two is split into LogLinearSubBuckets slots.
+func NewIntervalsFromLogLinearSlots(slots []uint32) []Interval {
+	return newIntervals(slots, func(i int) (uint64, uint64) {
+		if i < LogLinearSubBuckets {
+			return uint64(i), uint64(i)
+		}
+		exp := i/LogLinearSubBuckets + LogLinearSubBucketBits - 1
+		sub := uint64(i % LogLinearSubBuckets)
+		width := uint64(1) << (exp - LogLinearSubBucketBits)
+		start := (LogLinearSubBuckets + sub) * width
+		return start, start + width - 1
+	})
+}
+
+func newIntervals(slots []uint32, bounds func(i int) (uint64, uint64)) []Interval {
+	if len(slots) == 0 {
+		return nil
+	}
+
+	intervals := make([]Interval, 0, len(slots))
+	indexMax := 0
+	for i, val := range slots {
+		if val > 0 {
+			indexMax = i
+		}
+		start, end := bounds(i)
+		intervals = append(intervals, Interval{
+			Count: uint64(val),
+			Start: start,
+			End:   end,
+		})
+	}
+	return intervals[:indexMax+1]
+}
+
+// DeltaSlots returns the number of events added to each slot between two
+// readings of the same histogram
+func DeltaSlots(prev, cur []uint32) []uint32 {
+	delta := make([]uint32, len(cur))
+	for i, val := range cur {
+		if i < len(prev) && prev[i] <= val {
+			val -= prev[i]
+		}
+		delta[i] = val
+	}
+	return delta
+}
+
 // String returns a string representation of the histogram. It is a golang
 // adaption of iovisor/bcc print_log2_hist():
 // https://github.com/iovisor/bcc/blob/13b5563c11f7722a61a17c6ca0a1a387d2fa7788/libbpf-tools/trace_helpers.c#L895-L932
@@ -106,6 +228,10 @@ func (h *Histogram) String() string {
 			starsToString(b.Count, valMax, uint64(stars))))
 	}
 
+	if h.Percentiles != nil {
+		sb.WriteString(fmt.Sprintf("\n%*s%s (%s)\n", spaceBefore, "", h.Percentiles, h.Unit))
+	}
+
 	return sb.String()
 }
 
@@ -230,3 +230,117 @@ func TestHistogram_String(t *testing.T) {
 		})
 	}
 }
+
+func TestHistogram_NewIntervals(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		description string
+		layout      Layout
+		slots       []uint32
+		step        uint64
+		expected    []Interval
+		expectedErr bool
+	}{
+		{
+			description: "Exp2",
+			layout:      LayoutExp2,
+			slots:       []uint32{1, 2, 3},
+			expected: []Interval{
+				{Count: 1, Start: 0, End: 1},
+				{Count: 2, Start: 2, End: 3},
+				{Count: 3, Start: 4, End: 7},
+			},
+		},
+		{
+			description: "Linear",
+			layout:      LayoutLinear,
+			slots:       []uint32{1, 2, 3, 0},
+			step:        10,
+			expected: []Interval{
+				{Count: 1, Start: 0, End: 9},
+				{Count: 2, Start: 10, End: 19},
+				{Count: 3, Start: 20, End: 29},
+			},
+		},
+		{
+			description: "Linear without step",
+			layout:      LayoutLinear,
+			slots:       []uint32{1},
+			expectedErr: true,
+		},
+		{
+			description: "Log-linear",
+			layout:      LayoutLogLinear,
+			slots:       []uint32{1, 2, 3, 4, 5, 6, 7, 0},
+			expected: []Interval{
+				{Count: 1, Start: 0, End: 0},
+				{Count: 2, Start: 1, End: 1},
+				{Count: 3, Start: 2, End: 2},
+				{Count: 4, Start: 3, End: 3},
+				{Count: 5, Start: 4, End: 5},
+				{Count: 6, Start: 6, End: 7},
+				{Count: 7, Start: 8, End: 11},
+			},
+		},
+		{
+			description: "Unknown layout",
+			layout:      "unknown",
+			slots:       []uint32{1},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testTable {
+		test := test
+		t.Run(test.description, func(t *testing.T) {
+			t.Parallel()
+
+			intervals, err := NewIntervals(test.layout, test.slots, test.step)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, intervals)
+		})
+	}
+}
+
+func TestHistogram_DeltaSlots(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []uint32{1, 0, 5}, DeltaSlots([]uint32{1, 2}, []uint32{2, 2, 5}))
+	// A histogram that was reset is used as is
+	require.Equal(t, []uint32{1}, DeltaSlots([]uint32{3}, []uint32{1}))
+}
+
+func TestHistogram_Percentiles(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, NewPercentiles(nil))
+	require.Nil(t, NewPercentiles(NewIntervalsFromExp2Slots([]uint32{0, 0})))
+
+	// 100 events uniformly distributed between 0 and 99
+	intervals := NewIntervalsFromLinearSlots([]uint32{10, 10, 10, 10, 10, 10, 10, 10, 10, 10}, 10)
+	p := NewPercentiles(intervals)
+	require.InDelta(t, 50, p.P50, 0.001)
+	require.InDelta(t, 90, p.P90, 0.001)
+	require.InDelta(t, 99, p.P99, 0.001)
+	require.Equal(t, uint64(99), p.Max)
+
+	h := &Histogram{
+		Unit:      UnitMicroseconds,
+		Intervals: NewIntervalsFromExp2Slots([]uint32{0, 0, 4}),
+	}
+	h.ComputePercentiles()
+	require.Equal(t, &Percentiles{P50: 6, P90: 7.6, P99: 7.96, Max: 7}, h.Percentiles)
+	require.Equal(t, ""+
+		"        µs               : count    distribution\n"+
+		"         0 -> 1          : 0        |                                        |\n"+
+		"         2 -> 3          : 0        |                                        |\n"+
+		"         4 -> 7          : 4        |****************************************|\n"+
+		"\n"+
+		"        p50=6.0 p90=7.6 p99=8.0 max=7 (µs)\n",
+		h.String())
+}
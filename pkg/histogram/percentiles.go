@@ -0,0 +1,85 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package histogram
+
+import (
+	"fmt"
+)
+
+// Percentiles contains values computed from the intervals of a histogram.
+// Values are interpolated linearly inside the interval they fall into, so
+// they are estimations with the precision of the histogram layout.
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+
+	// Max is the upper bound of the highest non-empty interval
+	Max uint64 `json:"max"`
+}
+
+// NewPercentiles computes the percentiles of the given intervals. It returns
+// nil if there are no events in them.
+func NewPercentiles(intervals []Interval) *Percentiles {
+	total := uint64(0)
+	for _, interval := range intervals {
+		total += interval.Count
+	}
+	if total == 0 {
+		return nil
+	}
+
+	p := &Percentiles{
+		P50: percentile(intervals, total, 0.50),
+		P90: percentile(intervals, total, 0.90),
+		P99: percentile(intervals, total, 0.99),
+	}
+	for i := len(intervals) - 1; i >= 0; i-- {
+		if intervals[i].Count > 0 {
+			p.Max = intervals[i].End
+			break
+		}
+	}
+	return p
+}
+
+func percentile(intervals []Interval, total uint64, q float64) float64 {
+	rank := q * float64(total)
+	seen := uint64(0)
+	for _, interval := range intervals {
+		if interval.Count == 0 {
+			continue
+		}
+		if float64(seen+interval.Count) >= rank {
+			fraction := (rank - float64(seen)) / float64(interval.Count)
+			return float64(interval.Start) + fraction*float64(interval.End+1-interval.Start)
+		}
+		seen += interval.Count
+	}
+	return float64(intervals[len(intervals)-1].End)
+}
+
+// ComputePercentiles sets the percentiles of the histogram from its intervals
+func (h *Histogram) ComputePercentiles() {
+	h.Percentiles = NewPercentiles(h.Intervals)
+}
+
+// String returns a one-line summary of the percentiles
+func (p *Percentiles) String() string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("p50=%.1f p90=%.1f p99=%.1f max=%d", p.P50, p.P90, p.P99, p.Max)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/rlimit"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
 
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
@@ -70,11 +71,20 @@ func (l *IGManager) CreateMountNsMap(id string, containerSelector containercolle
 	return mountnsmap, nil
 }
 
+// CgroupMap returns the map of cgroup ids to filter on together with the mount
+// namespace map created by CreateMountNsMap for the same id. It's nil when
+// there are no systemd units to select.
+func (l *IGManager) CgroupMap(id string) (*ebpf.Map, error) {
+	return l.tracerCollection.TracerCgroupMap(id)
+}
+
 func (l *IGManager) RemoveMountNsMap(id string) error {
 	return l.tracerCollection.RemoveTracer(id)
 }
 
-func NewManager(runtimes []*containerutilsTypes.RuntimeConfig) (*IGManager, error) {
+// NewManager creates a container collection using the given runtimes. Extra
+// options, like WithSystemdUnits(), are added after the default ones.
+func NewManager(runtimes []*containerutilsTypes.RuntimeConfig, extraOpts ...containercollection.ContainerCollectionOption) (*IGManager, error) {
 	l := &IGManager{}
 
 	var err error
@@ -98,9 +108,18 @@ func NewManager(runtimes []*containerutilsTypes.RuntimeConfig) (*IGManager, erro
 		containercollection.WithCgroupEnrichment(),
 		containercollection.WithLinuxNamespaceEnrichment(),
 		containercollection.WithMultipleContainerRuntimesEnrichment(runtimes),
+	}
+
+	// The owners of the pods can only be looked up when running in a pod
+	if kubeconfig, err := rest.InClusterConfig(); err == nil {
+		opts = append(opts, containercollection.WithOwnerReferenceEnrichment(kubeconfig))
+	}
+
+	opts = append(opts,
 		containercollection.WithContainerFanotifyEbpf(),
 		containercollection.WithTracerCollection(l.tracerCollection),
-	}
+	)
+	opts = append(opts, extraOpts...)
 
 	if !log.IsLevelEnabled(log.DebugLevel) && isDefaultContainerRuntimeConfig(runtimes) {
 		warnings := []containercollection.ContainerCollectionOption{containercollection.WithDisableContainerRuntimeWarnings()}
@@ -126,7 +145,7 @@ func (l *IGManager) Close() {
 }
 
 func isDefaultContainerRuntimeConfig(runtimes []*containerutilsTypes.RuntimeConfig) bool {
-	if len(runtimes) != len(containerutils.AvailableRuntimes) {
+	if len(runtimes) != len(containerutils.DefaultRuntimes) {
 		return false
 	}
 
@@ -46,6 +46,7 @@ import (
 	"github.com/cilium/ebpf/perf"
 	"golang.org/x/sys/unix"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfstats"
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
@@ -76,6 +77,10 @@ type Tracer[Event any] struct {
 	prog              *ebpf.Program
 	perfRd            *perf.Reader
 
+	// objects attributes the programs loaded when attaching to network
+	// namespaces to the gadget instance, for top ebpf
+	objects bpfstats.Objects
+
 	// key: network namespace inode number
 	// value: Tracelet
 	attachments map[uint64]*attachment
@@ -100,6 +105,7 @@ func (t *Tracer[Event]) newAttachment(
 			if a.sockFd != -1 {
 				unix.Close(a.sockFd)
 			}
+			t.objects.Remove(&a.dispatcherObjs)
 			a.dispatcherObjs.Close()
 		}
 	}()
@@ -124,6 +130,7 @@ func (t *Tracer[Event]) newAttachment(
 	if err = dispatcherSpec.LoadAndAssign(&a.dispatcherObjs, &opts); err != nil {
 		return nil, fmt.Errorf("loading ebpf program: %w", err)
 	}
+	t.objects.Add(&a.dispatcherObjs)
 
 	a.sockFd, err = rawsock.OpenRawSock(pid)
 	if err != nil {
@@ -153,6 +160,7 @@ func NewTracer[Event any]() (_ *Tracer[Event], err error) {
 	if err != nil {
 		return nil, fmt.Errorf("creating tail_call map: %w", err)
 	}
+	t.objects.Add(t.dispatcherMap)
 	return t, nil
 }
 
@@ -160,6 +168,12 @@ func (t *Tracer[Event]) SetSocketEnricherMap(m *ebpf.Map) {
 	t.socketEnricherMap = m
 }
 
+// SetGadgetInfo attributes the eBPF programs and maps of the tracer, including
+// the ones loaded later, to the given gadget instance
+func (t *Tracer[Event]) SetGadgetInfo(info bpfstats.GadgetInfo) {
+	t.objects.SetOwner(info)
+}
+
 func (t *Tracer[Event]) Run(
 	spec *ebpf.CollectionSpec,
 	baseEvent func(ev types.Event) *Event,
@@ -173,6 +187,7 @@ func (t *Tracer[Event]) Run(
 				t.perfRd.Close()
 			}
 			if t.collection != nil {
+				t.objects.Remove(t.collection)
 				t.collection.Close()
 			}
 		}
@@ -226,8 +241,9 @@ func (t *Tracer[Event]) Run(
 	if err != nil {
 		return fmt.Errorf("creating BPF collection: %w", err)
 	}
+	t.objects.Add(t.collection)
 
-	t.perfRd, err = perf.NewReader(t.collection.Maps[bpfPerfMapName], gadgets.PerfBufferPages*os.Getpagesize())
+	t.perfRd, err = gadgets.NewPerfReader(t.collection.Maps[bpfPerfMapName], gadgets.PerfBufferPages*os.Getpagesize())
 	if err != nil {
 		return fmt.Errorf("getting a perf reader: %w", err)
 	}
@@ -328,8 +344,7 @@ func (t *Tracer[Event]) listen(
 		}
 
 		if record.LostSamples != 0 {
-			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
-			t.eventHandler(baseEvent(types.Warn(msg)))
+			t.eventHandler(baseEvent(types.Lost(record.LostSamples)))
 			continue
 		}
 
@@ -354,6 +369,7 @@ func (t *Tracer[Event]) listen(
 
 func (t *Tracer[Event]) releaseAttachment(netns uint64, a *attachment) {
 	unix.Close(a.sockFd)
+	t.objects.Remove(&a.dispatcherObjs)
 	a.dispatcherObjs.Close()
 	delete(t.attachments, netns)
 }
@@ -378,6 +394,7 @@ func (t *Tracer[Event]) Close() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	t.objects.Clear()
 	if t.perfRd != nil {
 		t.perfRd.Close()
 	}
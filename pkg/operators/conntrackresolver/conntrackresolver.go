@@ -0,0 +1,293 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conntrackresolver provides an operator that enriches network events
+// with the destination selected by the kernel when the flow was translated
+// (DNAT), e.g. the backend pod chosen by kube-proxy for a Kubernetes service.
+// It keeps a copy of the translated flows of the conntrack table of the host
+// network namespace up to date from the conntrack netlink events.
+package conntrackresolver
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+	netnsig "github.com/inspektor-gadget/inspektor-gadget/pkg/utils/netns"
+)
+
+const (
+	OperatorName = "ConntrackResolver"
+
+	ParamResolveNAT = "resolve-nat"
+
+	// receiveTimeout limits how long stopping the gadget waits for the
+	// goroutine receiving the conntrack events
+	receiveTimeout = time.Second
+)
+
+type ConntrackResolverInterface interface {
+	// GetConntrackFlow returns the endpoints of the flow as sent by the
+	// client. ok is false if the event doesn't describe an outgoing flow.
+	GetConntrackFlow() (src, dst types.L4Endpoint, ok bool)
+	SetNATDstEndpoint(endpoint types.L4Endpoint)
+}
+
+// endpointsGetter is the interface used by the KubeIPResolver operator. This
+// operator needs to be available for the same gadgets, as the KubeIPResolver
+// depends on it to resolve the destination after DNAT.
+type endpointsGetter interface {
+	GetEndpoints() []*types.L3Endpoint
+}
+
+type ConntrackResolver struct{}
+
+func (c *ConntrackResolver) Name() string {
+	return OperatorName
+}
+
+func (c *ConntrackResolver) Description() string {
+	return "ConntrackResolver resolves the destination of translated flows using the conntrack table"
+}
+
+func (c *ConntrackResolver) GlobalParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (c *ConntrackResolver) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamResolveNAT,
+			Description:  "Resolve the destination of translated flows, e.g. the backend of a Kubernetes service, using the conntrack table of the host",
+			DefaultValue: "false",
+			TypeHint:     params.TypeBool,
+		},
+	}
+}
+
+func (c *ConntrackResolver) Dependencies() []string {
+	return nil
+}
+
+func (c *ConntrackResolver) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	_, hasEndpointsGetter := gadget.EventPrototype().(endpointsGetter)
+	return hasEndpointsGetter
+}
+
+func (c *ConntrackResolver) Init(params *params.Params) error {
+	return nil
+}
+
+func (c *ConntrackResolver) Close() error {
+	return nil
+}
+
+func (c *ConntrackResolver) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	_, enabled := gadgetCtx.GadgetDesc().EventPrototype().(ConntrackResolverInterface)
+	enabled = enabled && params.Get(ParamResolveNAT).AsBool()
+	return &ConntrackResolverInstance{
+		gadgetCtx: gadgetCtx,
+		enabled:   enabled,
+	}, nil
+}
+
+type ConntrackResolverInstance struct {
+	gadgetCtx operators.GadgetContext
+	enabled   bool
+
+	handle *netlink.Handle
+	events *nl.NetlinkSocket
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	// mu protects table, which is kept up to date from the conntrack events
+	// in the background and only read on the event path
+	mu    sync.RWMutex
+	table natTable
+}
+
+func (c *ConntrackResolverInstance) Name() string {
+	return "ConntrackResolverInstance"
+}
+
+func (c *ConntrackResolverInstance) PreGadgetRun() error {
+	if !c.enabled {
+		return nil
+	}
+
+	// The gadget is still useful without the destination after DNAT, e.g.
+	// if the conntrack netlink interface isn't available
+	if err := c.start(); err != nil {
+		c.gadgetCtx.Logger().Warnf("NAT resolution disabled: %v", err)
+		c.close()
+		c.enabled = false
+	}
+	return nil
+}
+
+func (c *ConntrackResolverInstance) start() error {
+	netnsHandle, err := netnsig.GetFromPidWithAltProcfs(1, host.HostProcFs)
+	if err != nil {
+		return fmt.Errorf("getting host network namespace: %w", err)
+	}
+	defer netnsHandle.Close()
+
+	c.handle, err = netlink.NewHandleAt(netnsHandle, unix.NETLINK_NETFILTER)
+	if err != nil {
+		return fmt.Errorf("creating netlink handle in host network namespace: %w", err)
+	}
+
+	// Subscribe before dumping the table to not miss the flows created in
+	// between
+	c.events, err = nl.SubscribeAt(netnsHandle, netns.None(), unix.NETLINK_NETFILTER,
+		unix.NFNLGRP_CONNTRACK_NEW, unix.NFNLGRP_CONNTRACK_DESTROY)
+	if err != nil {
+		return fmt.Errorf("subscribing to conntrack events: %w", err)
+	}
+	// Wake up regularly to check whether the gadget is done
+	if err := c.events.SetReceiveTimeout(&unix.Timeval{Sec: int64(receiveTimeout.Seconds())}); err != nil {
+		return fmt.Errorf("setting receive timeout: %w", err)
+	}
+
+	c.dump()
+
+	c.done = make(chan struct{})
+	c.wg.Add(1)
+	go c.run()
+	return nil
+}
+
+func (c *ConntrackResolverInstance) PostGadgetRun() error {
+	if c.done != nil {
+		close(c.done)
+		c.wg.Wait()
+		c.done = nil
+	}
+	c.close()
+	return nil
+}
+
+func (c *ConntrackResolverInstance) close() {
+	if c.events != nil {
+		c.events.Close()
+		c.events = nil
+	}
+	if c.handle != nil {
+		c.handle.Close()
+		c.handle = nil
+	}
+}
+
+// dump replaces the table with the current content of the conntrack table
+func (c *ConntrackResolverInstance) dump() {
+	var flows []*netlink.ConntrackFlow
+	for _, family := range []netlink.InetFamily{unix.AF_INET, unix.AF_INET6} {
+		f, err := c.handle.ConntrackTableList(netlink.ConntrackTable, family)
+		if err != nil {
+			log.Warnf("listing conntrack table: %v", err)
+			continue
+		}
+		flows = append(flows, f...)
+	}
+	table := newNATTable(flows)
+
+	c.mu.Lock()
+	c.table = table
+	c.mu.Unlock()
+}
+
+// run applies the conntrack events to the table until the gadget is done
+func (c *ConntrackResolverInstance) run() {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+
+		msgs, _, err := c.events.Receive()
+		if err != nil {
+			switch {
+			case errors.Is(err, unix.EAGAIN), errors.Is(err, unix.EINTR):
+			case errors.Is(err, unix.ENOBUFS):
+				// Events were lost, start again from the current table
+				log.Debugf("conntrack events lost, dumping the table again")
+				c.dump()
+			default:
+				log.Warnf("receiving conntrack events: %v", err)
+				return
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		for _, msg := range msgs {
+			flow, destroyed, ok := parseConntrackEvent(msg)
+			if !ok {
+				continue
+			}
+			if destroyed {
+				c.table.remove(flow)
+			} else {
+				c.table.add(flow)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *ConntrackResolverInstance) enrich(ev any) {
+	event, ok := ev.(ConntrackResolverInterface)
+	if !ok {
+		return
+	}
+	src, dst, ok := event.GetConntrackFlow()
+	if !ok {
+		return
+	}
+
+	c.mu.RLock()
+	natDst, found := c.table.lookup(src, dst)
+	c.mu.RUnlock()
+
+	if found {
+		event.SetNATDstEndpoint(natDst)
+	}
+}
+
+func (c *ConntrackResolverInstance) EnrichEvent(ev any) error {
+	if !c.enabled {
+		return nil
+	}
+	c.enrich(ev)
+	return nil
+}
+
+func init() {
+	operators.Register(&ConntrackResolver{})
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntrackresolver
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// IPCTNL_MSG_CT_NEW, see enum cntl_msg_types in
+// include/uapi/linux/netfilter/nfnetlink_conntrack.h
+const ipctnlMsgCtNew = 0
+
+// sizeofNfgenmsg is the size of the netfilter header preceding the attributes
+const sizeofNfgenmsg = 4
+
+// tuple is one direction of a conntrack flow
+type tuple struct {
+	srcIP, dstIP     net.IP
+	proto            uint8
+	srcPort, dstPort uint16
+}
+
+// parseConntrackEvent returns the flow described by a conntrack event and
+// whether it was destroyed. ok is false for messages that aren't conntrack
+// events or can't be parsed.
+func parseConntrackEvent(msg syscall.NetlinkMessage) (flow *netlink.ConntrackFlow, destroyed bool, ok bool) {
+	if msg.Header.Type>>8 != unix.NFNL_SUBSYS_CTNETLINK {
+		return nil, false, false
+	}
+	switch msg.Header.Type & 0xff {
+	case ipctnlMsgCtNew:
+	case nl.IPCTNL_MSG_CT_DELETE:
+		destroyed = true
+	default:
+		return nil, false, false
+	}
+	if len(msg.Data) < sizeofNfgenmsg {
+		return nil, false, false
+	}
+
+	flow = &netlink.ConntrackFlow{FamilyType: msg.Data[0]}
+	attrs := parseAttrs(msg.Data[sizeofNfgenmsg:])
+	orig, okOrig := attrs[nl.CTA_TUPLE_ORIG]
+	reply, okReply := attrs[nl.CTA_TUPLE_REPLY]
+	if !okOrig || !okReply {
+		return nil, false, false
+	}
+	fwd, okOrig := parseTuple(orig)
+	rev, okReply := parseTuple(reply)
+	if !okOrig || !okReply {
+		return nil, false, false
+	}
+
+	flow.Forward.SrcIP, flow.Forward.DstIP = fwd.srcIP, fwd.dstIP
+	flow.Forward.Protocol = fwd.proto
+	flow.Forward.SrcPort, flow.Forward.DstPort = fwd.srcPort, fwd.dstPort
+	flow.Reverse.SrcIP, flow.Reverse.DstIP = rev.srcIP, rev.dstIP
+	flow.Reverse.Protocol = rev.proto
+	flow.Reverse.SrcPort, flow.Reverse.DstPort = rev.srcPort, rev.dstPort
+	return flow, destroyed, true
+}
+
+// parseTuple parses the nested CTA_TUPLE_IP and CTA_TUPLE_PROTO attributes of
+// a tuple
+func parseTuple(b []byte) (t tuple, ok bool) {
+	attrs := parseAttrs(b)
+
+	ip := parseAttrs(attrs[nl.CTA_TUPLE_IP])
+	switch {
+	case len(ip[nl.CTA_IP_V4_SRC]) == net.IPv4len && len(ip[nl.CTA_IP_V4_DST]) == net.IPv4len:
+		t.srcIP = net.IP(ip[nl.CTA_IP_V4_SRC])
+		t.dstIP = net.IP(ip[nl.CTA_IP_V4_DST])
+	case len(ip[nl.CTA_IP_V6_SRC]) == net.IPv6len && len(ip[nl.CTA_IP_V6_DST]) == net.IPv6len:
+		t.srcIP = net.IP(ip[nl.CTA_IP_V6_SRC])
+		t.dstIP = net.IP(ip[nl.CTA_IP_V6_DST])
+	default:
+		return t, false
+	}
+
+	proto := parseAttrs(attrs[nl.CTA_TUPLE_PROTO])
+	if len(proto[nl.CTA_PROTO_NUM]) != 1 {
+		return t, false
+	}
+	t.proto = proto[nl.CTA_PROTO_NUM][0]
+	// Ports are in network byte order. They are missing for ICMP.
+	if p := proto[nl.CTA_PROTO_SRC_PORT]; len(p) == 2 {
+		t.srcPort = binary.BigEndian.Uint16(p)
+	}
+	if p := proto[nl.CTA_PROTO_DST_PORT]; len(p) == 2 {
+		t.dstPort = binary.BigEndian.Uint16(p)
+	}
+	return t, true
+}
+
+// parseAttrs returns the netlink attributes of b indexed by type, without
+// their flags
+func parseAttrs(b []byte) map[uint16][]byte {
+	attrs := make(map[uint16][]byte)
+	native := nl.NativeEndian()
+	for len(b) >= unix.SizeofNlAttr {
+		length := int(native.Uint16(b[0:2]))
+		typ := native.Uint16(b[2:4]) & nl.NLA_TYPE_MASK
+		if length < unix.SizeofNlAttr || length > len(b) {
+			break
+		}
+		attrs[typ] = b[unix.SizeofNlAttr:length]
+
+		aligned := (length + unix.NLA_ALIGNTO - 1) &^ (unix.NLA_ALIGNTO - 1)
+		if aligned >= len(b) {
+			break
+		}
+		b = b[aligned:]
+	}
+	return attrs
+}
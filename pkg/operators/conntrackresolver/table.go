@@ -0,0 +1,147 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntrackresolver
+
+import (
+	"net"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// dstKey identifies the original destination of a flow
+type dstKey struct {
+	proto uint8
+	addr  string
+	port  uint16
+}
+
+// natEntry is a conntrack entry whose destination was translated
+type natEntry struct {
+	srcAddr string
+	srcPort uint16
+	natDst  types.L4Endpoint
+}
+
+// natTable indexes the translated conntrack entries by their original
+// destination
+type natTable map[dstKey][]natEntry
+
+func newNATTable(flows []*netlink.ConntrackFlow) natTable {
+	table := make(natTable)
+	for _, flow := range flows {
+		table.add(flow)
+	}
+	return table
+}
+
+// add indexes flow if its destination was translated. Adding the same flow
+// twice replaces the previous entry.
+func (t natTable) add(flow *netlink.ConntrackFlow) {
+	orig, reply := flow.Forward, flow.Reverse
+
+	// The reply direction is sent by the selected backend, so its source is
+	// the destination after DNAT.
+	if reply.SrcIP.Equal(orig.DstIP) && reply.SrcPort == orig.DstPort {
+		return
+	}
+
+	key := keyOf(flow)
+	entry := natEntry{
+		srcAddr: orig.SrcIP.String(),
+		srcPort: orig.SrcPort,
+		natDst: types.L4Endpoint{
+			L3Endpoint: types.L3Endpoint{
+				Addr:    reply.SrcIP.String(),
+				Version: ipVersion(reply.SrcIP),
+			},
+			Port:  reply.SrcPort,
+			Proto: uint16(orig.Protocol),
+		},
+	}
+	for i, e := range t[key] {
+		if e.srcAddr == entry.srcAddr && e.srcPort == entry.srcPort {
+			t[key][i] = entry
+			return
+		}
+	}
+	t[key] = append(t[key], entry)
+}
+
+// remove removes flow from the table, e.g. once conntrack destroyed it
+func (t natTable) remove(flow *netlink.ConntrackFlow) {
+	key := keyOf(flow)
+	srcAddr := flow.Forward.SrcIP.String()
+	for i, e := range t[key] {
+		if e.srcAddr == srcAddr && e.srcPort == flow.Forward.SrcPort {
+			t[key] = append(t[key][:i], t[key][i+1:]...)
+			break
+		}
+	}
+	if len(t[key]) == 0 {
+		delete(t, key)
+	}
+}
+
+func keyOf(flow *netlink.ConntrackFlow) dstKey {
+	return dstKey{
+		proto: flow.Forward.Protocol,
+		addr:  flow.Forward.DstIP.String(),
+		port:  flow.Forward.DstPort,
+	}
+}
+
+// lookup returns the destination after DNAT of the flow going from src to dst.
+// The source address is required. If the source port is unknown (0), the flows
+// from the source address to dst are only used if they were all translated to
+// the same destination, as there is no way to tell which one the event is
+// about otherwise.
+func (t natTable) lookup(src, dst types.L4Endpoint) (types.L4Endpoint, bool) {
+	if src.Addr == "" {
+		return types.L4Endpoint{}, false
+	}
+
+	key := dstKey{
+		proto: uint8(dst.Proto),
+		addr:  dst.Addr,
+		port:  dst.Port,
+	}
+	var natDst types.L4Endpoint
+	found := false
+	for _, entry := range t[key] {
+		if src.Addr != entry.srcAddr {
+			continue
+		}
+		if src.Port != 0 {
+			if src.Port == entry.srcPort {
+				return entry.natDst, true
+			}
+			continue
+		}
+		if found && (natDst.Addr != entry.natDst.Addr || natDst.Port != entry.natDst.Port) {
+			return types.L4Endpoint{}, false
+		}
+		natDst, found = entry.natDst, true
+	}
+	return natDst, found
+}
+
+func ipVersion(ip net.IP) uint8 {
+	if ip.To4() != nil {
+		return 4
+	}
+	return 6
+}
@@ -0,0 +1,228 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntrackresolver
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func flow(proto uint8, src string, sport uint16, dst string, dport uint16, replySrc string, replySport uint16) *netlink.ConntrackFlow {
+	f := &netlink.ConntrackFlow{}
+	f.Forward.Protocol = proto
+	f.Forward.SrcIP = net.ParseIP(src)
+	f.Forward.SrcPort = sport
+	f.Forward.DstIP = net.ParseIP(dst)
+	f.Forward.DstPort = dport
+	f.Reverse.Protocol = proto
+	f.Reverse.SrcIP = net.ParseIP(replySrc)
+	f.Reverse.SrcPort = replySport
+	f.Reverse.DstIP = net.ParseIP(src)
+	f.Reverse.DstPort = sport
+	return f
+}
+
+func endpoint(addr string, port uint16, proto uint16) types.L4Endpoint {
+	return types.L4Endpoint{
+		L3Endpoint: types.L3Endpoint{Addr: addr},
+		Port:       port,
+		Proto:      proto,
+	}
+}
+
+func TestNATTableLookup(t *testing.T) {
+	t.Parallel()
+
+	table := newNATTable([]*netlink.ConntrackFlow{
+		// Not translated
+		flow(unix.IPPROTO_TCP, "10.0.0.1", 40000, "10.0.0.2", 80, "10.0.0.2", 80),
+		// Service 10.96.0.10:53 to backend 10.0.0.3:5353
+		flow(unix.IPPROTO_UDP, "10.0.0.1", 40001, "10.96.0.10", 53, "10.0.0.3", 5353),
+		// Service 10.96.0.20:80 to backends 10.0.0.4:8080 and 10.0.0.5:8080
+		flow(unix.IPPROTO_TCP, "10.0.0.1", 40002, "10.96.0.20", 80, "10.0.0.4", 8080),
+		flow(unix.IPPROTO_TCP, "10.0.0.1", 40003, "10.96.0.20", 80, "10.0.0.5", 8080),
+		// IPv6 service
+		flow(unix.IPPROTO_TCP, "fd00::1", 40004, "fd00:96::20", 80, "fd00::4", 8080),
+	})
+
+	type testDefinition struct {
+		src           types.L4Endpoint
+		dst           types.L4Endpoint
+		expectedFound bool
+		expected      types.L4Endpoint
+	}
+
+	tests := map[string]testDefinition{
+		"not_translated": {
+			src: endpoint("10.0.0.1", 40000, 0),
+			dst: endpoint("10.0.0.2", 80, unix.IPPROTO_TCP),
+		},
+		"udp_service": {
+			src:           endpoint("10.0.0.1", 40001, 0),
+			dst:           endpoint("10.96.0.10", 53, unix.IPPROTO_UDP),
+			expectedFound: true,
+			expected: types.L4Endpoint{
+				L3Endpoint: types.L3Endpoint{Addr: "10.0.0.3", Version: 4},
+				Port:       5353,
+				Proto:      unix.IPPROTO_UDP,
+			},
+		},
+		"wrong_proto": {
+			src: endpoint("10.0.0.1", 40001, 0),
+			dst: endpoint("10.96.0.10", 53, unix.IPPROTO_TCP),
+		},
+		"select_by_src_port": {
+			src:           endpoint("10.0.0.1", 40003, 0),
+			dst:           endpoint("10.96.0.20", 80, unix.IPPROTO_TCP),
+			expectedFound: true,
+			expected: types.L4Endpoint{
+				L3Endpoint: types.L3Endpoint{Addr: "10.0.0.5", Version: 4},
+				Port:       8080,
+				Proto:      unix.IPPROTO_TCP,
+			},
+		},
+		"other_src_port": {
+			src: endpoint("10.0.0.1", 40009, 0),
+			dst: endpoint("10.96.0.20", 80, unix.IPPROTO_TCP),
+		},
+		"unknown_src_port": {
+			src:           endpoint("10.0.0.1", 0, 0),
+			dst:           endpoint("10.96.0.10", 53, unix.IPPROTO_UDP),
+			expectedFound: true,
+			expected: types.L4Endpoint{
+				L3Endpoint: types.L3Endpoint{Addr: "10.0.0.3", Version: 4},
+				Port:       5353,
+				Proto:      unix.IPPROTO_UDP,
+			},
+		},
+		"unknown_src_port_ambiguous": {
+			src: endpoint("10.0.0.1", 0, 0),
+			dst: endpoint("10.96.0.20", 80, unix.IPPROTO_TCP),
+		},
+		"unknown_src_addr": {
+			src: endpoint("", 40001, 0),
+			dst: endpoint("10.96.0.10", 53, unix.IPPROTO_UDP),
+		},
+		"other_src_addr": {
+			src: endpoint("10.0.0.9", 0, 0),
+			dst: endpoint("10.96.0.20", 80, unix.IPPROTO_TCP),
+		},
+		"ipv6": {
+			src:           endpoint("fd00::1", 40004, 0),
+			dst:           endpoint("fd00:96::20", 80, unix.IPPROTO_TCP),
+			expectedFound: true,
+			expected: types.L4Endpoint{
+				L3Endpoint: types.L3Endpoint{Addr: "fd00::4", Version: 6},
+				Port:       8080,
+				Proto:      unix.IPPROTO_TCP,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			natDst, found := table.lookup(test.src, test.dst)
+			require.Equal(t, test.expectedFound, found)
+			require.Equal(t, test.expected, natDst)
+		})
+	}
+}
+
+func TestNATTableAddRemove(t *testing.T) {
+	t.Parallel()
+
+	table := newNATTable(nil)
+	src := endpoint("10.0.0.1", 40000, 0)
+	dst := endpoint("10.96.0.10", 53, unix.IPPROTO_UDP)
+
+	f := flow(unix.IPPROTO_UDP, "10.0.0.1", 40000, "10.96.0.10", 53, "10.0.0.3", 5353)
+	table.add(f)
+	table.add(f)
+	require.Len(t, table[keyOf(f)], 1)
+
+	natDst, found := table.lookup(src, dst)
+	require.True(t, found)
+	require.Equal(t, "10.0.0.3", natDst.Addr)
+
+	table.remove(f)
+	_, found = table.lookup(src, dst)
+	require.False(t, found)
+	require.Empty(t, table)
+}
+
+func tupleAttr(attrType int, src, dst net.IP, proto uint8, sport, dport uint16) *nl.RtAttr {
+	tuple := nl.NewRtAttr(attrType|unix.NLA_F_NESTED, nil)
+
+	ip := tuple.AddRtAttr(nl.CTA_TUPLE_IP|unix.NLA_F_NESTED, nil)
+	ip.AddRtAttr(nl.CTA_IP_V4_SRC, src.To4())
+	ip.AddRtAttr(nl.CTA_IP_V4_DST, dst.To4())
+
+	protoAttr := tuple.AddRtAttr(nl.CTA_TUPLE_PROTO|unix.NLA_F_NESTED, nil)
+	protoAttr.AddRtAttr(nl.CTA_PROTO_NUM, []byte{proto})
+	port := make([]byte, 2)
+	binary.BigEndian.PutUint16(port, sport)
+	protoAttr.AddRtAttr(nl.CTA_PROTO_SRC_PORT, port)
+	port = make([]byte, 2)
+	binary.BigEndian.PutUint16(port, dport)
+	protoAttr.AddRtAttr(nl.CTA_PROTO_DST_PORT, port)
+
+	return tuple
+}
+
+func TestParseConntrackEvent(t *testing.T) {
+	t.Parallel()
+
+	data := []byte{unix.AF_INET, 0, 0, 0}
+	data = append(data, tupleAttr(nl.CTA_TUPLE_ORIG,
+		net.ParseIP("10.0.0.1"), net.ParseIP("10.96.0.10"), unix.IPPROTO_UDP, 40000, 53).Serialize()...)
+	data = append(data, tupleAttr(nl.CTA_TUPLE_REPLY,
+		net.ParseIP("10.0.0.3"), net.ParseIP("10.0.0.1"), unix.IPPROTO_UDP, 5353, 40000).Serialize()...)
+
+	msg := syscall.NetlinkMessage{Data: data}
+	msg.Header.Type = unix.NFNL_SUBSYS_CTNETLINK<<8 | ipctnlMsgCtNew
+
+	f, destroyed, ok := parseConntrackEvent(msg)
+	require.True(t, ok)
+	require.False(t, destroyed)
+	require.Equal(t, uint8(unix.AF_INET), f.FamilyType)
+	require.True(t, f.Forward.SrcIP.Equal(net.ParseIP("10.0.0.1")))
+	require.True(t, f.Forward.DstIP.Equal(net.ParseIP("10.96.0.10")))
+	require.Equal(t, uint16(40000), f.Forward.SrcPort)
+	require.Equal(t, uint16(53), f.Forward.DstPort)
+	require.Equal(t, uint8(unix.IPPROTO_UDP), f.Forward.Protocol)
+	require.True(t, f.Reverse.SrcIP.Equal(net.ParseIP("10.0.0.3")))
+	require.Equal(t, uint16(5353), f.Reverse.SrcPort)
+
+	msg.Header.Type = unix.NFNL_SUBSYS_CTNETLINK<<8 | nl.IPCTNL_MSG_CT_DELETE
+	_, destroyed, ok = parseConntrackEvent(msg)
+	require.True(t, ok)
+	require.True(t, destroyed)
+
+	msg.Header.Type = unix.NFNL_SUBSYS_CTNETLINK_EXP << 8
+	_, _, ok = parseConntrackEvent(msg)
+	require.False(t, ok)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/common"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/conntrackresolver"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
@@ -58,6 +59,12 @@ func (k *KubeIPResolver) Dependencies() []string {
 	return nil
 }
 
+func (k *KubeIPResolver) OptionalDependencies() []string {
+	// The destination after DNAT needs to be known before resolving the
+	// endpoints, so backends of services get resolved as well.
+	return []string{conntrackresolver.OperatorName}
+}
+
 func (k *KubeIPResolver) CanOperateOn(gadget gadgets.GadgetDesc) bool {
 	_, hasIPResolverInterface := gadget.EventPrototype().(KubeIPResolverInterface)
 	return hasIPResolverInterface
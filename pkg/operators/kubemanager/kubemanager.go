@@ -17,11 +17,13 @@ package kubemanager
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/cilium/ebpf"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
 
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
@@ -37,7 +39,13 @@ const (
 	ParamSelector      = "selector"
 	ParamAllNamespaces = "all-namespaces"
 	ParamPodName       = "podname"
+	ParamPodNameRegex  = "podname-regex"
 	ParamNamespace     = "namespace"
+	ParamExcludeNs     = "exclude-namespace"
+	ParamOwner         = "owner"
+	ParamImage         = "image"
+	ParamContainerID   = "containerid"
+	ParamRuntimeName   = "runtime-name"
 )
 
 type MountNsMapSetter interface {
@@ -81,30 +89,59 @@ func (k *KubeManager) ParamDescs() params.ParamDescs {
 		{
 			Key:         ParamSelector,
 			Alias:       "l",
-			Description: "Labels selector to filter on. Only '=' is supported (e.g. key1=value1,key2=value2).",
+			Description: "Labels selector to filter on. Supports '=', '!=', 'in', 'notin' and 'exists' (e.g. key1=value1,key2 in (value2,value3),!key4).",
 			ValueHint:   gadgets.K8SLabels,
 			Validator: func(value string) error {
-				if value == "" {
-					return nil
+				if _, err := labels.Parse(value); err != nil {
+					return fmt.Errorf("invalid label selector: %w", err)
 				}
-
-				pairs := strings.Split(value, ",")
-				for _, pair := range pairs {
-					kv := strings.Split(pair, "=")
-					if len(kv) != 2 {
-						return fmt.Errorf("should be a comma-separated list of key-value pairs (key=value[,key=value,...])")
-					}
-				}
-
 				return nil
 			},
 		},
 		{
 			Key:         ParamPodName,
 			Alias:       "p",
-			Description: "Show only data from pods with that name",
+			Description: "Show only data from pods with that name. Glob patterns are supported (e.g. nginx-*)",
 			ValueHint:   gadgets.K8SPodName,
 		},
+		{
+			Key:         ParamPodNameRegex,
+			Description: "Show only data from pods whose name matches this regular expression",
+			Validator: func(value string) error {
+				if _, err := regexp.Compile(value); err != nil {
+					return fmt.Errorf("invalid regular expression: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			Key:         ParamOwner,
+			Description: "Show only data from pods owned by this workload (e.g. deployment/nginx, daemonset/, or nginx for any kind)",
+			Validator: func(value string) error {
+				if strings.Count(value, "/") > 1 {
+					return fmt.Errorf("should be [kind/]name")
+				}
+				return nil
+			},
+		},
+		{
+			Key:         ParamImage,
+			Description: "Show only data from containers using this image. Glob patterns and digests are supported (e.g. docker.io/library/nginx:*, sha256:...)",
+		},
+		{
+			Key:         ParamContainerID,
+			Description: "Show only data from containers whose ID starts with this value",
+		},
+		{
+			Key:         ParamRuntimeName,
+			Description: "Show only data from containers managed by this container runtime (e.g. containerd, cri-o)",
+			Validator: func(value string) error {
+				if value != "" && types.String2RuntimeName(value) == types.RuntimeNameUnknown {
+					return fmt.Errorf("unknown container runtime %q", value)
+				}
+				return nil
+			},
+		},
 		{
 			Key:          ParamAllNamespaces,
 			Alias:        "A",
@@ -118,6 +155,11 @@ func (k *KubeManager) ParamDescs() params.ParamDescs {
 			Description: "Show only data from pods in a given namespace",
 			ValueHint:   gadgets.K8SNamespace,
 		},
+		{
+			Key:         ParamExcludeNs,
+			Description: "Don't show data from pods in these namespaces (e.g. kube-system)",
+			ValueHint:   gadgets.K8SNamespace,
+		},
 	}
 }
 
@@ -145,14 +187,18 @@ func (k *KubeManager) CanOperateOn(gadget gadgets.GadgetDesc) bool {
 	}
 	_, isMountNsMapSetter := instance.(MountNsMapSetter)
 	_, isAttacher := instance.(Attacher)
+	// Gadgets without a parser can still ask for parts of their results to be
+	// enriched (e.g. per-container histograms)
+	_, isEventEnricherSetter := instance.(gadgets.EventEnricherSetter)
 
 	log.Debugf("> canEnrichEvent: %v", canEnrichEvent)
 	log.Debugf(" > canEnrichEventFromMountNs: %v", canEnrichEventFromMountNs)
 	log.Debugf(" > canEnrichEventFromNetNs: %v", canEnrichEventFromNetNs)
 	log.Debugf("> isMountNsMapSetter: %v", isMountNsMapSetter)
 	log.Debugf("> isAttacher: %v", isAttacher)
+	log.Debugf("> isEventEnricherSetter: %v", isEventEnricherSetter)
 
-	return isMountNsMapSetter || canEnrichEvent || isAttacher
+	return isMountNsMapSetter || canEnrichEvent || isAttacher || isEventEnricherSetter
 }
 
 func (k *KubeManager) Init(params *params.Params) error {
@@ -163,10 +209,20 @@ func (k *KubeManager) Close() error {
 	return nil
 }
 
+// ContainerResolver returns the container collection used by the operator or nil if it isn't
+// available
+func (k *KubeManager) ContainerResolver() containercollection.ContainerResolver {
+	if k.gadgetTracerManager == nil {
+		return nil
+	}
+	return k.gadgetTracerManager
+}
+
 func (k *KubeManager) Instantiate(gadgetContext operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
 	_, canEnrichEventFromMountNs := gadgetContext.GadgetDesc().EventPrototype().(operators.ContainerInfoFromMountNSID)
 	_, canEnrichEventFromNetNs := gadgetContext.GadgetDesc().EventPrototype().(operators.ContainerInfoFromNetNSID)
-	canEnrichEvent := canEnrichEventFromMountNs || canEnrichEventFromNetNs
+	_, isEventEnricherSetter := gadgetInstance.(gadgets.EventEnricherSetter)
+	canEnrichEvent := canEnrichEventFromMountNs || canEnrichEventFromNetNs || isEventEnricherSetter
 
 	traceInstance := &KubeManagerInstance{
 		id:             uuid.New().String(),
@@ -201,11 +257,9 @@ func (m *KubeManagerInstance) Name() string {
 func (m *KubeManagerInstance) PreGadgetRun() error {
 	log := m.gadgetCtx.Logger()
 
-	labels := make(map[string]string)
-	selectorSlice := m.params.Get(ParamSelector).AsStringSlice()
-	for _, pair := range selectorSlice {
-		kv := strings.Split(pair, "=")
-		labels[kv[0]] = kv[1]
+	labelSelector, err := labels.Parse(m.params.Get(ParamSelector).AsString())
+	if err != nil {
+		return fmt.Errorf("parsing label selector: %w", err)
 	}
 
 	containerSelector := containercollection.ContainerSelector{
@@ -215,10 +269,28 @@ func (m *KubeManagerInstance) PreGadgetRun() error {
 				PodName:       m.params.Get(ParamPodName).AsString(),
 				ContainerName: m.params.Get(ParamContainerName).AsString(),
 			},
-			PodLabels: labels,
+			PodLabelSelector: labelSelector,
+			ExcludeNamespace: m.params.Get(ParamExcludeNs).AsString(),
 		},
 	}
 
+	if podNameRegex := m.params.Get(ParamPodNameRegex).AsString(); podNameRegex != "" {
+		containerSelector.K8s.PodNameRegex, err = regexp.Compile("^(?:" + podNameRegex + ")$")
+		if err != nil {
+			return fmt.Errorf("parsing pod name regex: %w", err)
+		}
+	}
+
+	containerSelector.K8s.OwnerKind, containerSelector.K8s.OwnerName =
+		containercollection.ParseOwnerSelector(m.params.Get(ParamOwner).AsString())
+
+	containerSelector.Runtime.ContainerID = m.params.Get(ParamContainerID).AsString()
+	if runtimeName := m.params.Get(ParamRuntimeName).AsString(); runtimeName != "" {
+		containerSelector.Runtime.RuntimeName = types.String2RuntimeName(runtimeName)
+	}
+	containerSelector.Runtime.ContainerImageName, containerSelector.Runtime.ContainerImageDigest =
+		containercollection.ParseImageSelector(m.params.Get(ParamImage).AsString())
+
 	if m.params.Get(ParamAllNamespaces).AsBool() {
 		containerSelector.K8s.Namespace = ""
 	}
@@ -17,6 +17,8 @@ package localmanager
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/cilium/ebpf"
@@ -28,6 +30,7 @@ import (
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
 	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/systemd"
 	containerutilsTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	igmanager "github.com/inspektor-gadget/inspektor-gadget/pkg/ig-manager"
@@ -41,11 +44,21 @@ const (
 	OperatorInstanceName = "LocalManagerTrace"
 	Runtimes             = "runtimes"
 	ContainerName        = "containername"
+	ContainerID          = "containerid"
+	Image                = "image"
+	RuntimeName          = "runtime-name"
+	PodNameRegex         = "podname-regex"
+	ExcludeNamespace     = "exclude-namespace"
+	Owner                = "owner"
 	Host                 = "host"
+	Unit                 = "unit"
+	SystemdUnits         = "systemd-units"
 	DockerSocketPath     = "docker-socketpath"
 	ContainerdSocketPath = "containerd-socketpath"
 	CrioSocketPath       = "crio-socketpath"
 	PodmanSocketPath     = "podman-socketpath"
+	LXDSocketPath        = "lxd-socketpath"
+	MachinedSocketPath   = "machined-socketpath"
 	ContainerdNamespace  = "containerd-namespace"
 )
 
@@ -53,14 +66,22 @@ type MountNsMapSetter interface {
 	SetMountNsMap(*ebpf.Map)
 }
 
+// CgroupMapSetter is implemented by the gadgets that filter by mount namespace
+// and also select the systemd units sharing the mount namespace of the host by
+// cgroup.
+type CgroupMapSetter interface {
+	SetCgroupMap(*ebpf.Map)
+}
+
 type Attacher interface {
 	AttachContainer(container *containercollection.Container) error
 	DetachContainer(*containercollection.Container) error
 }
 
 type LocalManager struct {
-	igManager *igmanager.IGManager
-	rc        []*containerutilsTypes.RuntimeConfig
+	igManager    *igmanager.IGManager
+	rc           []*containerutilsTypes.RuntimeConfig
+	systemdUnits bool
 }
 
 func (l *LocalManager) Name() string {
@@ -80,7 +101,7 @@ func (l *LocalManager) GlobalParamDescs() params.ParamDescs {
 		{
 			Key:          Runtimes,
 			Alias:        "r",
-			DefaultValue: strings.Join(containerutils.AvailableRuntimes, ","),
+			DefaultValue: strings.Join(containerutils.DefaultRuntimes, ","),
 			Description: fmt.Sprintf("Container runtimes to be used separated by comma. Supported values are: %s",
 				strings.Join(containerutils.AvailableRuntimes, ", ")),
 			// PossibleValues: containerutils.AvailableRuntimes, // TODO
@@ -105,11 +126,27 @@ func (l *LocalManager) GlobalParamDescs() params.ParamDescs {
 			DefaultValue: runtimeclient.PodmanDefaultSocketPath,
 			Description:  "Podman Unix socket path",
 		},
+		{
+			Key:          LXDSocketPath,
+			DefaultValue: runtimeclient.LXDDefaultSocketPath,
+			Description:  "LXD REST API Unix socket path",
+		},
+		{
+			Key:          MachinedSocketPath,
+			DefaultValue: runtimeclient.MachinedDefaultSocketPath,
+			Description:  "D-Bus system bus Unix socket path used to reach systemd-machined",
+		},
 		{
 			Key:          ContainerdNamespace,
 			DefaultValue: constants.K8sContainerdNamespace,
 			Description:  "Containerd namespace to use",
 		},
+		{
+			Key:          SystemdUnits,
+			DefaultValue: "false",
+			Description:  "Handle the systemd services and slices and the plain cgroups of the host as containers. Requires cgroup v2",
+			TypeHint:     params.TypeBool,
+		},
 	}
 }
 
@@ -121,6 +158,52 @@ func (l *LocalManager) ParamDescs() params.ParamDescs {
 			Description: "Show only data from containers with that name",
 			ValueHint:   gadgets.LocalContainer,
 		},
+		{
+			Key:         ContainerID,
+			Description: "Show only data from containers whose ID starts with this value",
+		},
+		{
+			Key:         Image,
+			Description: "Show only data from containers using this image. Glob patterns and digests are supported (e.g. docker.io/library/nginx:*, sha256:...)",
+		},
+		{
+			Key:         RuntimeName,
+			Description: "Show only data from containers managed by this container runtime (e.g. docker, containerd)",
+			Validator: func(value string) error {
+				if value != "" && types.String2RuntimeName(value) == types.RuntimeNameUnknown {
+					return fmt.Errorf("unknown container runtime %q", value)
+				}
+				return nil
+			},
+		},
+		{
+			Key:         PodNameRegex,
+			Description: "Show only data from Kubernetes pods whose name matches this regular expression",
+			Validator: func(value string) error {
+				if _, err := regexp.Compile(value); err != nil {
+					return fmt.Errorf("invalid regular expression: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			Key:         ExcludeNamespace,
+			Description: "Don't show data from Kubernetes pods in these namespaces (e.g. kube-system)",
+		},
+		{
+			Key:         Owner,
+			Description: "Show only data from Kubernetes pods owned by this workload (e.g. deployment/nginx). Requires running in a Kubernetes pod allowed to get the owners",
+			Validator: func(value string) error {
+				if strings.Count(value, "/") > 1 {
+					return fmt.Errorf("should be [kind/]name")
+				}
+				return nil
+			},
+		},
+		{
+			Key:         Unit,
+			Description: "Show only data from the systemd unit with that name, or from the units in it if it's a slice. The .service suffix can be omitted. Requires --systemd-units",
+		},
 		{
 			Key:          Host,
 			Description:  "Show data from both the host and containers",
@@ -150,14 +233,18 @@ func (l *LocalManager) CanOperateOn(gadget gadgets.GadgetDesc) bool {
 	}
 	_, isMountNsMapSetter := instance.(MountNsMapSetter)
 	_, isAttacher := instance.(Attacher)
+	// Gadgets without a parser can still ask for parts of their results to be
+	// enriched (e.g. per-container histograms)
+	_, isEventEnricherSetter := instance.(gadgets.EventEnricherSetter)
 
 	log.Debugf("> canEnrichEvent: %v", canEnrichEvent)
 	log.Debugf("\t> canEnrichEventFromMountNs: %v", canEnrichEventFromMountNs)
 	log.Debugf("\t> canEnrichEventFromNetNs: %v", canEnrichEventFromNetNs)
 	log.Debugf("> isMountNsMapSetter: %v", isMountNsMapSetter)
 	log.Debugf("> isAttacher: %v", isAttacher)
+	log.Debugf("> isEventEnricherSetter: %v", isEventEnricherSetter)
 
-	return isMountNsMapSetter || canEnrichEvent || isAttacher
+	return isMountNsMapSetter || canEnrichEvent || isAttacher || isEventEnricherSetter
 }
 
 func (l *LocalManager) Init(operatorParams *params.Params) error {
@@ -180,6 +267,10 @@ partsLoop:
 			socketPath = operatorParams.Get(CrioSocketPath).AsString()
 		case types.RuntimeNamePodman:
 			socketPath = operatorParams.Get(PodmanSocketPath).AsString()
+		case types.RuntimeNameLXD:
+			socketPath = operatorParams.Get(LXDSocketPath).AsString()
+		case types.RuntimeNameMachined:
+			socketPath = operatorParams.Get(MachinedSocketPath).AsString()
 		default:
 			return commonutils.WrapInErrInvalidArg("--runtime / -r",
 				fmt.Errorf("runtime %q is not supported", p))
@@ -207,8 +298,14 @@ partsLoop:
 	}
 
 	l.rc = rc
+	l.systemdUnits = operatorParams.Get(SystemdUnits).AsBool()
+
+	var opts []containercollection.ContainerCollectionOption
+	if l.systemdUnits {
+		opts = append(opts, containercollection.WithSystemdUnits())
+	}
 
-	igManager, err := igmanager.NewManager(l.rc)
+	igManager, err := igmanager.NewManager(l.rc, opts...)
 	if err != nil {
 		log.Warnf("Failed to create container-collection")
 		log.Debugf("Failed to create container-collection: %s", err)
@@ -224,10 +321,20 @@ func (l *LocalManager) Close() error {
 	return nil
 }
 
+// ContainerResolver returns the container collection used by the operator or nil if it isn't
+// available
+func (l *LocalManager) ContainerResolver() containercollection.ContainerResolver {
+	if l.igManager == nil {
+		return nil
+	}
+	return l.igManager
+}
+
 func (l *LocalManager) Instantiate(gadgetContext operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
 	_, canEnrichEventFromMountNs := gadgetContext.GadgetDesc().EventPrototype().(operators.ContainerInfoFromMountNSID)
 	_, canEnrichEventFromNetNs := gadgetContext.GadgetDesc().EventPrototype().(operators.ContainerInfoFromNetNSID)
-	canEnrichEvent := canEnrichEventFromMountNs || canEnrichEventFromNetNs
+	_, isEventEnricherSetter := gadgetInstance.(gadgets.EventEnricherSetter)
+	canEnrichEvent := canEnrichEventFromMountNs || canEnrichEventFromNetNs || isEventEnricherSetter
 
 	traceInstance := &localManagerTrace{
 		manager:            l,
@@ -273,8 +380,42 @@ func (l *localManagerTrace) PreGadgetRun() error {
 	containerSelector := containercollection.ContainerSelector{
 		Runtime: containercollection.RuntimeSelector{
 			ContainerName: l.params.Get(ContainerName).AsString(),
+			ContainerID:   l.params.Get(ContainerID).AsString(),
 		},
 	}
+	containerSelector.Runtime.ContainerImageName, containerSelector.Runtime.ContainerImageDigest =
+		containercollection.ParseImageSelector(l.params.Get(Image).AsString())
+	if runtimeName := l.params.Get(RuntimeName).AsString(); runtimeName != "" {
+		containerSelector.Runtime.RuntimeName = types.String2RuntimeName(runtimeName)
+	}
+
+	containerSelector.K8s.ExcludeNamespace = l.params.Get(ExcludeNamespace).AsString()
+	if podNameRegex := l.params.Get(PodNameRegex).AsString(); podNameRegex != "" {
+		var err error
+		containerSelector.K8s.PodNameRegex, err = regexp.Compile("^(?:" + podNameRegex + ")$")
+		if err != nil {
+			return commonutils.WrapInErrInvalidArg("--"+PodNameRegex, err)
+		}
+	}
+	containerSelector.K8s.OwnerKind, containerSelector.K8s.OwnerName =
+		containercollection.ParseOwnerSelector(l.params.Get(Owner).AsString())
+
+	if unit := l.params.Get(Unit).AsString(); unit != "" {
+		if !l.manager.systemdUnits {
+			return commonutils.WrapInErrInvalidArg("--unit",
+				errors.New("systemd units are only handled with --systemd-units"))
+		}
+		containerSelector.Runtime.SystemdUnit = systemd.NormalizeUnitName(unit)
+
+		// Gadgets filtering by cgroup trace the units sharing the mount
+		// namespace of the host, the other ones can't trace them.
+		_, filtersByCgroup := l.gadgetInstance.(CgroupMapSetter)
+		if !host && !filtersByCgroup && l.manager.igManager != nil {
+			if err := l.checkSystemdUnits(&containerSelector); err != nil {
+				return commonutils.WrapInErrInvalidArg("--unit", err)
+			}
+		}
+	}
 
 	// If --host is set, we do not want to create the below map because we do not
 	// want any filtering.
@@ -293,6 +434,18 @@ func (l *localManagerTrace) PreGadgetRun() error {
 			log.Debugf("set mountnsmap for gadget")
 			setter.SetMountNsMap(mountnsmap)
 
+			if cgroupSetter, ok := l.gadgetInstance.(CgroupMapSetter); ok {
+				cgroupmap, err := l.manager.igManager.CgroupMap(id.String())
+				if err != nil {
+					l.manager.igManager.RemoveMountNsMap(id.String())
+					return commonutils.WrapInErrManagerCreateMountNsMap(err)
+				}
+				if cgroupmap != nil {
+					log.Debugf("set cgroupmap for gadget")
+					cgroupSetter.SetCgroupMap(cgroupmap)
+				}
+			}
+
 			l.mountnsmap = mountnsmap
 		} else if l.manager.igManager == nil {
 			log.Warn("container-collection isn't available: container enrichment and filtering won't work")
@@ -312,6 +465,12 @@ func (l *localManagerTrace) PreGadgetRun() error {
 		var containers []*containercollection.Container
 
 		attachContainerFunc := func(container *containercollection.Container) {
+			// Attaching to the namespaces of the container would trace
+			// other processes as well, e.g. the whole host
+			if !container.HasOwnMntns() {
+				return
+			}
+
 			log.Debugf("calling gadget.AttachContainer()")
 			err := attacher.AttachContainer(container)
 			if err != nil {
@@ -373,6 +532,31 @@ func (l *localManagerTrace) PreGadgetRun() error {
 	return nil
 }
 
+// checkSystemdUnits checks that the systemd units selected with --unit can be
+// traced by a gadget attaching to the namespaces of the containers. Attaching
+// to the namespaces of units running in the ones of the host would trace the
+// other host processes as well: selecting one of them is an error, and they
+// are skipped with a warning when selecting a slice.
+func (l *localManagerTrace) checkSystemdUnits(selector *containercollection.ContainerSelector) error {
+	var skipped []string
+	for _, c := range l.manager.igManager.GetContainersBySelector(selector) {
+		if c.HasOwnMntns() || strings.HasSuffix(c.Runtime.ContainerName, systemd.SliceSuffix) {
+			continue
+		}
+		if c.Runtime.ContainerName == selector.Runtime.SystemdUnit {
+			return fmt.Errorf("systemd unit %q runs in the namespaces of the host and this gadget can't "+
+				"tell its events apart from the ones of other host processes: use --host instead", c.Runtime.ContainerName)
+		}
+		skipped = append(skipped, c.Runtime.ContainerName)
+	}
+	if len(skipped) > 0 {
+		sort.Strings(skipped)
+		l.gadgetCtx.Logger().Warnf("not tracing the systemd units running in the namespaces of the host: %s",
+			strings.Join(skipped, ", "))
+	}
+	return nil
+}
+
 func (l *localManagerTrace) PostGadgetRun() error {
 	if l.mountnsmap != nil {
 		log.Debugf("calling RemoveMountNsMap()")
@@ -71,6 +71,13 @@ type Operator interface {
 	Instantiate(gadgetContext GadgetContext, gadgetInstance any, params *params.Params) (OperatorInstance, error)
 }
 
+// OperatorWithOptionalDependencies can be implemented by operators that need to run after other
+// operators when those are used for the same gadget, but that don't require them
+type OperatorWithOptionalDependencies interface {
+	// OptionalDependencies lists the operators that need to run before this one if available
+	OptionalDependencies() []string
+}
+
 type OperatorInstance interface {
 	// Name returns the name of the operator instance
 	Name() string
@@ -272,7 +279,8 @@ func (oi OperatorInstances) Enrich(ev any) error {
 }
 
 // SortOperators builds a dependency tree of the given operator collection and sorts them by least dependencies first
-// Returns an error, if there are loops or missing dependencies
+// Returns an error, if there are loops or missing dependencies. Optional dependencies are only
+// taken into account if they are available in operators.
 func SortOperators(operators Operators) (Operators, error) {
 	// Create a map to store the incoming edge count for each element
 	incomingEdges := make(map[string]int)
@@ -281,9 +289,23 @@ func SortOperators(operators Operators) (Operators, error) {
 		incomingEdges[e.Name()] = 0
 	}
 
+	// Collect the dependencies of each element, including the available optional ones
+	dependencies := make(map[string][]string)
+	for _, e := range operators {
+		deps := append([]string(nil), e.Dependencies()...)
+		if o, ok := e.(OperatorWithOptionalDependencies); ok {
+			for _, d := range o.OptionalDependencies() {
+				if _, ok := incomingEdges[d]; ok {
+					deps = append(deps, d)
+				}
+			}
+		}
+		dependencies[e.Name()] = deps
+	}
+
 	// Build the graph by adding an incoming edge for each dependency
 	for _, e := range operators {
-		for _, d := range e.Dependencies() {
+		for _, d := range dependencies[e.Name()] {
 			incomingEdges[d]++
 		}
 	}
@@ -331,7 +353,7 @@ outerLoop:
 		}
 
 		// Decrement the incoming edge count for each of the element's dependencies
-		for _, d := range result[0].Dependencies() {
+		for _, d := range dependencies[result[0].Name()] {
 			incomingEdges[d]--
 			// If a dependency's incoming edge count becomes zero, add it to the queue
 			if incomingEdges[d] == 0 {
@@ -193,3 +193,25 @@ func Test_SortOperatorsLargeCyclicDep(t *testing.T) {
 	_, err := SortOperators(ops)
 	assert.ErrorContains(t, err, "dependency cycle detected")
 }
+
+type testOptionalOp struct {
+	testOp
+	optionalDependencies []string
+}
+
+func (op testOptionalOp) OptionalDependencies() []string {
+	return op.optionalDependencies
+}
+
+func Test_SortOperatorsOptionalDeps(t *testing.T) {
+	ops := Operators{
+		testOptionalOp{createOp("c", []string{}), []string{"b", "missing"}},
+		createOp("b", []string{"a"}),
+		createOp("a", []string{}),
+	}
+
+	sortedOps, err := SortOperators(ops)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"a", "b", "c"}, []string{sortedOps[0].Name(), sortedOps[1].Name(), sortedOps[2].Name()})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reversedns
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	name    string
+	expires time.Time
+}
+
+// cache keeps the names queried for each IP address seen in DNS answers. Entries are kept per
+// network namespace, as the same address can resolve to different names depending on the DNS
+// configuration of the containers.
+type cache struct {
+	mu sync.RWMutex
+	// key: network namespace inode id, then IP address
+	entries map[uint64]map[string]cacheEntry
+
+	// now can be overridden by tests
+	now func() time.Time
+}
+
+func newCache() *cache {
+	return &cache{
+		entries: make(map[uint64]map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+// add stores the name the given addresses resolve to in the netns, each of them for the
+// duration of the ttl at the same index
+func (c *cache) add(netns uint64, name string, addrs []string, ttls []time.Duration) {
+	if name == "" || len(addrs) == 0 || len(addrs) != len(ttls) {
+		return
+	}
+
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	netnsEntries, ok := c.entries[netns]
+	if !ok {
+		netnsEntries = make(map[string]cacheEntry)
+		c.entries[netns] = netnsEntries
+	}
+	for i, addr := range addrs {
+		netnsEntries[addr] = cacheEntry{name: name, expires: now.Add(ttls[i])}
+	}
+}
+
+// lookup returns the name of the address as seen in the netns. If netns is 0, the network
+// namespace of the event is unknown and the most recent answer from any network namespace is
+// used.
+func (c *cache) lookup(netns uint64, addr string) (string, bool) {
+	now := c.now()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if netns != 0 {
+		entry, ok := c.entries[netns][addr]
+		if !ok || now.After(entry.expires) {
+			return "", false
+		}
+		return entry.name, true
+	}
+
+	var found cacheEntry
+	for _, netnsEntries := range c.entries {
+		entry, ok := netnsEntries[addr]
+		if !ok || now.After(entry.expires) {
+			continue
+		}
+		if entry.expires.After(found.expires) {
+			found = entry
+		}
+	}
+	return found.name, found.name != ""
+}
+
+// gc removes expired entries
+func (c *cache) gc() {
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for netns, netnsEntries := range c.entries {
+		for addr, entry := range netnsEntries {
+			if now.After(entry.expires) {
+				delete(netnsEntries, addr)
+			}
+		}
+		if len(netnsEntries) == 0 {
+			delete(c.entries, netns)
+		}
+	}
+}
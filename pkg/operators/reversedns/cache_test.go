@@ -0,0 +1,82 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reversedns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	c := newCache()
+	c.now = func() time.Time { return now }
+
+	c.add(1, "api.example.com", []string{"192.0.2.1", "2001:db8::1"}, []time.Duration{time.Minute, time.Minute})
+	c.add(2, "other.example.com", []string{"192.0.2.1", "192.0.2.3"}, []time.Duration{2 * time.Minute, 30 * time.Second})
+	c.add(2, "", []string{"192.0.2.2"}, []time.Duration{time.Minute})
+
+	name, found := c.lookup(1, "192.0.2.1")
+	require.True(t, found)
+	require.Equal(t, "api.example.com", name)
+
+	name, found = c.lookup(1, "2001:db8::1")
+	require.True(t, found)
+	require.Equal(t, "api.example.com", name)
+
+	name, found = c.lookup(2, "192.0.2.1")
+	require.True(t, found)
+	require.Equal(t, "other.example.com", name)
+
+	_, found = c.lookup(2, "192.0.2.2")
+	require.False(t, found, "entries without name are ignored")
+
+	_, found = c.lookup(3, "192.0.2.1")
+	require.False(t, found, "entries are kept per network namespace")
+
+	// Unknown network namespace: the most recent answer wins
+	name, found = c.lookup(0, "192.0.2.1")
+	require.True(t, found)
+	require.Equal(t, "other.example.com", name)
+
+	name, found = c.lookup(2, "192.0.2.3")
+	require.True(t, found)
+	require.Equal(t, "other.example.com", name)
+
+	// Each address expires according to its own TTL
+	now = now.Add(45 * time.Second)
+	_, found = c.lookup(2, "192.0.2.3")
+	require.False(t, found)
+
+	// The entry of netns 1 expires first
+	now = now.Add(45 * time.Second)
+	_, found = c.lookup(1, "192.0.2.1")
+	require.False(t, found)
+	name, found = c.lookup(0, "192.0.2.1")
+	require.True(t, found)
+	require.Equal(t, "other.example.com", name)
+
+	c.gc()
+	require.NotContains(t, c.entries, uint64(1))
+	require.Contains(t, c.entries, uint64(2))
+
+	now = now.Add(time.Minute)
+	c.gc()
+	require.Empty(t, c.entries)
+}
@@ -0,0 +1,313 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reversedns provides an operator that enriches events with the hostnames of the IP
+// addresses they contain. The hostnames are taken from the DNS answers received by the containers,
+// so an address is shown with the name that was actually queried to reach it.
+package reversedns
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	dnstracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/tracer"
+	dnstypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/conntrackresolver"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperatorName = "ReverseDNS"
+
+	ParamResolveHostnames = "resolve-hostnames"
+	ParamMaxTTL           = "reverse-dns-max-ttl"
+
+	gcInterval = time.Minute
+)
+
+type ReverseDNSInterface interface {
+	GetEndpoints() []*types.L3Endpoint
+}
+
+// containerResolverGetter is implemented by the operators managing containers, like LocalManager
+// and KubeManager
+type containerResolverGetter interface {
+	ContainerResolver() containercollection.ContainerResolver
+}
+
+type ReverseDNS struct {
+	maxTTL time.Duration
+
+	mu       sync.Mutex
+	tracer   *dnstracer.Tracer
+	cache    *cache
+	refCount int
+	subKey   string
+	done     chan struct{}
+}
+
+func (r *ReverseDNS) Name() string {
+	return OperatorName
+}
+
+func (r *ReverseDNS) Description() string {
+	return "ReverseDNS resolves IP addresses to the hostnames found in DNS answers"
+}
+
+func (r *ReverseDNS) GlobalParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamMaxTTL,
+			DefaultValue: "5m",
+			Description:  "Maximum time to keep the hostnames found in DNS answers, regardless of their TTL",
+			TypeHint:     params.TypeDuration,
+		},
+	}
+}
+
+func (r *ReverseDNS) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamResolveHostnames,
+			DefaultValue: "false",
+			Description:  "Show the hostnames found in DNS answers for IP addresses",
+			TypeHint:     params.TypeBool,
+		},
+	}
+}
+
+func (r *ReverseDNS) Dependencies() []string {
+	return nil
+}
+
+func (r *ReverseDNS) OptionalDependencies() []string {
+	// Endpoints have to be resolved after the NAT destination and the Kubernetes metadata are
+	// known, so the hostnames aren't lost if those operators rewrite them. KubeIPResolver is
+	// referenced by name, as importing it would register it in ig, which doesn't use it.
+	return []string{conntrackresolver.OperatorName, "KubeIPResolver"}
+}
+
+func (r *ReverseDNS) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	_, hasReverseDNSInterface := gadget.EventPrototype().(ReverseDNSInterface)
+	return hasReverseDNSInterface
+}
+
+func (r *ReverseDNS) Init(params *params.Params) error {
+	r.maxTTL = params.Get(ParamMaxTTL).AsDuration()
+	return nil
+}
+
+func (r *ReverseDNS) Close() error {
+	return nil
+}
+
+func (r *ReverseDNS) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	return &ReverseDNSInstance{
+		gadgetCtx: gadgetCtx,
+		manager:   r,
+		enabled:   params.Get(ParamResolveHostnames).AsBool(),
+	}, nil
+}
+
+// getContainerResolver returns the container collection of the operator managing containers, if
+// any
+func getContainerResolver() containercollection.ContainerResolver {
+	for _, op := range operators.GetAll() {
+		getter, ok := operators.GetRaw(op.Name()).(containerResolverGetter)
+		if !ok {
+			continue
+		}
+		if resolver := getter.ContainerResolver(); resolver != nil {
+			return resolver
+		}
+	}
+	return nil
+}
+
+// start creates the dns tracer and attaches it to the host and all containers. It must be called
+// with the mutex held.
+func (r *ReverseDNS) start() error {
+	tracer, err := dnstracer.NewTracer()
+	if err != nil {
+		return fmt.Errorf("creating dns tracer: %w", err)
+	}
+
+	r.cache = newCache()
+	tracer.SetEventHandler(func(event *dnstypes.Event) {
+		if event.Type != types.NORMAL || event.Qr != dnstypes.DNSPktTypeResponse {
+			return
+		}
+		ttls := make([]time.Duration, len(event.Addresses))
+		for i := range ttls {
+			ttls[i] = r.maxTTL
+			if i < len(event.AddressTTLs) {
+				ttls[i] = min(time.Duration(event.AddressTTLs[i])*time.Second, r.maxTTL)
+			}
+		}
+		r.cache.add(event.NetNsID, strings.TrimSuffix(event.DNSName, "."), event.Addresses, ttls)
+	})
+
+	if err := tracer.Attach(1); err != nil {
+		log.Warnf("reverse dns: attaching to host network namespace: %v", err)
+	}
+
+	if resolver := getContainerResolver(); resolver != nil {
+		r.subKey = uuid.New().String()
+		containers := resolver.Subscribe(
+			r.subKey,
+			containercollection.ContainerSelector{},
+			func(event containercollection.PubSubEvent) {
+				switch event.Type {
+				case containercollection.EventTypeAddContainer:
+					if err := tracer.Attach(event.Container.Pid); err != nil {
+						log.Debugf("reverse dns: attaching to container %q: %v", event.Container.Runtime.ContainerID, err)
+					}
+				case containercollection.EventTypeRemoveContainer:
+					tracer.Detach(event.Container.Pid)
+				}
+			},
+		)
+		for _, container := range containers {
+			if err := tracer.Attach(container.Pid); err != nil {
+				log.Debugf("reverse dns: attaching to container %q: %v", container.Runtime.ContainerID, err)
+			}
+		}
+	} else {
+		log.Warnf("reverse dns: container-collection isn't available: only DNS answers from the host will be used")
+	}
+
+	r.done = make(chan struct{})
+	go func(c *cache, done chan struct{}) {
+		ticker := time.NewTicker(gcInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.gc()
+			}
+		}
+	}(r.cache, r.done)
+
+	r.tracer = tracer
+	return nil
+}
+
+// stop releases the resources created by start. It must be called with the mutex held.
+func (r *ReverseDNS) stop() {
+	if r.subKey != "" {
+		if resolver := getContainerResolver(); resolver != nil {
+			resolver.Unsubscribe(r.subKey)
+		}
+		r.subKey = ""
+	}
+	if r.done != nil {
+		close(r.done)
+		r.done = nil
+	}
+	if r.tracer != nil {
+		r.tracer.Close()
+		r.tracer = nil
+	}
+	r.cache = nil
+}
+
+type ReverseDNSInstance struct {
+	gadgetCtx operators.GadgetContext
+	manager   *ReverseDNS
+	enabled   bool
+	cache     *cache
+}
+
+func (i *ReverseDNSInstance) Name() string {
+	return "ReverseDNSInstance"
+}
+
+func (i *ReverseDNSInstance) PreGadgetRun() error {
+	if !i.enabled {
+		return nil
+	}
+
+	i.manager.mu.Lock()
+	defer i.manager.mu.Unlock()
+
+	if i.manager.refCount == 0 {
+		if err := i.manager.start(); err != nil {
+			return err
+		}
+	}
+	i.manager.refCount++
+	i.cache = i.manager.cache
+
+	return nil
+}
+
+func (i *ReverseDNSInstance) PostGadgetRun() error {
+	if !i.enabled {
+		return nil
+	}
+
+	i.manager.mu.Lock()
+	defer i.manager.mu.Unlock()
+
+	i.manager.refCount--
+	if i.manager.refCount == 0 {
+		i.manager.stop()
+	}
+	return nil
+}
+
+func (i *ReverseDNSInstance) enrich(ev any) {
+	event, ok := ev.(ReverseDNSInterface)
+	if !ok {
+		return
+	}
+
+	var netns uint64
+	if netnsEvent, ok := ev.(interface{ GetNetNSID() uint64 }); ok {
+		netns = netnsEvent.GetNetNSID()
+	}
+
+	for _, endpoint := range event.GetEndpoints() {
+		// Pods and services already have a name
+		if endpoint.Kind != "" && endpoint.Kind != types.EndpointKindRaw {
+			continue
+		}
+		if hostname, found := i.cache.lookup(netns, endpoint.Addr); found {
+			endpoint.Hostname = hostname
+		}
+	}
+}
+
+func (i *ReverseDNSInstance) EnrichEvent(ev any) error {
+	if i.cache == nil {
+		return nil
+	}
+	i.enrich(ev)
+	return nil
+}
+
+func init() {
+	operators.Register(&ReverseDNS{})
+}
@@ -89,6 +89,10 @@ type ParamDesc struct {
 	// PossibleValues holds all possible values for this parameter and will be considered
 	// when validating
 	PossibleValues []string `json:"possibleValues" yaml:"possibleValues,omitempty"`
+
+	// ValueDescriptions optionally holds an explanation for each of the PossibleValues,
+	// indexed by value; shown in user interfaces
+	ValueDescriptions map[string]string `json:"valueDescriptions,omitempty" yaml:"valueDescriptions,omitempty"`
 }
 
 // Param holds a ParamDesc but can additionally store a value
@@ -155,6 +159,16 @@ func (p *ParamDesc) IsBoolFlag() bool {
 	return p.TypeHint == TypeBool
 }
 
+// IsRepeatable returns whether the param holds a comma separated list of values that
+// can also be given by repeating the param, like "--labels a=b --labels c=d"
+func (p *ParamDesc) IsRepeatable() bool {
+	switch p.TypeHint {
+	case TypeStringMap, TypeCIDRList, TypePortRanges:
+		return true
+	}
+	return false
+}
+
 func (p ParamDescs) ToParams() *Params {
 	params := make(Params, 0, len(p))
 	for _, param := range p {
@@ -400,6 +414,14 @@ func (p *Param) AsAny() any {
 		return p.AsDuration()
 	case TypeIP:
 		return p.AsIP()
+	case TypeStringMap:
+		return p.AsStringMap()
+	case TypeCIDRList:
+		return p.AsCIDRs()
+	case TypePortRanges:
+		return p.AsPortRanges()
+	case TypeByteSize:
+		return p.AsByteSize()
 	default:
 		return p.value
 	}
@@ -529,3 +551,48 @@ func (p *Param) AsDuration() time.Duration {
 func (p *Param) AsIP() net.IP {
 	return net.ParseIP(p.value)
 }
+
+// AsStringMap returns the key=value pairs of the param. If a key is repeated, the
+// last value is used.
+func (p *Param) AsStringMap() map[string]string {
+	strs := p.AsStringSlice()
+	out := make(map[string]string, len(strs))
+
+	for _, entry := range strs {
+		key, value, _ := strings.Cut(entry, "=")
+		out[key] = value
+	}
+
+	return out
+}
+
+func (p *Param) AsCIDRs() []*net.IPNet {
+	strs := p.AsStringSlice()
+	out := make([]*net.IPNet, 0, len(strs))
+
+	for _, entry := range strs {
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			out = append(out, cidr)
+		}
+	}
+
+	return out
+}
+
+func (p *Param) AsPortRanges() []PortRange {
+	strs := p.AsStringSlice()
+	out := make([]PortRange, 0, len(strs))
+
+	for _, entry := range strs {
+		if r, err := ParsePortRange(entry); err == nil {
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
+
+func (p *Param) AsByteSize() uint64 {
+	n, _ := ParseByteSize(p.value)
+	return n
+}
@@ -221,6 +221,51 @@ func TestParamAs(t *testing.T) {
 			expected: net.IP{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
 			getter:   func(p *Param) any { return p.AsIP() },
 		},
+		{
+			name:     "StringMap()",
+			value:    "app=web,empty=,expr=a=b,app=db",
+			typeHint: TypeStringMap,
+			expected: map[string]string{"app": "db", "empty": "", "expr": "a=b"},
+			getter:   func(p *Param) any { return p.AsStringMap() },
+		},
+		{
+			name:     "StringMap()_empty",
+			value:    "",
+			typeHint: TypeStringMap,
+			expected: map[string]string{},
+			getter:   func(p *Param) any { return p.AsStringMap() },
+		},
+		{
+			name:     "CIDRs()",
+			value:    "10.1.2.3/8,fd00::/8",
+			typeHint: TypeCIDRList,
+			expected: []*net.IPNet{
+				{IP: net.IP{10, 0, 0, 0}, Mask: net.CIDRMask(8, 32)},
+				{IP: net.ParseIP("fd00::"), Mask: net.CIDRMask(8, 128)},
+			},
+			getter: func(p *Param) any { return p.AsCIDRs() },
+		},
+		{
+			name:     "PortRanges()",
+			value:    "53,8000-8080",
+			typeHint: TypePortRanges,
+			expected: []PortRange{{Start: 53, End: 53}, {Start: 8000, End: 8080}},
+			getter:   func(p *Param) any { return p.AsPortRanges() },
+		},
+		{
+			name:     "ByteSize()_binary",
+			value:    "64Ki",
+			typeHint: TypeByteSize,
+			expected: uint64(65536),
+			getter:   func(p *Param) any { return p.AsByteSize() },
+		},
+		{
+			name:     "ByteSize()_decimal",
+			value:    "2M",
+			typeHint: TypeByteSize,
+			expected: uint64(2_000_000),
+			getter:   func(p *Param) any { return p.AsByteSize() },
+		},
 	}
 
 	for _, test := range tests {
@@ -462,6 +507,45 @@ func TestBytesHandling(t *testing.T) {
 	require.Equal(t, testString, string(params[0].AsBytes()), "decompression + B64 decoding failed")
 }
 
+func TestStructuredValuesTransport(t *testing.T) {
+	// Structured values must be kept as they are when copied to and from maps,
+	// as done when sending them to the gadget service
+	descs := ParamDescs{
+		{Key: "labels", TypeHint: TypeStringMap},
+		{Key: "cidrs", TypeHint: TypeCIDRList},
+		{Key: "ports", TypeHint: TypePortRanges},
+		{Key: "size", TypeHint: TypeByteSize},
+		{Key: "layout", PossibleValues: []string{"a", "b"}, ValueDescriptions: map[string]string{"a": "A", "b": "B"}},
+	}
+	values := map[string]string{
+		"labels": "app=web,expr=a=b",
+		"cidrs":  "10.0.0.0/8,fd00::/8",
+		"ports":  "53,8000-8080",
+		"size":   "64Ki",
+		"layout": "b",
+	}
+
+	src := descs.ToParams()
+	for key, value := range values {
+		require.NoError(t, src.Set(key, value))
+	}
+
+	m := map[string]string{}
+	src.CopyToMap(m, "gadget.")
+
+	dst := descs.ToParams()
+	require.NoError(t, dst.CopyFromMap(m, "gadget."))
+	require.Equal(t, values, dst.ParamMap())
+	require.Equal(t, src.Get("labels").AsStringMap(), dst.Get("labels").AsStringMap())
+	require.Equal(t, src.Get("cidrs").AsCIDRs(), dst.Get("cidrs").AsCIDRs())
+	require.Equal(t, src.Get("ports").AsPortRanges(), dst.Get("ports").AsPortRanges())
+	require.Equal(t, src.Get("size").AsByteSize(), dst.Get("size").AsByteSize())
+
+	// Invalid values are rejected on the receiving side
+	m["gadget.ports"] = "80-70"
+	require.Error(t, dst.CopyFromMap(m, "gadget."))
+}
+
 func TestIsSet(t *testing.T) {
 	pd := ParamDesc{
 		DefaultValue: "foo",
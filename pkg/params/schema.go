@@ -0,0 +1,168 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package params
+
+import (
+	"math"
+	"strconv"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used to describe params
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Default     any    `json:"default,omitempty"`
+	Const       any    `json:"const,omitempty"`
+	Pattern     string `json:"pattern,omitempty"`
+	Minimum     any    `json:"minimum,omitempty"`
+	Maximum     any    `json:"maximum,omitempty"`
+
+	Enum  []string  `json:"enum,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+
+	// TypeHint is the type hint of the param, as some of them can't be expressed
+	// in JSON Schema, like durations
+	TypeHint TypeHint `json:"x-type-hint,omitempty"`
+}
+
+const (
+	portRangePattern = `^[0-9]+(-[0-9]+)?$`
+	byteSizePattern  = `^[0-9]+([kKMGTPE]|[KMGTPE]i)?$`
+)
+
+// JSONSchema returns a JSON Schema describing an object with one property per param.
+// Params holding lists or key=value pairs are described as arrays and objects; user
+// interfaces must join them with commas (and "=") before setting them.
+func (p ParamDescs) JSONSchema() *Schema {
+	schema := &Schema{
+		Schema:     jsonSchemaDraft,
+		Type:       "object",
+		Properties: make(map[string]*Schema, len(p)),
+	}
+	for _, param := range p {
+		schema.Properties[param.Key] = param.JSONSchema()
+		if param.IsMandatory && param.DefaultValue == "" {
+			schema.Required = append(schema.Required, param.Key)
+		}
+	}
+	return schema
+}
+
+// JSONSchema returns the JSON Schema of the values of the param
+func (p *ParamDesc) JSONSchema() *Schema {
+	schema := &Schema{
+		Type:        "string",
+		Title:       p.GetTitle(),
+		Description: p.Description,
+		TypeHint:    p.TypeHint,
+	}
+
+	if len(p.PossibleValues) > 0 {
+		if len(p.ValueDescriptions) == 0 {
+			schema.Enum = p.PossibleValues
+		} else {
+			for _, v := range p.PossibleValues {
+				schema.OneOf = append(schema.OneOf, &Schema{
+					Const:       v,
+					Description: p.ValueDescriptions[v],
+				})
+			}
+		}
+		if p.DefaultValue != "" {
+			schema.Default = p.DefaultValue
+		}
+		return schema
+	}
+
+	switch p.TypeHint {
+	case TypeBool:
+		schema.Type = "boolean"
+	case TypeInt, TypeInt8, TypeInt16, TypeInt32, TypeInt64:
+		bits := intBits(p.TypeHint)
+		schema.Type = "integer"
+		schema.Minimum = int64(-1) << (bits - 1)
+		schema.Maximum = int64(1<<(bits-1) - 1)
+	case TypeUint, TypeUint8, TypeUint16, TypeUint32, TypeUint64:
+		bits := intBits(p.TypeHint)
+		schema.Type = "integer"
+		schema.Minimum = 0
+		schema.Maximum = uint64(math.MaxUint64) >> (64 - bits)
+	case TypeFloat32, TypeFloat64:
+		schema.Type = "number"
+	case TypeStringMap:
+		schema.Type = "object"
+		schema.AdditionalProperties = &Schema{Type: "string"}
+	case TypeCIDRList:
+		schema.Type = "array"
+		schema.Items = &Schema{Type: "string"}
+	case TypePortRanges:
+		schema.Type = "array"
+		schema.Items = &Schema{Type: "string", Pattern: portRangePattern}
+	case TypeByteSize:
+		schema.Pattern = byteSizePattern
+	}
+
+	if p.DefaultValue != "" {
+		schema.Default = schemaDefault(p)
+	}
+
+	return schema
+}
+
+func intBits(typeHint TypeHint) int {
+	switch typeHint {
+	case TypeInt8, TypeUint8:
+		return 8
+	case TypeInt16, TypeUint16:
+		return 16
+	case TypeInt32, TypeUint32:
+		return 32
+	case TypeInt64, TypeUint64:
+		return 64
+	default:
+		return strconv.IntSize
+	}
+}
+
+// schemaDefault returns the default value of the param as it's described by its
+// schema
+func schemaDefault(p *ParamDesc) any {
+	param := p.ToParam()
+	switch p.TypeHint {
+	case TypeBool:
+		return param.AsBool()
+	case TypeInt, TypeInt8, TypeInt16, TypeInt32, TypeInt64:
+		return param.AsInt64()
+	case TypeUint, TypeUint8, TypeUint16, TypeUint32, TypeUint64:
+		return param.AsUint64()
+	case TypeFloat32, TypeFloat64:
+		return param.AsFloat64()
+	case TypeStringMap:
+		return param.AsStringMap()
+	case TypeCIDRList, TypePortRanges:
+		return param.AsStringSlice()
+	default:
+		return p.DefaultValue
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package params
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	descs := ParamDescs{
+		{
+			Key:          "count",
+			Description:  "Number of events",
+			DefaultValue: "10",
+			TypeHint:     TypeUint8,
+		},
+		{
+			Key:          "verbose",
+			DefaultValue: "false",
+			TypeHint:     TypeBool,
+		},
+		{
+			Key:          "labels",
+			DefaultValue: "app=web",
+			TypeHint:     TypeStringMap,
+		},
+		{
+			Key:         "ports",
+			TypeHint:    TypePortRanges,
+			IsMandatory: true,
+		},
+		{
+			Key:          "layout",
+			Title:        "Layout",
+			DefaultValue: "a",
+			PossibleValues: []string{
+				"a",
+				"b",
+			},
+			ValueDescriptions: map[string]string{
+				"a": "The A layout",
+				"b": "The B layout",
+			},
+		},
+	}
+
+	schema, err := json.Marshal(descs.JSONSchema())
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"count": {
+				"type": "integer",
+				"title": "Count",
+				"description": "Number of events",
+				"default": 10,
+				"minimum": 0,
+				"maximum": 255,
+				"x-type-hint": "uint8"
+			},
+			"verbose": {
+				"type": "boolean",
+				"title": "Verbose",
+				"default": false,
+				"x-type-hint": "bool"
+			},
+			"labels": {
+				"type": "object",
+				"title": "Labels",
+				"default": {"app": "web"},
+				"additionalProperties": {"type": "string"},
+				"x-type-hint": "map"
+			},
+			"ports": {
+				"type": "array",
+				"title": "Ports",
+				"items": {"type": "string", "pattern": "^[0-9]+(-[0-9]+)?$"},
+				"x-type-hint": "ports"
+			},
+			"layout": {
+				"type": "string",
+				"title": "Layout",
+				"default": "a",
+				"oneOf": [
+					{"const": "a", "description": "The A layout"},
+					{"const": "b", "description": "The B layout"}
+				]
+			}
+		},
+		"required": ["ports"]
+	}`, string(schema))
+}
@@ -539,3 +539,151 @@ func TestValidateIP(t *testing.T) {
 		ValidateIP,
 	)
 }
+
+func TestValidateKeyValue(t *testing.T) {
+	testValidate(t,
+		[]validateTest{
+			{
+				name:          "key_value_no_error",
+				value:         "app=web",
+				expectedError: false,
+			},
+			{
+				name:          "empty_value_no_error",
+				value:         "app=",
+				expectedError: false,
+			},
+			{
+				name:          "value_with_equal_no_error",
+				value:         "expr=a=b",
+				expectedError: false,
+			},
+			{
+				name:          "no_equal_error",
+				value:         "app",
+				expectedError: true,
+			},
+			{
+				name:          "empty_key_error",
+				value:         "=web",
+				expectedError: true,
+			},
+		},
+		ValidateKeyValue,
+	)
+}
+
+func TestValidateCIDR(t *testing.T) {
+	testValidate(t,
+		[]validateTest{
+			{
+				name:          "IPv4_no_error",
+				value:         "10.0.0.0/8",
+				expectedError: false,
+			},
+			{
+				name:          "IPv6_no_error",
+				value:         "fd00::/8",
+				expectedError: false,
+			},
+			{
+				name:          "no_mask_error",
+				value:         "10.0.0.1",
+				expectedError: true,
+			},
+			{
+				name:          "bad_mask_error",
+				value:         "10.0.0.0/33",
+				expectedError: true,
+			},
+			{
+				name:          "empty_error",
+				value:         "",
+				expectedError: true,
+			},
+		},
+		ValidateCIDR,
+	)
+}
+
+func TestValidatePortRange(t *testing.T) {
+	testValidate(t,
+		[]validateTest{
+			{
+				name:          "port_no_error",
+				value:         "53",
+				expectedError: false,
+			},
+			{
+				name:          "range_no_error",
+				value:         "8000-8080",
+				expectedError: false,
+			},
+			{
+				name:          "single_port_range_no_error",
+				value:         "80-80",
+				expectedError: false,
+			},
+			{
+				name:          "reversed_range_error",
+				value:         "8080-8000",
+				expectedError: true,
+			},
+			{
+				name:          "out_of_range_error",
+				value:         "65536",
+				expectedError: true,
+			},
+			{
+				name:          "open_range_error",
+				value:         "8000-",
+				expectedError: true,
+			},
+			{
+				name:          "empty_error",
+				value:         "",
+				expectedError: true,
+			},
+		},
+		ValidatePortRange,
+	)
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value         string
+		expected      uint64
+		expectedError bool
+	}{
+		{value: "0", expected: 0},
+		{value: "512", expected: 512},
+		{value: "1k", expected: 1000},
+		{value: "1K", expected: 1000},
+		{value: "64Ki", expected: 64 << 10},
+		{value: "3M", expected: 3_000_000},
+		{value: "3Mi", expected: 3 << 20},
+		{value: "1Gi", expected: 1 << 30},
+		{value: "15Ei", expected: 15 << 60},
+		{value: "16Ei", expectedError: true},
+		{value: "1ki", expectedError: true},
+		{value: "1KB", expectedError: true},
+		{value: "Ki", expectedError: true},
+		{value: "-1", expectedError: true},
+		{value: "1.5Gi", expectedError: true},
+		{value: "", expectedError: true},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.value, func(t *testing.T) {
+			size, err := ParseByteSize(test.value)
+			if test.expectedError {
+				require.Error(t, err)
+				require.Error(t, ValidateByteSize(test.value))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, size)
+		})
+	}
+}
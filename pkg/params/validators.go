@@ -16,6 +16,7 @@ package params
 
 import (
 	"fmt"
+	"math/bits"
 	"net"
 	"strconv"
 	"strings"
@@ -43,6 +44,16 @@ const (
 	TypeFloat64  TypeHint = "float64"
 	TypeDuration TypeHint = "duration"
 	TypeIP       TypeHint = "ip"
+
+	// TypeStringMap holds comma separated key=value pairs, e.g. "app=web,tier=db"
+	TypeStringMap TypeHint = "map"
+	// TypeCIDRList holds comma separated CIDRs, e.g. "10.0.0.0/8,fd00::/8"
+	TypeCIDRList TypeHint = "cidrs"
+	// TypePortRanges holds comma separated ports or port ranges, e.g. "53,8000-8080"
+	TypePortRanges TypeHint = "ports"
+	// TypeByteSize holds a number of bytes with an optional decimal (k, M, G, T,
+	// P, E) or binary (Ki, Mi, Gi, Ti, Pi, Ei) suffix, e.g. "64Ki"
+	TypeByteSize TypeHint = "bytesize"
 )
 
 var typeHintValidators = map[TypeHint]ParamValidator{
@@ -61,6 +72,11 @@ var typeHintValidators = map[TypeHint]ParamValidator{
 	TypeFloat64:  ValidateFloat(64),
 	TypeDuration: ValidateDuration,
 	TypeIP:       ValidateIP,
+
+	TypeStringMap:  ValidateSlice(ValidateKeyValue),
+	TypeCIDRList:   ValidateSlice(ValidateCIDR),
+	TypePortRanges: ValidateSlice(ValidatePortRange),
+	TypeByteSize:   ValidateByteSize,
 }
 
 type ValueHint string
@@ -157,3 +173,110 @@ func ValidateIP(value string) error {
 	}
 	return nil
 }
+
+func ValidateKeyValue(value string) error {
+	key, _, found := strings.Cut(value, "=")
+	if !found {
+		return fmt.Errorf("expected key=value, got: %q", value)
+	}
+	if key == "" {
+		return fmt.Errorf("empty key in %q", value)
+	}
+	return nil
+}
+
+func ValidateCIDR(value string) error {
+	if _, _, err := net.ParseCIDR(value); err != nil {
+		return fmt.Errorf("%q is not a valid CIDR", value)
+	}
+	return nil
+}
+
+func ValidatePortRange(value string) error {
+	_, err := ParsePortRange(value)
+	return err
+}
+
+func ValidateByteSize(value string) error {
+	_, err := ParseByteSize(value)
+	return err
+}
+
+// PortRange is an inclusive range of ports
+type PortRange struct {
+	Start uint16
+	End   uint16
+}
+
+// ParsePortRange parses a single port like "53" or a range like "8000-8080"
+func ParsePortRange(value string) (PortRange, error) {
+	startStr, endStr, isRange := strings.Cut(value, "-")
+	start, err := strconv.ParseUint(startStr, 10, 16)
+	if err != nil {
+		return PortRange{}, fmt.Errorf("expected port or port range, got: %q", value)
+	}
+	if !isRange {
+		return PortRange{Start: uint16(start), End: uint16(start)}, nil
+	}
+
+	end, err := strconv.ParseUint(endStr, 10, 16)
+	if err != nil {
+		return PortRange{}, fmt.Errorf("expected port or port range, got: %q", value)
+	}
+	if end < start {
+		return PortRange{}, fmt.Errorf("invalid port range %q: end is lower than start", value)
+	}
+	return PortRange{Start: uint16(start), End: uint16(end)}, nil
+}
+
+func (r PortRange) Contains(port uint16) bool {
+	return port >= r.Start && port <= r.End
+}
+
+func (r PortRange) String() string {
+	if r.Start == r.End {
+		return strconv.FormatUint(uint64(r.Start), 10)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+var byteSizeSuffixes = map[string]uint64{
+	"":   1,
+	"k":  1e3,
+	"K":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// ParseByteSize parses a number of bytes with an optional decimal (k, M, G,
+// T, P, E) or binary (Ki, Mi, Gi, Ti, Pi, Ei) suffix, like "64Ki" or "1G"
+func ParseByteSize(value string) (uint64, error) {
+	i := strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' })
+	if i == -1 {
+		i = len(value)
+	}
+
+	multiplier, ok := byteSizeSuffixes[value[i:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid size suffix %q in %q", value[i:], value)
+	}
+	number, err := strconv.ParseUint(value[:i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected size like \"64Ki\", got: %q", value)
+	}
+
+	hi, size := bits.Mul64(number, multiplier)
+	if hi != 0 {
+		return 0, fmt.Errorf("size %q is too big", value)
+	}
+	return size, nil
+}
@@ -0,0 +1,363 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// reservoirInterval is the time window in which events are collected per key
+// when using reservoir sampling
+const reservoirInterval = time.Second
+
+// LostSamplesGetter is implemented by events that can report the number of
+// events lost by the kernel (see types.Lost)
+type LostSamplesGetter interface {
+	GetLostSamples() uint64
+}
+
+// Limits configures sampling and rate limiting of events before they are
+// passed downstream. Errors, warnings and other messages are never dropped.
+type Limits struct {
+	// SampleRate keeps only every n-th event; 0 and 1 keep all events
+	SampleRate uint32
+
+	// SampleKeys are the columns identifying groups of events; if set,
+	// ReservoirSize random events per group are passed downstream every second
+	SampleKeys []string
+
+	// ReservoirSize is the number of events kept per group and second
+	ReservoirSize uint32
+
+	// RateLimit is the maximum number of events per second passed downstream;
+	// 0 disables rate limiting
+	RateLimit float64
+
+	// RateBurst is the number of events that can be passed downstream at once
+	// before RateLimit applies; defaults to RateLimit
+	RateBurst uint32
+}
+
+func (l Limits) enabled() bool {
+	return l.SampleRate > 1 || len(l.SampleKeys) > 0 || l.RateLimit != 0
+}
+
+type reservoirEntry[T any] struct {
+	ev   *T
+	emit func(*T)
+}
+
+type reservoir[T any] struct {
+	seen    int
+	entries []reservoirEntry[T]
+}
+
+// limiter drops events according to Limits and counts them
+type limiter[T any] struct {
+	now  func() time.Time
+	rand *rand.Rand
+
+	sampleRate  uint32
+	sampleCount uint32
+
+	keyFuncs      []func(*T) string
+	reservoirSize int
+	reservoirs    map[string]*reservoir[T]
+
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	sampled     uint64
+	rateLimited uint64
+}
+
+func newLimiter[T any](cols *columns.Columns[T], limits Limits) (*limiter[T], error) {
+	if limits.RateLimit < 0 {
+		return nil, fmt.Errorf("rate limit must not be negative")
+	}
+
+	l := &limiter[T]{
+		now:        time.Now,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		sampleRate: limits.SampleRate,
+		rate:       limits.RateLimit,
+	}
+
+	if len(limits.SampleKeys) > 0 {
+		if limits.ReservoirSize == 0 {
+			return nil, fmt.Errorf("number of events per key must be greater than 0")
+		}
+		for _, key := range limits.SampleKeys {
+			col, ok := cols.GetColumn(key)
+			if !ok {
+				return nil, fmt.Errorf("sample key %q: column not found", key)
+			}
+			l.keyFuncs = append(l.keyFuncs, columns.GetFieldAsString[T](col))
+		}
+		l.reservoirSize = int(limits.ReservoirSize)
+		l.reservoirs = make(map[string]*reservoir[T])
+	}
+
+	if l.rate > 0 {
+		l.burst = float64(limits.RateBurst)
+		if l.burst == 0 {
+			l.burst = math.Max(1, math.Ceil(l.rate))
+		}
+		l.tokens = l.burst
+		l.last = l.now()
+	}
+
+	return l, nil
+}
+
+// allow takes a token from the bucket, if rate limiting is enabled; it has to
+// be called with the lock of the parser held
+func (l *limiter[T]) allow() bool {
+	if l.rate == 0 {
+		return true
+	}
+	now := l.now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens < 1 {
+		l.rateLimited++
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+func (l *limiter[T]) key(ev *T) string {
+	values := make([]string, 0, len(l.keyFuncs))
+	for _, f := range l.keyFuncs {
+		values = append(values, f(ev))
+	}
+	return strings.Join(values, "\x00")
+}
+
+// add decides whether to pass ev downstream and returns true if that should
+// happen immediately; events kept for reservoir sampling are passed to emit on
+// the next flush. It has to be called with the lock of the parser held.
+func (l *limiter[T]) add(ev *T, emit func(*T)) bool {
+	if l.sampleRate > 1 {
+		l.sampleCount++
+		if l.sampleCount < l.sampleRate {
+			l.sampled++
+			return false
+		}
+		l.sampleCount = 0
+	}
+
+	if l.reservoirs == nil {
+		return l.allow()
+	}
+
+	key := l.key(ev)
+	r, ok := l.reservoirs[key]
+	if !ok {
+		r = &reservoir[T]{}
+		l.reservoirs[key] = r
+	}
+	r.seen++
+	entry := reservoirEntry[T]{ev: ev, emit: emit}
+	if len(r.entries) < l.reservoirSize {
+		r.entries = append(r.entries, entry)
+		return false
+	}
+	// Replace a random entry so that every event of the window has the same
+	// chance of being kept
+	if j := l.rand.Intn(r.seen); j < l.reservoirSize {
+		r.entries[j] = entry
+	}
+	l.sampled++
+	return false
+}
+
+// collect returns the events kept for reservoir sampling since the last call
+// that pass the rate limit; it has to be called with the lock of the parser
+// held
+func (l *limiter[T]) collect() []reservoirEntry[T] {
+	keys := make([]string, 0, len(l.reservoirs))
+	for key := range l.reservoirs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries []reservoirEntry[T]
+	for _, key := range keys {
+		for _, entry := range l.reservoirs[key].entries {
+			if l.allow() {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	l.reservoirs = make(map[string]*reservoir[T])
+	return entries
+}
+
+func (l *limiter[T]) dropped() types.DroppedEvents {
+	return types.DroppedEvents{
+		Sampled:     l.sampled,
+		RateLimited: l.rateLimited,
+	}
+}
+
+func (p *parser[T]) SetLimits(limits Limits) (func(), error) {
+	if !limits.enabled() {
+		return func() {}, nil
+	}
+
+	l, err := newLimiter(p.columns, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	p.limitsLock.Lock()
+	p.limiter = l
+	p.limitsLock.Unlock()
+
+	if l.reservoirs == nil {
+		return func() {}, nil
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(reservoirInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.flushReservoirs()
+			case <-done:
+				p.flushReservoirs()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}, nil
+}
+
+func (p *parser[T]) flushReservoirs() {
+	p.limitsLock.Lock()
+	entries := p.limiter.collect()
+	p.limitsLock.Unlock()
+
+	for _, entry := range entries {
+		entry.emit(entry.ev)
+	}
+}
+
+// limit passes ev to emit unless it is dropped by the limits
+func (p *parser[T]) limit(ev *T, emit func(*T)) {
+	if getter, ok := any(ev).(ErrorGetter); ok {
+		switch getter.GetType() {
+		case types.ERR, types.WARN, types.DEBUG, types.INFO, types.READY:
+			emit(ev)
+			return
+		}
+	}
+
+	p.limitsLock.Lock()
+	if p.limiter == nil {
+		p.limitsLock.Unlock()
+		emit(ev)
+		return
+	}
+	pass := p.limiter.add(ev, emit)
+	p.limitsLock.Unlock()
+
+	if pass {
+		emit(ev)
+	}
+}
+
+// countLost returns true if ev only reports events lost by the kernel, after
+// adding them to the dropped events
+func (p *parser[T]) countLost(ev *T) bool {
+	getter, ok := any(ev).(LostSamplesGetter)
+	if !ok {
+		return false
+	}
+	lost := getter.GetLostSamples()
+	if lost == 0 {
+		return false
+	}
+
+	p.limitsLock.Lock()
+	p.lost += lost
+	p.limitsLock.Unlock()
+	return true
+}
+
+// countLostArray returns events without the ones only reporting events lost
+// by the kernel, after adding them to the dropped events
+func (p *parser[T]) countLostArray(events []*T) []*T {
+	var out []*T
+	for i, ev := range events {
+		if p.countLost(ev) {
+			if out == nil {
+				out = make([]*T, i, len(events)-1)
+				copy(out, events[:i])
+			}
+			continue
+		}
+		if out != nil {
+			out = append(out, ev)
+		}
+	}
+	if out == nil {
+		return events
+	}
+	return out
+}
+
+func (p *parser[T]) ReportDropped(key string, dropped types.DroppedEvents) {
+	p.limitsLock.Lock()
+	defer p.limitsLock.Unlock()
+
+	if p.remoteDropped == nil {
+		p.remoteDropped = make(map[string]types.DroppedEvents)
+	}
+	p.remoteDropped[key] = dropped
+}
+
+func (p *parser[T]) DroppedEvents() types.DroppedEvents {
+	p.limitsLock.Lock()
+	defer p.limitsLock.Unlock()
+
+	dropped := types.DroppedEvents{Lost: p.lost}
+	if p.limiter != nil {
+		dropped.Add(p.limiter.dropped())
+	}
+	for _, remote := range p.remoteDropped {
+		dropped.Add(remote)
+	}
+	return dropped
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type limitsEvent struct {
+	types.Event
+	Comm string `column:"comm"`
+	Pid  uint32 `column:"pid"`
+}
+
+func newLimitsParser(t *testing.T, limits Limits) (*parser[limitsEvent], func(*limitsEvent), *[]*limitsEvent) {
+	p := NewParser(columns.MustCreateColumns[limitsEvent]()).(*parser[limitsEvent])
+
+	var received []*limitsEvent
+	p.SetEventCallback(func(ev *limitsEvent) {
+		received = append(received, ev)
+	})
+
+	stop, err := p.SetLimits(limits)
+	require.NoError(t, err)
+	t.Cleanup(stop)
+
+	return p, p.EventHandlerFunc().(func(*limitsEvent)), &received
+}
+
+func TestLostSamples(t *testing.T) {
+	t.Parallel()
+
+	p, handler, received := newLimitsParser(t, Limits{})
+
+	handler(&limitsEvent{Event: types.Lost(5)})
+	handler(&limitsEvent{Event: types.Lost(2)})
+	handler(&limitsEvent{Comm: "cat"})
+
+	require.Len(t, *received, 1)
+	require.Equal(t, types.DroppedEvents{Lost: 7}, p.DroppedEvents())
+
+	p.ReportDropped("node1", types.DroppedEvents{Buffer: 3})
+	p.ReportDropped("node2", types.DroppedEvents{Sampled: 1})
+	p.ReportDropped("node1", types.DroppedEvents{Buffer: 4})
+	require.Equal(t, types.DroppedEvents{Lost: 7, Buffer: 4, Sampled: 1}, p.DroppedEvents())
+}
+
+func TestLostSamplesArray(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser(columns.MustCreateColumns[limitsEvent]()).(*parser[limitsEvent])
+
+	var received []*limitsEvent
+	p.SetEventCallback(func(events []*limitsEvent) {
+		received = events
+	})
+	handler := p.EventHandlerFuncArray().(func([]*limitsEvent))
+
+	handler([]*limitsEvent{{Comm: "cat"}, {Event: types.Lost(5)}, {Comm: "ls"}})
+
+	require.Len(t, received, 2)
+	require.Equal(t, "cat", received[0].Comm)
+	require.Equal(t, "ls", received[1].Comm)
+	require.Equal(t, types.DroppedEvents{Lost: 5}, p.DroppedEvents())
+}
+
+func TestSampleRate(t *testing.T) {
+	t.Parallel()
+
+	p, handler, received := newLimitsParser(t, Limits{SampleRate: 3})
+
+	for i := uint32(1); i <= 10; i++ {
+		handler(&limitsEvent{Pid: i})
+	}
+	// Messages are never sampled
+	handler(&limitsEvent{Event: types.Warn("warning")})
+
+	pids := []uint32{}
+	for _, ev := range *received {
+		pids = append(pids, ev.Pid)
+	}
+	require.Equal(t, []uint32{3, 6, 9, 0}, pids)
+	require.Equal(t, types.DroppedEvents{Sampled: 7}, p.DroppedEvents())
+}
+
+func TestSamplePerKey(t *testing.T) {
+	t.Parallel()
+
+	p, handler, received := newLimitsParser(t, Limits{SampleKeys: []string{"comm"}, ReservoirSize: 2})
+
+	for i := uint32(1); i <= 10; i++ {
+		handler(&limitsEvent{Comm: "cat", Pid: i})
+	}
+	handler(&limitsEvent{Comm: "ls"})
+	require.Empty(t, *received)
+
+	p.flushReservoirs()
+
+	require.Len(t, *received, 3)
+	require.Equal(t, "cat", (*received)[0].Comm)
+	require.Equal(t, "cat", (*received)[1].Comm)
+	require.Equal(t, "ls", (*received)[2].Comm)
+	require.Equal(t, types.DroppedEvents{Sampled: 8}, p.DroppedEvents())
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	p, handler, received := newLimitsParser(t, Limits{RateLimit: 2, RateBurst: 3})
+
+	now := time.Now()
+	p.limiter.now = func() time.Time { return now }
+	p.limiter.last = now
+
+	for i := 0; i < 5; i++ {
+		handler(&limitsEvent{})
+	}
+	require.Len(t, *received, 3)
+
+	// Two more events are allowed after one second
+	now = now.Add(time.Second)
+	for i := 0; i < 5; i++ {
+		handler(&limitsEvent{})
+	}
+	require.Len(t, *received, 5)
+	require.Equal(t, types.DroppedEvents{RateLimited: 5}, p.DroppedEvents())
+}
+
+func TestLimitsInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]Limits{
+		"unknown_key":     {SampleKeys: []string{"unknown"}, ReservoirSize: 1},
+		"empty_reservoir": {SampleKeys: []string{"comm"}},
+		"negative_rate":   {RateLimit: -1},
+	}
+
+	for name, limits := range tests {
+		limits := limits
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := NewParser(columns.MustCreateColumns[limitsEvent]())
+			_, err := p.SetLimits(limits)
+			require.Error(t, err)
+		})
+	}
+}
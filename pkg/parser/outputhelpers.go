@@ -16,7 +16,11 @@ package parser
 
 import (
 	"encoding/json"
+	"fmt"
+	"sync"
 
+	columns_json "github.com/inspektor-gadget/inspektor-gadget/pkg/columns/formatter/json"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/formatter/parquet"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/formatter/textcolumns"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
@@ -132,3 +136,94 @@ func (oh *outputHelper[T]) SetEnableExtraLines(newVal bool) {
 	}
 	oh.enableExtraLines = newVal
 }
+
+// ParquetWriter is the interface used for parquetHelper
+type ParquetWriter interface {
+	EventHandlerFunc() any
+	EventHandlerFuncArray() any
+	Close() error
+}
+
+// parquetHelper writes events to a Parquet file; log messages sent as events are
+// forwarded to the log callback of the parser instead
+type parquetHelper[T any] struct {
+	parser    *parser[T]
+	formatter *parquet.Formatter[T]
+	mu        sync.Mutex
+	err       error
+}
+
+func (ph *parquetHelper[T]) writeEntry(ev *T) {
+	if getter, ok := any(ev).(ErrorGetter); ok {
+		switch getter.GetType() {
+		case types.ERR:
+			ph.parser.writeLogMessage(logger.ErrorLevel, getter.GetMessage())
+			return
+		case types.WARN:
+			ph.parser.writeLogMessage(logger.WarnLevel, getter.GetMessage())
+			return
+		case types.DEBUG:
+			ph.parser.writeLogMessage(logger.DebugLevel, getter.GetMessage())
+			return
+		case types.INFO:
+			ph.parser.writeLogMessage(logger.InfoLevel, getter.GetMessage())
+			return
+		}
+	}
+
+	if ph.err != nil {
+		return
+	}
+	if err := ph.formatter.WriteEntry(ev); err != nil {
+		ph.err = err
+		ph.parser.writeLogMessage(logger.ErrorLevel, "writing parquet file: %v", err)
+	}
+}
+
+func (ph *parquetHelper[T]) EventHandlerFunc() any {
+	return func(ev *T) {
+		ph.mu.Lock()
+		defer ph.mu.Unlock()
+		ph.writeEntry(ev)
+	}
+}
+
+func (ph *parquetHelper[T]) EventHandlerFuncArray() any {
+	return func(events []*T) {
+		ph.mu.Lock()
+		defer ph.mu.Unlock()
+		for _, ev := range events {
+			ph.writeEntry(ev)
+		}
+	}
+}
+
+// Close writes the remaining events and the footer of the file
+func (ph *parquetHelper[T]) Close() error {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	if ph.err != nil {
+		return ph.err
+	}
+	return ph.formatter.Close()
+}
+
+// JSONFormatter encodes events using the columns, like the JSON output of the
+// run gadget
+type JSONFormatter interface {
+	// FormatEvent returns the JSON representation of ev, which has to be of
+	// type *T
+	FormatEvent(ev any) (string, error)
+}
+
+type jsonHelper[T any] struct {
+	formatter *columns_json.Formatter[T]
+}
+
+func (jh *jsonHelper[T]) FormatEvent(ev any) (string, error) {
+	entry, ok := ev.(*T)
+	if !ok {
+		return "", fmt.Errorf("unexpected event type %T", ev)
+	}
+	return jh.formatter.FormatEntry(entry), nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+)
+
+type jsonEvent struct {
+	Comm     string `json:"command" column:"comm"`
+	Pid      uint32 `column:"pid"`
+	Internal string `column:"internal,hide"`
+}
+
+func TestJSONFormatter(t *testing.T) {
+	t.Parallel()
+
+	p := NewParser(columns.MustCreateColumns[jsonEvent]())
+	formatter := p.GetJSONFormatter()
+
+	out, err := formatter.FormatEvent(&jsonEvent{Comm: "cat", Pid: 42, Internal: "x"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"comm": "cat", "pid": 42, "internal": "x"}`, out)
+
+	_, err = formatter.FormatEvent(jsonEvent{})
+	require.Error(t, err)
+}
@@ -24,6 +24,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"reflect"
 	"sync"
 	"time"
@@ -32,10 +33,13 @@ import (
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/filter"
+	columns_json "github.com/inspektor-gadget/inspektor-gadget/pkg/columns/formatter/json"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/formatter/parquet"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/formatter/textcolumns"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/sort"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/snapshotcombiner"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
 type LogCallback func(severity logger.Level, fmt string, params ...any)
@@ -51,6 +55,16 @@ type Parser interface {
 	// GetTextColumnsFormatter returns the default formatter for this columns instance
 	GetTextColumnsFormatter(options ...textcolumns.Option) TextColumnsFormatter
 
+	// GetJSONFormatter returns a formatter encoding events as JSON using the columns
+	GetJSONFormatter(options ...columns_json.Option) JSONFormatter
+
+	// GetParquetWriter returns a writer storing events in the Parquet format to w; all columns are written
+	GetParquetWriter(w io.Writer, options ...parquet.Option) ParquetWriter
+
+	// GetEventTable returns a table keeping up to maxEvents events in memory, which can be sorted, filtered and
+	// rendered on demand; used by interactive frontends
+	GetEventTable(maxEvents int) EventTable
+
 	// GetColumnAttributes returns a map of column names to their respective attributes
 	GetColumnAttributes() []columns.Attributes
 
@@ -87,12 +101,40 @@ type Parser interface {
 	JSONHandlerFunc(enrichers ...func(any) error) func([]byte)
 	JSONHandlerFuncArray(key string, enrichers ...func(any) error) func([]byte)
 
+	// JSONRowsFunc returns a function that accepts a JSON encoded event or array of events and returns the values
+	// of all columns (in the order of GetColumnAttributes) formatted as strings, one row per event, after applying
+	// enrichers. Callbacks and filters are not involved; it's used by frontends rendering tables on their own.
+	JSONRowsFunc(enrichers ...func(any) error) func([]byte) ([][]string, error)
+
 	// SetEventCallback sets the downstream callback
 	SetEventCallback(eventCallback any)
 
 	// SetLogCallback sets the function to use to send log messages
 	SetLogCallback(logCallback LogCallback)
 
+	// SetSinkCallback sets a function receiving every event (*T) after enrichers and filters were
+	// applied, in addition to the downstream callback. Events of arrays are passed one by one. It
+	// is used to forward events to output sinks.
+	SetSinkCallback(sinkCallback func(any))
+
+	// SetLimits configures sampling and rate limiting of single events after filters were applied; events of
+	// arrays are not affected. When sampling per key, events are collected and passed downstream every second.
+	// The returned function passes the remaining events downstream and has to be called after the gadget stopped.
+	SetLimits(limits Limits) (func(), error)
+
+	// DroppedEvents returns the number of events dropped so far, including events lost by the kernel and the ones
+	// reported using ReportDropped
+	DroppedEvents() types.DroppedEvents
+
+	// ReportDropped sets the total number of events dropped by the source identified by key (e.g. a node), replacing
+	// earlier reports of the same source
+	ReportDropped(key string, dropped types.DroppedEvents)
+
+	// SetRecordCallback sets a function receiving the events after enrichers, but before filters were applied,
+	// i.e. the raw stream of the gadget. Events are passed as *T, arrays as []*T together with the key identifying
+	// their source (e.g. the node) or an empty string if unknown. It is used to record gadget runs.
+	SetRecordCallback(recordCallback func(key string, ev any))
+
 	// EnableSnapshots initializes the snapshot combiner, which is able to aggregate snapshots from several sources
 	// and can return (optionally cached) results on demand; used for top gadgets
 	EnableSnapshots(ctx context.Context, t time.Duration, ttl int)
@@ -134,9 +176,17 @@ type parser[T any] struct {
 	eventCallback      func(*T)
 	eventCallbackArray func([]*T)
 	logCallback        LogCallback
+	sinkCallback       func(any)
+	recordCallback     func(string, any)
 	snapshotCombiner   *snapshotcombiner.SnapshotCombiner[T]
 	columnFilters      []columns.ColumnFilter
 
+	// sampling, rate limiting and accounting of dropped events
+	limiter       *limiter[T]
+	lost          uint64
+	remoteDropped map[string]types.DroppedEvents
+	limitsLock    sync.Mutex
+
 	// event combiner related fields
 	eventCombinerEnabled bool
 	combinedEvents       []*T
@@ -207,6 +257,14 @@ func (p *parser[T]) SetLogCallback(logCallback LogCallback) {
 	p.logCallback = logCallback
 }
 
+func (p *parser[T]) SetSinkCallback(sinkCallback func(any)) {
+	p.sinkCallback = sinkCallback
+}
+
+func (p *parser[T]) SetRecordCallback(recordCallback func(key string, ev any)) {
+	p.recordCallback = recordCallback
+}
+
 func (p *parser[T]) SetEventCallback(eventCallback any) {
 	switch cb := eventCallback.(type) {
 	case func(*T):
@@ -232,27 +290,43 @@ func (p *parser[T]) eventHandler(cb func(*T), enrichers ...func(any) error) func
 	if cb == nil {
 		panic("cb can't be nil in eventHandler from parser")
 	}
+	emit := func(ev *T) {
+		if p.sinkCallback != nil {
+			p.sinkCallback(ev)
+		}
+		cb(ev)
+	}
 	return func(ev *T) {
+		if p.countLost(ev) {
+			return
+		}
 		for _, enricher := range enrichers {
 			enricher(ev)
 		}
+		if p.recordCallback != nil {
+			p.recordCallback("", ev)
+		}
 		if p.filterSpecs != nil && !p.filterSpecs.MatchAll(ev) {
 			return
 		}
-		cb(ev)
+		p.limit(ev, emit)
 	}
 }
 
-func (p *parser[T]) eventHandlerArray(cb func([]*T), enrichers ...func(any) error) func([]*T) {
+func (p *parser[T]) eventHandlerArray(key string, cb func([]*T), enrichers ...func(any) error) func([]*T) {
 	if cb == nil {
 		panic("cb can't be nil in eventHandlerArray from parser")
 	}
 	return func(events []*T) {
+		events = p.countLostArray(events)
 		for _, enricher := range enrichers {
 			for _, ev := range events {
 				enricher(ev)
 			}
 		}
+		if p.recordCallback != nil {
+			p.recordCallback(key, events)
+		}
 		if p.filterSpecs != nil {
 			filteredEvents := make([]*T, 0, len(events))
 			for _, event := range events {
@@ -266,6 +340,11 @@ func (p *parser[T]) eventHandlerArray(cb func([]*T), enrichers ...func(any) erro
 		if p.sortSpec != nil {
 			p.sortSpec.Sort(events)
 		}
+		if p.sinkCallback != nil {
+			for _, ev := range events {
+				p.sinkCallback(ev)
+			}
+		}
 		cb(events)
 	}
 }
@@ -319,7 +398,7 @@ func (p *parser[T]) JSONHandlerFuncArray(key string, enrichers ...func(any) erro
 		}
 	}
 
-	handler := p.eventHandlerArray(cb, enrichers...)
+	handler := p.eventHandlerArray(key, cb, enrichers...)
 
 	return func(event []byte) {
 		var ev []*T
@@ -332,12 +411,48 @@ func (p *parser[T]) JSONHandlerFuncArray(key string, enrichers ...func(any) erro
 	}
 }
 
+func (p *parser[T]) JSONRowsFunc(enrichers ...func(any) error) func([]byte) ([][]string, error) {
+	cols := p.columns.GetOrderedColumns(p.columnFilters...)
+	formatters := make([]func(*T) string, 0, len(cols))
+	for _, col := range cols {
+		formatters = append(formatters, columns.GetFieldAsStringExt[T](col, 'f', col.Precision))
+	}
+
+	return func(payload []byte) ([][]string, error) {
+		var events []*T
+		if len(payload) > 0 && payload[0] == '[' {
+			if err := json.Unmarshal(payload, &events); err != nil {
+				return nil, fmt.Errorf("unmarshalling: %w", err)
+			}
+		} else {
+			ev := new(T)
+			if err := json.Unmarshal(payload, ev); err != nil {
+				return nil, fmt.Errorf("unmarshalling: %w", err)
+			}
+			events = []*T{ev}
+		}
+
+		rows := make([][]string, 0, len(events))
+		for _, ev := range events {
+			for _, enricher := range enrichers {
+				enricher(ev)
+			}
+			row := make([]string, len(formatters))
+			for i, formatter := range formatters {
+				row[i] = formatter(ev)
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+}
+
 func (p *parser[T]) EventHandlerFunc(enrichers ...func(any) error) any {
 	return p.eventHandler(p.eventCallback, enrichers...)
 }
 
 func (p *parser[T]) EventHandlerFuncArray(enrichers ...func(any) error) any {
-	return p.eventHandlerArray(p.eventCallbackArray, enrichers...)
+	return p.eventHandlerArray("", p.eventCallbackArray, enrichers...)
 }
 
 func (p *parser[T]) GetTextColumnsFormatter(options ...textcolumns.Option) TextColumnsFormatter {
@@ -347,6 +462,19 @@ func (p *parser[T]) GetTextColumnsFormatter(options ...textcolumns.Option) TextC
 	}
 }
 
+func (p *parser[T]) GetJSONFormatter(options ...columns_json.Option) JSONFormatter {
+	return &jsonHelper[T]{
+		formatter: columns_json.NewFormatter(p.columns.GetColumnMap(p.columnFilters...), options...),
+	}
+}
+
+func (p *parser[T]) GetParquetWriter(w io.Writer, options ...parquet.Option) ParquetWriter {
+	return &parquetHelper[T]{
+		parser:    p,
+		formatter: parquet.NewFormatter(p.columns.GetColumnMap(p.columnFilters...), w, options...),
+	}
+}
+
 func (p *parser[T]) GetColumnAttributes() []columns.Attributes {
 	out := make([]columns.Attributes, 0)
 	for _, column := range p.columns.GetOrderedColumns(p.columnFilters...) {
@@ -0,0 +1,322 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/filter"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/formatter/textcolumns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/sort"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// EventTable is the interface used for tableHelper
+type EventTable interface {
+	// EventHandlerFunc returns a function appending events (*T) to the table; once the table is full, the
+	// oldest events are dropped
+	EventHandlerFunc() any
+	// EventHandlerFuncArray returns a function replacing all events of the table with the given ones ([]*T),
+	// as used by gadgets reporting at intervals
+	EventHandlerFuncArray() any
+
+	// SetSorting sets the columns to sort the events by; without sorting, events are kept in the order they
+	// were received
+	SetSorting([]string) error
+	// SetFilters sets the filters events need to match to be shown; other events are kept nevertheless
+	SetFilters([]string) error
+	// SetShowColumns sets the columns to show
+	SetShowColumns([]string) error
+	// SetWidth sets the width the shown columns are scaled to
+	SetWidth(int)
+	// SetPaused stops updating the shown events until called with false again
+	SetPaused(bool)
+	// SetChangeCallback sets a function that is called whenever the shown events change
+	SetChangeCallback(func())
+
+	// Len returns the number of shown events, i.e. the ones matching the filters
+	Len() int
+	// Pending returns the number of events received while paused
+	Pending() int
+	// FormatHeader returns the header of the shown columns
+	FormatHeader() string
+	// FormatRows returns up to limit shown events, starting at offset, formatted as rows
+	FormatRows(offset, limit int) []string
+	// EventJSON returns the shown event at index as indented JSON
+	EventJSON(index int) (string, error)
+}
+
+// tableHelper keeps events in memory and applies its own sorting and filters, so
+// they can be changed while the gadget is running
+type tableHelper[T any] struct {
+	parser    *parser[T]
+	formatter *textcolumns.TextColumnsFormatter[T]
+	maxEvents int
+	width     int
+
+	mu             sync.Mutex
+	events         []*T
+	pending        []*T
+	pendingArray   []*T
+	paused         bool
+	sortSpec       *sort.ColumnSorterCollection[T]
+	filterSpecs    *filter.FilterSpecs[T]
+	view           []*T
+	dirty          bool
+	changeCallback func()
+}
+
+// GetEventTable returns a table keeping up to maxEvents events
+func (p *parser[T]) GetEventTable(maxEvents int) EventTable {
+	return &tableHelper[T]{
+		parser:    p,
+		formatter: textcolumns.NewFormatter(p.columns.GetColumnMap(p.columnFilters...), textcolumns.WithAutoScale(false)),
+		maxEvents: maxEvents,
+		dirty:     true,
+	}
+}
+
+// isLogMessage forwards events carrying log messages to the log callback of the
+// parser and returns true for them
+func (th *tableHelper[T]) isLogMessage(ev *T) bool {
+	getter, ok := any(ev).(ErrorGetter)
+	if !ok {
+		return false
+	}
+	switch getter.GetType() {
+	case types.ERR:
+		th.parser.writeLogMessage(logger.ErrorLevel, getter.GetMessage())
+	case types.WARN:
+		th.parser.writeLogMessage(logger.WarnLevel, getter.GetMessage())
+	case types.DEBUG:
+		th.parser.writeLogMessage(logger.DebugLevel, getter.GetMessage())
+	case types.INFO:
+		th.parser.writeLogMessage(logger.InfoLevel, getter.GetMessage())
+	default:
+		return false
+	}
+	return true
+}
+
+func (th *tableHelper[T]) EventHandlerFunc() any {
+	return func(ev *T) {
+		if th.isLogMessage(ev) {
+			return
+		}
+
+		th.mu.Lock()
+		if th.paused {
+			th.pending = append(th.pending, ev)
+			if len(th.pending) > th.maxEvents {
+				th.pending = th.pending[len(th.pending)-th.maxEvents:]
+			}
+			th.mu.Unlock()
+			th.changed()
+			return
+		}
+		th.events = append(th.events, ev)
+		if len(th.events) > th.maxEvents {
+			th.events = th.events[len(th.events)-th.maxEvents:]
+		}
+		th.dirty = true
+		th.mu.Unlock()
+		th.changed()
+	}
+}
+
+func (th *tableHelper[T]) EventHandlerFuncArray() any {
+	return func(events []*T) {
+		th.mu.Lock()
+		if th.paused {
+			th.pendingArray = events
+			th.mu.Unlock()
+			th.changed()
+			return
+		}
+		th.events = events
+		th.dirty = true
+		th.mu.Unlock()
+		th.changed()
+	}
+}
+
+func (th *tableHelper[T]) changed() {
+	if th.changeCallback != nil {
+		th.changeCallback()
+	}
+}
+
+func (th *tableHelper[T]) SetSorting(sortBy []string) error {
+	_, invalid := th.parser.columns.VerifyColumnNames(sortBy)
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid columns to sort by: %v", invalid)
+	}
+
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	th.sortSpec = nil
+	if len(sortBy) > 0 {
+		th.sortSpec = sort.Prepare(th.parser.columns.ColumnMap, sortBy)
+	}
+	th.dirty = true
+	return nil
+}
+
+func (th *tableHelper[T]) SetFilters(filters []string) error {
+	var filterSpecs *filter.FilterSpecs[T]
+	if len(filters) > 0 {
+		var err error
+		filterSpecs, err = filter.GetFiltersFromStrings(th.parser.columns.ColumnMap, filters)
+		if err != nil {
+			return err
+		}
+	}
+
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	th.filterSpecs = filterSpecs
+	th.dirty = true
+	return nil
+}
+
+func (th *tableHelper[T]) SetShowColumns(cols []string) error {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	if err := th.formatter.SetShowColumns(cols); err != nil {
+		return err
+	}
+	if th.width > 0 {
+		th.formatter.RecalculateWidths(th.width, false)
+	}
+	return nil
+}
+
+func (th *tableHelper[T]) SetWidth(width int) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	th.width = width
+	th.formatter.RecalculateWidths(width, false)
+}
+
+func (th *tableHelper[T]) SetPaused(paused bool) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	th.paused = paused
+	if paused {
+		return
+	}
+	if th.pendingArray != nil {
+		th.events = th.pendingArray
+	}
+	th.events = append(th.events, th.pending...)
+	if len(th.events) > th.maxEvents {
+		th.events = th.events[len(th.events)-th.maxEvents:]
+	}
+	th.pending = nil
+	th.pendingArray = nil
+	th.dirty = true
+}
+
+func (th *tableHelper[T]) SetChangeCallback(changeCallback func()) {
+	th.changeCallback = changeCallback
+}
+
+// updateView filters and sorts the events, if they or the settings changed
+// since the last call; th.mu must be held
+func (th *tableHelper[T]) updateView() {
+	if !th.dirty {
+		return
+	}
+	th.dirty = false
+
+	view := make([]*T, 0, len(th.events))
+	for _, ev := range th.events {
+		if th.filterSpecs != nil && !th.filterSpecs.MatchAll(ev) {
+			continue
+		}
+		view = append(view, ev)
+	}
+	if th.sortSpec != nil {
+		th.sortSpec.Sort(view)
+	}
+	th.view = view
+}
+
+func (th *tableHelper[T]) Len() int {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	th.updateView()
+	return len(th.view)
+}
+
+func (th *tableHelper[T]) Pending() int {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	if th.pendingArray != nil {
+		return len(th.pendingArray)
+	}
+	return len(th.pending)
+}
+
+func (th *tableHelper[T]) FormatHeader() string {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	return th.formatter.FormatHeader()
+}
+
+func (th *tableHelper[T]) FormatRows(offset, limit int) []string {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	th.updateView()
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(th.view) {
+		return nil
+	}
+	end := min(offset+limit, len(th.view))
+	rows := make([]string, 0, end-offset)
+	for _, ev := range th.view[offset:end] {
+		rows = append(rows, th.formatter.FormatEntry(ev))
+	}
+	return rows
+}
+
+func (th *tableHelper[T]) EventJSON(index int) (string, error) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	th.updateView()
+	if index < 0 || index >= len(th.view) {
+		return "", fmt.Errorf("no event at index %d", index)
+	}
+	out, err := json.MarshalIndent(th.view[index], "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,258 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package recording stores the raw event stream of gadget runs, so they can be
+// replayed later on. A recording is a stream of JSON documents: a Header
+// followed by one Entry per event, array of events or result, as the gadget
+// service would send them as payload of a GadgetEvent.
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	runTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/run/types"
+)
+
+// Version is the version of the format written by Writer
+const Version = 1
+
+// Header describes the gadget run contained in a recording
+type Header struct {
+	Version  int    `json:"version"`
+	Category string `json:"category"`
+	Gadget   string `json:"gadget"`
+	// Params contains the gadget params of the run
+	Params map[string]string `json:"params,omitempty"`
+	Args   []string          `json:"args,omitempty"`
+	// GadgetInfo is only set for the run gadget
+	GadgetInfo *runTypes.GadgetInfo `json:"gadgetInfo,omitempty"`
+	// HiddenColumnTags are the tags of columns hidden by default when recording
+	HiddenColumnTags []string `json:"hiddenColumnTags,omitempty"`
+	// Node is the host the recording was taken on; it is used as key for
+	// arrays without a key of their own
+	Node      string    `json:"node,omitempty"`
+	StartTime time.Time `json:"startTime"`
+}
+
+// Entry is a single payload of a recording
+type Entry struct {
+	// Time of the entry in nanoseconds since the epoch
+	Time int64 `json:"t"`
+	// Key identifies the source of arrays and results, e.g. the node
+	Key string `json:"k,omitempty"`
+	// Result is set if Payload is the result of a gadget without parser,
+	// encoded as JSON string
+	Result  bool            `json:"r,omitempty"`
+	Payload json.RawMessage `json:"p"`
+}
+
+// IsArray returns whether the payload of the entry is an array of events
+func (e *Entry) IsArray() bool {
+	return !e.Result && len(e.Payload) > 0 && e.Payload[0] == '['
+}
+
+// ResultPayload returns the result stored in the entry
+func (e *Entry) ResultPayload() ([]byte, error) {
+	var result string
+	if err := json.Unmarshal(e.Payload, &result); err != nil {
+		return nil, fmt.Errorf("decoding result: %w", err)
+	}
+	return []byte(result), nil
+}
+
+// Writer writes a recording; it's safe for concurrent use
+type Writer struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// NewWriter writes the header to w and returns a Writer for the entries
+func NewWriter(w io.Writer, header *Header) (*Writer, error) {
+	bw := bufio.NewWriter(w)
+	rw := &Writer{
+		w:   bw,
+		enc: json.NewEncoder(bw),
+	}
+	header.Version = Version
+	if err := rw.enc.Encode(header); err != nil {
+		return nil, fmt.Errorf("writing header: %w", err)
+	}
+	return rw, nil
+}
+
+// Create creates the file at path and writes the header to it
+func Create(path string, header *Header) (*Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating recording: %w", err)
+	}
+	w, err := NewWriter(file, header)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	w.closer = file
+	return w, nil
+}
+
+// Record marshals ev (an event or an array of events) and adds it to the
+// recording
+func (w *Writer) Record(key string, ev any) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+	return w.write(&Entry{Key: key, Payload: payload})
+}
+
+// RecordResult adds the result of a gadget without parser to the recording
+func (w *Writer) RecordResult(key string, result []byte) error {
+	// Results aren't necessarily JSON, so they are stored as string
+	payload, err := json.Marshal(string(result))
+	if err != nil {
+		return fmt.Errorf("marshaling result: %w", err)
+	}
+	return w.write(&Entry{Key: key, Result: true, Payload: payload})
+}
+
+func (w *Writer) write(entry *Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry.Time = time.Now().UnixNano()
+	return w.enc.Encode(entry)
+}
+
+// Close flushes the recording and closes the underlying file, if it was
+// created using Create
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.w.Flush()
+	if w.closer != nil {
+		err = errors.Join(err, w.closer.Close())
+	}
+	return err
+}
+
+// Recording is a recording read into memory
+type Recording struct {
+	Header  *Header
+	Entries []*Entry
+}
+
+// Read reads a recording from r
+func Read(r io.Reader) (*Recording, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	header := &Header{}
+	if err := dec.Decode(header); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if header.Version != Version {
+		return nil, fmt.Errorf("unsupported recording version %d", header.Version)
+	}
+
+	rec := &Recording{Header: header}
+	for {
+		entry := &Entry{}
+		err := dec.Decode(entry)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// A recording that was interrupted might end with an incomplete entry
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, fmt.Errorf("reading entry %d: %w", len(rec.Entries), err)
+		}
+		if entry.Key == "" && (entry.Result || entry.IsArray()) {
+			entry.Key = header.Node
+		}
+		rec.Entries = append(rec.Entries, entry)
+	}
+	return rec, nil
+}
+
+// Open reads the recordings at the given paths and merges them
+func Open(paths ...string) (*Recording, error) {
+	recordings := make([]*Recording, 0, len(paths))
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening recording: %w", err)
+		}
+		rec, err := Read(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading recording %q: %w", path, err)
+		}
+		recordings = append(recordings, rec)
+	}
+	return Merge(recordings...)
+}
+
+// Merge combines recordings of the same gadget, e.g. taken on different
+// nodes, into a single one with entries ordered by time. Params and other
+// information are taken from the first recording.
+func Merge(recordings ...*Recording) (*Recording, error) {
+	if len(recordings) == 0 {
+		return nil, errors.New("no recordings given")
+	}
+	if len(recordings) == 1 {
+		return recordings[0], nil
+	}
+
+	first := recordings[0].Header
+	header := *first
+	merged := &Recording{Header: &header}
+	for i, rec := range recordings {
+		h := rec.Header
+		if h.Category != first.Category || h.Gadget != first.Gadget || !slices.Equal(h.Args, first.Args) {
+			return nil, fmt.Errorf("recording %d is of gadget %s, expected %s", i, h.describe(), first.describe())
+		}
+		if h.StartTime.Before(header.StartTime) {
+			header.StartTime = h.StartTime
+		}
+		merged.Entries = append(merged.Entries, rec.Entries...)
+	}
+	sort.SliceStable(merged.Entries, func(i, j int) bool {
+		return merged.Entries[i].Time < merged.Entries[j].Time
+	})
+	return merged, nil
+}
+
+func (h *Header) describe() string {
+	name := h.Gadget
+	if h.Category != "" {
+		name = h.Category + " " + name
+	}
+	if len(h.Args) > 0 {
+		name = fmt.Sprintf("%s %v", name, h.Args)
+	}
+	return fmt.Sprintf("%q", name)
+}
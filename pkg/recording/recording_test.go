@@ -0,0 +1,124 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recording
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	Comm string `json:"comm"`
+	Pid  int    `json:"pid"`
+}
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, &Header{
+		Category: "trace",
+		Gadget:   "exec",
+		Params:   map[string]string{"paths": "true"},
+		Node:     "node1",
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Record("", &testEvent{Comm: "cat", Pid: 1}))
+	require.NoError(t, w.Record("", []*testEvent{{Comm: "ls", Pid: 2}}))
+	require.NoError(t, w.Record("node2", []*testEvent{}))
+	require.NoError(t, w.RecordResult("", []byte("not json")))
+	require.NoError(t, w.Close())
+
+	rec, err := Read(&buf)
+	require.NoError(t, err)
+	require.Equal(t, Version, rec.Header.Version)
+	require.Equal(t, "exec", rec.Header.Gadget)
+	require.Equal(t, "true", rec.Header.Params["paths"])
+	require.Len(t, rec.Entries, 4)
+
+	require.False(t, rec.Entries[0].IsArray())
+	require.Equal(t, "", rec.Entries[0].Key)
+	require.JSONEq(t, `{"comm":"cat","pid":1}`, string(rec.Entries[0].Payload))
+
+	// Arrays and results without key belong to the node of the recording
+	require.True(t, rec.Entries[1].IsArray())
+	require.Equal(t, "node1", rec.Entries[1].Key)
+	require.Equal(t, "node2", rec.Entries[2].Key)
+
+	require.True(t, rec.Entries[3].Result)
+	require.Equal(t, "node1", rec.Entries[3].Key)
+	result, err := rec.Entries[3].ResultPayload()
+	require.NoError(t, err)
+	require.Equal(t, "not json", string(result))
+}
+
+func TestReadTruncated(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, &Header{Gadget: "exec"})
+	require.NoError(t, err)
+	require.NoError(t, w.Record("", &testEvent{Comm: "cat"}))
+	require.NoError(t, w.Record("", &testEvent{Comm: "ls"}))
+	require.NoError(t, w.Close())
+
+	rec, err := Read(bytes.NewReader(buf.Bytes()[:buf.Len()-5]))
+	require.NoError(t, err)
+	require.Len(t, rec.Entries, 1)
+
+	_, err = Read(bytes.NewBufferString(`{"version":42}`))
+	require.Error(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now()
+
+	create := func(name string, header *Header, times ...int64) string {
+		path := filepath.Join(dir, name)
+		w, err := Create(path, header)
+		require.NoError(t, err)
+		for range times {
+			require.NoError(t, w.Record("", []*testEvent{{Comm: name}}))
+		}
+		require.NoError(t, w.Close())
+		return path
+	}
+
+	node1 := create("node1", &Header{Category: "top", Gadget: "tcp", Node: "node1", StartTime: start}, 1, 2)
+	node2 := create("node2", &Header{Category: "top", Gadget: "tcp", Node: "node2", StartTime: start.Add(-time.Second)}, 1)
+	other := create("other", &Header{Category: "top", Gadget: "file", Node: "node3", StartTime: start}, 1)
+
+	rec, err := Open(node1, node2)
+	require.NoError(t, err)
+	require.True(t, rec.Header.StartTime.Equal(start.Add(-time.Second)))
+	require.Len(t, rec.Entries, 3)
+	keys := map[string]int{}
+	for i, entry := range rec.Entries {
+		if i > 0 {
+			require.LessOrEqual(t, rec.Entries[i-1].Time, entry.Time)
+		}
+		keys[entry.Key]++
+	}
+	require.Equal(t, map[string]int{"node1": 2, "node2": 1}, keys)
+
+	_, err = Open(node1, other)
+	require.Error(t, err)
+
+	_, err = Merge()
+	require.Error(t, err)
+}
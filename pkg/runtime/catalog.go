@@ -22,12 +22,14 @@ import (
 
 // GadgetInfo is used to store GadgetDesc information in a serializable way
 type GadgetInfo struct {
-	ID                       string                `json:"id"`
-	Name                     string                `json:"name"`
-	Category                 string                `json:"category"`
-	Type                     string                `json:"type"`
-	Description              string                `json:"description"`
-	Params                   params.ParamDescs     `json:"params"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Category    string            `json:"category"`
+	Type        string            `json:"type"`
+	Description string            `json:"description"`
+	Params      params.ParamDescs `json:"params"`
+	// GadgetParams are the params depending on the type of the gadget, like interval or sort
+	GadgetParams             params.ParamDescs     `json:"gadgetParams,omitempty"`
 	ColumnsDefinition        any                   `json:"columnsDefinition"`
 	OperatorParamsCollection params.DescCollection `json:"operatorParamsCollection"`
 }
@@ -46,14 +48,21 @@ type Catalog struct {
 }
 
 func GadgetInfoFromGadgetDesc(gadgetDesc gadgets.GadgetDesc) *GadgetInfo {
-	return &GadgetInfo{
+	parser := gadgetDesc.Parser()
+	info := &GadgetInfo{
 		Name:                     gadgetDesc.Name(),
 		Category:                 gadgetDesc.Category(),
 		Type:                     string(gadgetDesc.Type()),
 		Description:              gadgetDesc.Description(),
 		Params:                   gadgetDesc.ParamDescs(),
+		GadgetParams:             gadgets.GadgetParams(gadgetDesc, gadgetDesc.Type(), parser),
 		OperatorParamsCollection: operators.GetOperatorsForGadget(gadgetDesc).ParamDescCollection(),
 	}
+	if parser != nil {
+		// Column attributes allow frontends to render events without knowing the gadget
+		info.ColumnsDefinition = parser.GetColumnAttributes()
+	}
+	return info
 }
 
 func OperatorToOperatorInfo(operator operators.Operator) *OperatorInfo {
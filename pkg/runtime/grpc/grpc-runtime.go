@@ -42,6 +42,7 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
 type ConnectionMode int
@@ -350,6 +351,32 @@ func (r *Runtime) getConnToRandomTarget(ctx context.Context, runtimeParams *para
 	return conn, nil
 }
 
+// DialNodes connects to the gadget service of all nodes selected by the given
+// runtime params and returns the connections by node name; the caller has to
+// close them. It's used to proxy the gadget service, e.g. for the web UI.
+func (r *Runtime) DialNodes(ctx context.Context, runtimeParams *params.Params) (map[string]*grpc.ClientConn, error) {
+	targets, err := r.getTargets(ctx, runtimeParams)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.Second * time.Duration(r.globalParams.Get(ParamConnectionTimeout).AsUint16())
+	conns := make(map[string]*grpc.ClientConn, len(targets))
+	for _, target := range targets {
+		dialCtx, cancelDial := context.WithTimeout(ctx, timeout)
+		conn, err := r.dialContext(dialCtx, target, timeout)
+		cancelDial()
+		if err != nil {
+			for _, conn := range conns {
+				conn.Close()
+			}
+			return nil, err
+		}
+		conns[target.node] = conn
+	}
+	return conns, nil
+}
+
 func (r *Runtime) runGadgetOnTargets(
 	gadgetCtx runtime.GadgetContext,
 	paramMap map[string]string,
@@ -513,6 +540,16 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allP
 				gadgetCtx.Logger().Debugf("%-20s | got result from server", target.node)
 				result = ev.Payload
 			case api.EventTypeGadgetJobID: // not needed right now
+			case api.EventTypeGadgetDropped:
+				if parser == nil {
+					continue
+				}
+				var dropped types.DroppedEvents
+				if err := json.Unmarshal(ev.Payload, &dropped); err != nil {
+					gadgetCtx.Logger().Warnf("%-20s | unmarshalling dropped events: %v", target.node, err)
+					continue
+				}
+				parser.ReportDropped(target.node, dropped)
 			default:
 				if ev.Type >= 1<<api.EventLogShift {
 					gadgetCtx.Logger().Log(logger.Level(ev.Type>>api.EventLogShift), fmt.Sprintf("%-20s | %s", target.node, string(ev.Payload)))
@@ -29,11 +29,23 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/sinks"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
 )
 
 type Runtime struct {
 	catalog *runtime.Catalog
+
+	// defaultSinks receive the events of all gadget runs, in addition to the
+	// sinks given per run
+	defaultSinks sinks.Sinks
+
+	// restrictRunSinks limits the sinks given per run to the ones not giving
+	// access to the host, with file sinks confined to runSinkFileDir and
+	// network sinks to runSinkAllowedHosts
+	restrictRunSinks    bool
+	runSinkFileDir      string
+	runSinkAllowedHosts []string
 }
 
 func New() *Runtime {
@@ -74,6 +86,22 @@ func (r *Runtime) Close() error {
 	return nil
 }
 
+// SetDefaultSinks sets sinks receiving the events of all gadgets run by this
+// runtime. The caller stays responsible for closing them.
+func (r *Runtime) SetDefaultSinks(s sinks.Sinks) {
+	r.defaultSinks = s
+}
+
+// RestrictRunSinks only allows the sinks given per run that send events to
+// allowedHosts over the network, and file sinks inside fileDir. File sinks are
+// rejected if fileDir is empty, network sinks if allowedHosts is empty. It's
+// meant for runtimes serving less privileged clients.
+func (r *Runtime) RestrictRunSinks(fileDir string, allowedHosts []string) {
+	r.restrictRunSinks = true
+	r.runSinkFileDir = fileDir
+	r.runSinkAllowedHosts = allowedHosts
+}
+
 func (r *Runtime) GlobalParamDescs() params.ParamDescs {
 	return nil
 }
@@ -102,6 +130,36 @@ func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGa
 
 	operatorsParamCollection := gadgetCtx.OperatorsParamCollection()
 
+	// Open output sinks first, so they are closed only after the gadget
+	if parser := gadgetCtx.Parser(); parser != nil {
+		var sinkURLs []string
+		if p := gadgetCtx.GadgetParams().Get(gadgets.ParamSink); p != nil {
+			sinkURLs = p.AsStringSlice()
+		}
+		var runSinks sinks.Sinks
+		var err error
+		if r.restrictRunSinks {
+			runSinks, err = sinks.OpenRestricted(sinkURLs, r.runSinkFileDir, r.runSinkAllowedHosts)
+		} else {
+			runSinks, err = sinks.Open(sinkURLs)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("opening sinks: %w", err)
+		}
+		defer runSinks.Close()
+
+		if allSinks := append(runSinks, r.defaultSinks...); len(allSinks) > 0 {
+			log.Debugf("set %d sinks", len(allSinks))
+			parser.SetSinkCallback(allSinks.EventCallback(gadgetCtx.GadgetDesc(), gadgetCtx.GadgetInfo(), parser, log))
+		}
+
+		stopLimits, err := parser.SetLimits(gadgets.LimitsFromParams(gadgetCtx.GadgetParams()))
+		if err != nil {
+			return nil, fmt.Errorf("setting limits: %w", err)
+		}
+		defer stopLimits()
+	}
+
 	// Create gadget instance
 	gadgetInstance, err := gadget.NewInstance()
 	if err != nil {
@@ -144,12 +202,13 @@ func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGa
 			log.Debugf("set event handler for arrays")
 			setter.SetEventHandlerArray(parser.EventHandlerFuncArray(operatorInstances.Enrich))
 		}
+	}
 
-		// Set event enricher (currently only used by profile/cpu)
-		if setter, ok := gadgetInstance.(gadgets.EventEnricherSetter); ok {
-			log.Debugf("set event enricher")
-			setter.SetEventEnricher(operatorInstances.Enrich)
-		}
+	// Set event enricher; this is also used by gadgets without parser to
+	// enrich parts of their results (e.g. per-container histograms)
+	if setter, ok := gadgetInstance.(gadgets.EventEnricherSetter); ok {
+		log.Debugf("set event enricher")
+		setter.SetEventEnricher(operatorInstances.Enrich)
 	}
 
 	log.Debug("calling operator.PreGadgetRun()")
@@ -0,0 +1,263 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package replay provides a runtime that doesn't run gadgets, but feeds the
+// events of a recording (see pkg/recording) into the parser of the gadget, just
+// like the grpc runtime does with the events it receives from remote nodes.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	runTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/run/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/recording"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/sinks"
+)
+
+const (
+	ParamSpeed = "speed"
+)
+
+type Runtime struct {
+	recording *recording.Recording
+}
+
+func New(rec *recording.Recording) *Runtime {
+	return &Runtime{
+		recording: rec,
+	}
+}
+
+func (r *Runtime) Init(globalRuntimeParams *params.Params) error {
+	return nil
+}
+
+func (r *Runtime) Close() error {
+	return nil
+}
+
+func (r *Runtime) GlobalParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (r *Runtime) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamSpeed,
+			Description:  "Speed of the replay relative to the recording, e.g. 2 replays twice as fast; 0 replays as fast as possible",
+			DefaultValue: "1",
+			TypeHint:     params.TypeFloat64,
+		},
+	}
+}
+
+func (r *Runtime) GetGadgetInfo(_ context.Context, desc gadgets.GadgetDesc, _ *params.Params, _ []string) (*runTypes.GadgetInfo, error) {
+	if r.recording.Header.GadgetInfo == nil {
+		return nil, fmt.Errorf("recording doesn't contain information about gadget %s", desc.Name())
+	}
+	return r.recording.Header.GadgetInfo, nil
+}
+
+func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGadgetResult, error) {
+	log := gadgetCtx.Logger()
+
+	speed := gadgetCtx.RuntimeParams().Get(ParamSpeed).AsFloat64()
+	if speed < 0 {
+		return nil, fmt.Errorf("invalid speed %v: must not be negative", speed)
+	}
+
+	var gType gadgets.GadgetType
+	if info := gadgetCtx.GadgetInfo(); info != nil {
+		gType = info.GadgetType
+	} else {
+		gType = gadgetCtx.GadgetDesc().Type()
+	}
+
+	log.Debugf("replaying %d entries with speed %v", len(r.recording.Entries), speed)
+
+	parser := gadgetCtx.Parser()
+
+	jsonHandler := func([]byte) {}
+	jsonArrayHandler := func(string, []byte) {}
+	flush := func() {}
+
+	if parser != nil {
+		var sinkURLs []string
+		if p := gadgetCtx.GadgetParams().Get(gadgets.ParamSink); p != nil {
+			sinkURLs = p.AsStringSlice()
+		}
+		runSinks, err := sinks.Open(sinkURLs)
+		if err != nil {
+			return nil, fmt.Errorf("opening sinks: %w", err)
+		}
+		defer runSinks.Close()
+		if len(runSinks) > 0 {
+			parser.SetSinkCallback(runSinks.EventCallback(gadgetCtx.GadgetDesc(), gadgetCtx.GadgetInfo(), parser, log))
+		}
+
+		switch gType {
+		case gadgets.TypeTraceIntervals:
+			// Instead of combining the snapshots of all nodes periodically, they are
+			// combined per interval as recorded, independent of the speed
+			combiner := newIntervalCombiner(r.recording.Entries)
+			arrayHandler := parser.JSONHandlerFuncArray("")
+			jsonArrayHandler = func(key string, payload []byte) {
+				if combined := combiner.add(key, payload); combined != nil {
+					arrayHandler(combined)
+				}
+			}
+			flush = func() {
+				if combined := combiner.flush(); combined != nil {
+					arrayHandler(combined)
+				}
+			}
+		case gadgets.TypeOneShot:
+			parser.EnableCombiner()
+			flush = parser.Flush
+			fallthrough
+		default:
+			arrayHandlers := make(map[string]func([]byte))
+			jsonArrayHandler = func(key string, payload []byte) {
+				handler, ok := arrayHandlers[key]
+				if !ok {
+					handler = parser.JSONHandlerFuncArray(key)
+					arrayHandlers[key] = handler
+				}
+				handler(payload)
+			}
+		}
+		jsonHandler = parser.JSONHandlerFunc()
+	}
+
+	ctx := gadgetCtx.Context()
+	results := make(runtime.CombinedGadgetResult)
+	start := time.Now()
+	recordingStart := r.recording.Header.StartTime.UnixNano()
+
+	// wait blocks until the entry recorded at ts is due and returns false if
+	// the replay was stopped in the meantime
+	wait := func(ts int64) bool {
+		if speed == 0 {
+			return ctx.Err() == nil
+		}
+		offset := time.Duration(float64(ts-recordingStart) / speed)
+		timer := time.NewTimer(time.Until(start.Add(offset)))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return true
+		}
+	}
+
+	for _, entry := range r.recording.Entries {
+		if !wait(entry.Time) {
+			break
+		}
+
+		switch {
+		case entry.Result:
+			payload, err := entry.ResultPayload()
+			results[entry.Key] = &runtime.GadgetResult{Payload: payload, Error: err}
+		case entry.IsArray():
+			jsonArrayHandler(entry.Key, entry.Payload)
+		default:
+			jsonHandler(entry.Payload)
+		}
+	}
+	flush()
+
+	return results, results.Err()
+}
+
+func (r *Runtime) GetCatalog() (*runtime.Catalog, error) {
+	return nil, errors.New("the replay runtime doesn't provide a catalog")
+}
+
+func (r *Runtime) SetDefaultValue(key params.ValueHint, value string) {
+	panic("not supported, yet")
+}
+
+func (r *Runtime) GetDefaultValue(key params.ValueHint) (string, bool) {
+	return "", false
+}
+
+// intervalCombiner combines the arrays recorded for the different keys (nodes)
+// of interval gadgets into a single array per interval. An interval is
+// complete, once all keys sent an array or a key sent a second one.
+type intervalCombiner struct {
+	keys    map[string]struct{}
+	pending map[string]json.RawMessage
+	order   []string
+}
+
+func newIntervalCombiner(entries []*recording.Entry) *intervalCombiner {
+	c := &intervalCombiner{
+		keys:    make(map[string]struct{}),
+		pending: make(map[string]json.RawMessage),
+	}
+	for _, entry := range entries {
+		if entry.IsArray() {
+			c.keys[entry.Key] = struct{}{}
+		}
+	}
+	return c
+}
+
+// add adds the array of key and returns the combined array of an interval, if
+// one was completed
+func (c *intervalCombiner) add(key string, payload []byte) []byte {
+	var combined []byte
+	if _, ok := c.pending[key]; ok {
+		combined = c.flush()
+	}
+	c.pending[key] = payload
+	c.order = append(c.order, key)
+	if len(c.pending) == len(c.keys) {
+		combined = c.flush()
+	}
+	return combined
+}
+
+// flush returns the combined array of the pending interval or nil if there is
+// none
+func (c *intervalCombiner) flush() []byte {
+	if len(c.pending) == 0 {
+		return nil
+	}
+	var events []json.RawMessage
+	for _, key := range c.order {
+		var arr []json.RawMessage
+		if err := json.Unmarshal(c.pending[key], &arr); err != nil {
+			continue
+		}
+		events = append(events, arr...)
+	}
+	c.pending = make(map[string]json.RawMessage)
+	c.order = nil
+
+	if events == nil {
+		events = []json.RawMessage{}
+	}
+	combined, _ := json.Marshal(events)
+	return combined
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/recording"
+)
+
+func TestIntervalCombiner(t *testing.T) {
+	entries := []*recording.Entry{
+		{Key: "node1", Payload: []byte(`[1]`)},
+		{Key: "node2", Payload: []byte(`[2,3]`)},
+		{Key: "", Payload: []byte(`{"single":true}`)},
+	}
+	c := newIntervalCombiner(entries)
+
+	// An interval is complete once all nodes sent their array
+	require.Nil(t, c.add("node1", []byte(`[1]`)))
+	require.JSONEq(t, `[1,2,3]`, string(c.add("node2", []byte(`[2,3]`))))
+
+	// or once a node sends its next array
+	require.Nil(t, c.add("node2", []byte(`[4]`)))
+	require.JSONEq(t, `[4]`, string(c.add("node2", []byte(`[]`))))
+
+	require.JSONEq(t, `[]`, string(c.flush()))
+	require.Nil(t, c.flush())
+}
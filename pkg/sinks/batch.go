@@ -0,0 +1,122 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultQueueSize     = 4096
+	defaultBatchSize     = 512
+	defaultFlushInterval = time.Second
+)
+
+// batcher queues events and hands them to flush in batches from a separate
+// goroutine, so that slow destinations don't block the gadget. Events are
+// dropped when the queue is full.
+type batcher struct {
+	name    string
+	flush   func(events [][]byte) error
+	queue   chan []byte
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+	dropped atomic.Uint64
+}
+
+func newBatcher(name string, flush func(events [][]byte) error) *batcher {
+	b := &batcher{
+		name:  name,
+		flush: flush,
+		queue: make(chan []byte, defaultQueueSize),
+		done:  make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+func (b *batcher) Write(event []byte) error {
+	// The caller may reuse the buffer
+	event = append([]byte(nil), event...)
+	select {
+	case b.queue <- event:
+	default:
+		if b.dropped.Add(1)%defaultQueueSize == 1 {
+			log.Warnf("%s sink: queue full, dropping events", b.name)
+		}
+	}
+	return nil
+}
+
+func (b *batcher) run() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(defaultFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, defaultBatchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := b.flush(batch); err != nil {
+			log.Warnf("%s sink: sending %d events: %v", b.name, len(batch), err)
+		}
+		batch = make([][]byte, 0, defaultBatchSize)
+	}
+
+	for {
+		select {
+		case event := <-b.queue:
+			batch = append(batch, event)
+			if len(batch) >= defaultBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case <-b.done:
+			for {
+				select {
+				case event := <-b.queue:
+					batch = append(batch, event)
+					if len(batch) >= defaultBatchSize {
+						send()
+					}
+				default:
+					send()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close flushes the queued events and stops the batcher
+func (b *batcher) Close() error {
+	b.once.Do(func() {
+		close(b.done)
+		b.wg.Wait()
+		if dropped := b.dropped.Load(); dropped > 0 {
+			log.Warnf("%s sink: %d events dropped", b.name, dropped)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+)
+
+const (
+	defaultMaxSize    = 100 * 1024 * 1024
+	defaultMaxBackups = 5
+
+	backupTimeFormat = "20060102T150405.000000000"
+)
+
+// fileSink writes events as JSON lines to a local file. The file is rotated
+// once it reaches max-size bytes or is older than max-age, keeping at most
+// max-backups rotated files next to it.
+type fileSink struct {
+	mu sync.Mutex
+
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	file    *os.File
+	size    int64
+	created time.Time
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+func newFileSink(u *url.URL) (Sink, error) {
+	// file:///abs/path and file://relative/path are both accepted
+	path := u.Host + u.Path
+	if path == "" {
+		return nil, errors.New("missing path")
+	}
+
+	s := &fileSink{
+		path:       path,
+		maxSize:    defaultMaxSize,
+		maxBackups: defaultMaxBackups,
+		now:        time.Now,
+	}
+
+	query := u.Query()
+	if v := query.Get("max-size"); v != "" {
+		size, err := units.RAMInBytes(v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing max-size: %w", err)
+		}
+		s.maxSize = size
+	}
+	if v := query.Get("max-age"); v != "" {
+		age, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing max-age: %w", err)
+		}
+		s.maxAge = age
+	}
+	if v := query.Get("max-backups"); v != "" {
+		backups, err := strconv.Atoi(v)
+		if err != nil || backups < 0 {
+			return nil, fmt.Errorf("invalid max-backups %q", v)
+		}
+		s.maxBackups = backups
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating directory: %w", err)
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening %q: %w", s.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat %q: %w", s.path, err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+	s.created = s.now()
+	if s.size > 0 {
+		// Keep the age of an existing file
+		s.created = info.ModTime()
+	}
+	return nil
+}
+
+func (s *fileSink) Write(event []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return errors.New("file sink closed")
+	}
+
+	length := int64(len(event)) + 1
+	if s.size > 0 && (s.size+length > s.maxSize || (s.maxAge > 0 && s.now().Sub(s.created) >= s.maxAge)) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	line := make([]byte, 0, length)
+	line = append(append(line, event...), '\n')
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("writing to %q: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("closing %q: %w", s.path, err)
+	}
+	s.file = nil
+
+	if s.maxBackups > 0 {
+		backup := s.path + "." + s.now().UTC().Format(backupTimeFormat)
+		if err := os.Rename(s.path, backup); err != nil {
+			return fmt.Errorf("rotating %q: %w", s.path, err)
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return fmt.Errorf("removing %q: %w", s.path, err)
+	}
+
+	if err := s.removeOldBackups(); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileSink) backups() ([]string, error) {
+	matches, err := filepath.Glob(s.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]string, 0, len(matches))
+	prefix := s.path + "."
+	for _, match := range matches {
+		if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(match, prefix)); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	// The timestamp format sorts chronologically
+	sort.Strings(backups)
+	return backups, nil
+}
+
+func (s *fileSink) removeOldBackups() error {
+	backups, err := s.backups()
+	if err != nil {
+		return fmt.Errorf("listing backups of %q: %w", s.path, err)
+	}
+	for len(backups) > s.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("removing backup: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := map[string]struct {
+		url   string
+		valid bool
+	}{
+		"file":                {url: "file://" + filepath.Join(dir, "events.jsonl"), valid: true},
+		"file_limits":         {url: "file://" + filepath.Join(dir, "a.jsonl") + "?max-size=1MB&max-age=1h&max-backups=2", valid: true},
+		"file_missing_path":   {url: "file://"},
+		"file_invalid_size":   {url: "file://" + filepath.Join(dir, "b.jsonl") + "?max-size=big"},
+		"file_invalid_age":    {url: "file://" + filepath.Join(dir, "c.jsonl") + "?max-age=tomorrow"},
+		"file_invalid_backup": {url: "file://" + filepath.Join(dir, "d.jsonl") + "?max-backups=-1"},
+		"kafka":               {url: "kafka://localhost:9092/events", valid: true},
+		"kafka_missing_topic": {url: "kafka://localhost:9092"},
+		"otlp_http":           {url: "otlp-http://localhost:4318", valid: true},
+		"otlp_grpc":           {url: "otlp-grpc://localhost:4317", valid: true},
+		"otlp_missing_host":   {url: "otlp-grpc://"},
+		"syslog_tcp_no_host":  {url: "syslog+tcp://"},
+		"unknown":             {url: "ftp://localhost/events"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			sink, err := New(test.url)
+			if !test.valid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, sink.Close())
+		})
+	}
+
+	require.NoError(t, Validate("kafka://localhost:9092/events"))
+	require.Error(t, Validate("ftp://localhost/events"))
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	sink, err := New("file://" + path + "?max-size=20&max-backups=2")
+	require.NoError(t, err)
+	fs := sink.(*fileSink)
+
+	// Make sure backups get different names
+	now := time.Now()
+	fs.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	// Each event takes 10 bytes including the new line, so every file
+	// contains 2 of them
+	for i := 0; i < 7; i++ {
+		require.NoError(t, sink.Write([]byte(`{"n":"`+string(rune('a'+i))+`"}`)))
+	}
+	require.NoError(t, sink.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "{\"n\":\"g\"}\n", string(content))
+
+	backups, err := fs.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+
+	content, err = os.ReadFile(backups[0])
+	require.NoError(t, err)
+	require.Equal(t, "{\"n\":\"c\"}\n{\"n\":\"d\"}\n", string(content))
+
+	content, err = os.ReadFile(backups[1])
+	require.NoError(t, err)
+	require.Equal(t, "{\"n\":\"e\"}\n{\"n\":\"f\"}\n", string(content))
+}
+
+func TestFileSinkMaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	sink, err := New("file://" + path + "?max-age=1h&max-backups=0")
+	require.NoError(t, err)
+	fs := sink.(*fileSink)
+
+	now := time.Now()
+	fs.now = func() time.Time { return now }
+	fs.created = now
+
+	require.NoError(t, sink.Write([]byte(`{"n":1}`)))
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, sink.Write([]byte(`{"n":2}`)))
+	require.NoError(t, sink.Close())
+
+	// Without backups, the old file is just removed
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "{\"n\":2}\n", string(content))
+
+	backups, err := fs.backups()
+	require.NoError(t, err)
+	require.Empty(t, backups)
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+const (
+	kafkaClientID = "inspektor-gadget"
+	kafkaTimeout  = 10 * time.Second
+)
+
+var kafkaCompressions = map[string]kgo.CompressionCodec{
+	"none":   kgo.NoCompression(),
+	"gzip":   kgo.GzipCompression(),
+	"snappy": kgo.SnappyCompression(),
+	"lz4":    kgo.Lz4Compression(),
+	"zstd":   kgo.ZstdCompression(),
+}
+
+// kafkaSink produces events to a Kafka topic: kafka://broker:9092/topic.
+// The query parameters are:
+//   - broker: additional bootstrap brokers, can be repeated
+//   - tls: "true" to connect with TLS
+//   - sasl: "plain", "scram-sha-256" or "scram-sha-512", using the user and
+//     password of the URL
+//   - compression: "none", "gzip", "snappy" (default), "lz4" or "zstd"
+type kafkaSink struct {
+	*batcher
+	client *kgo.Client
+}
+
+func kafkaOpts(u *url.URL) ([]kgo.Opt, error) {
+	if u.Host == "" {
+		return nil, errors.New("missing broker")
+	}
+	topic := strings.Trim(u.Path, "/")
+	if topic == "" || strings.Contains(topic, "/") {
+		return nil, fmt.Errorf("invalid topic %q", topic)
+	}
+
+	query := u.Query()
+	opts := []kgo.Opt{
+		kgo.ClientID(kafkaClientID),
+		kgo.SeedBrokers(append([]string{u.Host}, query["broker"]...)...),
+		kgo.DefaultProduceTopic(topic),
+		kgo.RecordDeliveryTimeout(kafkaTimeout),
+	}
+
+	if query.Get("tls") == "true" {
+		opts = append(opts, kgo.DialTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+	}
+
+	compression := query.Get("compression")
+	if compression == "" {
+		compression = "snappy"
+	}
+	codec, ok := kafkaCompressions[compression]
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+	opts = append(opts, kgo.ProducerBatchCompression(codec))
+
+	mechanism, err := kafkaSASL(query.Get("sasl"), u.User)
+	if err != nil {
+		return nil, err
+	}
+	if mechanism != nil {
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+
+	return opts, nil
+}
+
+func kafkaSASL(method string, user *url.Userinfo) (sasl.Mechanism, error) {
+	if method == "" {
+		if user != nil {
+			return nil, errors.New("credentials given without sasl")
+		}
+		return nil, nil
+	}
+	if user == nil {
+		return nil, fmt.Errorf("sasl %q needs credentials", method)
+	}
+	password, _ := user.Password()
+
+	switch method {
+	case "plain":
+		return plain.Auth{User: user.Username(), Pass: password}.AsMechanism(), nil
+	case "scram-sha-256":
+		return scram.Auth{User: user.Username(), Pass: password}.AsSha256Mechanism(), nil
+	case "scram-sha-512":
+		return scram.Auth{User: user.Username(), Pass: password}.AsSha512Mechanism(), nil
+	}
+	return nil, fmt.Errorf("unsupported sasl %q", method)
+}
+
+func newKafkaSink(u *url.URL) (Sink, error) {
+	opts, err := kafkaOpts(u)
+	if err != nil {
+		return nil, err
+	}
+
+	// The brokers are only contacted when producing, so a cluster that isn't
+	// up yet doesn't prevent gadgets from running
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating kafka client: %w", err)
+	}
+
+	s := &kafkaSink{client: client}
+	s.batcher = newBatcher("kafka", s.send)
+	return s, nil
+}
+
+func (s *kafkaSink) send(events [][]byte) error {
+	records := make([]*kgo.Record, 0, len(events))
+	for _, event := range events {
+		records = append(records, &kgo.Record{Value: event})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaTimeout)
+	defer cancel()
+	return s.client.ProduceSync(ctx, records...).FirstErr()
+}
+
+func (s *kafkaSink) Close() error {
+	s.batcher.Close()
+	s.client.Close()
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	otlpServiceName  = "inspektor-gadget"
+	otlpScopeName    = "github.com/inspektor-gadget/inspektor-gadget/pkg/sinks"
+	otlpDefaultPath  = "/v1/logs"
+	otlpSendTimeout  = 10 * time.Second
+	otlpProtobufType = "application/x-protobuf"
+)
+
+// otlpRequest builds an OTLP export request with one log record per event.
+// The body of each record is the JSON document of the event.
+func otlpRequest(events [][]byte) *collogspb.ExportLogsServiceRequest {
+	now := uint64(time.Now().UnixNano())
+	records := make([]*logspb.LogRecord, 0, len(events))
+	for _, event := range events {
+		records = append(records, &logspb.LogRecord{
+			ObservedTimeUnixNano: now,
+			SeverityNumber:       logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+			SeverityText:         "INFO",
+			Body: &commonpb.AnyValue{
+				Value: &commonpb.AnyValue_StringValue{StringValue: string(event)},
+			},
+		})
+	}
+
+	attributes := []*commonpb.KeyValue{stringAttribute("service.name", otlpServiceName)}
+	if hostname, err := os.Hostname(); err == nil {
+		attributes = append(attributes, stringAttribute("host.name", hostname))
+	}
+
+	return &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: &resourcepb.Resource{Attributes: attributes},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: otlpScopeName},
+				LogRecords: records,
+			}},
+		}},
+	}
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+// otlpGRPCSink exports events as OTLP logs over gRPC. The connection is
+// insecure unless the "tls=true" query parameter is given.
+type otlpGRPCSink struct {
+	*batcher
+	conn   *grpc.ClientConn
+	client collogspb.LogsServiceClient
+}
+
+func newOTLPGRPCSink(u *url.URL) (Sink, error) {
+	if u.Host == "" {
+		return nil, errors.New("missing host")
+	}
+
+	creds := insecure.NewCredentials()
+	if u.Query().Get("tls") == "true" {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	// The connection is established lazily, so a collector that isn't up yet
+	// doesn't prevent gadgets from running
+	conn, err := grpc.Dial(u.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("dialing %q: %w", u.Host, err)
+	}
+
+	s := &otlpGRPCSink{
+		conn:   conn,
+		client: collogspb.NewLogsServiceClient(conn),
+	}
+	s.batcher = newBatcher("otlp-grpc", s.send)
+	return s, nil
+}
+
+func (s *otlpGRPCSink) send(events [][]byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), otlpSendTimeout)
+	defer cancel()
+
+	_, err := s.client.Export(ctx, otlpRequest(events))
+	return err
+}
+
+func (s *otlpGRPCSink) Close() error {
+	s.batcher.Close()
+	return s.conn.Close()
+}
+
+// otlpHTTPSink exports events as protobuf encoded OTLP logs over HTTP(S). The
+// path defaults to /v1/logs.
+type otlpHTTPSink struct {
+	*batcher
+	endpoint string
+	client   *http.Client
+}
+
+func newOTLPHTTPSink(u *url.URL) (Sink, error) {
+	if u.Host == "" {
+		return nil, errors.New("missing host")
+	}
+
+	endpoint := url.URL{
+		Scheme: "http",
+		Host:   u.Host,
+		Path:   u.Path,
+	}
+	if u.Scheme == "otlp-https" {
+		endpoint.Scheme = "https"
+	}
+	if endpoint.Path == "" || endpoint.Path == "/" {
+		endpoint.Path = otlpDefaultPath
+	}
+
+	s := &otlpHTTPSink{
+		endpoint: endpoint.String(),
+		client:   &http.Client{Timeout: otlpSendTimeout},
+	}
+	s.batcher = newBatcher(u.Scheme, s.send)
+	return s, nil
+}
+
+func (s *otlpHTTPSink) send(events [][]byte) error {
+	body, err := proto.Marshal(otlpRequest(events))
+	if err != nil {
+		return fmt.Errorf("marshaling request: %w", err)
+	}
+
+	resp, err := s.client.Post(s.endpoint, otlpProtobufType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %q", resp.Status)
+	}
+	return nil
+}
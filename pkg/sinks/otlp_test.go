@@ -0,0 +1,84 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOTLPHTTPSink(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	var paths []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, otlpProtobufType, r.Header.Get("Content-Type"))
+
+		req := &collogspb.ExportLogsServiceRequest{}
+		require.NoError(t, proto.Unmarshal(data, req))
+		require.Len(t, req.ResourceLogs, 1)
+
+		resourceLogs := req.ResourceLogs[0]
+		require.Equal(t, "service.name", resourceLogs.Resource.Attributes[0].Key)
+		require.Equal(t, otlpServiceName, resourceLogs.Resource.Attributes[0].Value.GetStringValue())
+
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		for _, record := range resourceLogs.ScopeLogs[0].LogRecords {
+			bodies = append(bodies, record.Body.GetStringValue())
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink, err := New(strings.Replace(server.URL, "http://", "otlp-http://", 1))
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Write([]byte(`{"comm":"cat"}`)))
+	require.NoError(t, sink.Write([]byte(`{"comm":"ls"}`)))
+	require.NoError(t, sink.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{`{"comm":"cat"}`, `{"comm":"ls"}`}, bodies)
+	require.Equal(t, []string{otlpDefaultPath}, paths)
+}
+
+func TestOTLPHTTPSinkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	sink, err := New(strings.Replace(server.URL, "http://", "otlp-http://", 1) + "/custom/path")
+	require.NoError(t, err)
+	defer sink.Close()
+
+	hs := sink.(*otlpHTTPSink)
+	require.True(t, strings.HasSuffix(hs.endpoint, "/custom/path"))
+	require.Error(t, hs.send([][]byte{[]byte(`{}`)}))
+}
@@ -0,0 +1,328 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sinks forwards gadget events to remote or local destinations, next
+// to the output of the frontends. Sinks are described by URLs:
+//
+//	file:///var/log/ig/events.jsonl?max-size=100MB&max-age=24h&max-backups=5
+//	syslog://host:514 (udp), syslog+tcp://host:514, syslog:// (local syslog)
+//	otlp-grpc://host:4317, otlp-http://host:4318, otlp-https://host:4318
+//	kafka://broker:9092/topic
+//
+// Each event is written as a JSON document.
+package sinks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	runTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/run/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+)
+
+// Sink receives JSON encoded events
+type Sink interface {
+	// Write sends the event to the sink. Sinks sending events over the
+	// network buffer them and drop them if the destination is too slow; Write
+	// only returns an error if the sink can't work at all anymore.
+	Write(event []byte) error
+
+	// Close flushes buffered events and releases the resources of the sink
+	Close() error
+}
+
+type newSinkFunc func(u *url.URL) (Sink, error)
+
+var sinkTypes = map[string]newSinkFunc{
+	"file":       newFileSink,
+	"syslog":     newSyslogSink,
+	"syslog+udp": newSyslogSink,
+	"syslog+tcp": newSyslogSink,
+	"otlp-grpc":  newOTLPGRPCSink,
+	"otlp-http":  newOTLPHTTPSink,
+	"otlp-https": newOTLPHTTPSink,
+	"kafka":      newKafkaSink,
+}
+
+// New creates the sink described by the given URL
+func New(sinkURL string) (Sink, error) {
+	u, err := url.Parse(sinkURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sink %q: %w", sinkURL, err)
+	}
+	return newFromURL(u)
+}
+
+func newFromURL(u *url.URL) (Sink, error) {
+	newSink, ok := sinkTypes[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sink type %q (supported: %s)", u.Scheme, supportedTypes())
+	}
+	sink, err := newSink(u)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s sink: %w", u.Scheme, err)
+	}
+	return sink, nil
+}
+
+// Validate checks that the given URL describes a supported sink without
+// creating it
+func Validate(sinkURL string) error {
+	u, err := url.Parse(sinkURL)
+	if err != nil {
+		return fmt.Errorf("parsing sink %q: %w", sinkURL, err)
+	}
+	if _, ok := sinkTypes[u.Scheme]; !ok {
+		return fmt.Errorf("unsupported sink type %q (supported: %s)", u.Scheme, supportedTypes())
+	}
+	return nil
+}
+
+func supportedTypes() string {
+	return "file, syslog, syslog+udp, syslog+tcp, otlp-grpc, otlp-http, otlp-https, kafka"
+}
+
+// NewRestricted creates the sink described by the given URL, if it doesn't give
+// access to the local host or to arbitrary hosts. It's meant for sinks
+// requested by clients, which can be less privileged than the process opening
+// the sinks: sinks sending events over the network are only allowed if all the
+// hosts they connect to are in allowedHosts, and file sinks if their path is
+// inside fileDir. Relative file paths are relative to fileDir. File sinks are
+// rejected if fileDir is empty, network sinks if allowedHosts is empty.
+//
+// Entries of allowedHosts are either a host, allowing all its ports, or a
+// host:port pair. Host names aren't resolved.
+func NewRestricted(sinkURL string, fileDir string, allowedHosts []string) (Sink, error) {
+	u, err := url.Parse(sinkURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sink %q: %w", sinkURL, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		if fileDir == "" {
+			return nil, errors.New("file sinks are not allowed")
+		}
+		path, err := confinePath(u.Host+u.Path, fileDir)
+		if err != nil {
+			return nil, fmt.Errorf("file sink: %w", err)
+		}
+		u.Host = ""
+		u.Path = path
+	case "syslog", "syslog+udp", "syslog+tcp":
+		if u.Host == "" {
+			return nil, errors.New("local syslog sinks are not allowed")
+		}
+		fallthrough
+	case "otlp-grpc", "otlp-http", "otlp-https", "kafka":
+		if len(allowedHosts) == 0 {
+			return nil, errors.New("network sinks are not allowed")
+		}
+		hosts := []string{u.Host}
+		if u.Scheme == "kafka" {
+			hosts = append(hosts, u.Query()["broker"]...)
+		}
+		for _, host := range hosts {
+			if !hostAllowed(host, allowedHosts) {
+				return nil, fmt.Errorf("host %q is not allowed", host)
+			}
+		}
+	}
+
+	return newFromURL(u)
+}
+
+// hostAllowed returns whether host, given as host or host:port, matches one of
+// allowedHosts
+func hostAllowed(host string, allowedHosts []string) bool {
+	if host == "" {
+		return false
+	}
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	for _, allowed := range allowedHosts {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "" {
+			continue
+		}
+		if strings.EqualFold(allowed, host) || strings.EqualFold(strings.Trim(allowed, "[]"), hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+// confinePath returns the absolute path of path, relative to dir if it isn't
+// absolute, and checks that it's inside dir, even after resolving the symbolic
+// links of its existing parent directories.
+func confinePath(path string, dir string) (string, error) {
+	if path == "" {
+		return "", errors.New("missing path")
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+	if !isInside(path, dir) || path == dir {
+		return "", fmt.Errorf("%q is not inside %q", path, dir)
+	}
+
+	// The sink creates the missing directories: only resolve the existing ones
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("resolving %q: %w", dir, err)
+	}
+	parent := filepath.Dir(path)
+	for {
+		realParent, err := filepath.EvalSymlinks(parent)
+		if err == nil {
+			if !isInside(realParent, realDir) {
+				return "", fmt.Errorf("%q is not inside %q", realParent, realDir)
+			}
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("resolving %q: %w", parent, err)
+		}
+		parent = filepath.Dir(parent)
+	}
+
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return "", fmt.Errorf("%q is a symbolic link", path)
+	}
+
+	return path, nil
+}
+
+// isInside returns whether path is dir or one of its descendants
+func isInside(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Sinks is a group of sinks written to together
+type Sinks []Sink
+
+// Open creates the sinks described by the given URLs. Sinks already created
+// are closed if one of them fails.
+func Open(sinkURLs []string) (Sinks, error) {
+	sinks := make(Sinks, 0, len(sinkURLs))
+	for _, sinkURL := range sinkURLs {
+		sinkURL = strings.TrimSpace(sinkURL)
+		if sinkURL == "" {
+			continue
+		}
+		sink, err := New(sinkURL)
+		if err != nil {
+			sinks.Close()
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// OpenRestricted is like Open, but only allows the sinks NewRestricted allows
+func OpenRestricted(sinkURLs []string, fileDir string, allowedHosts []string) (Sinks, error) {
+	sinks := make(Sinks, 0, len(sinkURLs))
+	for _, sinkURL := range sinkURLs {
+		sinkURL = strings.TrimSpace(sinkURL)
+		if sinkURL == "" {
+			continue
+		}
+		sink, err := NewRestricted(sinkURL, fileDir, allowedHosts)
+		if err != nil {
+			sinks.Close()
+			return nil, fmt.Errorf("sink %q: %w", sinkURL, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// Write writes the event to all sinks
+func (s Sinks) Write(event []byte) error {
+	var errs []error
+	for _, sink := range s {
+		if err := sink.Write(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes all sinks
+func (s Sinks) Close() error {
+	var errs []error
+	for _, sink := range s {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EventCallback returns a function suitable for p.SetSinkCallback() writing
+// events to the sinks. Events of image-based gadgets are serialized by the JSON
+// converter of the run gadget, so fields are named like in the output of
+// "ig run -o json"; other events are serialized by the JSON formatter of p. In
+// both cases, the Kubernetes and runtime enrichment is included.
+func (s Sinks) EventCallback(desc gadgets.GadgetDesc, info *runTypes.GadgetInfo, p parser.Parser, log logger.Logger) func(any) {
+	if runDesc, ok := desc.(runTypes.RunGadgetDesc); ok && info != nil {
+		if converter := runDesc.JSONConverter(info, &printer{sinks: s, log: log}); converter != nil {
+			return converter
+		}
+	}
+	formatter := p.GetJSONFormatter()
+	return func(ev any) {
+		data, err := formatter.FormatEvent(ev)
+		if err != nil {
+			log.Warnf("formatting event for sinks: %v", err)
+			return
+		}
+		if err := s.Write([]byte(data)); err != nil {
+			log.Warnf("writing event to sinks: %v", err)
+		}
+	}
+}
+
+// printer implements runTypes.Printer for the JSON converter of the run gadget
+type printer struct {
+	sinks Sinks
+	log   logger.Logger
+}
+
+func (p *printer) Output(payload string) {
+	if err := p.sinks.Write([]byte(payload)); err != nil {
+		p.log.Warnf("writing event to sinks: %v", err)
+	}
+}
+
+func (p *printer) Logf(severity logger.Level, format string, params ...any) {
+	p.log.Logf(severity, format, params...)
+}
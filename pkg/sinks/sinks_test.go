@@ -0,0 +1,74 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRestricted(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "f.jsonl"), filepath.Join(dir, "f.jsonl")))
+
+	tests := map[string]struct {
+		url          string
+		fileDir      string
+		allowedHosts []string
+		path         string
+	}{
+		"file_disabled":     {url: "file://" + filepath.Join(dir, "events.jsonl")},
+		"file_absolute":     {url: "file://" + filepath.Join(dir, "events.jsonl"), fileDir: dir, path: filepath.Join(dir, "events.jsonl")},
+		"file_relative":     {url: "file://sub/events.jsonl", fileDir: dir, path: filepath.Join(dir, "sub", "events.jsonl")},
+		"file_outside":      {url: "file:///etc/cron.d/events", fileDir: dir},
+		"file_dotdot":       {url: "file://../events.jsonl", fileDir: dir},
+		"file_dir":          {url: "file://" + dir, fileDir: dir},
+		"file_symlink_dir":  {url: "file://link/events.jsonl", fileDir: dir},
+		"file_symlink_file": {url: "file://f.jsonl", fileDir: dir},
+		"syslog_local":      {url: "syslog://"},
+		"otlp_http":         {url: "otlp-http://localhost:4318", allowedHosts: []string{"localhost:4318"}, path: "-"},
+		"otlp_any_port":     {url: "otlp-http://localhost:4318", allowedHosts: []string{"localhost"}, path: "-"},
+		"otlp_ipv6":         {url: "otlp-http://[::1]:4318", allowedHosts: []string{"::1"}, path: "-"},
+		"otlp_disabled":     {url: "otlp-http://localhost:4318"},
+		"otlp_other_host":   {url: "otlp-grpc://169.254.169.254:80", allowedHosts: []string{"localhost"}},
+		"otlp_other_port":   {url: "otlp-http://localhost:22", allowedHosts: []string{"localhost:4318"}},
+		"syslog_remote":     {url: "syslog+tcp://10.0.0.1:514", allowedHosts: []string{"localhost"}},
+		"kafka_broker":      {url: "kafka://localhost:9092/events?broker=10.0.0.1:9092", allowedHosts: []string{"localhost"}},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			sink, err := NewRestricted(test.url, test.fileDir, test.allowedHosts)
+			if test.path == "" {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer sink.Close()
+			if fs, ok := sink.(*fileSink); ok {
+				require.Equal(t, test.path, fs.path)
+			}
+		})
+	}
+
+	_, err := os.Stat(filepath.Join(outside, "events.jsonl"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"fmt"
+	"log/syslog"
+	"net/url"
+)
+
+const defaultSyslogTag = "inspektor-gadget"
+
+// syslogSink sends events to the local syslog daemon (syslog://) or to a
+// remote one (syslog://host:514, syslog+tcp://host:514). The tag can be set
+// with the "tag" query parameter.
+type syslogSink struct {
+	*batcher
+	writer *syslog.Writer
+}
+
+func newSyslogSink(u *url.URL) (Sink, error) {
+	network := ""
+	switch u.Scheme {
+	case "syslog", "syslog+udp":
+		if u.Host != "" {
+			network = "udp"
+		}
+	case "syslog+tcp":
+		network = "tcp"
+	}
+	if network != "" && u.Host == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+
+	tag := u.Query().Get("tag")
+	if tag == "" {
+		tag = defaultSyslogTag
+	}
+
+	writer, err := syslog.Dial(network, u.Host, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to syslog: %w", err)
+	}
+	s := &syslogSink{writer: writer}
+	s.batcher = newBatcher("syslog", s.send)
+	return s, nil
+}
+
+func (s *syslogSink) send(events [][]byte) error {
+	for _, event := range events {
+		if _, err := s.writer.Write(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	s.batcher.Close()
+	return s.writer.Close()
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/cilium/ebpf/link"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfstats"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/btfgen"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/kallsyms"
@@ -47,10 +48,17 @@ type SocketEnricher struct {
 	objsIter socketsiterObjects
 	links    []link.Link
 
+	unregisterObjects func()
+
 	closeOnce sync.Once
 	done      chan bool
 }
 
+// gadgetInfo attributes the programs and maps of the socket enricher in top
+// ebpf. They are shared by all the gadgets using it, so they are reported on
+// their own.
+var gadgetInfo = bpfstats.GadgetInfo{ID: "socketenricher", Name: "socket enricher"}
+
 func (se *SocketEnricher) SocketsMap() *ebpf.Map {
 	return se.objs.GadgetSockets
 }
@@ -81,7 +89,7 @@ func (se *SocketEnricher) start() error {
 
 	opts := ebpf.CollectionOptions{
 		Programs: ebpf.ProgramOptions{
-			KernelTypes: btfgen.GetBTFSpec(),
+			KernelTypes: btfgen.GetBTFSpec(specIter),
 		},
 	}
 
@@ -95,6 +103,7 @@ func (se *SocketEnricher) start() error {
 	if err != nil {
 		return fmt.Errorf("loading socket enricher asset: %w", err)
 	}
+	opts.Programs.KernelTypes = btfgen.GetBTFSpec(spec)
 
 	if disableBPFIterators {
 		spec.RewriteConstants(map[string]interface{}{
@@ -109,6 +118,7 @@ func (se *SocketEnricher) start() error {
 	if err := spec.LoadAndAssign(&se.objs, &opts); err != nil {
 		return fmt.Errorf("loading ebpf program: %w", err)
 	}
+	se.unregisterObjects = bpfstats.RegisterObjects(gadgetInfo, &se.objs, &se.objsIter)
 
 	var l link.Link
 
@@ -242,6 +252,10 @@ func (se *SocketEnricher) Close() {
 		gadgets.CloseLink(l)
 	}
 	se.links = nil
+	if se.unregisterObjects != nil {
+		se.unregisterObjects()
+		se.unregisterObjects = nil
+	}
 	se.objs.Close()
 	se.objsIter.Close()
 }
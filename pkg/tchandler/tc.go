@@ -22,8 +22,10 @@ import (
 	"net"
 
 	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
 	"github.com/florianl/go-tc"
 	tccore "github.com/florianl/go-tc/core"
+	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
 )
 
@@ -38,8 +40,17 @@ const (
 	filterInfo = uint32(0x1<<16 | 0x0300) // priority (1) << 16 | proto (htons(ETH_P_ALL))
 
 	filterHandleMax = 128
+
+	// Attach types for netkit devices, not available in golang.org/x/sys yet. See
+	// https://github.com/torvalds/linux/blob/v6.7/include/uapi/linux/bpf.h#L1113-L1114
+	attachNetkitPrimary = ebpf.AttachType(54)
+	attachNetkitPeer    = ebpf.AttachType(55)
 )
 
+// errTCXNotSupported is returned when the kernel doesn't support attaching programs with TCX
+// links, the legacy clsact qdisc and tc filters have to be used in that case.
+var errTCXNotSupported = errors.New("tcx links not supported")
+
 func ptr[T any](v T) *T {
 	return &v
 }
@@ -118,3 +129,70 @@ func addTCFilter(tcnl *tc.Tc, prog *ebpf.Program, iface *net.Interface, dir Atta
 
 	return nil, fmt.Errorf("creating filter (too many tries)")
 }
+
+// isNetkit returns true if the given interface is a netkit device. It must be called from the
+// network namespace of the interface.
+func isNetkit(iface *net.Interface) (bool, error) {
+	l, err := netlink.LinkByIndex(iface.Index)
+	if err != nil {
+		return false, fmt.Errorf("getting link %s: %w", iface.Name, err)
+	}
+	return l.Type() == "netkit", nil
+}
+
+// attachTCX attaches the program to the given interface using a TCX link (kernel >= 6.6). The
+// program is detached by the kernel when the link is closed, including when the process exits.
+func attachTCX(prog *ebpf.Program, iface *net.Interface, dir AttachmentDirection) (link.Link, error) {
+	var attachType ebpf.AttachType
+
+	switch dir {
+	case AttachmentDirectionIngress:
+		attachType = ebpf.AttachType(unix.BPF_TCX_INGRESS)
+	case AttachmentDirectionEgress:
+		attachType = ebpf.AttachType(unix.BPF_TCX_EGRESS)
+	default:
+		return nil, fmt.Errorf("invalid attachment direction")
+	}
+
+	l, err := link.AttachRawLink(link.RawLinkOptions{
+		Target:  iface.Index,
+		Program: prog,
+		Attach:  attachType,
+	})
+	if err != nil {
+		return nil, tcxError(err)
+	}
+	return l, nil
+}
+
+// tcxError wraps err with errTCXNotSupported if it means that the kernel doesn't support TCX links
+func tcxError(err error) error {
+	// Older kernels don't know about the TCX attach types or bpf links at all
+	if errors.Is(err, unix.EINVAL) || errors.Is(err, ebpf.ErrNotSupported) {
+		return fmt.Errorf("%w: %w", errTCXNotSupported, err)
+	}
+	return err
+}
+
+// attachNetkit attaches the program to the given netkit device (kernel >= 6.7). Netkit devices
+// don't have ingress hooks, programs run when packets are transmitted by either the primary device
+// or its peer. Hence, ingress on the primary device is the peer transmitting and egress is the
+// primary device transmitting. iface must be the primary device.
+func attachNetkit(prog *ebpf.Program, iface *net.Interface, dir AttachmentDirection) (link.Link, error) {
+	var attachType ebpf.AttachType
+
+	switch dir {
+	case AttachmentDirectionIngress:
+		attachType = attachNetkitPeer
+	case AttachmentDirectionEgress:
+		attachType = attachNetkitPrimary
+	default:
+		return nil, fmt.Errorf("invalid attachment direction")
+	}
+
+	return link.AttachRawLink(link.RawLinkOptions{
+		Target:  iface.Index,
+		Program: prog,
+		Attach:  attachType,
+	})
+}
@@ -17,6 +17,8 @@
 // pkg/networktracer/tracer.go.
 // The main difference is that SchedCLS programs need to be attached to network interfaces and can
 // be attached on ingress or egress.
+// Programs are attached with TCX links when the kernel supports them (>= 6.6) and with a clsact
+// qdisc and tc filters otherwise. Netkit devices are supported through netkit links.
 package tchandler
 
 import (
@@ -26,9 +28,12 @@ import (
 	"sync"
 
 	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
 	"github.com/florianl/go-tc"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfstats"
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/netnsenter"
@@ -41,14 +46,24 @@ const (
 	tailCallMapName = "gadget_tail_call"
 )
 
+// Functions used by attachLink, replaced in tests
+var (
+	isNetkitFunc     = isNetkit
+	attachTCXFunc    = attachTCX
+	attachNetkitFunc = attachNetkit
+)
+
 type attachment struct {
 	// dispatcher is a small eBPF program we attach to each network interface. This programs
 	// does a tail call to the gadget. The purpose of this program is to avoid loading multiple
 	// instances of the gadget when there are different networking interfaces it must be
 	// attached to.
 	dispatcher dispatcherObjects
-	// filter is the tc ebpf filter we attach to the network interface. This filter will execute
-	// the dispatcher above.
+	// link is the TCX or netkit link executing the dispatcher above. It's owned by this process,
+	// so the kernel detaches the program if we exit without cleaning up.
+	link link.Link
+	// filter is the tc ebpf filter we attach to the network interface when TCX isn't supported
+	// by the kernel. This filter will execute the dispatcher above.
 	filter *tc.Object
 
 	// users keeps track of the users' pid that have called Attach(). This can happen for when
@@ -58,9 +73,13 @@ type attachment struct {
 }
 
 func (t *Handler) closeAttachment(a *attachment) {
+	if a.link != nil {
+		a.link.Close()
+	}
 	if a.filter != nil {
 		t.tcnl.Filter().Delete(a.filter)
 	}
+	t.objects.Remove(&a.dispatcher)
 	a.dispatcher.Close()
 }
 
@@ -72,6 +91,10 @@ type Handler struct {
 	// value: attachment
 	attachments map[string]*attachment
 
+	// objects attributes the dispatchers loaded for each network interface to the gadget
+	// instance, for top ebpf
+	objects bpfstats.Objects
+
 	// socket to talk to netlink
 	// TODO: Currently we keep once instance of the socket for each Handler instance. Check if
 	// it makes sense to move this to the tracer to have one single instance per gadget.
@@ -80,6 +103,10 @@ type Handler struct {
 
 	direction AttachmentDirection
 
+	// tcxUnsupported is set once the kernel failed to create a TCX link, so the legacy tc
+	// filters are used directly for the next attachments.
+	tcxUnsupported bool
+
 	// mu protects attachments from concurrent access
 	// AttachContainer and DetachContainer can be called in parallel
 	mu sync.Mutex
@@ -126,9 +153,16 @@ func NewHandler(direction AttachmentDirection) (*Handler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("creating tail call map: %w", err)
 	}
+	t.objects.Add(t.dispatcherMap)
 	return t, nil
 }
 
+// SetGadgetInfo attributes the eBPF programs and maps of the handler, including the ones loaded
+// later, to the given gadget instance
+func (t *Handler) SetGadgetInfo(info bpfstats.GadgetInfo) {
+	t.objects.SetOwner(info)
+}
+
 func (t *Handler) AttachProg(prog *ebpf.Program) error {
 	return t.dispatcherMap.Update(uint32(0), uint32(prog.FD()), ebpf.UpdateAny)
 }
@@ -161,12 +195,6 @@ func (t *Handler) newAttachment(pid uint32, iface *net.Interface, netns uint64,
 		return nil, fmt.Errorf("RewriteConstants while attaching to pid %d: %w", pid, err)
 	}
 
-	// We create the clsact qdisc and leak it. We can't remove it because we'll break any other
-	// application (including other ig instances) that are using it.
-	if qdisc, err = createClsActQdisc(t.tcnl, iface); err != nil && !errors.Is(err, unix.EEXIST) {
-		return nil, fmt.Errorf("creating clsact qdisc: %w", err)
-	}
-
 	optsIngress := ebpf.CollectionOptions{
 		MapReplacements: map[string]*ebpf.Map{
 			tailCallMapName: t.dispatcherMap,
@@ -175,6 +203,21 @@ func (t *Handler) newAttachment(pid uint32, iface *net.Interface, netns uint64,
 	if err = dispatcherSpec.LoadAndAssign(&a.dispatcher, &optsIngress); err != nil {
 		return nil, fmt.Errorf("loading ebpf program: %w", err)
 	}
+	t.objects.Add(&a.dispatcher)
+
+	a.link, err = t.attachLink(a.dispatcher.IgNetDisp, iface, direction)
+	if err == nil {
+		return a, nil
+	}
+	if !errors.Is(err, errTCXNotSupported) {
+		return nil, fmt.Errorf("attaching ebpf program to interface %s: %w", iface.Name, err)
+	}
+
+	// We create the clsact qdisc and leak it. We can't remove it because we'll break any other
+	// application (including other ig instances) that are using it.
+	if qdisc, err = createClsActQdisc(t.tcnl, iface); err != nil && !errors.Is(err, unix.EEXIST) {
+		return nil, fmt.Errorf("creating clsact qdisc: %w", err)
+	}
 
 	a.filter, err = addTCFilter(t.tcnl, a.dispatcher.IgNetDisp, iface, direction)
 	if err != nil {
@@ -184,6 +227,32 @@ func (t *Handler) newAttachment(pid uint32, iface *net.Interface, netns uint64,
 	return a, nil
 }
 
+// attachLink attaches the program to the interface using a bpf link: a netkit one for netkit
+// devices or a TCX one otherwise. It returns errTCXNotSupported if the legacy tc filters have to be
+// used instead. Errors attaching netkit links are returned on purpose, without falling back to tc
+// filters: netkit devices only exist on kernels supporting netkit links (>= 6.7), so these errors
+// are real failures and not a lack of support.
+func (t *Handler) attachLink(prog *ebpf.Program, iface *net.Interface, direction AttachmentDirection) (link.Link, error) {
+	netkit, err := isNetkitFunc(iface)
+	if err != nil {
+		return nil, err
+	}
+	if netkit {
+		return attachNetkitFunc(prog, iface, direction)
+	}
+
+	if t.tcxUnsupported {
+		return nil, errTCXNotSupported
+	}
+
+	l, err := attachTCXFunc(prog, iface, direction)
+	if errors.Is(err, errTCXNotSupported) {
+		log.Debugf("falling back to tc filters: %v", err)
+		t.tcxUnsupported = true
+	}
+	return l, err
+}
+
 func (t *Handler) AttachContainer(container *containercollection.Container) error {
 	// It's not clear what to do with hostNetwork containers. For now we just ignore them.
 	if container.HostNetwork {
@@ -292,6 +361,7 @@ func (t *Handler) DetachIface(iface *net.Interface) error {
 }
 
 func (t *Handler) Close() {
+	t.objects.Clear()
 	for _, a := range t.attachments {
 		t.closeAttachment(a)
 	}
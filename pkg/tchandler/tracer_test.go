@@ -0,0 +1,131 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tchandler
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestTCXError(t *testing.T) {
+	tests := map[string]struct {
+		err         error
+		unsupported bool
+	}{
+		"einval":        {err: unix.EINVAL, unsupported: true},
+		"not_supported": {err: fmt.Errorf("creating link: %w", ebpf.ErrNotSupported), unsupported: true},
+		"eperm":         {err: unix.EPERM},
+		"ebusy":         {err: unix.EBUSY},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			err := tcxError(test.err)
+			require.ErrorIs(t, err, test.err)
+			require.Equal(t, test.unsupported, errors.Is(err, errTCXNotSupported))
+		})
+	}
+}
+
+// fakeAttach replaces the functions used by attachLink for the duration of the test. It records
+// the kind of the links attached.
+func fakeAttach(t *testing.T, netkit bool, tcxErr, netkitErr error) *[]string {
+	attached := []string{}
+
+	oldIsNetkit, oldAttachTCX, oldAttachNetkit := isNetkitFunc, attachTCXFunc, attachNetkitFunc
+	t.Cleanup(func() {
+		isNetkitFunc, attachTCXFunc, attachNetkitFunc = oldIsNetkit, oldAttachTCX, oldAttachNetkit
+	})
+
+	isNetkitFunc = func(*net.Interface) (bool, error) {
+		return netkit, nil
+	}
+	attachTCXFunc = func(*ebpf.Program, *net.Interface, AttachmentDirection) (link.Link, error) {
+		attached = append(attached, "tcx")
+		return nil, tcxErr
+	}
+	attachNetkitFunc = func(*ebpf.Program, *net.Interface, AttachmentDirection) (link.Link, error) {
+		attached = append(attached, "netkit")
+		return nil, netkitErr
+	}
+	return &attached
+}
+
+func TestAttachLink(t *testing.T) {
+	iface := &net.Interface{Index: 1, Name: "eth0"}
+	tcxUnsupportedErr := tcxError(unix.EINVAL)
+
+	t.Run("tcx", func(t *testing.T) {
+		attached := fakeAttach(t, false, nil, nil)
+		h := &Handler{}
+
+		_, err := h.attachLink(nil, iface, AttachmentDirectionIngress)
+		require.NoError(t, err)
+		require.False(t, h.tcxUnsupported)
+		require.Equal(t, []string{"tcx"}, *attached)
+	})
+
+	t.Run("tcx_unsupported", func(t *testing.T) {
+		attached := fakeAttach(t, false, tcxUnsupportedErr, nil)
+		h := &Handler{}
+
+		_, err := h.attachLink(nil, iface, AttachmentDirectionIngress)
+		require.ErrorIs(t, err, errTCXNotSupported)
+		require.True(t, h.tcxUnsupported)
+
+		// The next attachments don't try TCX anymore
+		_, err = h.attachLink(nil, iface, AttachmentDirectionEgress)
+		require.ErrorIs(t, err, errTCXNotSupported)
+		require.Equal(t, []string{"tcx"}, *attached)
+	})
+
+	t.Run("tcx_error", func(t *testing.T) {
+		fakeAttach(t, false, unix.EPERM, nil)
+		h := &Handler{}
+
+		_, err := h.attachLink(nil, iface, AttachmentDirectionIngress)
+		require.ErrorIs(t, err, unix.EPERM)
+		require.NotErrorIs(t, err, errTCXNotSupported)
+		require.False(t, h.tcxUnsupported)
+	})
+
+	t.Run("netkit", func(t *testing.T) {
+		attached := fakeAttach(t, true, nil, nil)
+		h := &Handler{tcxUnsupported: true}
+
+		_, err := h.attachLink(nil, iface, AttachmentDirectionIngress)
+		require.NoError(t, err)
+		require.Equal(t, []string{"netkit"}, *attached)
+	})
+
+	t.Run("netkit_error", func(t *testing.T) {
+		fakeAttach(t, true, nil, unix.EINVAL)
+		h := &Handler{}
+
+		// Netkit errors never fall back to tc filters
+		_, err := h.attachLink(nil, iface, AttachmentDirectionIngress)
+		require.ErrorIs(t, err, unix.EINVAL)
+		require.NotErrorIs(t, err, errTCXNotSupported)
+		require.False(t, h.tcxUnsupported)
+	})
+}
@@ -28,6 +28,7 @@ import (
 const (
 	MaxContainersPerNode = 1024
 	MountMapPrefix       = "mntnsset_"
+	CgroupMapPrefix      = "cgroupset_"
 )
 
 type TracerCollection struct {
@@ -43,6 +44,11 @@ type tracer struct {
 
 	mntnsSetMap *ebpf.Map
 
+	// cgroupSetMap contains the cgroup ids of the matching containers that
+	// don't have their own mount namespace. It's only created when the
+	// collection includes systemd units.
+	cgroupSetMap *ebpf.Map
+
 	gadgetStream *stream.GadgetStream
 }
 
@@ -77,63 +83,105 @@ func (tc *TracerCollection) TracerMapsUpdater() containercollection.FuncNotify {
 
 			for _, t := range tc.tracers {
 				if containercollection.ContainerSelectorMatches(&t.containerSelector, event.Container) {
-					mntnsC := uint64(event.Container.Mntns)
-					one := uint32(1)
-					if mntnsC != 0 {
-						t.mntnsSetMap.Put(mntnsC, one)
-					} else {
-						log.Errorf("new container with mntns=0")
-					}
+					t.addContainer(event.Container)
 				}
 			}
 
 		case containercollection.EventTypeRemoveContainer:
 			for _, t := range tc.tracers {
-				if containercollection.ContainerSelectorMatches(&t.containerSelector, event.Container) {
-					mntnsC := uint64(event.Container.Mntns)
-					t.mntnsSetMap.Delete(mntnsC)
+				if !containercollection.ContainerSelectorMatches(&t.containerSelector, event.Container) {
+					continue
+				}
+				if !event.Container.HasOwnMntns() {
+					if t.cgroupSetMap != nil && event.Container.CgroupID != 0 {
+						t.cgroupSetMap.Delete(event.Container.CgroupID)
+					}
+					continue
 				}
+				// Several containers can share the same mount namespace,
+				// e.g. systemd units running in the host one
+				if tc.mntnsInUse(&t.containerSelector, event.Container) {
+					continue
+				}
+				t.mntnsSetMap.Delete(uint64(event.Container.Mntns))
 			}
 		}
 	}
 }
 
+// addContainer adds the container to the filter maps of the tracer. Tracing
+// the mount namespace of containers that don't have their own would trace
+// other processes as well, e.g. the whole host: they are filtered by cgroup
+// instead.
+func (t *tracer) addContainer(c *containercollection.Container) {
+	one := uint32(1)
+	if !c.HasOwnMntns() {
+		if t.cgroupSetMap != nil && c.CgroupID != 0 {
+			t.cgroupSetMap.Put(c.CgroupID, one)
+		}
+		return
+	}
+
+	mntnsC := uint64(c.Mntns)
+	if mntnsC == 0 {
+		log.Errorf("new container with mntns=0")
+		return
+	}
+	t.mntnsSetMap.Put(mntnsC, one)
+}
+
+// mntnsInUse tells if other containers matching the selector use the mount
+// namespace of the given container
+func (tc *TracerCollection) mntnsInUse(selector *containercollection.ContainerSelector, container *containercollection.Container) bool {
+	inUse := false
+	tc.containerCollection.ContainerRangeWithSelector(selector, func(c *containercollection.Container) {
+		if c.HasOwnMntns() && c.Mntns == container.Mntns && c.Runtime.ContainerID != container.Runtime.ContainerID {
+			inUse = true
+		}
+	})
+	return inUse
+}
+
 func (tc *TracerCollection) AddTracer(id string, containerSelector containercollection.ContainerSelector) error {
 	if _, ok := tc.tracers[id]; ok {
 		return fmt.Errorf("tracer id %q: %w", id, os.ErrExist)
 	}
-	var mntnsSetMap *ebpf.Map
+	t := tracer{
+		tracerID:          id,
+		containerSelector: containerSelector,
+		gadgetStream:      stream.NewGadgetStream(),
+	}
 	if !tc.testOnly {
-		mntnsSpec := &ebpf.MapSpec{
-			Name:       MountMapPrefix + id,
-			Type:       ebpf.Hash,
-			KeySize:    8,
-			ValueSize:  4,
-			MaxEntries: MaxContainersPerNode,
-		}
 		var err error
-		mntnsSetMap, err = ebpf.NewMap(mntnsSpec)
+		t.mntnsSetMap, err = newSetMap(MountMapPrefix + id)
 		if err != nil {
 			return fmt.Errorf("creating mntnsset map: %w", err)
 		}
 
-		tc.containerCollection.ContainerRangeWithSelector(&containerSelector, func(c *containercollection.Container) {
-			one := uint32(1)
-			mntnsC := uint64(c.Mntns)
-			if mntnsC != 0 {
-				mntnsSetMap.Put(mntnsC, one)
+		if tc.containerCollection.HasSystemdUnits() {
+			t.cgroupSetMap, err = newSetMap(CgroupMapPrefix + id)
+			if err != nil {
+				t.mntnsSetMap.Close()
+				return fmt.Errorf("creating cgroupset map: %w", err)
 			}
-		})
-	}
-	tc.tracers[id] = tracer{
-		tracerID:          id,
-		containerSelector: containerSelector,
-		mntnsSetMap:       mntnsSetMap,
-		gadgetStream:      stream.NewGadgetStream(),
+		}
+
+		tc.containerCollection.ContainerRangeWithSelector(&containerSelector, t.addContainer)
 	}
+	tc.tracers[id] = t
 	return nil
 }
 
+func newSetMap(name string) (*ebpf.Map, error) {
+	return ebpf.NewMap(&ebpf.MapSpec{
+		Name:       name,
+		Type:       ebpf.Hash,
+		KeySize:    8,
+		ValueSize:  4,
+		MaxEntries: MaxContainersPerNode,
+	})
+}
+
 func (tc *TracerCollection) RemoveTracer(id string) error {
 	if id == "" {
 		return fmt.Errorf("container id not set")
@@ -144,6 +192,9 @@ func (tc *TracerCollection) RemoveTracer(id string) error {
 		return fmt.Errorf("unknown tracer %q", id)
 	}
 
+	if t.cgroupSetMap != nil {
+		t.cgroupSetMap.Close()
+	}
 	if t.mntnsSetMap != nil {
 		t.mntnsSetMap.Close()
 	}
@@ -199,3 +250,14 @@ func (tc *TracerCollection) TracerMountNsMap(id string) (*ebpf.Map, error) {
 
 	return t.mntnsSetMap, nil
 }
+
+// TracerCgroupMap returns the map of cgroup ids selected by the tracer. It's
+// nil when there are no systemd units to select.
+func (tc *TracerCollection) TracerCgroupMap(id string) (*ebpf.Map, error) {
+	t, ok := tc.tracers[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown tracer %q", id)
+	}
+
+	return t.cgroupSetMap, nil
+}
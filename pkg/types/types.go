@@ -17,6 +17,7 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
@@ -88,6 +89,10 @@ const (
 	RuntimeNameContainerd RuntimeName = "containerd"
 	RuntimeNameCrio       RuntimeName = "cri-o"
 	RuntimeNamePodman     RuntimeName = "podman"
+	RuntimeNameSystemd    RuntimeName = "systemd"
+	RuntimeNameCgroup     RuntimeName = "cgroup"
+	RuntimeNameLXD        RuntimeName = "lxd"
+	RuntimeNameMachined   RuntimeName = "machined"
 	RuntimeNameUnknown    RuntimeName = "unknown"
 )
 
@@ -101,6 +106,14 @@ func String2RuntimeName(name string) RuntimeName {
 		return RuntimeNameCrio
 	case string(RuntimeNamePodman):
 		return RuntimeNamePodman
+	case string(RuntimeNameSystemd):
+		return RuntimeNameSystemd
+	case string(RuntimeNameCgroup):
+		return RuntimeNameCgroup
+	case string(RuntimeNameLXD):
+		return RuntimeNameLXD
+	case string(RuntimeNameMachined):
+		return RuntimeNameMachined
 	}
 	return RuntimeNameUnknown
 }
@@ -221,6 +234,10 @@ type L3Endpoint struct {
 	Name      string            `json:"podname,omitempty" column:"name,hide"`
 	Kind      EndpointKind      `json:"kind,omitempty" column:"kind,hide"`
 	PodLabels map[string]string `json:"podlabels,omitempty" column:"podLabels,hide"`
+
+	// Hostname gets populated by the ReverseDNS operator for endpoints that aren't pods or
+	// services
+	Hostname string `json:"hostname,omitempty" column:"hostname,hide"`
 }
 
 func (e *L3Endpoint) String() string {
@@ -230,8 +247,14 @@ func (e *L3Endpoint) String() string {
 	case EndpointKindService:
 		return "s/" + e.Namespace + "/" + e.Name
 	case EndpointKindRaw:
+		if e.Hostname != "" {
+			return "r/" + e.Hostname
+		}
 		return "r/" + e.Addr
 	default:
+		if e.Hostname != "" {
+			return e.Hostname
+		}
 		if e.Version == 6 {
 			return "[" + e.Addr + "]"
 		}
@@ -311,6 +334,10 @@ type Event struct {
 
 	// Message when Type is ERR, WARN, DEBUG or INFO
 	Message string `json:"message,omitempty"`
+
+	// LostSamples is set on warnings about events lost by the kernel, e.g.
+	// because the perf buffer was full
+	LostSamples uint64 `json:"lostSamples,omitempty"`
 }
 
 // GetBaseEvent is needed to implement commonutils.BaseElement and
@@ -327,6 +354,10 @@ func (e *Event) GetMessage() string {
 	return e.Message
 }
 
+func (e *Event) GetLostSamples() uint64 {
+	return e.LostSamples
+}
+
 func Err(msg string) Event {
 	return Event{
 		CommonData: CommonData{
@@ -375,6 +406,63 @@ func Info(msg string) Event {
 	}
 }
 
+// Lost returns a warning about count events lost by the kernel
+func Lost(count uint64) Event {
+	ev := Warn(fmt.Sprintf("lost %d samples", count))
+	ev.LostSamples = count
+	return ev
+}
+
+// DroppedEvents counts the events of a gadget run that were not delivered, by
+// reason
+type DroppedEvents struct {
+	// Lost is the number of events lost by the kernel, e.g. because the perf or
+	// ring buffer was full
+	Lost uint64 `json:"lost,omitempty"`
+	// Buffer is the number of events dropped because the client didn't receive
+	// them fast enough
+	Buffer uint64 `json:"buffer,omitempty"`
+	// Sampled is the number of events dropped by sampling
+	Sampled uint64 `json:"sampled,omitempty"`
+	// RateLimited is the number of events dropped by the rate limit
+	RateLimited uint64 `json:"rateLimited,omitempty"`
+}
+
+func (d *DroppedEvents) Add(o DroppedEvents) {
+	d.Lost += o.Lost
+	d.Buffer += o.Buffer
+	d.Sampled += o.Sampled
+	d.RateLimited += o.RateLimited
+}
+
+func (d DroppedEvents) Total() uint64 {
+	return d.Lost + d.Buffer + d.Sampled + d.RateLimited
+}
+
+// Unintended returns the number of events dropped because the gadget produced
+// more events than could be handled, i.e. not on purpose
+func (d DroppedEvents) Unintended() uint64 {
+	return d.Lost + d.Buffer
+}
+
+func (d DroppedEvents) String() string {
+	var reasons []string
+	for _, r := range []struct {
+		name  string
+		count uint64
+	}{
+		{"lost", d.Lost},
+		{"client too slow", d.Buffer},
+		{"sampled", d.Sampled},
+		{"rate limited", d.RateLimited},
+	} {
+		if r.count > 0 {
+			reasons = append(reasons, fmt.Sprintf("%s: %d", r.name, r.count))
+		}
+	}
+	return fmt.Sprintf("%d events dropped (%s)", d.Total(), strings.Join(reasons, ", "))
+}
+
 func EventString(i interface{}) string {
 	b, err := json.Marshal(i)
 	if err != nil {
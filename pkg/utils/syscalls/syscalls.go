@@ -1,4 +1,4 @@
-// Copyright 2023 The Inspektor Gadget authors
+// Copyright 2023-2024 The Inspektor Gadget authors
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -12,16 +12,136 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package syscalls provides the syscall tables of the supported architectures.
+// The tables are selected at runtime, so the syscalls of an architecture can be
+// decoded from any other one.
 package syscalls
 
-func GetSyscallNumberByName(name string) (int, bool) {
-	number, ok := syscallsNameToNumber[name]
+import (
+	"fmt"
+	"runtime"
+	"sort"
+)
+
+// Arch is the name of a syscall ABI. Native ones use the names of GOARCH.
+type Arch string
+
+const (
+	ArchAMD64 Arch = "amd64"
+	ArchARM64 Arch = "arm64"
+	// ArchI386 is the 32-bit x86 ABI, used by compat syscalls on amd64
+	ArchI386 Arch = "i386"
+)
+
+// Table maps the syscall names of an architecture to their numbers
+type Table struct {
+	arch         Arch
+	nameToNumber map[string]int
+	numberToName map[int]string
+}
+
+var tables = map[Arch]*Table{
+	ArchAMD64: {ArchAMD64, amd64NameToNumber, amd64NumberToName},
+	ArchARM64: {ArchARM64, arm64NameToNumber, arm64NumberToName},
+	ArchI386:  {ArchI386, i386NameToNumber, i386NumberToName},
+}
+
+// compatArches holds the architecture of the compat syscalls of the
+// architectures supporting them
+var compatArches = map[Arch]Arch{
+	ArchAMD64: ArchI386,
+}
+
+// Arches returns the architectures having a syscall table
+func Arches() []Arch {
+	arches := make([]Arch, 0, len(tables))
+	for arch := range tables {
+		arches = append(arches, arch)
+	}
+	sort.Slice(arches, func(i, j int) bool { return arches[i] < arches[j] })
+	return arches
+}
+
+// NativeArch returns the architecture this binary runs on
+func NativeArch() Arch {
+	return Arch(runtime.GOARCH)
+}
+
+// CompatArch returns the architecture of the compat syscalls of arch, if any
+func CompatArch(arch Arch) (Arch, bool) {
+	compat, ok := compatArches[arch]
+	return compat, ok
+}
+
+// GetTable returns the syscall table of arch
+func GetTable(arch Arch) (*Table, error) {
+	table, ok := tables[arch]
+	if !ok {
+		return nil, fmt.Errorf("no syscall table for architecture %q", arch)
+	}
+	return table, nil
+}
+
+// Native returns the syscall table of the architecture this binary runs on, or
+// nil if there isn't any.
+func Native() *Table {
+	return tables[NativeArch()]
+}
+
+// Compat returns the syscall table of the compat syscalls of the architecture
+// this binary runs on, or nil if there isn't any.
+func Compat() *Table {
+	compat, ok := CompatArch(NativeArch())
+	if !ok {
+		return nil
+	}
+	return tables[compat]
+}
+
+// Arch returns the architecture of the table
+func (t *Table) Arch() Arch {
+	return t.arch
+}
+
+func (t *Table) GetSyscallNumberByName(name string) (int, bool) {
+	if t == nil {
+		return 0, false
+	}
+	number, ok := t.nameToNumber[name]
 
 	return number, ok
 }
 
-func GetSyscallNameByNumber(number int) (string, bool) {
-	name, ok := syscallsNumberToName[number]
+func (t *Table) GetSyscallNameByNumber(number int) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	name, ok := t.numberToName[number]
 
 	return name, ok
 }
+
+// Names returns the sorted names of the syscalls of the table
+func (t *Table) Names() []string {
+	if t == nil {
+		return nil
+	}
+	names := make([]string, 0, len(t.nameToNumber))
+	for name := range t.nameToNumber {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetSyscallNumberByName returns the number of a syscall of the architecture
+// this binary runs on
+func GetSyscallNumberByName(name string) (int, bool) {
+	return Native().GetSyscallNumberByName(name)
+}
+
+// GetSyscallNameByNumber returns the name of a syscall of the architecture this
+// binary runs on
+func GetSyscallNameByNumber(number int) (string, bool) {
+	return Native().GetSyscallNameByNumber(number)
+}
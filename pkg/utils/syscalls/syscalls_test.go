@@ -0,0 +1,63 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syscalls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTables(t *testing.T) {
+	t.Parallel()
+
+	for arch, expected := range map[Arch]int{
+		ArchAMD64: 59,
+		ArchARM64: 221,
+		ArchI386:  11,
+	} {
+		table, err := GetTable(arch)
+		require.NoError(t, err)
+		require.Equal(t, arch, table.Arch())
+
+		number, ok := table.GetSyscallNumberByName("execve")
+		require.True(t, ok)
+		require.Equal(t, expected, number, arch)
+
+		name, ok := table.GetSyscallNameByNumber(expected)
+		require.True(t, ok)
+		require.Equal(t, "execve", name)
+
+		// Both maps must describe the same syscalls
+		require.Len(t, table.numberToName, len(table.nameToNumber), arch)
+		for name, number := range table.nameToNumber {
+			require.Equal(t, name, table.numberToName[number], arch)
+		}
+	}
+
+	_, err := GetTable("foo")
+	require.Error(t, err)
+
+	compat, ok := CompatArch(ArchAMD64)
+	require.True(t, ok)
+	require.Equal(t, ArchI386, compat)
+
+	_, ok = CompatArch(ArchARM64)
+	require.False(t, ok)
+
+	var table *Table
+	_, ok = table.GetSyscallNumberByName("execve")
+	require.False(t, ok)
+}
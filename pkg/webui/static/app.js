@@ -0,0 +1,469 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+'use strict';
+
+// maxRows is the number of rows kept for gadgets streaming events
+const maxRows = 10000;
+
+// maxRenderedRows limits the rows added to the page, the newest ones are shown
+const maxRenderedRows = 1000;
+
+const $ = (id) => document.getElementById(id);
+
+const state = {
+  nodes: [],
+  gadgets: [],
+  selected: null,
+  socket: null,
+
+  // Table of the current run
+  columns: [],      // attributes of all columns, in the order of the cells of rows
+  defaultColumns: [],
+  rows: [],         // {node, cells}
+  showNode: false,
+  sortColumn: -1,
+  sortDesc: false,
+  renderPending: false,
+  logs: 0,
+  dropped: {},      // node => number of dropped events
+};
+
+function el(tag, attrs = {}, ...children) {
+  const e = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs)) {
+    if (k === 'class') {
+      e.className = v;
+    } else if (k.startsWith('on')) {
+      e.addEventListener(k.substring(2), v);
+    } else if (v !== undefined && v !== null && v !== false) {
+      e.setAttribute(k, v === true ? '' : v);
+    }
+  }
+  for (const c of children) {
+    if (c !== null && c !== undefined) {
+      e.append(c);
+    }
+  }
+  return e;
+}
+
+async function loadInfo() {
+  const res = await fetch('api/info');
+  if (!res.ok) {
+    throw new Error(await res.text());
+  }
+  const info = await res.json();
+  state.nodes = info.nodes || [];
+  state.gadgets = (info.catalog && info.catalog.Gadgets) || [];
+  state.gadgets.sort((a, b) => (a.category + a.name).localeCompare(b.category + b.name));
+  $('nodes-info').textContent = state.nodes.length === 1 ?
+    `Node: ${state.nodes[0]}` : `${state.nodes.length} nodes`;
+  renderCatalog();
+}
+
+function renderCatalog() {
+  const search = $('catalog-search').value.toLowerCase();
+  const list = $('catalog-list');
+  list.replaceChildren();
+  let category = null;
+  for (const g of state.gadgets) {
+    const name = gadgetName(g);
+    if (search && !name.toLowerCase().includes(search) && !g.description.toLowerCase().includes(search)) {
+      continue;
+    }
+    if (g.category !== category) {
+      category = g.category;
+      list.append(el('h3', {}, category || 'other'));
+    }
+    list.append(el('a', {
+      href: `#${name}`,
+      title: g.description,
+      class: g === state.selected ? 'selected' : '',
+      onclick: (e) => {
+        e.preventDefault();
+        selectGadget(g);
+      },
+    }, g.name));
+  }
+}
+
+function gadgetName(g) {
+  return g.category ? `${g.category} ${g.name}` : g.name;
+}
+
+function selectGadget(g) {
+  if (state.socket) {
+    return;
+  }
+  state.selected = g;
+  renderCatalog();
+  $('gadget-empty').hidden = true;
+  $('gadget-details').hidden = false;
+  $('gadget-title').textContent = gadgetName(g);
+  $('gadget-description').textContent = g.description;
+
+  const params = $('gadget-params');
+  params.replaceChildren();
+  const gadgetParams = [...(g.params || []), ...(g.gadgetParams || [])];
+  if (gadgetParams.length > 0) {
+    params.append(paramFieldset('Gadget', gadgetParams, ''));
+  }
+  const operators = g.operatorParamsCollection || {};
+  for (const name of Object.keys(operators).sort()) {
+    const descs = operators[name] || [];
+    if (descs.length > 0) {
+      params.append(paramFieldset(`Operator ${name}`, descs, `operator.${name}.`));
+    }
+  }
+  params.append(runFieldset());
+}
+
+// placeholders show the expected format of the params holding structured values
+const placeholders = {
+  map: 'key=value,key2=value2',
+  cidrs: '10.0.0.0/8,fd00::/8',
+  ports: '53,8000-8080',
+  bytesize: '64Ki',
+};
+
+// paramFieldset builds inputs for the given params; the prefix is prepended to
+// the keys of the params as expected by the gadget service
+function paramFieldset(title, descs, prefix) {
+  const fieldset = el('fieldset', {}, el('legend', {}, title));
+  for (const desc of descs) {
+    const key = prefix + desc.key;
+    const label = desc.title || desc.key;
+    const mandatory = desc.isMandatory ? el('span', {class: 'mandatory'}, ' *') : null;
+    let input;
+    if (desc.type === 'bool') {
+      input = el('input', {type: 'checkbox', 'data-key': key, 'data-default': desc.defaultValue || 'false'});
+      input.checked = desc.defaultValue === 'true';
+      fieldset.append(el('label', {class: 'checkbox', title: desc.description}, input, label, mandatory));
+      continue;
+    }
+    if (desc.possibleValues && desc.possibleValues.length > 0) {
+      input = el('select', {'data-key': key, 'data-default': desc.defaultValue || ''});
+      if (!desc.possibleValues.includes(desc.defaultValue || '')) {
+        input.append(el('option', {value: ''}, ''));
+      }
+      const descriptions = desc.valueDescriptions || {};
+      for (const v of desc.possibleValues) {
+        const text = descriptions[v] ? `${v} - ${descriptions[v]}` : v;
+        input.append(el('option', {value: v, selected: v === desc.defaultValue, title: descriptions[v]}, text));
+      }
+    } else {
+      input = el('input', {
+        type: 'text',
+        'data-key': key,
+        'data-default': desc.defaultValue || '',
+        placeholder: desc.defaultValue || placeholders[desc.type] || desc.type || '',
+        required: desc.isMandatory && !desc.defaultValue,
+      });
+    }
+    fieldset.append(el('label', {title: desc.description}, el('span', {}, label, mandatory), input));
+  }
+  return fieldset;
+}
+
+function runFieldset() {
+  const fieldset = el('fieldset', {}, el('legend', {}, 'Run'));
+  if (state.nodes.length > 1) {
+    const nodes = el('select', {id: 'run-nodes', multiple: true, size: Math.min(state.nodes.length, 5)});
+    for (const node of state.nodes) {
+      nodes.append(el('option', {value: node, selected: true}, node));
+    }
+    fieldset.append(el('label', {title: 'Nodes to run the gadget on'}, 'Nodes', nodes));
+  }
+  fieldset.append(el('label', {title: 'Number of seconds to run the gadget; 0 runs it until stopped'},
+    'Timeout', el('input', {id: 'run-timeout', type: 'number', min: 0, value: 0})));
+  fieldset.append(el('label', {title: 'Arguments not given as params, separated by spaces'},
+    'Arguments', el('input', {id: 'run-args', type: 'text'})));
+  return fieldset;
+}
+
+// collectParams returns the params that differ from their defaults
+function collectParams() {
+  const params = {};
+  for (const input of $('gadget-params').querySelectorAll('[data-key]')) {
+    const value = input.type === 'checkbox' ? String(input.checked) : input.value.trim();
+    if (value !== '' && value !== input.dataset.default) {
+      params[input.dataset.key] = value;
+    }
+  }
+  return params;
+}
+
+function run() {
+  const g = state.selected;
+  const nodesSelect = $('run-nodes');
+  const nodes = nodesSelect ? [...nodesSelect.selectedOptions].map((o) => o.value) : [];
+  if (nodesSelect && nodes.length === 0) {
+    alert('Select at least one node');
+    return;
+  }
+  const args = $('run-args').value.trim();
+
+  resetOutput();
+  state.showNode = nodes.length > 1 || (!nodesSelect && state.nodes.length > 1);
+
+  const url = new URL('api/run', window.location.href);
+  url.protocol = url.protocol === 'https:' ? 'wss:' : 'ws:';
+  const socket = new WebSocket(url);
+  state.socket = socket;
+  setRunning(true);
+
+  socket.onopen = () => {
+    socket.send(JSON.stringify({
+      type: 'run',
+      category: g.category,
+      gadget: g.name,
+      params: collectParams(),
+      args: args ? args.split(/\s+/) : [],
+      nodes: nodes,
+      timeout: parseInt($('run-timeout').value, 10) || 0,
+    }));
+    setStatus('running');
+  };
+  socket.onmessage = (e) => handleMessage(JSON.parse(e.data));
+  socket.onerror = () => addLog('error', '', 'connection error');
+  socket.onclose = () => {
+    state.socket = null;
+    setRunning(false);
+    setStatus('finished');
+  };
+}
+
+function stop() {
+  if (state.socket) {
+    state.socket.send(JSON.stringify({type: 'stop'}));
+    setStatus('stopping');
+  }
+}
+
+function setRunning(running) {
+  $('run').disabled = running;
+  $('stop').disabled = !running;
+}
+
+function handleMessage(msg) {
+  switch (msg.type) {
+    case 'columns':
+      state.columns = msg.columns || [];
+      state.defaultColumns = msg.defaultColumns || [];
+      scheduleRender();
+      break;
+    case 'rows':
+      addRows(msg.node, msg.rows || [], msg.replace);
+      break;
+    case 'event':
+      if (state.columns.length === 0) {
+        state.columns = [{Name: 'event', Visible: true}];
+        state.defaultColumns = ['event'];
+      }
+      addRows(msg.node, [[JSON.stringify(msg.event)]], false);
+      break;
+    case 'result':
+      showResult(msg.node, msg.message);
+      break;
+    case 'log':
+      addLog(msg.level, msg.node, msg.message);
+      break;
+    case 'error':
+      addLog('error', msg.node, msg.message);
+      break;
+    case 'dropped':
+      state.dropped[msg.node || ''] = Object.values(msg.dropped || {}).reduce((a, b) => a + b, 0);
+      scheduleRender();
+      break;
+    case 'done':
+      setStatus('finished');
+      break;
+  }
+}
+
+function addRows(node, rows, replace) {
+  if (replace) {
+    // Gadgets reporting at intervals send all their rows at once
+    state.rows = state.rows.filter((r) => r.node !== node);
+  }
+  for (const cells of rows) {
+    state.rows.push({node: node || '', cells});
+  }
+  if (state.rows.length > maxRows) {
+    state.rows.splice(0, state.rows.length - maxRows);
+  }
+  scheduleRender();
+}
+
+function showResult(node, message) {
+  const results = $('results');
+  results.hidden = false;
+  if (state.showNode) {
+    results.append(`--- ${node} ---\n`);
+  }
+  results.append(message + '\n');
+}
+
+function addLog(level, node, message) {
+  state.logs++;
+  $('logs-count').textContent = state.logs;
+  const prefix = node ? `${node} | ` : '';
+  $('logs').append(el('div', {class: `log-${level}`}, `[${level}] ${prefix}${message}`));
+  if (level === 'error') {
+    $('logs-details').open = true;
+  }
+}
+
+function setStatus(status) {
+  state.status = status;
+  scheduleRender();
+}
+
+function resetOutput() {
+  state.columns = [];
+  state.defaultColumns = [];
+  state.rows = [];
+  state.sortColumn = -1;
+  state.sortDesc = false;
+  state.logs = 0;
+  state.dropped = {};
+  $('output').hidden = false;
+  $('results').hidden = true;
+  $('results').replaceChildren();
+  $('logs').replaceChildren();
+  $('logs-count').textContent = '0';
+  scheduleRender();
+}
+
+function scheduleRender() {
+  if (!state.renderPending) {
+    state.renderPending = true;
+    requestAnimationFrame(render);
+  }
+}
+
+// shownColumns returns the indexes of the columns to show
+function shownColumns() {
+  const all = $('all-columns').checked;
+  const shown = [];
+  state.columns.forEach((c, i) => {
+    if (all || state.defaultColumns.includes(c.Name)) {
+      shown.push(i);
+    }
+  });
+  return shown;
+}
+
+function compareCells(a, b) {
+  const na = Number(a);
+  const nb = Number(b);
+  if (a !== '' && b !== '' && !isNaN(na) && !isNaN(nb)) {
+    return na - nb;
+  }
+  return a.localeCompare(b);
+}
+
+function render() {
+  state.renderPending = false;
+
+  const shown = shownColumns();
+  const thead = el('tr');
+  if (state.showNode) {
+    thead.append(el('th', {}, 'NODE'));
+  }
+  for (const i of shown) {
+    const c = state.columns[i];
+    const arrow = state.sortColumn === i ? (state.sortDesc ? ' ▼' : ' ▲') : '';
+    thead.append(el('th', {
+      class: c.Alignment === 1 ? 'right' : '',
+      title: c.Description || '',
+      onclick: () => sortBy(i),
+    }, c.Name.toUpperCase() + arrow));
+  }
+  $('events').tHead.replaceChildren(thead);
+
+  const filter = $('filter').value.toLowerCase();
+  let rows = state.rows;
+  if (filter) {
+    rows = rows.filter((r) => r.node.toLowerCase().includes(filter) ||
+      shown.some((i) => r.cells[i].toLowerCase().includes(filter)));
+  }
+  if (state.sortColumn >= 0) {
+    const i = state.sortColumn;
+    rows = [...rows].sort((a, b) => {
+      const res = compareCells(a.cells[i], b.cells[i]);
+      return state.sortDesc ? -res : res;
+    });
+  }
+
+  const visible = state.sortColumn >= 0 ? rows.slice(0, maxRenderedRows) : rows.slice(-maxRenderedRows);
+  const tbody = document.createElement('tbody');
+  for (const r of visible) {
+    const tr = el('tr');
+    if (state.showNode) {
+      tr.append(el('td', {}, r.node));
+    }
+    for (const i of shown) {
+      tr.append(el('td', {class: state.columns[i].Alignment === 1 ? 'right' : ''}, r.cells[i]));
+    }
+    tbody.append(tr);
+  }
+  $('events').tBodies[0].replaceWith(tbody);
+
+  let status = `${state.status || ''} · ${rows.length} rows`;
+  if (rows.length > visible.length) {
+    status += ` (showing ${visible.length})`;
+  }
+  const dropped = Object.values(state.dropped).reduce((a, b) => a + b, 0);
+  if (dropped > 0) {
+    status += ` · ${dropped} events dropped`;
+  }
+  $('status').textContent = status;
+
+  if ($('follow').checked && state.sortColumn < 0) {
+    const wrapper = $('table-wrapper');
+    wrapper.scrollTop = wrapper.scrollHeight;
+  }
+}
+
+function sortBy(i) {
+  if (state.sortColumn === i) {
+    if (state.sortDesc) {
+      state.sortColumn = -1;
+    }
+    state.sortDesc = !state.sortDesc;
+  } else {
+    state.sortColumn = i;
+    state.sortDesc = false;
+  }
+  scheduleRender();
+}
+
+$('catalog-search').addEventListener('input', renderCatalog);
+$('filter').addEventListener('input', scheduleRender);
+$('all-columns').addEventListener('change', scheduleRender);
+$('clear').addEventListener('click', () => {
+  state.rows = [];
+  scheduleRender();
+});
+$('stop').addEventListener('click', stop);
+$('gadget-form').addEventListener('submit', (e) => {
+  e.preventDefault();
+  run();
+});
+
+loadInfo().catch((err) => {
+  $('gadget-empty').textContent = `Loading the catalog failed: ${err.message}`;
+});
@@ -0,0 +1,559 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webui serves a web interface for the gadget service. It shows the
+// catalog of gadgets, builds forms for their params and runs them, showing the
+// events as live tables. Browsers talk to it using plain HTTP and a WebSocket
+// per run; the server forwards the requests to the gadget service of one or
+// more nodes using gRPC.
+package webui
+
+import (
+	"context"
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+const (
+	// stopTimeout is the time given to gadgets to send their results after
+	// the browser closed the connection
+	stopTimeout = 5 * time.Second
+
+	// writeTimeout is the maximum time to wait for the browser to accept a
+	// message
+	writeTimeout = 10 * time.Second
+
+	// tokenParam is the query parameter the token is given with, it's then
+	// kept in the tokenCookie cookie
+	tokenParam  = "token"
+	tokenCookie = "ig-web-token"
+)
+
+// Connector returns clients for the gadget service of the given nodes by node
+// name, or for all nodes if nodes is empty. The returned function releases
+// the clients once they're not needed anymore.
+type Connector func(ctx context.Context, nodes []string) (map[string]api.GadgetManagerClient, func(), error)
+
+type Server struct {
+	connect          Connector
+	logger           logger.Logger
+	hiddenColumnTags []string
+	token            string
+}
+
+// New returns a server using connect to reach the gadget service.
+// hiddenColumnTags hides columns with any of the given tags by default, like
+// for the columns output mode.
+func New(connect Connector, logger logger.Logger, hiddenColumnTags []string) *Server {
+	return &Server{
+		connect:          connect,
+		logger:           logger,
+		hiddenColumnTags: hiddenColumnTags,
+	}
+}
+
+// SetToken requires browsers to authenticate with token. It's given once in
+// the "token" query parameter, e.g. http://127.0.0.1:8080/?token=..., and then
+// kept in a cookie.
+func (s *Server) SetToken(token string) {
+	s.token = token
+}
+
+// Handler returns the handler serving the web interface and its API. If
+// restrictHosts is set, only requests using localhost or a loopback address as
+// host are accepted; this protects servers listening on loopback addresses
+// from DNS rebinding attacks by other websites.
+func (s *Server) Handler(restrictHosts bool) http.Handler {
+	static, _ := fs.Sub(staticFiles, "static")
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	mux.HandleFunc("/api/info", s.handleInfo)
+	mux.HandleFunc("/api/gadget-info", s.handleGadgetInfo)
+	mux.HandleFunc("/api/run", s.handleRun)
+
+	var handler http.Handler = mux
+	if s.token != "" {
+		handler = s.authenticate(handler)
+	}
+
+	if !restrictHosts {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLocalHost(r.Host) {
+			http.Error(w, "invalid host", http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// authenticate only passes requests carrying the token of the server to next
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if token := query.Get(tokenParam); token != "" {
+			if !s.validToken(token) {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+
+			// Don't leave the token in the address bar and the history
+			query.Del(tokenParam)
+			u := *r.URL
+			u.RawQuery = query.Encode()
+			http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+			return
+		}
+
+		cookie, err := r.Cookie(tokenCookie)
+		if err != nil || !s.validToken(cookie.Value) {
+			http.Error(w, "missing or invalid token, open the web interface with ?token=<token>", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) validToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// Serve serves the web interface on listener until ctx is done
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	restrictHosts := false
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+		restrictHosts = addr.IP.IsLoopback()
+	}
+
+	server := &http.Server{
+		Handler:           s.Handler(restrictHosts),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	err := server.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// isLocalHost returns whether host (as given in the Host header) refers to
+// the local machine
+func isLocalHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+type infoResponse struct {
+	Nodes        []string        `json:"nodes"`
+	Catalog      json.RawMessage `json:"catalog"`
+	Experimental bool            `json:"experimental"`
+}
+
+// handleInfo returns the available nodes and the catalog of the first one
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clients, release, err := s.connect(r.Context(), nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("connecting to gadget service: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer release()
+
+	nodes := sortedNodes(clients)
+	if len(nodes) == 0 {
+		http.Error(w, "no nodes available", http.StatusBadGateway)
+		return
+	}
+
+	info, err := clients[nodes[0]].GetInfo(r.Context(), &api.InfoRequest{Version: "1.0"})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("getting info from node %q: %v", nodes[0], err), http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, &infoResponse{
+		Nodes:        nodes,
+		Catalog:      info.Catalog,
+		Experimental: info.Experimental,
+	})
+}
+
+type gadgetInfoRequest struct {
+	Params map[string]string `json:"params"`
+	Args   []string          `json:"args"`
+}
+
+// handleGadgetInfo returns the information about a gadget image, like its
+// params and fields, from the first node
+func (s *Server) handleGadgetInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req gadgetInfoRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("decoding request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	clients, release, err := s.connect(r.Context(), nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("connecting to gadget service: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer release()
+
+	nodes := sortedNodes(clients)
+	if len(nodes) == 0 {
+		http.Error(w, "no nodes available", http.StatusBadGateway)
+		return
+	}
+
+	res, err := clients[nodes[0]].GetGadgetInfo(r.Context(), &api.GetGadgetInfoRequest{
+		Params: req.Params,
+		Args:   req.Args,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("getting gadget info: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(res.Info)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func sortedNodes(clients map[string]api.GadgetManagerClient) []string {
+	nodes := make([]string, 0, len(clients))
+	for node := range clients {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// clientMessage is sent by the browser; the first message of a run must be of
+// type "run", afterwards "stop" can be sent to stop the gadget
+type clientMessage struct {
+	Type     string            `json:"type"`
+	Category string            `json:"category"`
+	Gadget   string            `json:"gadget"`
+	Params   map[string]string `json:"params"`
+	Args     []string          `json:"args"`
+	Nodes    []string          `json:"nodes"`
+	// Timeout in seconds; 0 runs the gadget until it's stopped
+	Timeout int64 `json:"timeout"`
+}
+
+// Types of messages sent to the browser
+const (
+	// messageColumns describes the columns of the rows that follow
+	messageColumns = "columns"
+	// messageRows contains events formatted as rows; if replace is set, they
+	// replace all rows previously received from the node
+	messageRows = "rows"
+	// messageEvent contains an event as JSON; it's used for gadgets unknown to
+	// the server
+	messageEvent  = "event"
+	messageResult = "result"
+	messageLog    = "log"
+	messageError  = "error"
+	// messageDropped contains the number of events of the node dropped so far
+	messageDropped = "dropped"
+	// messageDone is the last message of a run
+	messageDone = "done"
+)
+
+type serverMessage struct {
+	Type           string          `json:"type"`
+	Node           string          `json:"node,omitempty"`
+	Columns        any             `json:"columns,omitempty"`
+	DefaultColumns []string        `json:"defaultColumns,omitempty"`
+	Rows           [][]string      `json:"rows,omitempty"`
+	Replace        bool            `json:"replace,omitempty"`
+	Event          json.RawMessage `json:"event,omitempty"`
+	Level          string          `json:"level,omitempty"`
+	Message        string          `json:"message,omitempty"`
+	Dropped        json.RawMessage `json:"dropped,omitempty"`
+}
+
+// wsConn serializes writes to a WebSocket connection
+type wsConn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (c *wsConn) send(msg *serverMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WriteJSON(msg)
+}
+
+var upgrader = websocket.Upgrader{
+	// The default CheckOrigin rejects requests from other origins, so other
+	// websites can't run gadgets using the browser of the user
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// handleRun runs a gadget on the requested nodes and forwards its output to
+// the browser via WebSocket
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	c, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already replied with an error
+		s.logger.Debugf("upgrading connection: %v", err)
+		return
+	}
+	defer c.Close()
+	conn := &wsConn{conn: c}
+
+	var req clientMessage
+	if err := c.ReadJSON(&req); err != nil {
+		s.logger.Debugf("reading run request: %v", err)
+		return
+	}
+	if req.Type != "run" || req.Gadget == "" {
+		conn.send(&serverMessage{Type: messageError, Message: "expected run request"})
+		return
+	}
+
+	if err := s.run(r.Context(), conn, &req); err != nil {
+		conn.send(&serverMessage{Type: messageError, Message: err.Error()})
+	}
+	conn.send(&serverMessage{Type: messageDone})
+}
+
+func (s *Server) run(ctx context.Context, conn *wsConn, req *clientMessage) error {
+	// The connections to the nodes aren't bound to the request, so results
+	// can still be received after the browser asked to stop
+	connCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clients, release, err := s.connect(ctx, req.Nodes)
+	if err != nil {
+		return fmt.Errorf("connecting to gadget service: %w", err)
+	}
+	defer release()
+	if len(clients) == 0 {
+		return errors.New("no nodes available")
+	}
+
+	runRequest := &api.GadgetRunRequest{
+		GadgetName:     req.Gadget,
+		GadgetCategory: req.Category,
+		Params:         req.Params,
+		Args:           req.Args,
+		LogLevel:       uint32(logger.InfoLevel),
+		Timeout:        int64(time.Duration(req.Timeout) * time.Second),
+	}
+
+	// Gadgets known to this binary are sent as rows formatted by their parser,
+	// so the browser doesn't need to know how columns map to fields
+	var newRowsFunc func(node string) func([]byte) ([][]string, error)
+	if gadgetDesc := gadgetregistry.Get(req.Category, req.Gadget); gadgetDesc != nil {
+		if parser := gadgetDesc.Parser(); parser != nil {
+			_, setsNode := gadgetDesc.EventPrototype().(operators.NodeSetter)
+			newRowsFunc = func(node string) func([]byte) ([][]string, error) {
+				if !setsNode {
+					return parser.JSONRowsFunc()
+				}
+				return parser.JSONRowsFunc(func(ev any) error {
+					ev.(operators.NodeSetter).SetNode(node)
+					return nil
+				})
+			}
+			err := conn.send(&serverMessage{
+				Type:           messageColumns,
+				Columns:        parser.GetColumnAttributes(),
+				DefaultColumns: parser.GetDefaultColumns(s.hiddenColumnTags...),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		// Any message (or closing the connection) stops the gadget
+		var msg clientMessage
+		err := conn.conn.ReadJSON(&msg)
+		close(stop)
+		if err != nil {
+			// Nobody is left to receive the results
+			time.AfterFunc(stopTimeout, cancel)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for node, client := range clients {
+		node, client := node, client
+		var rowsFunc func([]byte) ([][]string, error)
+		if newRowsFunc != nil {
+			rowsFunc = newRowsFunc(node)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.runOnNode(connCtx, conn, node, client, runRequest, rowsFunc, stop)
+			if err != nil {
+				conn.send(&serverMessage{Type: messageError, Node: node, Message: err.Error()})
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s *Server) runOnNode(
+	ctx context.Context,
+	conn *wsConn,
+	node string,
+	client api.GadgetManagerClient,
+	runRequest *api.GadgetRunRequest,
+	rowsFunc func([]byte) ([][]string, error),
+	stop <-chan struct{},
+) error {
+	runClient, err := client.RunGadget(ctx)
+	if err != nil {
+		return err
+	}
+	controlRequest := &api.GadgetControlRequest{Event: &api.GadgetControlRequest_RunRequest{RunRequest: runRequest}}
+	if err := runClient.Send(controlRequest); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			select {
+			case <-done:
+				// Finished already; stop is also closed when the browser
+				// disconnects after the run
+				return
+			default:
+			}
+			controlRequest := &api.GadgetControlRequest{Event: &api.GadgetControlRequest_StopRequest{StopRequest: &api.GadgetStopRequest{}}}
+			runClient.Send(controlRequest)
+		case <-done:
+		}
+	}()
+
+	for {
+		ev, err := runClient.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var msg *serverMessage
+		switch ev.Type {
+		case api.EventTypeGadgetPayload:
+			if rowsFunc == nil {
+				msg = &serverMessage{Type: messageEvent, Node: node, Event: ev.Payload}
+				break
+			}
+			rows, err := rowsFunc(ev.Payload)
+			if err != nil {
+				s.logger.Warnf("%-20s | %v", node, err)
+				continue
+			}
+			msg = &serverMessage{
+				Type:    messageRows,
+				Node:    node,
+				Rows:    rows,
+				Replace: len(ev.Payload) > 0 && ev.Payload[0] == '[',
+			}
+		case api.EventTypeGadgetResult:
+			msg = &serverMessage{Type: messageResult, Node: node, Message: string(ev.Payload)}
+		case api.EventTypeGadgetDropped:
+			msg = &serverMessage{Type: messageDropped, Node: node, Dropped: ev.Payload}
+		case api.EventTypeGadgetJobID, api.EventTypeGadgetDone:
+			continue
+		default:
+			if ev.Type < 1<<api.EventLogShift {
+				s.logger.Debugf("%-20s | unknown payload type %d", node, ev.Type)
+				continue
+			}
+			msg = &serverMessage{
+				Type:    messageLog,
+				Node:    node,
+				Level:   logger.Level(ev.Type >> api.EventLogShift).String(),
+				Message: string(ev.Payload),
+			}
+		}
+		if err := conn.send(msg); err != nil {
+			return fmt.Errorf("sending to browser: %w", err)
+		}
+	}
+}
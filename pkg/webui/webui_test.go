@@ -0,0 +1,315 @@
+// Copyright 2024 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+)
+
+type stubEvent struct {
+	Node string `json:"node,omitempty" column:"node"`
+	Comm string `json:"command,omitempty" column:"comm"`
+	Pid  uint32 `json:"pid,omitempty" column:"pid,hide"`
+}
+
+func (ev *stubEvent) SetNode(node string) {
+	ev.Node = node
+}
+
+type stubGadget struct{}
+
+func (g *stubGadget) Name() string                  { return "stub" }
+func (g *stubGadget) Description() string           { return "stub gadget" }
+func (g *stubGadget) Category() string              { return "webui" }
+func (g *stubGadget) Type() gadgets.GadgetType      { return gadgets.TypeTrace }
+func (g *stubGadget) ParamDescs() params.ParamDescs { return nil }
+func (g *stubGadget) EventPrototype() any           { return &stubEvent{} }
+func (g *stubGadget) Parser() parser.Parser {
+	return parser.NewParser(columns.MustCreateColumns[stubEvent]())
+}
+
+func init() {
+	gadgetregistry.Register(&stubGadget{})
+}
+
+// fakeClient replays events for every gadget run and records the requests
+type fakeClient struct {
+	events []*api.GadgetEvent
+
+	mu       sync.Mutex
+	requests []*api.GadgetControlRequest
+}
+
+func (c *fakeClient) GetInfo(ctx context.Context, in *api.InfoRequest, opts ...grpc.CallOption) (*api.InfoResponse, error) {
+	return &api.InfoResponse{Catalog: []byte(`{"Gadgets":[{"name":"stub"}]}`)}, nil
+}
+
+func (c *fakeClient) GetGadgetInfo(ctx context.Context, in *api.GetGadgetInfoRequest, opts ...grpc.CallOption) (*api.GetGadgetInfoResponse, error) {
+	return &api.GetGadgetInfoResponse{Info: []byte(`{"id":"` + in.Args[0] + `"}`)}, nil
+}
+
+func (c *fakeClient) RunGadget(ctx context.Context, opts ...grpc.CallOption) (api.GadgetManager_RunGadgetClient, error) {
+	return &fakeRunClient{client: c, events: c.events}, nil
+}
+
+type fakeRunClient struct {
+	grpc.ClientStream
+	client *fakeClient
+	events []*api.GadgetEvent
+}
+
+func (rc *fakeRunClient) Send(req *api.GadgetControlRequest) error {
+	rc.client.mu.Lock()
+	defer rc.client.mu.Unlock()
+
+	rc.client.requests = append(rc.client.requests, req)
+	return nil
+}
+
+func (rc *fakeRunClient) Recv() (*api.GadgetEvent, error) {
+	if len(rc.events) == 0 {
+		return nil, io.EOF
+	}
+	ev := rc.events[0]
+	rc.events = rc.events[1:]
+	return ev, nil
+}
+
+func newTestServer(t *testing.T, clients map[string]api.GadgetManagerClient) *httptest.Server {
+	connect := func(ctx context.Context, nodes []string) (map[string]api.GadgetManagerClient, func(), error) {
+		if len(nodes) == 0 {
+			return clients, func() {}, nil
+		}
+		selected := make(map[string]api.GadgetManagerClient)
+		for _, node := range nodes {
+			selected[node] = clients[node]
+		}
+		return selected, func() {}, nil
+	}
+	server := httptest.NewServer(New(connect, logger.DefaultLogger(), nil).Handler(false))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestInfo(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, map[string]api.GadgetManagerClient{
+		"node2": &fakeClient{},
+		"node1": &fakeClient{},
+	})
+
+	res, err := http.Get(server.URL + "/api/info")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var info infoResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&info))
+	require.Equal(t, []string{"node1", "node2"}, info.Nodes)
+	require.JSONEq(t, `{"Gadgets":[{"name":"stub"}]}`, string(info.Catalog))
+
+	res, err = http.Post(server.URL+"/api/gadget-info", "application/json", strings.NewReader(`{"args":["image"]}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"image"}`, string(body))
+
+	res, err = http.Get(server.URL + "/")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestRestrictHosts(t *testing.T) {
+	t.Parallel()
+
+	handler := New(nil, logger.DefaultLogger(), nil).Handler(true)
+
+	tests := map[string]struct {
+		host     string
+		expected int
+	}{
+		"localhost":      {host: "localhost:8080", expected: http.StatusOK},
+		"loopback_ipv4":  {host: "127.0.0.1:8080", expected: http.StatusOK},
+		"loopback_ipv6":  {host: "[::1]:8080", expected: http.StatusOK},
+		"other_host":     {host: "attacker.example.com:8080", expected: http.StatusForbidden},
+		"other_address":  {host: "192.0.2.1", expected: http.StatusForbidden},
+		"localhost_like": {host: "localhost.example.com", expected: http.StatusForbidden},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = test.host
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, test.expected, rec.Code)
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	t.Parallel()
+
+	server := New(nil, logger.DefaultLogger(), nil)
+	server.SetToken("secret")
+	handler := server.Handler(false)
+
+	serve := func(target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusUnauthorized, serve("/", nil).Code)
+	require.Equal(t, http.StatusUnauthorized, serve("/api/info", nil).Code)
+	require.Equal(t, http.StatusUnauthorized, serve("/?token=wrong", nil).Code)
+	require.Equal(t, http.StatusUnauthorized, serve("/", &http.Cookie{Name: tokenCookie, Value: "wrong"}).Code)
+
+	// The token is moved from the address to a cookie
+	rec := serve("/?token=secret&x=1", nil)
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	require.Equal(t, "/?x=1", rec.Header().Get("Location"))
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, tokenCookie, cookies[0].Name)
+	require.Equal(t, "secret", cookies[0].Value)
+	require.True(t, cookies[0].HttpOnly)
+
+	require.Equal(t, http.StatusOK, serve("/", cookies[0]).Code)
+}
+
+func readMessages(t *testing.T, conn *websocket.Conn) []serverMessage {
+	var msgs []serverMessage
+	for {
+		var msg serverMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		msgs = append(msgs, msg)
+		if msg.Type == messageDone {
+			return msgs
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{
+		events: []*api.GadgetEvent{
+			{Type: api.EventTypeGadgetJobID, Payload: []byte("id")},
+			{Type: uint32(logger.WarnLevel) << api.EventLogShift, Payload: []byte("warning")},
+			{Type: api.EventTypeGadgetPayload, Seq: 1, Payload: []byte(`{"command":"cat","pid":1}`)},
+			{Type: api.EventTypeGadgetPayload, Seq: 2, Payload: []byte(`[{"command":"ls"},{"command":"sh"}]`)},
+			{Type: api.EventTypeGadgetDropped, Payload: []byte(`{"buffer":3}`)},
+			{Type: api.EventTypeGadgetResult, Payload: []byte("result")},
+		},
+	}
+	server := newTestServer(t, map[string]api.GadgetManagerClient{"node1": client})
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/run"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(&clientMessage{
+		Type:     "run",
+		Category: "webui",
+		Gadget:   "stub",
+		Params:   map[string]string{"operator.localmanager.host": "true"},
+		Timeout:  2,
+	}))
+	msgs := readMessages(t, conn)
+
+	require.Len(t, msgs, 7)
+	require.Equal(t, messageColumns, msgs[0].Type)
+	require.Equal(t, []string{"node", "comm"}, msgs[0].DefaultColumns)
+	require.Equal(t, serverMessage{Type: messageLog, Node: "node1", Level: "warning", Message: "warning"}, msgs[1])
+	require.Equal(t, serverMessage{Type: messageRows, Node: "node1", Rows: [][]string{{"node1", "cat", "1"}}}, msgs[2])
+	require.Equal(t, serverMessage{
+		Type:    messageRows,
+		Node:    "node1",
+		Rows:    [][]string{{"node1", "ls", "0"}, {"node1", "sh", "0"}},
+		Replace: true,
+	}, msgs[3])
+	require.Equal(t, messageDropped, msgs[4].Type)
+	require.JSONEq(t, `{"buffer":3}`, string(msgs[4].Dropped))
+	require.Equal(t, serverMessage{Type: messageResult, Node: "node1", Message: "result"}, msgs[5])
+	require.Equal(t, serverMessage{Type: messageDone}, msgs[6])
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	require.Len(t, client.requests, 1)
+	runRequest := client.requests[0].GetRunRequest()
+	require.NotNil(t, runRequest)
+	require.Equal(t, "stub", runRequest.GadgetName)
+	require.Equal(t, "webui", runRequest.GadgetCategory)
+	require.Equal(t, map[string]string{"operator.localmanager.host": "true"}, runRequest.Params)
+	require.Equal(t, int64(2e9), runRequest.Timeout)
+}
+
+func TestRunUnknownGadget(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, map[string]api.GadgetManagerClient{
+		"node1": &fakeClient{
+			events: []*api.GadgetEvent{
+				{Type: api.EventTypeGadgetPayload, Seq: 1, Payload: []byte(`{"a":1}`)},
+			},
+		},
+	})
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/run"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(&clientMessage{Type: "run", Gadget: "unknown"}))
+	msgs := readMessages(t, conn)
+
+	// Events of gadgets unknown to the server are forwarded as they are
+	require.Len(t, msgs, 2)
+	require.Equal(t, messageEvent, msgs[0].Type)
+	require.JSONEq(t, `{"a":1}`, string(msgs[0].Event))
+	require.Equal(t, messageDone, msgs[1].Type)
+}